@@ -1,6 +1,8 @@
 package blockchain
 
 import (
+	"bytes"
+	"expvar"
 	"fmt"
 	"math/big"
 	"path/filepath"
@@ -19,8 +21,20 @@ import (
 	lru "github.com/hashicorp/golang-lru"
 )
 
+var (
+	// metricBlockGasUsed is the gas used by the last written block
+	metricBlockGasUsed = expvar.NewInt("chain_block_gas_used")
+
+	// metricBlockGasLimit is the gas limit of the last written block
+	metricBlockGasLimit = expvar.NewInt("chain_block_gas_limit")
+)
+
 // Blockchain is a blockchain reference
 type Blockchain struct {
+	// The number of the current header (atomic). It is the first
+	// field to keep the 64-bit alignment on 32-bit platforms
+	currentNumber uint64
+
 	logger hclog.Logger // The logger object
 
 	db        storage.Storage // The Storage object (database)
@@ -50,6 +64,17 @@ type Verifier interface {
 	GetBlockCreator(header *types.Header) (types.Address, error)
 }
 
+// EpochHook is implemented by the consensus engines that need to react
+// to epoch boundaries (validator rotation, reward distribution...)
+type EpochHook interface {
+	// EpochSize returns the number of blocks in an epoch. Zero disables the hook
+	EpochSize() uint64
+
+	// OnEpochTransition is called once the block at an epoch boundary
+	// becomes part of the canonical chain
+	OnEpochTransition(block *types.Block)
+}
+
 type Executor interface {
 	ProcessBlock(parentRoot types.Hash, block *types.Block, blockCreator types.Address) (*state.BlockResult, error)
 }
@@ -77,6 +102,7 @@ func (b *Blockchain) GetAvgGasPrice() *big.Int {
 func NewBlockchain(
 	logger hclog.Logger,
 	dataDir string,
+	dbConfig *leveldb.Config,
 	config *chain.Chain,
 	consensus Verifier,
 	executor Executor,
@@ -102,6 +128,7 @@ func NewBlockchain(
 	} else {
 		if db, err = leveldb.NewLevelDBStorage(
 			filepath.Join(dataDir, "blockchain"),
+			dbConfig,
 			logger,
 		); err != nil {
 			return nil, err
@@ -185,6 +212,7 @@ func (b *Blockchain) setCurrentHeader(h *types.Header, diff *big.Int) {
 	// Update the header (atomic)
 	header := h.Copy()
 	b.currentHeader.Store(header)
+	atomic.StoreUint64(&b.currentNumber, header.Number)
 
 	// Update the difficulty (atomic)
 	difficulty := new(big.Int).Set(diff)
@@ -196,6 +224,11 @@ func (b *Blockchain) Header() *types.Header {
 	return b.currentHeader.Load().(*types.Header)
 }
 
+// HeadNumber returns the number of the current header (atomic)
+func (b *Blockchain) HeadNumber() uint64 {
+	return atomic.LoadUint64(&b.currentNumber)
+}
+
 // CurrentTD returns the current total difficulty (atomic)
 func (b *Blockchain) CurrentTD() *big.Int {
 	return b.currentDifficulty.Load().(*big.Int)
@@ -590,8 +623,16 @@ func (b *Blockchain) WriteBlocks(blocks []*types.Block) error {
 
 		b.dispatchEvent(evnt)
 
+		if evnt.Type != EventFork {
+			b.notifyEpochTransitions(evnt, block)
+		}
+
 		// Update the average gas price
 		b.UpdateGasPriceAvg(new(big.Int).SetUint64(header.GasUsed))
+
+		// Update the block utilization metrics
+		metricBlockGasUsed.Set(int64(header.GasUsed))
+		metricBlockGasLimit.Set(int64(header.GasLimit))
 	}
 
 	b.logger.Info("new head", "hash", b.Header().Hash, "number", b.Header().Number)
@@ -599,6 +640,43 @@ func (b *Blockchain) WriteBlocks(blocks []*types.Block) error {
 	return nil
 }
 
+// notifyEpochTransitions invokes the consensus epoch hook for every block
+// that became canonical with the event and is on an epoch boundary
+func (b *Blockchain) notifyEpochTransitions(evnt *Event, block *types.Block) {
+	hook, ok := b.consensus.(EpochHook)
+	if !ok {
+		return
+	}
+
+	epochSize := hook.EpochSize()
+	if epochSize == 0 {
+		return
+	}
+
+	// the new chain goes from the head down to the common ancestor, walk it backwards
+	for i := len(evnt.NewChain) - 1; i >= 0; i-- {
+		header := evnt.NewChain[i]
+		if header.Number == 0 || header.Number%epochSize != 0 {
+			continue
+		}
+
+		if header.Hash == block.Hash() {
+			hook.OnEpochTransition(block)
+
+			continue
+		}
+
+		epochBlock, ok := b.GetBlockByHash(header.Hash, true)
+		if !ok {
+			b.logger.Error("failed to read the epoch block", "number", header.Number, "hash", header.Hash)
+
+			continue
+		}
+
+		hook.OnEpochTransition(epochBlock)
+	}
+}
+
 // writeBody writes the block body to the DB.
 // Additionally, it also updates the txn lookup, for txnHash -> block lookups
 func (b *Blockchain) writeBody(block *types.Block) error {
@@ -609,9 +687,9 @@ func (b *Blockchain) writeBody(block *types.Block) error {
 		return err
 	}
 
-	// Write txn lookups (txHash -> block)
-	for _, txn := range block.Transactions {
-		if err := b.db.WriteTxLookup(txn.Hash, block.Hash()); err != nil {
+	// Write txn lookups (txHash -> block, index)
+	for indx, txn := range block.Transactions {
+		if err := b.db.WriteTxLookup(txn.Hash, block.Hash(), uint64(indx)); err != nil {
 			return err
 		}
 	}
@@ -621,11 +699,36 @@ func (b *Blockchain) writeBody(block *types.Block) error {
 
 // ReadTxLookup returns the block hash using the transaction hash
 func (b *Blockchain) ReadTxLookup(hash types.Hash) (types.Hash, bool) {
-	v, ok := b.db.ReadTxLookup(hash)
+	v, _, ok := b.db.ReadTxLookup(hash)
 
 	return v, ok
 }
 
+// GetTransactionByHash returns a sealed transaction along with
+// its block and its index in the block
+func (b *Blockchain) GetTransactionByHash(hash types.Hash) (*types.Transaction, *types.Block, int, bool) {
+	blockHash, indx, ok := b.db.ReadTxLookup(hash)
+	if !ok {
+		return nil, nil, 0, false
+	}
+	block, ok := b.GetBlockByHash(blockHash, true)
+	if !ok {
+		return nil, nil, 0, false
+	}
+
+	// check the index against the body, the lookups written
+	// without the index require a search in the block
+	if indx < uint64(len(block.Transactions)) && block.Transactions[indx].Hash == hash {
+		return block.Transactions[indx], block, int(indx), true
+	}
+	for i, txn := range block.Transactions {
+		if txn.Hash == hash {
+			return txn, block, i, true
+		}
+	}
+	return nil, nil, 0, false
+}
+
 // processBlock Processes the block, and does validation
 func (b *Blockchain) processBlock(block *types.Block) (*state.BlockResult, error) {
 	header := block.Header
@@ -721,6 +824,18 @@ func (b *Blockchain) dispatchEvent(evnt *Event) {
 	b.stream.push(evnt)
 }
 
+// isBetterChain is the fork choice rule. It returns true if the chain with the incoming
+// head is preferred over the current canonical chain. The chain with the highest total
+// difficulty wins. If both chains have the same total difficulty (i.e. IBFT, where
+// each block has difficulty one) the chain whose head has the lowest hash wins, so that
+// every node picks the same canonical chain regardless of the order it received the blocks
+func isBetterChain(incomingDiff, headDiff *big.Int, incoming, head *types.Header) bool {
+	if diff := incomingDiff.Cmp(headDiff); diff != 0 {
+		return diff > 0
+	}
+	return bytes.Compare(incoming.Hash.Bytes(), head.Hash.Bytes()) < 0
+}
+
 // writeHeaderImpl writes a block and the data, assumes the genesis is already set
 func (b *Blockchain) writeHeaderImpl(evnt *Event, header *types.Header) error {
 	head := b.Header()
@@ -764,8 +879,8 @@ func (b *Blockchain) writeHeaderImpl(evnt *Event, header *types.Header) error {
 	b.headersCache.Add(header.Hash, header)
 
 	incomingDiff := big.NewInt(1).Add(parentDiff, new(big.Int).SetUint64(header.Difficulty))
-	if incomingDiff.Cmp(headerDiff) > 0 {
-		// new block has higher difficulty, reorg the chain
+	if isBetterChain(incomingDiff, headerDiff, header, head) {
+		// new block has higher difficulty (or wins the tie), reorg the chain
 		if err := b.handleReorg(evnt, head, header); err != nil {
 			return err
 		}
@@ -854,6 +969,10 @@ func (b *Blockchain) handleReorg(
 		}
 
 		oldChain = append(oldChain, oldHeader)
+
+		if oldHeader.Hash != newHeader.Hash {
+			newChain = append(newChain, newHeader)
+		}
 	}
 
 	for _, b := range oldChain[:len(oldChain)-1] {
@@ -1,6 +1,8 @@
 package blockchain
 
 import (
+	"bytes"
+	"expvar"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -10,7 +12,10 @@ import (
 
 	"github.com/0xPolygon/minimal/blockchain/storage"
 	"github.com/0xPolygon/minimal/blockchain/storage/memory"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state"
 	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
 )
 
 func TestGenesis(t *testing.T) {
@@ -561,3 +566,243 @@ func TestBlockchainWriteBody(t *testing.T) {
 	fmt.Println(body)
 	fmt.Println(ok)
 }
+
+type mockEpochVerifier struct {
+	MockVerifier
+
+	epochSize uint64
+	blocks    []*types.Block
+}
+
+func (m *mockEpochVerifier) EpochSize() uint64 {
+	return m.epochSize
+}
+
+func (m *mockEpochVerifier) OnEpochTransition(block *types.Block) {
+	m.blocks = append(m.blocks, block)
+}
+
+// newEpochTestBlockchain creates a blockchain with a mock executor
+// and writes the genesis and the first header
+func newEpochTestBlockchain(t *testing.T, headers []*types.Header) *Blockchain {
+	b, err := newBlockChain(&chain.Chain{Genesis: &chain.Genesis{}}, nil)
+	assert.NoError(t, err)
+
+	_, err = b.advanceHead(headers[0])
+	assert.NoError(t, err)
+	assert.NoError(t, b.WriteHeaders(headers[1:]))
+
+	return b
+}
+
+func TestEpochTransitionHook(t *testing.T) {
+	headers := NewTestHeaderChain(11)
+
+	b := newEpochTestBlockchain(t, headers[:2])
+
+	engine := &mockEpochVerifier{epochSize: 3}
+	b.SetConsensus(engine)
+
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(headers[2:])))
+
+	// the hook fires only at blocks 3, 6 and 9
+	assert.Len(t, engine.blocks, 3)
+	for indx, block := range engine.blocks {
+		assert.Equal(t, uint64(3*(indx+1)), block.Number())
+		assert.Equal(t, headers[3*(indx+1)].Hash, block.Hash())
+	}
+}
+
+func TestEpochTransitionHook_Disabled(t *testing.T) {
+	headers := NewTestHeaderChain(5)
+
+	b := newEpochTestBlockchain(t, headers[:2])
+
+	engine := &mockEpochVerifier{epochSize: 0}
+	b.SetConsensus(engine)
+
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(headers[2:])))
+	assert.Len(t, engine.blocks, 0)
+}
+
+func TestEpochTransitionHook_Reorg(t *testing.T) {
+	common := NewTestHeaderChain(2)
+
+	chainA := NewTestHeaderFromChainWithSeed(common, 2, 1)
+	chainB := NewTestHeaderFromChainWithSeed(common, 3, 2)
+
+	b := newEpochTestBlockchain(t, common)
+
+	engine := &mockEpochVerifier{epochSize: 2}
+	b.SetConsensus(engine)
+
+	assert.NoError(t, b.WriteBlocks(HeadersToBlocks(chainA[2:])))
+
+	// the longer chain replaces the canonical one, the hook fires
+	// for its block 2 even if it was written before the reorg
+	for _, header := range chainB[2:] {
+		assert.NoError(t, b.WriteBlocks(HeadersToBlocks([]*types.Header{header})))
+	}
+
+	assert.Equal(t, chainB[4].Hash, b.Header().Hash)
+
+	assert.Len(t, engine.blocks, 3)
+	assert.Equal(t, chainA[2].Hash, engine.blocks[0].Hash())
+	assert.Equal(t, chainB[2].Hash, engine.blocks[1].Hash())
+	assert.Equal(t, chainB[4].Hash, engine.blocks[2].Hash())
+
+	// the blocks below the head of the new chain are canonical too
+	for _, header := range chainB[2:] {
+		hash, ok := b.db.ReadCanonicalHash(header.Number)
+		assert.True(t, ok)
+		assert.Equal(t, header.Hash, hash)
+	}
+}
+
+type mockGasExecutor struct {
+	gasUsed uint64
+}
+
+func (m *mockGasExecutor) ProcessBlock(parentRoot types.Hash, block *types.Block, blockCreator types.Address) (*state.BlockResult, error) {
+	return &state.BlockResult{TotalGas: m.gasUsed}, nil
+}
+
+func TestBlockGasMetrics(t *testing.T) {
+	headers := NewTestHeaderChain(2)
+
+	b := newEpochTestBlockchain(t, headers)
+	b.executor = &mockGasExecutor{gasUsed: 21000}
+
+	header := &types.Header{
+		ParentHash:   headers[1].Hash,
+		Number:       2,
+		GasLimit:     8000000,
+		GasUsed:      21000,
+		TxRoot:       types.EmptyRootHash,
+		Sha3Uncles:   types.EmptyUncleHash,
+		ReceiptsRoot: types.EmptyRootHash,
+	}
+	header.ComputeHash()
+
+	assert.NoError(t, b.WriteBlocks([]*types.Block{{Header: header}}))
+
+	assert.Equal(t, "21000", expvar.Get("chain_block_gas_used").String())
+	assert.Equal(t, "8000000", expvar.Get("chain_block_gas_limit").String())
+}
+
+func TestForkChoice_EqualDifficulty(t *testing.T) {
+	common := NewTestHeaderChain(2)
+
+	// two chains with the same total difficulty
+	chainA := NewTestHeaderFromChainWithSeed(common, 2, 1)
+	chainB := NewTestHeaderFromChainWithSeed(common, 2, 2)
+
+	// the head with the lowest hash wins
+	expected := chainA[3]
+	if bytes.Compare(chainB[3].Hash.Bytes(), expected.Hash.Bytes()) < 0 {
+		expected = chainB[3]
+	}
+
+	orders := [][][]*types.Header{
+		{chainA, chainB},
+		{chainB, chainA},
+	}
+	for _, order := range orders {
+		b := NewTestBlockchain(t, common)
+		for _, chain := range order {
+			assert.NoError(t, b.WriteHeaders(chain[2:]))
+		}
+		assert.Equal(t, expected.Hash, b.Header().Hash)
+	}
+}
+
+func TestGetTransactionByHash(t *testing.T) {
+	b := newExportTestBlockchain(t, nil, nil)
+	executor := &mockReceiptsExecutor{receipts: map[types.Hash][]*types.Receipt{}}
+	b.executor = executor
+
+	// a block with several transactions
+	to := types.StringToAddress("1")
+	txns := []*types.Transaction{}
+	receipts := []*types.Receipt{}
+	for i := 0; i < 3; i++ {
+		txn := &types.Transaction{
+			Nonce:    uint64(i),
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(1),
+			V:        0x27,
+		}
+		txn.ComputeHash()
+		txns = append(txns, txn)
+
+		status := types.ReceiptSuccess
+		receipts = append(receipts, &types.Receipt{
+			Status:            &status,
+			GasUsed:           21000,
+			CumulativeGasUsed: 21000 * uint64(i+1),
+		})
+	}
+
+	header := &types.Header{
+		ParentHash:   b.Header().Hash,
+		Number:       1,
+		GasLimit:     8000000,
+		GasUsed:      21000 * 3,
+		ExtraData:    []byte{},
+		Sha3Uncles:   types.EmptyUncleHash,
+		TxRoot:       buildroot.CalculateTransactionsRoot(txns),
+		ReceiptsRoot: buildroot.CalculateReceiptsRoot(receipts),
+	}
+	header.ComputeHash()
+	executor.receipts[header.Hash] = receipts
+
+	assert.NoError(t, b.WriteBlocks([]*types.Block{{Header: header, Transactions: txns}}))
+
+	for indx, txn := range txns {
+		found, block, foundIndx, ok := b.GetTransactionByHash(txn.Hash)
+		assert.True(t, ok)
+		assert.Equal(t, txn.Hash, found.Hash)
+		assert.Equal(t, header.Hash, block.Hash())
+		assert.Equal(t, indx, foundIndx)
+	}
+
+	// a lookup without the index falls back to a search in the block
+	assert.NoError(t, b.db.WriteTxLookup(txns[2].Hash, header.Hash, 0))
+
+	found, _, foundIndx, ok := b.GetTransactionByHash(txns[2].Hash)
+	assert.True(t, ok)
+	assert.Equal(t, txns[2].Hash, found.Hash)
+	assert.Equal(t, 2, foundIndx)
+
+	// unknown transaction
+	_, _, _, ok = b.GetTransactionByHash(types.StringToHash("1"))
+	assert.False(t, ok)
+}
+
+func TestHeadNumber(t *testing.T) {
+	headers := NewTestHeaderChain(10)
+	b := NewTestBlockchain(t, headers[:5])
+	assert.Equal(t, b.Header().Number, b.HeadNumber())
+	assert.Equal(t, uint64(4), b.HeadNumber())
+
+	assert.NoError(t, b.WriteHeaders(headers[5:]))
+	assert.Equal(t, b.Header().Number, b.HeadNumber())
+	assert.Equal(t, uint64(9), b.HeadNumber())
+}
+
+func BenchmarkHeadNumber(b *testing.B) {
+	chain := NewTestBlockchain(b, NewTestHeaderChain(10))
+
+	b.Run("Header", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = chain.Header().Number
+		}
+	})
+	b.Run("HeadNumber", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = chain.HeadNumber()
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
+	"github.com/umbracle/fastrlp"
+)
+
+// maxExportEntrySize is the maximum size of an exported block with its receipts
+const maxExportEntrySize = 64 * 1024 * 1024
+
+// ExportChain writes the canonical blocks in the range [from, to] with their
+// receipts to w. Every block is a RLP list of the block and its receipts.
+// The genesis is not exported since it is built from the chain config
+func (b *Blockchain) ExportChain(w io.Writer, from, to uint64) error {
+	if from > to {
+		return fmt.Errorf("invalid range %d to %d", from, to)
+	}
+	if head := b.Header().Number; to > head {
+		return fmt.Errorf("block %d is beyond the head %d", to, head)
+	}
+
+	if from == 0 {
+		from = 1
+	}
+
+	ar := &fastrlp.Arena{}
+	buf := []byte{}
+
+	for i := from; i <= to; i++ {
+		block, ok := b.GetBlockByNumber(i, true)
+		if !ok {
+			return fmt.Errorf("block %d not found", i)
+		}
+		receipts, err := b.GetReceiptsByHash(block.Hash())
+		if err != nil {
+			return fmt.Errorf("receipts of block %d not found: %v", i, err)
+		}
+
+		v := ar.NewArray()
+		v.Set(block.MarshalRLPWith(ar))
+		v.Set((*types.Receipts)(&receipts).MarshalStoreRLPWith(ar))
+
+		buf = v.MarshalTo(buf[:0])
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+		ar.Reset()
+	}
+	return nil
+}
+
+// ImportChain reads the blocks exported with ExportChain from r and writes
+// them to the chain. The blocks are validated against the consensus
+// and the import stops on the first invalid block. The blocks already
+// in the chain are skipped. It returns the number of imported blocks
+func (b *Blockchain) ImportChain(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+
+	p := &fastrlp.Parser{}
+	imported := 0
+
+	for {
+		data, err := readRLPList(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, err
+		}
+
+		v, err := p.Parse(data)
+		if err != nil {
+			return imported, err
+		}
+		elems, err := v.GetElems()
+		if err != nil {
+			return imported, err
+		}
+		if len(elems) != 2 {
+			return imported, fmt.Errorf("expected 2 elements but found %d", len(elems))
+		}
+
+		block := &types.Block{}
+		if err := block.UnmarshalRLPFrom(p, elems[0]); err != nil {
+			return imported, err
+		}
+		receipts := types.Receipts{}
+		if err := receipts.UnmarshalStoreRLPFrom(p, elems[1]); err != nil {
+			return imported, err
+		}
+
+		if _, ok := b.GetHeaderByHash(block.Hash()); ok {
+			continue
+		}
+
+		// the receipts are computed again while the block is written,
+		// check them first so that a corrupted export is detected early
+		if root := buildroot.CalculateReceiptsRoot(receipts); root != block.Header.ReceiptsRoot {
+			return imported, fmt.Errorf("invalid receipts of block %d", block.Number())
+		}
+		if err := b.WriteBlocks([]*types.Block{block}); err != nil {
+			return imported, fmt.Errorf("failed to import block %d: %v", block.Number(), err)
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// readRLPList reads the next RLP encoded list from the reader
+func readRLPList(r *bufio.Reader) ([]byte, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if prefix < 0xc0 {
+		return nil, fmt.Errorf("expected a rlp list")
+	}
+
+	header := []byte{prefix}
+	size := uint64(prefix - 0xc0)
+	if prefix > 0xf7 {
+		// long list, the prefix is followed by the size
+		sizeBuf := make([]byte, prefix-0xf7)
+		if _, err := io.ReadFull(r, sizeBuf); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		header = append(header, sizeBuf...)
+
+		buf := make([]byte, 8)
+		copy(buf[8-len(sizeBuf):], sizeBuf)
+		size = binary.BigEndian.Uint64(buf)
+	}
+	if size > maxExportEntrySize {
+		return nil, fmt.Errorf("rlp list too large: %d", size)
+	}
+
+	data := make([]byte, len(header)+int(size))
+	copy(data, header)
+	if _, err := io.ReadFull(r, data[len(header):]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return data, nil
+}
+
+// unexpectedEOF turns an EOF in the middle of a list into an error
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
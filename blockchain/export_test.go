@@ -0,0 +1,225 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/0xPolygon/minimal/types/buildroot"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockReceiptsExecutor returns the receipts of the test blocks
+type mockReceiptsExecutor struct {
+	receipts map[types.Hash][]*types.Receipt
+}
+
+func (m *mockReceiptsExecutor) ProcessBlock(parentRoot types.Hash, block *types.Block, blockCreator types.Address) (*state.BlockResult, error) {
+	receipts := m.receipts[block.Hash()]
+
+	res := &state.BlockResult{Receipts: receipts}
+	if len(receipts) != 0 {
+		res.TotalGas = receipts[len(receipts)-1].CumulativeGasUsed
+	}
+	return res, nil
+}
+
+// newExportTestBlocks creates a chain of n blocks with one transaction each on top of genesis
+func newExportTestBlocks(genesis *types.Header, n int) ([]*types.Block, *mockReceiptsExecutor) {
+	executor := &mockReceiptsExecutor{receipts: map[types.Hash][]*types.Receipt{}}
+
+	blocks := []*types.Block{}
+	parent := genesis
+	for i := 1; i <= n; i++ {
+		to := types.StringToAddress("1")
+		txn := &types.Transaction{
+			Nonce:    uint64(i),
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      21000,
+			GasPrice: big.NewInt(1),
+			V:        0x27,
+		}
+		txn.ComputeHash()
+
+		status := types.ReceiptSuccess
+		receipts := []*types.Receipt{
+			{
+				Status:            &status,
+				GasUsed:           21000,
+				CumulativeGasUsed: 21000,
+				TxHash:            txn.Hash,
+			},
+		}
+
+		header := &types.Header{
+			ParentHash:   parent.Hash,
+			Number:       uint64(i),
+			Difficulty:   1,
+			GasLimit:     8000000,
+			GasUsed:      21000,
+			ExtraData:    []byte{},
+			Sha3Uncles:   types.EmptyUncleHash,
+			TxRoot:       buildroot.CalculateTransactionsRoot([]*types.Transaction{txn}),
+			ReceiptsRoot: buildroot.CalculateReceiptsRoot(receipts),
+			LogsBloom:    types.CreateBloom(receipts),
+		}
+		header.ComputeHash()
+
+		blocks = append(blocks, &types.Block{
+			Header:       header,
+			Transactions: []*types.Transaction{txn},
+		})
+		executor.receipts[header.Hash] = receipts
+		parent = header
+	}
+	return blocks, executor
+}
+
+// newExportTestBlockchain creates a blockchain with the genesis of the test blocks
+func newExportTestBlockchain(t *testing.T, executor Executor, verifier Verifier) *Blockchain {
+	b, err := newBlockChain(&chain.Chain{Genesis: &chain.Genesis{}}, executor)
+	assert.NoError(t, err)
+
+	if verifier != nil {
+		b.SetConsensus(verifier)
+	}
+	return b
+}
+
+func TestExportImportChain(t *testing.T) {
+	src := newExportTestBlockchain(t, nil, nil)
+	blocks, executor := newExportTestBlocks(src.Header(), 10)
+
+	src.executor = executor
+	assert.NoError(t, src.WriteBlocks(blocks))
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, src.ExportChain(buf, 1, 10))
+
+	// import the blocks into a fresh chain
+	dst := newExportTestBlockchain(t, executor, nil)
+
+	imported, err := dst.ImportChain(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, imported)
+	assert.Equal(t, src.Header().Hash, dst.Header().Hash)
+
+	for _, block := range blocks {
+		found, ok := dst.GetBlockByNumber(block.Number(), true)
+		assert.True(t, ok)
+		assert.Equal(t, block.Hash(), found.Hash())
+		assert.Len(t, found.Transactions, 1)
+		assert.Equal(t, block.Transactions[0].Hash, found.Transactions[0].Hash)
+
+		receipts, err := dst.GetReceiptsByHash(block.Hash())
+		assert.NoError(t, err)
+		assert.Len(t, receipts, 1)
+		assert.Equal(t, uint64(21000), receipts[0].GasUsed)
+		assert.Equal(t, types.ReceiptSuccess, *receipts[0].Status)
+	}
+}
+
+func TestExportImportChain_Partial(t *testing.T) {
+	src := newExportTestBlockchain(t, nil, nil)
+	blocks, executor := newExportTestBlocks(src.Header(), 6)
+
+	src.executor = executor
+	assert.NoError(t, src.WriteBlocks(blocks))
+
+	dst := newExportTestBlockchain(t, executor, nil)
+
+	// the blocks are imported in two ranges, the genesis and the
+	// overlapping blocks are already in the chain and they are skipped
+	for _, r := range [][2]uint64{{0, 3}, {2, 6}} {
+		buf := bytes.NewBuffer(nil)
+		assert.NoError(t, src.ExportChain(buf, r[0], r[1]))
+
+		_, err := dst.ImportChain(buf)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, src.Header().Hash, dst.Header().Hash)
+}
+
+// mockRejectVerifier rejects the headers with the given number
+type mockRejectVerifier struct {
+	MockVerifier
+
+	number uint64
+}
+
+func (m *mockRejectVerifier) VerifyHeader(parent, header *types.Header) error {
+	if header.Number == m.number {
+		return fmt.Errorf("invalid header")
+	}
+	return nil
+}
+
+func TestImportChain_InvalidBlock(t *testing.T) {
+	src := newExportTestBlockchain(t, nil, nil)
+	blocks, executor := newExportTestBlocks(src.Header(), 6)
+
+	src.executor = executor
+	assert.NoError(t, src.WriteBlocks(blocks))
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, src.ExportChain(buf, 1, 6))
+
+	// the import stops on the first invalid block
+	dst := newExportTestBlockchain(t, executor, &mockRejectVerifier{number: 4})
+
+	imported, err := dst.ImportChain(buf)
+	assert.Error(t, err)
+	assert.Equal(t, 3, imported)
+	assert.Equal(t, uint64(3), dst.Header().Number)
+}
+
+func TestImportChain_InvalidReceipts(t *testing.T) {
+	src := newExportTestBlockchain(t, nil, nil)
+	blocks, executor := newExportTestBlocks(src.Header(), 2)
+
+	src.executor = executor
+	assert.NoError(t, src.WriteBlocks(blocks))
+
+	// the receipts stored for the second block do not match its header
+	status := types.ReceiptFailed
+	assert.NoError(t, src.db.WriteReceipts(blocks[1].Hash(), []*types.Receipt{{Status: &status}}))
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, src.ExportChain(buf, 1, 2))
+
+	dst := newExportTestBlockchain(t, executor, nil)
+
+	imported, err := dst.ImportChain(buf)
+	assert.Error(t, err)
+	assert.Equal(t, 1, imported)
+}
+
+func TestImportChain_Truncated(t *testing.T) {
+	src := newExportTestBlockchain(t, nil, nil)
+	blocks, executor := newExportTestBlocks(src.Header(), 2)
+
+	src.executor = executor
+	assert.NoError(t, src.WriteBlocks(blocks))
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, src.ExportChain(buf, 1, 2))
+
+	dst := newExportTestBlockchain(t, executor, nil)
+
+	data := buf.Bytes()
+	imported, err := dst.ImportChain(bytes.NewReader(data[:len(data)-1]))
+	assert.Error(t, err)
+	assert.Equal(t, 1, imported)
+}
+
+func TestExportChain_InvalidRange(t *testing.T) {
+	b := newExportTestBlockchain(t, nil, nil)
+
+	assert.Error(t, b.ExportChain(bytes.NewBuffer(nil), 2, 1))
+	assert.Error(t, b.ExportChain(bytes.NewBuffer(nil), 0, 1))
+}
@@ -239,18 +239,35 @@ func (s *KeyValueStorage) ReadReceipts(hash types.Hash) ([]*types.Receipt, error
 // TX LOOKUP //
 
 // WriteTxLookup maps the transaction hash to the block hash
-func (s *KeyValueStorage) WriteTxLookup(hash types.Hash, blockHash types.Hash) error {
+// and the index of the transaction in the block
+func (s *KeyValueStorage) WriteTxLookup(hash types.Hash, blockHash types.Hash, index uint64) error {
 	ar := &fastrlp.Arena{}
-	vr := ar.NewBytes(blockHash.Bytes())
+	vr := ar.NewArray()
+	vr.Set(ar.NewBytes(blockHash.Bytes()))
+	vr.Set(ar.NewUint(index))
 	return s.write2(TX_LOOKUP_PREFIX, hash.Bytes(), vr)
 }
 
-// ReadTxLookup reads the block hash using the transaction hash
-func (s *KeyValueStorage) ReadTxLookup(hash types.Hash) (types.Hash, bool) {
+// ReadTxLookup reads the block hash and the index of the transaction
+// using the transaction hash. The lookups written before the index
+// was stored only have the block hash and their index is zero
+func (s *KeyValueStorage) ReadTxLookup(hash types.Hash) (types.Hash, uint64, bool) {
 	parser := &fastrlp.Parser{}
 	v := s.read2(TX_LOOKUP_PREFIX, hash.Bytes(), parser)
 	if v == nil {
-		return types.Hash{}, false
+		return types.Hash{}, 0, false
+	}
+
+	index := uint64(0)
+	if v.Type() == fastrlp.TypeArray {
+		elems, err := v.GetElems()
+		if err != nil || len(elems) != 2 {
+			return types.Hash{}, 0, false
+		}
+		if index, err = elems[1].GetUint64(); err != nil {
+			return types.Hash{}, 0, false
+		}
+		v = elems[0]
 	}
 
 	blockHash := []byte{}
@@ -259,7 +276,7 @@ func (s *KeyValueStorage) ReadTxLookup(hash types.Hash) (types.Hash, bool) {
 		panic(err)
 	}
 
-	return types.BytesToHash(blockHash), true
+	return types.BytesToHash(blockHash), index, true
 }
 
 // WRITE OPERATIONS //
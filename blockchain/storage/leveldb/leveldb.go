@@ -6,8 +6,38 @@ import (
 	"github.com/0xPolygon/minimal/blockchain/storage"
 	"github.com/hashicorp/go-hclog"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
+// Config is the configuration of the leveldb stores
+type Config struct {
+	// Compression enables the snappy compression of the tables.
+	// It trades CPU for disk space
+	Compression bool
+}
+
+// DefaultConfig returns the default leveldb configuration (snappy compression)
+func DefaultConfig() *Config {
+	return &Config{
+		Compression: true,
+	}
+}
+
+// Options returns the leveldb options for the configuration
+func (c *Config) Options() *opt.Options {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	compression := opt.SnappyCompression
+	if !c.Compression {
+		compression = opt.NoCompression
+	}
+	return &opt.Options{
+		Compression: compression,
+	}
+}
+
 // Factory creates a leveldb storage
 func Factory(config map[string]interface{}, logger hclog.Logger) (storage.Storage, error) {
 	path, ok := config["path"]
@@ -18,12 +48,20 @@ func Factory(config map[string]interface{}, logger hclog.Logger) (storage.Storag
 	if !ok {
 		return nil, fmt.Errorf("path is not a string")
 	}
-	return NewLevelDBStorage(pathStr, logger)
+
+	dbConfig := DefaultConfig()
+	if compression, ok := config["compression"]; ok {
+		if dbConfig.Compression, ok = compression.(bool); !ok {
+			return nil, fmt.Errorf("compression is not a bool")
+		}
+	}
+	return NewLevelDBStorage(pathStr, dbConfig, logger)
 }
 
-// NewLevelDBStorage creates the new storage reference with leveldb
-func NewLevelDBStorage(path string, logger hclog.Logger) (storage.Storage, error) {
-	db, err := leveldb.OpenFile(path, nil)
+// NewLevelDBStorage creates the new storage reference with leveldb.
+// A nil config uses the default configuration
+func NewLevelDBStorage(path string, config *Config, logger hclog.Logger) (storage.Storage, error) {
+	db, err := leveldb.OpenFile(path, config.Options())
 	if err != nil {
 		return nil, err
 	}
@@ -9,23 +9,34 @@ import (
 	"github.com/hashicorp/go-hclog"
 )
 
-func newStorage(t *testing.T) (storage.Storage, func()) {
-	path, err := ioutil.TempDir("/tmp", "minimal_storage")
-	if err != nil {
-		t.Fatal(err)
-	}
-	s, err := NewLevelDBStorage(path, hclog.NewNullLogger())
-	if err != nil {
-		t.Fatal(err)
-	}
-	close := func() {
-		if err := os.RemoveAll(path); err != nil {
+func newStorageWithConfig(config *Config) storage.MockStorage {
+	return func(t *testing.T) (storage.Storage, func()) {
+		path, err := ioutil.TempDir("/tmp", "minimal_storage")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewLevelDBStorage(path, config, hclog.NewNullLogger())
+		if err != nil {
 			t.Fatal(err)
 		}
+		close := func() {
+			if err := os.RemoveAll(path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return s, close
 	}
-	return s, close
 }
 
 func TestStorage(t *testing.T) {
-	storage.TestStorage(t, newStorage)
+	storage.TestStorage(t, newStorageWithConfig(nil))
+}
+
+func TestStorage_Compression(t *testing.T) {
+	t.Run("Enabled", func(t *testing.T) {
+		storage.TestStorage(t, newStorageWithConfig(&Config{Compression: true}))
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		storage.TestStorage(t, newStorageWithConfig(&Config{Compression: false}))
+	})
 }
@@ -37,8 +37,8 @@ type Storage interface {
 	WriteReceipts(hash types.Hash, receipts []*types.Receipt) error
 	ReadReceipts(hash types.Hash) ([]*types.Receipt, error)
 
-	WriteTxLookup(hash types.Hash, blockHash types.Hash) error
-	ReadTxLookup(hash types.Hash) (types.Hash, bool)
+	WriteTxLookup(hash types.Hash, blockHash types.Hash, index uint64) error
+	ReadTxLookup(hash types.Hash) (types.Hash, uint64, bool)
 
 	Close() error
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/fastrlp"
 )
 
 type MockStorage func(t *testing.T) (Storage, func())
@@ -49,6 +50,9 @@ func TestStorage(t *testing.T, m MockStorage) {
 	t.Run("", func(t *testing.T) {
 		testReceipts(t, m)
 	})
+	t.Run("", func(t *testing.T) {
+		testTxLookup(t, m)
+	})
 }
 
 func testCanonicalChain(t *testing.T, m MockStorage) {
@@ -362,6 +366,34 @@ func testReceipts(t *testing.T, m MockStorage) {
 	assert.True(t, reflect.DeepEqual(receipts, found))
 }
 
+func testTxLookup(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	_, _, ok := s.ReadTxLookup(hash1)
+	assert.False(t, ok)
+
+	assert.NoError(t, s.WriteTxLookup(hash1, hash2, 3))
+
+	blockHash, index, ok := s.ReadTxLookup(hash1)
+	assert.True(t, ok)
+	assert.Equal(t, hash2, blockHash)
+	assert.Equal(t, uint64(3), index)
+
+	// the lookups written before the index only have the block hash
+	kv, ok := s.(*KeyValueStorage)
+	if !ok {
+		return
+	}
+	ar := &fastrlp.Arena{}
+	assert.NoError(t, kv.write2(TX_LOOKUP_PREFIX, hash2.Bytes(), ar.NewBytes(hash1.Bytes())))
+
+	blockHash, index, ok = s.ReadTxLookup(hash2)
+	assert.True(t, ok)
+	assert.Equal(t, hash1, blockHash)
+	assert.Equal(t, uint64(0), index)
+}
+
 func testWriteCanonicalHeader(t *testing.T, m MockStorage) {
 	s, close := m(t)
 	defer close()
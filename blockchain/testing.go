@@ -143,7 +143,7 @@ func NewTestBodyChain(n int) ([]*types.Header, []*types.Block, [][]*types.Receip
 }
 
 // NewTestBlockchain creates a new dummy blockchain for testing
-func NewTestBlockchain(t *testing.T, headers []*types.Header) *Blockchain {
+func NewTestBlockchain(t testing.TB, headers []*types.Header) *Blockchain {
 	genesis := &chain.Genesis{
 		Number:   0,
 		GasLimit: 0,
@@ -214,7 +214,7 @@ func newBlockChain(config *chain.Chain, executor Executor) (*Blockchain, error)
 	if executor == nil {
 		executor = &mockExecutor{}
 	}
-	b, err := NewBlockchain(hclog.NewNullLogger(), "", config, &MockVerifier{}, executor)
+	b, err := NewBlockchain(hclog.NewNullLogger(), "", nil, config, &MockVerifier{}, executor)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +41,12 @@ type Genesis struct {
 	Coinbase   types.Address                     `json:"coinbase"`
 	Alloc      map[types.Address]*GenesisAccount `json:"alloc,omitempty"`
 
+	// TotalSupply is the expected sum of the alloc balances (optional)
+	TotalSupply *big.Int `json:"totalSupply,omitempty"`
+
+	// ExpectedStateRoot is the expected state root of the alloc (optional)
+	ExpectedStateRoot *types.Hash `json:"expectedStateRoot,omitempty"`
+
 	// Override
 	StateRoot types.Hash
 
@@ -98,17 +104,20 @@ func (g *Genesis) Hash() types.Hash {
 // MarshalJSON implements the json interface
 func (g *Genesis) MarshalJSON() ([]byte, error) {
 	type Genesis struct {
-		Nonce      string                      `json:"nonce"`
-		Timestamp  *string                     `json:"timestamp,omitempty"`
-		ExtraData  *string                     `json:"extraData,omitempty"`
-		GasLimit   *string                     `json:"gasLimit,omitempty"`
-		Difficulty *string                     `json:"difficulty,omitempty"`
-		Mixhash    types.Hash                  `json:"mixHash"`
-		Coinbase   types.Address               `json:"coinbase"`
-		Alloc      *map[string]*GenesisAccount `json:"alloc,omitempty"`
-		Number     *string                     `json:"number,omitempty"`
-		GasUsed    *string                     `json:"gasUsed,omitempty"`
-		ParentHash types.Hash                  `json:"parentHash"`
+		Nonce       string                      `json:"nonce"`
+		Timestamp   *string                     `json:"timestamp,omitempty"`
+		ExtraData   *string                     `json:"extraData,omitempty"`
+		GasLimit    *string                     `json:"gasLimit,omitempty"`
+		Difficulty  *string                     `json:"difficulty,omitempty"`
+		Mixhash     types.Hash                  `json:"mixHash"`
+		Coinbase    types.Address               `json:"coinbase"`
+		Alloc       *map[string]*GenesisAccount `json:"alloc,omitempty"`
+		TotalSupply *string                     `json:"totalSupply,omitempty"`
+		Number      *string                     `json:"number,omitempty"`
+		GasUsed     *string                     `json:"gasUsed,omitempty"`
+		ParentHash  types.Hash                  `json:"parentHash"`
+
+		ExpectedStateRoot *types.Hash `json:"expectedStateRoot,omitempty"`
 	}
 
 	var enc Genesis
@@ -130,10 +139,14 @@ func (g *Genesis) MarshalJSON() ([]byte, error) {
 		}
 		enc.Alloc = &alloc
 	}
+	if g.TotalSupply != nil {
+		enc.TotalSupply = types.EncodeBigInt(g.TotalSupply)
+	}
 
 	enc.Number = types.EncodeUint64(g.Number)
 	enc.GasUsed = types.EncodeUint64(g.GasUsed)
 	enc.ParentHash = g.ParentHash
+	enc.ExpectedStateRoot = g.ExpectedStateRoot
 
 	return json.Marshal(&enc)
 }
@@ -141,17 +154,20 @@ func (g *Genesis) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON implements the json interface
 func (g *Genesis) UnmarshalJSON(data []byte) error {
 	type Genesis struct {
-		Nonce      *string                    `json:"nonce"`
-		Timestamp  *string                    `json:"timestamp"`
-		ExtraData  *string                    `json:"extraData"`
-		GasLimit   *string                    `json:"gasLimit"`
-		Difficulty *string                    `json:"difficulty"`
-		Mixhash    *types.Hash                `json:"mixHash"`
-		Coinbase   *types.Address             `json:"coinbase"`
-		Alloc      map[string]*GenesisAccount `json:"alloc"`
-		Number     *string                    `json:"number"`
-		GasUsed    *string                    `json:"gasUsed"`
-		ParentHash *types.Hash                `json:"parentHash"`
+		Nonce       *string                    `json:"nonce"`
+		Timestamp   *string                    `json:"timestamp"`
+		ExtraData   *string                    `json:"extraData"`
+		GasLimit    *string                    `json:"gasLimit"`
+		Difficulty  *string                    `json:"difficulty"`
+		Mixhash     *types.Hash                `json:"mixHash"`
+		Coinbase    *types.Address             `json:"coinbase"`
+		Alloc       map[string]*GenesisAccount `json:"alloc"`
+		TotalSupply *string                    `json:"totalSupply"`
+		Number      *string                    `json:"number"`
+		GasUsed     *string                    `json:"gasUsed"`
+		ParentHash  *types.Hash                `json:"parentHash"`
+
+		ExpectedStateRoot *types.Hash `json:"expectedStateRoot"`
 	}
 
 	var dec Genesis
@@ -207,6 +223,11 @@ func (g *Genesis) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	g.TotalSupply, subErr = types.ParseUint256orHex(dec.TotalSupply)
+	if subErr != nil {
+		parseError("totalsupply", subErr)
+	}
+
 	g.Number, subErr = types.ParseUint64orHex(dec.Number)
 	if subErr != nil {
 		parseError("number", subErr)
@@ -219,6 +240,7 @@ func (g *Genesis) UnmarshalJSON(data []byte) error {
 	if dec.ParentHash != nil {
 		g.ParentHash = *dec.ParentHash
 	}
+	g.ExpectedStateRoot = dec.ExpectedStateRoot
 	return err
 }
 
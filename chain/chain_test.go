@@ -155,3 +155,42 @@ func TestChainFolder(t *testing.T) {
 		}
 	}
 }
+
+func TestGenesis_TotalSupplyEncoding(t *testing.T) {
+	genesis := &Genesis{
+		GasLimit:    1,
+		TotalSupply: big.NewInt(1000),
+	}
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesis2 := &Genesis{}
+	if err := json.Unmarshal(data, genesis2); err != nil {
+		t.Fatal(err)
+	}
+	if genesis.TotalSupply.Cmp(genesis2.TotalSupply) != 0 {
+		t.Fatalf("expected total supply %s but found %s", genesis.TotalSupply, genesis2.TotalSupply)
+	}
+}
+
+func TestGenesis_ExpectedStateRootEncoding(t *testing.T) {
+	root := types.StringToHash("1")
+	genesis := &Genesis{
+		GasLimit:          1,
+		ExpectedStateRoot: &root,
+	}
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesis2 := &Genesis{}
+	if err := json.Unmarshal(data, genesis2); err != nil {
+		t.Fatal(err)
+	}
+	if genesis2.ExpectedStateRoot == nil || *genesis2.ExpectedStateRoot != root {
+		t.Fatalf("expected state root %s but found %v", root, genesis2.ExpectedStateRoot)
+	}
+}
@@ -2,6 +2,8 @@ package chain
 
 import (
 	"math/big"
+
+	"github.com/0xPolygon/minimal/types"
 )
 
 // Params are all the set of params for the chain
@@ -9,6 +11,13 @@ type Params struct {
 	Forks   *Forks                 `json:"forks"`
 	ChainID int                    `json:"chainID"`
 	Engine  map[string]interface{} `json:"engine"`
+
+	// Interpreter is the runtime used to run the contracts code (evm by default)
+	Interpreter string `json:"interpreter,omitempty"`
+
+	// DisabledPrecompiles are the precompiled contracts not available in the chain.
+	// Calls to these addresses behave as calls to a plain account
+	DisabledPrecompiles []types.Address `json:"disabledPrecompiles,omitempty"`
 }
 
 func (p *Params) GetEngine() string {
@@ -29,6 +38,8 @@ type Forks struct {
 	EIP150         *Fork `json:"EIP150,omitempty"`
 	EIP158         *Fork `json:"EIP158,omitempty"`
 	EIP155         *Fork `json:"EIP155,omitempty"`
+	Berlin         *Fork `json:"berlin,omitempty"`
+	London         *Fork `json:"london,omitempty"`
 }
 
 func (f *Forks) active(ff *Fork, block uint64) bool {
@@ -66,6 +77,16 @@ func (f *Forks) IsEIP155(block uint64) bool {
 	return f.active(f.EIP155, block)
 }
 
+// IsBerlin returns true if the access lists gas model (EIP-2929, EIP-2930) is enabled
+func (f *Forks) IsBerlin(block uint64) bool {
+	return f.active(f.Berlin, block)
+}
+
+// IsLondon returns true if the typed transactions (EIP-2718, EIP-1559) are accepted
+func (f *Forks) IsLondon(block uint64) bool {
+	return f.active(f.London, block)
+}
+
 func (f *Forks) At(block uint64) ForksInTime {
 	return ForksInTime{
 		Homestead:      f.active(f.Homestead, block),
@@ -76,6 +97,8 @@ func (f *Forks) At(block uint64) ForksInTime {
 		EIP150:         f.active(f.EIP150, block),
 		EIP158:         f.active(f.EIP158, block),
 		EIP155:         f.active(f.EIP155, block),
+		Berlin:         f.active(f.Berlin, block),
+		London:         f.active(f.London, block),
 	}
 }
 
@@ -103,7 +126,28 @@ type ForksInTime struct {
 	Istanbul,
 	EIP150,
 	EIP158,
-	EIP155 bool
+	EIP155,
+	Berlin,
+	London bool
+}
+
+// AcceptsTx returns true if the type and the access list of the transaction are
+// enabled: the access lists need berlin (EIP-2930) and the dynamic fees london (EIP-1559)
+func (f ForksInTime) AcceptsTx(tx *types.Transaction) bool {
+	switch tx.Type {
+	case types.LegacyTx:
+	case types.AccessListTx:
+		if !f.Berlin {
+			return false
+		}
+	case types.DynamicFeeTx:
+		if !f.London {
+			return false
+		}
+	default:
+		return false
+	}
+	return len(tx.AccessList) == 0 || f.Berlin
 }
 
 var AllForksEnabled = &Forks{
@@ -115,4 +159,6 @@ var AllForksEnabled = &Forks{
 	Constantinople: NewFork(0),
 	Petersburg:     NewFork(0),
 	Istanbul:       NewFork(0),
+	Berlin:         NewFork(0),
+	London:         NewFork(0),
 }
@@ -6,35 +6,59 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/jsonrpc"
 	"github.com/0xPolygon/minimal/minimal"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/hcl"
 	"github.com/imdario/mergo"
 )
 
 // Config defines the server configuration params
 type Config struct {
-	Chain       string                 `json:"chain"`
-	DataDir     string                 `json:"data_dir"`
-	GRPCAddr    string                 `json:"rpc_addr"`
-	JSONRPCAddr string                 `json:"jsonrpc_addr"`
-	Network     *Network               `json:"network"`
-	Seal        bool                   `json:"seal"`
-	LogLevel    string                 `json:"log_level"`
-	Consensus   map[string]interface{} `json:"consensus"`
-	Dev         bool
-	DevInterval uint64
-	Join        string
+	Chain         string                 `json:"chain"`
+	DataDir       string                 `json:"data_dir"`
+	GRPCAddr      string                 `json:"rpc_addr"`
+	JSONRPCAddr   string                 `json:"jsonrpc_addr"`
+	Network       *Network               `json:"network"`
+	Seal          bool                   `json:"seal"`
+	LogLevel      string                 `json:"log_level"`
+	Consensus     map[string]interface{} `json:"consensus"`
+	Dev           bool
+	DevInterval   uint64
+	Join          string
+	ReadyMinPeers uint64   `json:"ready_min_peers"`
+	ReadySyncDist uint64   `json:"ready_max_sync_distance"`
+	TxPoolJournal string   `json:"txpool_journal"`
+	JSONRPCGzip   bool     `json:"jsonrpc_gzip"`
+	Metrics       bool     `json:"jsonrpc_metrics"`
+	JSONRPCCache  uint64   `json:"jsonrpc_call_cache"`
+	Parallel      bool     `json:"parallel_execution"`
+	MaxLogRange   uint64   `json:"jsonrpc_max_log_range"`
+	MaxLogAddrs   uint64   `json:"jsonrpc_max_log_addresses"`
+	MaxLogs       uint64   `json:"jsonrpc_max_logs"`
+	RateLimits    []string `json:"jsonrpc_rate_limits"`
+	GasCap        uint64   `json:"jsonrpc_gas_cap"`
+	Senders       []string `json:"jsonrpc_sender_allowlist"`
+	MaxBlockTxs   uint64   `json:"max_txs_per_block"`
+	GasTarget     uint64   `json:"block_gas_target"`
+	GasElastic    bool     `json:"block_gas_elasticity"`
+	IBFTTimeout   uint64   `json:"ibft_round_timeout"`
+	Prune         uint64   `json:"prune"`
 }
 
 // Network defines the network configuration params
 type Network struct {
 	NoDiscover bool   `json:"no_discover"`
+	NoGossip   bool   `json:"no_gossip"`
 	Addr       string `json:"addr"`
 	NatAddr    string `json:"nat_addr"`
 	MaxPeers   uint64 `json:"max_peers"`
+	EnableMDNS bool   `json:"enable_mdns"`
 }
 
 // DefaultConfig returns the default server configuration
@@ -66,6 +90,29 @@ func (c *Config) BuildConfig() (*minimal.Config, error) {
 	conf.Chain = cc
 	conf.Seal = c.Seal
 	conf.DataDir = c.DataDir
+	conf.ReadyMinPeers = c.ReadyMinPeers
+	conf.ReadyMaxSyncDistance = c.ReadySyncDist
+	conf.TxPool.Journal = c.TxPoolJournal
+	conf.JSONRPCGzip = c.JSONRPCGzip
+	conf.JSONRPCMetrics = c.Metrics
+	conf.JSONRPCCallCache = int(c.JSONRPCCache)
+	conf.ParallelExecution = c.Parallel
+	conf.JSONRPCMaxLogBlockRange = c.MaxLogRange
+	conf.JSONRPCMaxLogAddresses = int(c.MaxLogAddrs)
+	conf.JSONRPCMaxLogs = int(c.MaxLogs)
+	conf.JSONRPCGasEstimationCap = c.GasCap
+	conf.MaxTxsPerBlock = c.MaxBlockTxs
+	conf.BlockGasTarget = c.GasTarget
+	conf.BlockGasElasticity = c.GasElastic
+	conf.IBFTRoundTimeout = time.Duration(c.IBFTTimeout) * time.Second
+	conf.PruneRetention = c.Prune
+
+	if conf.JSONRPCRateLimits, err = parseRateLimits(c.RateLimits); err != nil {
+		return nil, err
+	}
+	if conf.JSONRPCSenderAllowlist, err = parseAddresses(c.Senders); err != nil {
+		return nil, err
+	}
 
 	// JSON RPC + GRPC
 	if c.GRPCAddr != "" {
@@ -94,7 +141,9 @@ func (c *Config) BuildConfig() (*minimal.Config, error) {
 		}
 
 		conf.Network.NoDiscover = c.Network.NoDiscover
+		conf.Network.NoGossip = c.Network.NoGossip
 		conf.Network.MaxPeers = c.Network.MaxPeers
+		conf.Network.EnableMDNS = c.Network.EnableMDNS
 
 		conf.Chain = cc
 	}
@@ -133,6 +182,50 @@ func resolveAddr(raw string) (*net.TCPAddr, error) {
 	return addr, nil
 }
 
+// parseRateLimits parses the JSON-RPC rate limits in the <namespace>=<rate>:<burst> format
+func parseRateLimits(raw []string) (map[string]*jsonrpc.RateLimit, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	limits := map[string]*jsonrpc.RateLimit{}
+	for _, item := range raw {
+		parts := strings.FieldsFunc(item, func(r rune) bool {
+			return r == '=' || r == ':'
+		})
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("failed to parse rate limit '%s', expected <namespace>=<rate>:<burst>", item)
+		}
+
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rate of '%s': %v", item, err)
+		}
+		burst, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse burst of '%s': %v", item, err)
+		}
+		limits[parts[0]] = &jsonrpc.RateLimit{
+			Rate:  rate,
+			Burst: burst,
+		}
+	}
+	return limits, nil
+}
+
+// parseAddresses parses a list of hex encoded addresses
+func parseAddresses(raw []string) ([]types.Address, error) {
+	addrs := make([]types.Address, 0, len(raw))
+	for _, item := range raw {
+		var addr types.Address
+		if err := addr.UnmarshalText([]byte(item)); err != nil {
+			return nil, fmt.Errorf("failed to parse address '%s': %v", item, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
 // mergeConfigWith merges the passed in configuration to the current configuration
 func (c *Config) mergeConfigWith(otherConfig *Config) error {
 	if otherConfig.DataDir != "" {
@@ -171,6 +264,78 @@ func (c *Config) mergeConfigWith(otherConfig *Config) error {
 		c.Join = otherConfig.Join
 	}
 
+	if otherConfig.ReadyMinPeers != 0 {
+		c.ReadyMinPeers = otherConfig.ReadyMinPeers
+	}
+
+	if otherConfig.ReadySyncDist != 0 {
+		c.ReadySyncDist = otherConfig.ReadySyncDist
+	}
+
+	if otherConfig.TxPoolJournal != "" {
+		c.TxPoolJournal = otherConfig.TxPoolJournal
+	}
+
+	if otherConfig.JSONRPCGzip {
+		c.JSONRPCGzip = true
+	}
+
+	if otherConfig.Metrics {
+		c.Metrics = true
+	}
+
+	if otherConfig.JSONRPCCache != 0 {
+		c.JSONRPCCache = otherConfig.JSONRPCCache
+	}
+
+	if otherConfig.Parallel {
+		c.Parallel = true
+	}
+
+	if otherConfig.MaxLogRange != 0 {
+		c.MaxLogRange = otherConfig.MaxLogRange
+	}
+
+	if otherConfig.MaxLogAddrs != 0 {
+		c.MaxLogAddrs = otherConfig.MaxLogAddrs
+	}
+
+	if otherConfig.MaxLogs != 0 {
+		c.MaxLogs = otherConfig.MaxLogs
+	}
+
+	if len(otherConfig.RateLimits) != 0 {
+		c.RateLimits = otherConfig.RateLimits
+	}
+
+	if otherConfig.GasCap != 0 {
+		c.GasCap = otherConfig.GasCap
+	}
+
+	if len(otherConfig.Senders) != 0 {
+		c.Senders = otherConfig.Senders
+	}
+
+	if otherConfig.MaxBlockTxs != 0 {
+		c.MaxBlockTxs = otherConfig.MaxBlockTxs
+	}
+
+	if otherConfig.GasTarget != 0 {
+		c.GasTarget = otherConfig.GasTarget
+	}
+
+	if otherConfig.GasElastic {
+		c.GasElastic = true
+	}
+
+	if otherConfig.IBFTTimeout != 0 {
+		c.IBFTTimeout = otherConfig.IBFTTimeout
+	}
+
+	if otherConfig.Prune != 0 {
+		c.Prune = otherConfig.Prune
+	}
+
 	{
 		// Network
 		if otherConfig.Network.Addr != "" {
@@ -185,6 +350,12 @@ func (c *Config) mergeConfigWith(otherConfig *Config) error {
 		if otherConfig.Network.NoDiscover {
 			c.Network.NoDiscover = true
 		}
+		if otherConfig.Network.NoGossip {
+			c.Network.NoGossip = true
+		}
+		if otherConfig.Network.EnableMDNS {
+			c.Network.EnableMDNS = true
+		}
 	}
 
 	if err := mergo.Merge(&c.Consensus, otherConfig.Consensus, mergo.WithOverride); err != nil {
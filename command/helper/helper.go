@@ -387,7 +387,27 @@ func ReadConfig(baseCommand string, args []string) (*Config, error) {
 	flags.StringVar(&cliConfig.Network.Addr, "libp2p", "", "")
 	flags.StringVar(&cliConfig.Network.NatAddr, "nat", "", "the external IP address without port, as can be seen by peers")
 	flags.BoolVar(&cliConfig.Network.NoDiscover, "no-discover", false, "")
+	flags.BoolVar(&cliConfig.Network.NoGossip, "no-gossip", false, "")
+	flags.BoolVar(&cliConfig.Network.EnableMDNS, "mdns", false, "")
 	flags.Uint64Var(&cliConfig.Network.MaxPeers, "max-peers", 0, "")
+	flags.Uint64Var(&cliConfig.ReadyMinPeers, "ready-min-peers", 0, "")
+	flags.Uint64Var(&cliConfig.ReadySyncDist, "ready-max-sync-distance", 0, "")
+	flags.StringVar(&cliConfig.TxPoolJournal, "txpool-journal", "", "")
+	flags.BoolVar(&cliConfig.JSONRPCGzip, "jsonrpc-gzip", false, "")
+	flags.BoolVar(&cliConfig.Metrics, "jsonrpc-metrics", false, "")
+	flags.Uint64Var(&cliConfig.JSONRPCCache, "jsonrpc-call-cache", 0, "")
+	flags.BoolVar(&cliConfig.Parallel, "parallel-execution", false, "")
+	flags.Uint64Var(&cliConfig.MaxLogRange, "jsonrpc-max-log-range", 0, "")
+	flags.Uint64Var(&cliConfig.MaxLogAddrs, "jsonrpc-max-log-addresses", 0, "")
+	flags.Uint64Var(&cliConfig.MaxLogs, "jsonrpc-max-logs", 0, "")
+	flags.Var((*helperFlags.ArrayFlags)(&cliConfig.RateLimits), "jsonrpc-rate-limit", "")
+	flags.Uint64Var(&cliConfig.GasCap, "jsonrpc-gas-cap", 0, "")
+	flags.Var((*helperFlags.ArrayFlags)(&cliConfig.Senders), "jsonrpc-sender-allowlist", "")
+	flags.Uint64Var(&cliConfig.MaxBlockTxs, "max-txs-per-block", 0, "")
+	flags.Uint64Var(&cliConfig.GasTarget, "block-gas-target", 0, "")
+	flags.BoolVar(&cliConfig.GasElastic, "block-gas-elasticity", false, "")
+	flags.Uint64Var(&cliConfig.IBFTTimeout, "ibft-round-timeout", 0, "")
+	flags.Uint64Var(&cliConfig.Prune, "prune", 0, "")
 	flags.BoolVar(&cliConfig.Dev, "dev", false, "")
 	flags.Uint64Var(&cliConfig.DevInterval, "dev-interval", 0, "")
 
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/0xPolygon/minimal/command/helper"
+	"github.com/0xPolygon/minimal/consensus"
 	"github.com/0xPolygon/minimal/minimal"
 	"github.com/0xPolygon/minimal/network"
 	"github.com/hashicorp/go-hclog"
@@ -117,6 +120,22 @@ func (c *ServerCommand) DefineFlags() {
 		FlagOptional: true,
 	}
 
+	c.flagMap["no-gossip"] = helper.FlagDescriptor{
+		Description: "Disables the gossip protocol used to broadcast transactions and consensus messages. Default: false",
+		Arguments: []string{
+			"NO_GOSSIP",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["mdns"] = helper.FlagDescriptor{
+		Description: "Finds the peers in the local network with mDNS. Default: false",
+		Arguments: []string{
+			"ENABLE_MDNS",
+		},
+		FlagOptional: true,
+	}
+
 	c.flagMap["max-peers"] = helper.FlagDescriptor{
 		Description: fmt.Sprintf("Sets the client's max peer count. Default: %d", helper.DefaultConfig().Network.MaxPeers),
 		Arguments: []string{
@@ -125,6 +144,147 @@ func (c *ServerCommand) DefineFlags() {
 		FlagOptional: true,
 	}
 
+	c.flagMap["ready-min-peers"] = helper.FlagDescriptor{
+		Description: "Sets the minimum number of connected peers required to report the node as ready on the /ready endpoint. Default: 0",
+		Arguments: []string{
+			"PEER_COUNT",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["ready-max-sync-distance"] = helper.FlagDescriptor{
+		Description: "Sets the maximum number of blocks the node can be behind its best peer to report the node as ready on the /ready endpoint. Default: 10",
+		Arguments: []string{
+			"BLOCK_COUNT",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["txpool-journal"] = helper.FlagDescriptor{
+		Description: "Sets the path of the file where the local transactions are persisted to survive a restart. Default: disabled",
+		Arguments: []string{
+			"JOURNAL_PATH",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-gzip"] = helper.FlagDescriptor{
+		Description: "Enables the gzip compression of the JSON-RPC responses for the clients that accept it. Default: false",
+		Arguments: []string{
+			"JSONRPC_GZIP",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-metrics"] = helper.FlagDescriptor{
+		Description: "Serves the metrics of the node on the /debug/vars endpoint of the JSON-RPC. Default: false",
+		Arguments: []string{
+			"JSONRPC_METRICS",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-call-cache"] = helper.FlagDescriptor{
+		Description: "Sets the number of eth_call results to cache. Default: 0 (disabled)",
+		Arguments: []string{
+			"CACHE_SIZE",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-max-log-range"] = helper.FlagDescriptor{
+		Description: "Sets the maximum number of blocks queried by eth_getLogs. Default: 1000",
+		Arguments: []string{
+			"MAX_BLOCKS",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-max-log-addresses"] = helper.FlagDescriptor{
+		Description: "Sets the maximum number of addresses of a log filter (eth_getLogs, eth_newFilter and the logs subscriptions). Default: 256",
+		Arguments: []string{
+			"MAX_ADDRESSES",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-max-logs"] = helper.FlagDescriptor{
+		Description: "Sets the maximum number of logs returned by an eth_getLogs query that is not paginated. Default: 10000",
+		Arguments: []string{
+			"MAX_LOGS",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-rate-limit"] = helper.FlagDescriptor{
+		Description: "Sets the rate limit of the requests to a JSON-RPC namespace, in requests per second. The flag can be repeated. Default: no limits",
+		Arguments: []string{
+			"NAMESPACE=RATE:BURST",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-gas-cap"] = helper.FlagDescriptor{
+		Description: "Sets the maximum gas limit searched by eth_estimateGas. Default: 5000000",
+		Arguments: []string{
+			"GAS_CAP",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["jsonrpc-sender-allowlist"] = helper.FlagDescriptor{
+		Description: "Sets the only addresses allowed to send transactions through the JSON-RPC. The flag can be repeated. Default: all the addresses",
+		Arguments: []string{
+			"ADDRESS",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["max-txs-per-block"] = helper.FlagDescriptor{
+		Description: "Sets the maximum number of transactions sealed in a block. Default: 0 (unlimited)",
+		Arguments: []string{
+			"MAX_TXS",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["block-gas-target"] = helper.FlagDescriptor{
+		Description: fmt.Sprintf("Sets the gas limit of the sealed blocks. Default: %d", consensus.DefaultBlockGasTarget),
+		Arguments: []string{
+			"GAS_LIMIT",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["block-gas-elasticity"] = helper.FlagDescriptor{
+		Description:  "Moves the gas limit of the sealed blocks towards the target by at most 1/1024 per block. Default: false",
+		FlagOptional: true,
+	}
+
+	c.flagMap["ibft-round-timeout"] = helper.FlagDescriptor{
+		Description: fmt.Sprintf("Sets the base timeout in seconds of an IBFT round, 2^round seconds are added to it after a round change. Default: %d", consensus.DefaultIBFTRoundTimeout/time.Second),
+		Arguments: []string{
+			"SECONDS",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["prune"] = helper.FlagDescriptor{
+		Description: "Prunes the state of the blocks older than the given number of recent blocks. Default: 0 (archive mode)",
+		Arguments: []string{
+			"RETENTION",
+		},
+		FlagOptional: true,
+	}
+
+	c.flagMap["parallel-execution"] = helper.FlagDescriptor{
+		Description: "Enables the experimental parallel execution of the transactions of a block. Default: false",
+		Arguments: []string{
+			"PARALLEL_EXECUTION",
+		},
+		FlagOptional: true,
+	}
+
 	c.flagMap["dev"] = helper.FlagDescriptor{
 		Description: "Sets the client to dev mode. Default: false",
 		Arguments: []string{
@@ -193,7 +353,7 @@ func (c *ServerCommand) Run(args []string) int {
 
 	if conf.Join != "" {
 		// make a non-blocking join request
-		if err = server.Join(conf.Join, 0); err != nil {
+		if err = server.Join(context.Background(), conf.Join, 0); err != nil {
 			c.UI.Error(fmt.Sprintf("Failed to join address %s: %v", conf.Join, err))
 		}
 	}
@@ -3,6 +3,7 @@ package status
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/0xPolygon/minimal/command/helper"
 	"github.com/0xPolygon/minimal/minimal/proto"
@@ -63,6 +64,7 @@ func (c *StatusCommand) Run(args []string) int {
 		fmt.Sprintf("Current Block Number (base 10)|%d", status.Current.Number),
 		fmt.Sprintf("Current Block Hash|%s", status.Current.Hash),
 		fmt.Sprintf("Libp2p Address|%s", status.P2PAddr),
+		fmt.Sprintf("Uptime|%s", time.Duration(status.Uptime)*time.Second),
 	})
 
 	output += "\n"
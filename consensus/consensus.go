@@ -3,6 +3,7 @@ package consensus
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/chain"
@@ -23,6 +24,19 @@ type Consensus interface {
 	// GetBlockCreator retrieves the block creator (or signer) given the block header
 	GetBlockCreator(header *types.Header) (types.Address, error)
 
+	// EpochSize returns the number of blocks in an epoch
+	EpochSize() uint64
+
+	// OnEpochTransition is called by the blockchain when a block
+	// on an epoch boundary is written to the canonical chain
+	OnEpochTransition(block *types.Block)
+
+	// SetSealing enables or disables the sealing of new blocks
+	SetSealing(enabled bool)
+
+	// IsSealing returns true if the node is sealing new blocks
+	IsSealing() bool
+
 	// Start starts the consensus
 	Start() error
 
@@ -30,6 +44,25 @@ type Consensus interface {
 	Close() error
 }
 
+// Syncer is implemented by the consensus mechanisms that sync the chain from their peers
+type Syncer interface {
+	// BestPeerNumber returns the block height of the best peer,
+	// false if there is no peer ahead of the node
+	BestPeerNumber() (uint64, bool)
+}
+
+// NoEpoch is the default implementation of the epoch hooks for the
+// consensus mechanisms that do not have the concept of epochs
+type NoEpoch struct{}
+
+// EpochSize returns zero, which disables the epoch transitions
+func (NoEpoch) EpochSize() uint64 {
+	return 0
+}
+
+// OnEpochTransition is a no-op
+func (NoEpoch) OnEpochTransition(block *types.Block) {}
+
 // Config is the configuration for the consensus
 type Config struct {
 	// Logger to be used by the backend
@@ -43,6 +76,59 @@ type Config struct {
 
 	// Path is the directory path for the consensus protocol tos tore information
 	Path string
+
+	// MaxTxsPerBlock is the maximum number of transactions sealed in a block (unlimited if zero)
+	MaxTxsPerBlock uint64
+
+	// BlockGasTarget is the gas limit of the sealed blocks (DefaultBlockGasTarget if zero)
+	BlockGasTarget uint64
+
+	// BlockGasElasticity moves the gas limit of the sealed blocks towards
+	// the target gradually instead of using the target right away
+	BlockGasElasticity bool
+
+	// IBFTRoundTimeout is the base timeout of an IBFT round, 2^round seconds are
+	// added to it after a round change (DefaultIBFTRoundTimeout if zero)
+	IBFTRoundTimeout time.Duration
+}
+
+const (
+	// DefaultBlockGasTarget is the gas limit of the sealed blocks without a target
+	DefaultBlockGasTarget uint64 = 100000000
+
+	// gasLimitBoundDivisor bounds the change of the gas limit with elasticity
+	// to 1/gasLimitBoundDivisor of the gas limit of the parent
+	gasLimitBoundDivisor uint64 = 1024
+
+	// DefaultIBFTRoundTimeout is the base timeout of an IBFT round without a configured one
+	DefaultIBFTRoundTimeout = 10 * time.Second
+)
+
+// CalcGasLimit returns the gas limit of a block sealed on top of
+// a parent with the given gas limit
+func (c *Config) CalcGasLimit(parentGasLimit uint64) uint64 {
+	target := c.BlockGasTarget
+	if target == 0 {
+		target = DefaultBlockGasTarget
+	}
+	if !c.BlockGasElasticity {
+		return target
+	}
+
+	delta := parentGasLimit / gasLimitBoundDivisor
+	if delta == 0 {
+		delta = 1
+	}
+	if parentGasLimit < target {
+		if parentGasLimit+delta > target {
+			return target
+		}
+		return parentGasLimit + delta
+	}
+	if parentGasLimit-target < delta {
+		return target
+	}
+	return parentGasLimit - delta
 }
 
 // Factory is the factory function to create a discovery backend
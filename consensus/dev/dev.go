@@ -3,6 +3,7 @@ package dev
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
@@ -17,14 +18,24 @@ import (
 
 // Dev consensus protocol seals any new transaction immediately
 type Dev struct {
+	consensus.NoEpoch
+
 	logger hclog.Logger
 
+	sealing uint32 // Flag indicating if new blocks are sealed (atomic)
+
 	notifyCh chan struct{}
+	resumeCh chan struct{}
 	closeCh  chan struct{}
 
 	interval uint64
 	txpool   *txpool.TxPool
 
+	// maximum number of transactions sealed in a block (unlimited if zero)
+	maxTxsPerBlock uint64
+
+	config *consensus.Config
+
 	blockchain *blockchain.Blockchain
 	executor   *state.Executor
 }
@@ -46,10 +57,14 @@ func Factory(
 	d := &Dev{
 		logger:     logger,
 		notifyCh:   make(chan struct{}),
+		resumeCh:   make(chan struct{}, 1),
 		closeCh:    make(chan struct{}),
 		blockchain: blockchain,
 		executor:   executor,
 		txpool:     txpool,
+
+		maxTxsPerBlock: config.MaxTxsPerBlock,
+		config:         config,
 	}
 
 	rawInterval, ok := config.Config["interval"]
@@ -61,8 +76,18 @@ func Factory(
 		d.interval = interval
 	}
 
+	if err := consensus.SetupBlockReward(config, executor); err != nil {
+		return nil, err
+	}
+
+	// the dev consensus always starts sealing
+	d.SetSealing(true)
+
 	// enable dev mode so that we can accept non-signed txns
 	txpool.EnableDev()
+	txpool.SetBlockGasLimit(func() uint64 {
+		return config.CalcGasLimit(blockchain.Header().GasLimit)
+	})
 	txpool.NotifyCh = d.notifyCh
 
 	return d, nil
@@ -70,68 +95,108 @@ func Factory(
 
 // Start starts the consensus mechanism
 func (d *Dev) Start() error {
-	go d.run()
+	// subscribe before the loop starts so that no head is missed. With a
+	// fixed interval the heads are not used, so there is no subscription
+	var sub blockchain.Subscription
+	if d.interval == 0 {
+		sub = d.blockchain.SubscribeEvents()
+	}
+
+	go d.run(sub)
 
 	return nil
 }
 
-func (d *Dev) nextNotify() chan struct{} {
-	if d.interval != 0 {
-		ch := make(chan struct{})
-		go func() {
-			<-time.After(time.Duration(d.interval) * time.Second)
-			ch <- struct{}{}
-		}()
+func (d *Dev) run(sub blockchain.Subscription) {
+	d.logger.Info("consensus started")
 
-		return ch
+	if sub != nil {
+		defer sub.Close()
 	}
 
-	return d.notifyCh
-}
+	// the notifications of the pool are dropped while a block is being sealed,
+	// a new head means that the sealer is ready again for the transactions left
+	// in the pool. With a fixed interval the blocks are only sealed on each tick
+	var notifyCh, resumeCh chan struct{}
+	var headCh chan *blockchain.Event
+	if d.interval == 0 {
+		notifyCh = d.notifyCh
+		resumeCh = d.resumeCh
+		headCh = sub.GetEventCh()
+	}
 
-func (d *Dev) run() {
-	d.logger.Info("consensus started")
+	// stalled is set if the last block did not seal any transaction, the
+	// transactions that cannot be applied are left in the pool and they
+	// would seal an empty block on each head otherwise
+	var stalled bool
 
 	for {
-		// wait until there is a new txn
+		var tickCh <-chan time.Time
+		if d.interval != 0 {
+			tickCh = time.After(time.Duration(d.interval) * time.Second)
+		}
+
+		// wait until there is a new txn or a new head
 		select {
-		case <-d.nextNotify():
+		case <-notifyCh:
+		case <-tickCh:
+		case <-headCh:
+			if stalled || d.txpool.Length() == 0 {
+				// nothing left to seal
+				continue
+			}
+		case <-resumeCh:
+			if d.txpool.Length() == 0 {
+				// nothing was added while the sealing was paused
+				continue
+			}
 		case <-d.closeCh:
 			return
 		}
 
+		if !d.IsSealing() {
+			// sealing is paused, leave the transactions in the pool
+			continue
+		}
+
 		// There are new transactions in the pool, try to seal them
 		header := d.blockchain.Header()
-		if err := d.writeNewBlock(header); err != nil {
+		num, err := d.writeNewBlock(header)
+		if err != nil {
 			d.logger.Error("failed to mine block", "err", err)
 		}
+		stalled = num == 0
 	}
 }
 
 // writeNewBLock generates a new block based on transactions from the pool,
-// and writes them to the blockchain
-func (d *Dev) writeNewBlock(parent *types.Header) error {
+// and writes them to the blockchain. It returns the number of sealed transactions
+func (d *Dev) writeNewBlock(parent *types.Header) (int, error) {
 
 	// Generate the base block
 	num := parent.Number
 	header := &types.Header{
 		ParentHash: parent.Hash,
 		Number:     num + 1,
-		GasLimit:   100000000, // placeholder for now
+		GasLimit:   d.config.CalcGasLimit(parent.GasLimit),
 		Timestamp:  uint64(time.Now().Unix()),
 	}
 
 	miner, err := d.GetBlockCreator(header)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	transition, err := d.executor.BeginTxn(parent.StateRoot, header, miner)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	txns := []*types.Transaction{}
 	for {
+		if d.maxTxsPerBlock != 0 && uint64(len(txns)) >= d.maxTxsPerBlock {
+			break
+		}
+
 		// Add transactions to the list until there are none left
 		txn, retFn := d.txpool.Pop()
 
@@ -149,6 +214,9 @@ func (d *Dev) writeNewBlock(parent *types.Header) error {
 		txns = append(txns, txn)
 	}
 
+	// Credit the block reward
+	transition.Finalize()
+
 	// Commit the changes
 	_, root := transition.Commit()
 
@@ -166,10 +234,10 @@ func (d *Dev) writeNewBlock(parent *types.Header) error {
 
 	// Write the block to the blockchain
 	if err := d.blockchain.WriteBlocks([]*types.Block{block}); err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return len(txns), nil
 }
 
 // REQUIRED BASE INTERFACE METHODS //
@@ -193,6 +261,26 @@ func (d *Dev) Seal(block *types.Block, ctx context.Context) (*types.Block, error
 	return nil, nil
 }
 
+// SetSealing enables or disables the sealing of new blocks
+func (d *Dev) SetSealing(enabled bool) {
+	var val uint32
+	if enabled {
+		val = 1
+	}
+	if old := atomic.SwapUint32(&d.sealing, val); old == 0 && enabled {
+		// wake up the sealer for the transactions added while paused
+		select {
+		case d.resumeCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// IsSealing returns true if new blocks are being sealed
+func (d *Dev) IsSealing() bool {
+	return atomic.LoadUint32(&d.sealing) == 1
+}
+
 func (d *Dev) Close() error {
 	close(d.closeCh)
 	return nil
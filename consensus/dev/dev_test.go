@@ -0,0 +1,343 @@
+package dev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/consensus"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/txpool"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type poolStore struct {
+	*blockchain.Blockchain
+}
+
+func (p *poolStore) GetNonce(root types.Hash, addr types.Address) uint64 {
+	return 0
+}
+
+// testSenders are funded in the genesis so that they can pay for the gas
+var testSenders = []types.Address{{0x10}, {0x11}, {0x12}}
+
+func newTestDev(t *testing.T, consensusConfig *consensus.Config) (*Dev, *blockchain.Blockchain) {
+	alloc := map[types.Address]*chain.GenesisAccount{}
+	for _, addr := range testSenders {
+		alloc[addr] = &chain.GenesisAccount{
+			Balance: big.NewInt(1000000000000000000),
+		}
+	}
+
+	config := &chain.Chain{
+		Genesis: &chain.Genesis{
+			GasLimit: 100000000,
+			Alloc:    alloc,
+		},
+		Params: &chain.Params{
+			Forks: &chain.Forks{
+				Homestead: chain.NewFork(0),
+				Byzantium: chain.NewFork(0),
+			},
+		},
+	}
+
+	executor := state.NewExecutor(config.Params, itrie.NewState(itrie.NewMemoryStorage()))
+
+	root, err := executor.WriteGenesis(config.Genesis)
+	assert.NoError(t, err)
+	config.Genesis.StateRoot = root
+
+	b, err := blockchain.NewBlockchain(hclog.NewNullLogger(), "", nil, config, nil, executor)
+	assert.NoError(t, err)
+	assert.NoError(t, b.ComputeGenesis())
+	executor.GetHash = b.GetHashHelper
+
+	pool, err := txpool.NewTxPool(hclog.NewNullLogger(), true, nil, &poolStore{b}, nil, nil)
+	assert.NoError(t, err)
+	pool.AddSigner(crypto.NewEIP155Signer(100))
+
+	engine, err := Factory(context.Background(), true, consensusConfig, pool, nil, b, executor, nil, hclog.NewNullLogger())
+	assert.NoError(t, err)
+	b.SetConsensus(engine)
+
+	return engine.(*Dev), b
+}
+
+func TestDev_SealOnNewHead(t *testing.T) {
+	d, b := newTestDev(t, &consensus.Config{})
+
+	assert.NoError(t, d.Start())
+	defer d.Close()
+
+	// the notification of the pool is dropped (i.e. the sealer is busy)
+	notifyCh := d.txpool.NotifyCh
+	d.txpool.NotifyCh = nil
+
+	to := types.Address{0x1}
+	txn := &types.Transaction{
+		From:     types.Address{0x2},
+		To:       &to,
+		Gas:      21000,
+		GasPrice: big.NewInt(0),
+		Value:    big.NewInt(0),
+	}
+	assert.NoError(t, d.txpool.AddTx(txn))
+
+	d.txpool.NotifyCh = notifyCh
+
+	// write an empty block, the new head wakes up the sealer
+	genesis := b.Header()
+	block := consensus.BuildBlock(consensus.BuildBlockParams{
+		Header: &types.Header{
+			ParentHash: genesis.Hash,
+			Number:     1,
+			GasLimit:   genesis.GasLimit,
+			StateRoot:  genesis.StateRoot,
+			Timestamp:  uint64(time.Now().Unix()),
+		},
+	})
+	assert.NoError(t, b.WriteBlocks([]*types.Block{block}))
+
+	// the next block includes the transaction without any other notification
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Header().Number != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the sealer did not build the next block")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sealed, ok := b.GetBlockByNumber(2, true)
+	assert.True(t, ok)
+	assert.Len(t, sealed.Transactions, 1)
+	assert.Equal(t, txn.Hash, sealed.Transactions[0].Hash)
+}
+
+func TestDev_SealOnResume(t *testing.T) {
+	d, b := newTestDev(t, &consensus.Config{})
+
+	assert.NoError(t, d.Start())
+	defer d.Close()
+
+	// the transaction is added while the sealing is paused
+	d.SetSealing(false)
+
+	to := types.Address{0x1}
+	txn := &types.Transaction{
+		From:     types.Address{0x2},
+		To:       &to,
+		Gas:      21000,
+		GasPrice: big.NewInt(0),
+		Value:    big.NewInt(0),
+	}
+	assert.NoError(t, d.txpool.AddTx(txn))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, uint64(0), b.Header().Number)
+
+	// the transaction is sealed once the sealing is resumed
+	d.SetSealing(true)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Header().Number != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("the sealer did not build the block")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sealed, ok := b.GetBlockByNumber(1, true)
+	assert.True(t, ok)
+	assert.Len(t, sealed.Transactions, 1)
+	assert.Equal(t, txn.Hash, sealed.Transactions[0].Hash)
+}
+
+func TestDev_MaxTxsPerBlock(t *testing.T) {
+	d, b := newTestDev(t, &consensus.Config{MaxTxsPerBlock: 3})
+
+	to := types.Address{0x1}
+	for nonce := uint64(0); nonce < 5; nonce++ {
+		txn := &types.Transaction{
+			From:     types.Address{0x2},
+			To:       &to,
+			Nonce:    nonce,
+			Gas:      21000,
+			GasPrice: big.NewInt(0),
+			Value:    big.NewInt(0),
+		}
+		assert.NoError(t, d.txpool.AddTx(txn))
+	}
+
+	num, err := d.writeNewBlock(b.Header())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, num)
+
+	block, ok := b.GetBlockByNumber(1, true)
+	assert.True(t, ok)
+	assert.Len(t, block.Transactions, 3)
+
+	// the rest of the transactions are left in the pool
+	assert.Equal(t, uint64(2), d.txpool.Length())
+}
+
+func TestDev_BlockGasTarget(t *testing.T) {
+	d, b := newTestDev(t, &consensus.Config{BlockGasTarget: 8000000})
+
+	for i := 0; i < 3; i++ {
+		_, err := d.writeNewBlock(b.Header())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(8000000), b.Header().GasLimit)
+	}
+
+	// the pool rejects the transactions that do not fit in a block
+	to := types.Address{0x1}
+	txn := &types.Transaction{
+		From:     types.Address{0x2},
+		To:       &to,
+		Gas:      8000001,
+		GasPrice: big.NewInt(0),
+		Value:    big.NewInt(0),
+	}
+	assert.Equal(t, txpool.ErrBlockLimitExceeded, d.txpool.AddTx(txn))
+}
+
+func TestDev_BlockGasElasticity(t *testing.T) {
+	target := uint64(100300000)
+	d, b := newTestDev(t, &consensus.Config{BlockGasTarget: target, BlockGasElasticity: true})
+
+	// the gas limit grows from the genesis gas limit
+	// by 1/1024 of the parent gas limit per block
+	for i := 0; i < 3; i++ {
+		parent := b.Header().GasLimit
+
+		_, err := d.writeNewBlock(b.Header())
+		assert.NoError(t, err)
+		assert.Equal(t, parent+parent/1024, b.Header().GasLimit)
+	}
+
+	// and stops at the target
+	_, err := d.writeNewBlock(b.Header())
+	assert.NoError(t, err)
+	assert.Equal(t, target, b.Header().GasLimit)
+}
+
+func TestDev_SortByGasPrice(t *testing.T) {
+	d, b := newTestDev(t, &consensus.Config{})
+
+	a, b0, c := testSenders[0], testSenders[1], testSenders[2]
+	to := types.Address{0x1}
+
+	txns := []*types.Transaction{
+		{From: a, Nonce: 0, GasPrice: big.NewInt(1)},
+		{From: a, Nonce: 1, GasPrice: big.NewInt(10)},
+		{From: b0, Nonce: 0, GasPrice: big.NewInt(5)},
+		{From: c, Nonce: 1, GasPrice: big.NewInt(7)},
+		{From: c, Nonce: 0, GasPrice: big.NewInt(3)},
+	}
+	for _, txn := range txns {
+		txn.To = &to
+		txn.Gas = 21000
+		txn.Value = big.NewInt(0)
+		txn.ComputeHash()
+		assert.NoError(t, d.txpool.AddTx(txn))
+	}
+
+	num, err := d.writeNewBlock(b.Header())
+	assert.NoError(t, err)
+	assert.Equal(t, len(txns), num)
+
+	block, ok := b.GetBlockByNumber(1, true)
+	assert.True(t, ok)
+
+	// the transactions are sorted by gas price, but a transaction
+	// of an account never goes before one with a lower nonce
+	expected := []struct {
+		from  types.Address
+		nonce uint64
+	}{
+		{b0, 0},
+		{c, 0},
+		{c, 1},
+		{a, 0},
+		{a, 1},
+	}
+	assert.Len(t, block.Transactions, len(expected))
+	for i, txn := range block.Transactions {
+		assert.Equal(t, expected[i].from, txn.From)
+		assert.Equal(t, expected[i].nonce, txn.Nonce)
+	}
+}
+
+func TestDev_BlockReward(t *testing.T) {
+	d, b := newTestDev(t, &consensus.Config{
+		Config: map[string]interface{}{
+			"blockReward": "0x64",
+		},
+	})
+
+	balanceAt := func(header *types.Header, addr types.Address) *big.Int {
+		snap, err := d.executor.StateAt(header.StateRoot)
+		assert.NoError(t, err)
+		return state.NewTxn(d.executor.State(), snap).GetBalance(addr)
+	}
+
+	for i := 0; i < 2; i++ {
+		parent := b.Header()
+
+		_, err := d.writeNewBlock(parent)
+		assert.NoError(t, err)
+
+		// the block is written, so the reward is credited the
+		// same way when the blockchain processes the block
+		header := b.Header()
+		assert.Equal(t, parent.Number+1, header.Number)
+
+		coinbase, err := d.GetBlockCreator(header)
+		assert.NoError(t, err)
+
+		reward := new(big.Int).Sub(balanceAt(header, coinbase), balanceAt(parent, coinbase))
+		assert.Equal(t, big.NewInt(100), reward)
+	}
+}
+
+func TestDev_InvalidBlockReward(t *testing.T) {
+	_, err := Factory(context.Background(), true, &consensus.Config{
+		Config: map[string]interface{}{
+			"blockReward": "abc",
+		},
+	}, nil, nil, nil, nil, nil, hclog.NewNullLogger())
+	assert.Error(t, err)
+}
+
+func TestDev_BlockReward_JSON(t *testing.T) {
+	loadReward := func(engine string) (*big.Int, error) {
+		config := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(engine), &config); err != nil {
+			return nil, err
+		}
+		return (&consensus.Config{Config: config}).BlockReward()
+	}
+
+	reward, err := loadReward(`{"blockReward": "100"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), reward)
+
+	reward, err = loadReward(`{"blockReward": "0x64"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), reward)
+
+	// the numbers are decoded as float64 and not accepted
+	_, err = loadReward(`{"blockReward": 100}`)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "decimal or hex string")
+}
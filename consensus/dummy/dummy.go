@@ -2,6 +2,7 @@ package dummy
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/consensus"
@@ -14,7 +15,9 @@ import (
 )
 
 type Dummy struct {
-	sealing    bool
+	consensus.NoEpoch
+
+	sealing    uint32
 	logger     hclog.Logger
 	notifyCh   chan struct{}
 	closeCh    chan struct{}
@@ -27,7 +30,6 @@ func Factory(ctx context.Context, sealing bool, config *consensus.Config, txpool
 	logger = logger.Named("dummy")
 
 	d := &Dummy{
-		sealing:    sealing,
 		logger:     logger,
 		notifyCh:   make(chan struct{}),
 		closeCh:    make(chan struct{}),
@@ -36,6 +38,8 @@ func Factory(ctx context.Context, sealing bool, config *consensus.Config, txpool
 		txpool:     txpool,
 	}
 
+	d.SetSealing(sealing)
+
 	txpool.NotifyCh = d.notifyCh
 
 	return d, nil
@@ -55,6 +59,18 @@ func (d *Dummy) GetBlockCreator(header *types.Header) (types.Address, error) {
 	return header.Miner, nil
 }
 
+func (d *Dummy) SetSealing(enabled bool) {
+	var val uint32
+	if enabled {
+		val = 1
+	}
+	atomic.StoreUint32(&d.sealing, val)
+}
+
+func (d *Dummy) IsSealing() bool {
+	return atomic.LoadUint32(&d.sealing) == 1
+}
+
 func (d *Dummy) Close() error {
 	close(d.closeCh)
 	return nil
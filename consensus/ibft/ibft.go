@@ -4,9 +4,9 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
-	"math"
 	"path/filepath"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
@@ -36,7 +36,7 @@ type blockchainInterface interface {
 
 // Ibft represents the IBFT consensus mechanism object
 type Ibft struct {
-	sealing bool // Flag indicating if the node is a sealer
+	sealing uint32 // Flag indicating if the node is a sealer (atomic)
 
 	logger hclog.Logger      // Output logger
 	config *consensus.Config // Consensus configuration
@@ -92,7 +92,17 @@ func Factory(
 		network:      network,
 		epochSize:    DefaultEpochSize,
 		syncNotifyCh: make(chan bool),
-		sealing:      sealing,
+	}
+
+	p.SetSealing(sealing)
+
+	// reject the transactions that would not fit in a sealed block
+	txpool.SetBlockGasLimit(func() uint64 {
+		return config.CalcGasLimit(blockchain.Header().GasLimit)
+	})
+
+	if err := consensus.SetupBlockReward(config, executor); err != nil {
+		return nil, err
 	}
 
 	// Istanbul requires a different header hash function
@@ -150,8 +160,25 @@ func (g *gossipTransport) Gossip(msg *proto.MessageReq) error {
 	return g.topic.Publish(msg)
 }
 
+// noopTransport is the transport used when the gossip protocol is disabled
+type noopTransport struct{}
+
+// Gossip drops the message
+func (n *noopTransport) Gossip(msg *proto.MessageReq) error {
+	return nil
+}
+
 // setupTransport sets up the gossip transport protocol
 func (i *Ibft) setupTransport() error {
+	if !i.network.IsGossipEnabled() {
+		// the messages are only relayed internally, which is
+		// only enough for a network with a single validator
+		i.logger.Warn("gossip is disabled, consensus messages are not broadcasted")
+		i.transport = &noopTransport{}
+
+		return nil
+	}
+
 	// Define a new topic
 	topic, err := i.network.NewTopic(ibftProto, &proto.MessageReq{})
 	if err != nil {
@@ -245,6 +272,12 @@ func (i *Ibft) runCycle() {
 		i.logger.Debug("cycle", "state", i.getState(), "sequence", i.state.view.Sequence, "round", i.state.view.Round)
 	}
 
+	// If the sealing has been disabled, go back to sync
+	// and stop participating in the consensus
+	if !i.isSealing() && !i.isState(SyncState) {
+		i.setState(SyncState)
+	}
+
 	// Based on the current state, execute the corresponding section
 	switch i.getState() {
 	case AcceptState:
@@ -353,7 +386,7 @@ func (i *Ibft) buildBlock(snap *Snapshot, parent *types.Header) (*types.Block, e
 		Difficulty: parent.Number + 1,   // we need to do this because blockchain needs difficulty to organize blocks and forks
 		StateRoot:  types.EmptyRootHash, // this avoids needing state for now
 		Sha3Uncles: types.EmptyUncleHash,
-		GasLimit:   100000000, // placeholder for now
+		GasLimit:   i.config.CalcGasLimit(parent.GasLimit),
 	}
 
 	// try to pick a candidate
@@ -384,6 +417,9 @@ func (i *Ibft) buildBlock(snap *Snapshot, parent *types.Header) (*types.Block, e
 	}
 	txns := []*types.Transaction{}
 	for {
+		if limit := i.config.MaxTxsPerBlock; limit != 0 && uint64(len(txns)) >= limit {
+			break
+		}
 		txn, retFn := i.txpool.Pop()
 		if txn == nil {
 			break
@@ -395,6 +431,9 @@ func (i *Ibft) buildBlock(snap *Snapshot, parent *types.Header) (*types.Block, e
 		txns = append(txns, txn)
 	}
 
+	// credit the block reward
+	transition.Finalize()
+
 	_, root := transition.Commit()
 	header.StateRoot = root
 	header.GasUsed = transition.TotalGas()
@@ -834,13 +873,31 @@ func (i *Ibft) forceTimeout() {
 	i.forceTimeoutCh = true
 }
 
-// randomTimeout calculates the timeout duration depending on the current round
-func (i *Ibft) randomTimeout() chan struct{} {
-	timeout := time.Duration(10000) * time.Millisecond
-	round := i.state.view.Round
+// maxRoundTimeout is the upper bound of the timeout of a round
+const maxRoundTimeout = 10 * time.Minute
+
+// roundTimeout returns the timeout of the round: the base timeout of the
+// config plus 2^round seconds after a round change, up to maxRoundTimeout
+func (i *Ibft) roundTimeout(round uint64) time.Duration {
+	timeout := i.config.IBFTRoundTimeout
+	if timeout == 0 {
+		timeout = consensus.DefaultIBFTRoundTimeout
+	}
 	if round > 0 {
-		timeout += time.Duration(math.Pow(2, float64(round))) * time.Second
+		if round >= 32 {
+			return maxRoundTimeout
+		}
+		timeout += time.Duration(uint64(1)<<round) * time.Second
+	}
+	if timeout > maxRoundTimeout {
+		timeout = maxRoundTimeout
 	}
+	return timeout
+}
+
+// randomTimeout calculates the timeout duration depending on the current round
+func (i *Ibft) randomTimeout() chan struct{} {
+	timeout := i.roundTimeout(i.state.view.Round)
 
 	doneCh := make(chan struct{})
 	go func() {
@@ -853,7 +910,26 @@ func (i *Ibft) randomTimeout() chan struct{} {
 
 // isSealing checks if the current node is sealing blocks
 func (i *Ibft) isSealing() bool {
-	return i.sealing
+	return atomic.LoadUint32(&i.sealing) == 1
+}
+
+// BestPeerNumber returns the block height of the best peer of the syncer
+func (i *Ibft) BestPeerNumber() (uint64, bool) {
+	return i.syncer.BestPeerNumber()
+}
+
+// IsSealing returns true if the node is sealing blocks
+func (i *Ibft) IsSealing() bool {
+	return i.isSealing()
+}
+
+// SetSealing enables or disables the participation of the node in the consensus
+func (i *Ibft) SetSealing(enabled bool) {
+	var val uint32
+	if enabled {
+		val = 1
+	}
+	atomic.StoreUint32(&i.sealing, val)
 }
 
 // verifyHeaderImpl implements the actual header verification logic
@@ -921,6 +997,17 @@ func (i *Ibft) GetBlockCreator(header *types.Header) (types.Address, error) {
 	return ecrecoverFromHeader(header)
 }
 
+// EpochSize returns the number of blocks in an IBFT epoch
+func (i *Ibft) EpochSize() uint64 {
+	return i.epochSize
+}
+
+// OnEpochTransition is called when a checkpoint block is written.
+// The votes are already reset by the snapshot when processing the header
+func (i *Ibft) OnEpochTransition(block *types.Block) {
+	i.logger.Debug("epoch transition", "number", block.Number(), "epoch", block.Number()/i.epochSize)
+}
+
 // Close closes the IBFT consensus mechanism, and does write back to disk
 func (i *Ibft) Close() error {
 	close(i.closeCh)
@@ -2,6 +2,7 @@ package ibft
 
 import (
 	"testing"
+	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/consensus"
@@ -356,6 +357,85 @@ func TestTransition_RoundChangeState_Timeout(t *testing.T) {
 	})
 }
 
+func TestTransition_RoundChangeState_MissingProposer(t *testing.T) {
+	m := newMockIbft(t, []string{"A", "B", "C", "D"}, "C")
+	m.config.IBFTRoundTimeout = 100 * time.Millisecond
+	m.setState(AcceptState)
+
+	// A is the proposer of the round 0 but it is offline, the node
+	// moves to the round change after the configured timeout
+	now := time.Now()
+	m.runCycle()
+	assert.GreaterOrEqual(t, int64(time.Since(now)), int64(m.config.IBFTRoundTimeout))
+
+	m.expect(expectResult{
+		sequence: 1,
+		state:    RoundChangeState,
+	})
+
+	// the rest of the validators also move to the round 1
+	m.emitMsg(&proto.MessageReq{
+		From: "B",
+		Type: proto.MessageReq_RoundChange,
+		View: proto.ViewMsg(1, 1),
+	})
+	m.emitMsg(&proto.MessageReq{
+		From: "D",
+		Type: proto.MessageReq_RoundChange,
+		View: proto.ViewMsg(1, 1),
+	})
+	m.runCycle()
+
+	m.expect(expectResult{
+		sequence: 1,
+		round:    1,
+		outgoing: 1, // our round change
+		state:    AcceptState,
+	})
+
+	// B is the proposer of the round 1 and takes over
+	block := m.DummyBlock()
+	header, err := writeSeal(m.pool.get("B").priv, block.Header)
+	assert.NoError(t, err)
+	block.Header = header
+
+	m.emitMsg(&proto.MessageReq{
+		From: "B",
+		Type: proto.MessageReq_Preprepare,
+		Proposal: &any.Any{
+			Value: block.MarshalRLP(),
+		},
+		View: proto.ViewMsg(1, 1),
+	})
+	m.runCycle()
+
+	m.expect(expectResult{
+		sequence: 1,
+		round:    1,
+		outgoing: 2, // round change and prepare
+		state:    ValidateState,
+	})
+	assert.Equal(t, m.pool.get("B").Address(), m.state.proposer)
+}
+
+func TestRoundTimeout(t *testing.T) {
+	m := newMockIbft(t, []string{"A", "B", "C", "D"}, "A")
+
+	// default timeout
+	assert.Equal(t, consensus.DefaultIBFTRoundTimeout, m.roundTimeout(0))
+	assert.Equal(t, consensus.DefaultIBFTRoundTimeout+4*time.Second, m.roundTimeout(2))
+
+	// 2^round seconds are added to the configured timeout
+	m.config.IBFTRoundTimeout = time.Second
+	assert.Equal(t, time.Second, m.roundTimeout(0))
+	assert.Equal(t, 3*time.Second, m.roundTimeout(1))
+	assert.Equal(t, 9*time.Second, m.roundTimeout(3))
+
+	// the timeout is bounded
+	assert.Equal(t, maxRoundTimeout, m.roundTimeout(10))
+	assert.Equal(t, maxRoundTimeout, m.roundTimeout(1000))
+}
+
 func TestTransition_RoundChangeState_WeakCertificate(t *testing.T) {
 	m := newMockIbft(t, []string{"A", "B", "C", "D", "E", "F", "G"}, "A")
 
@@ -512,6 +592,10 @@ func newMockIbft(t *testing.T, accounts []string, account string) *mockIbft {
 	pool := newTesterAccountPool()
 	pool.add(accounts...)
 
+	// the validators are sorted by address in the snapshot, reassign the
+	// keys so that the order of the accounts matches the canonical order
+	pool.sortByAddress()
+
 	m := &mockIbft{
 		t:          t,
 		pool:       pool,
@@ -544,6 +628,9 @@ func newMockIbft(t *testing.T, accounts []string, account string) *mockIbft {
 	// by default set the state to (1, 0)
 	ibft.state.view = proto.ViewMsg(1, 0)
 
+	// the mock node takes part in the consensus
+	ibft.SetSealing(true)
+
 	m.Ibft = ibft
 
 	assert.NoError(t, ibft.setupSnapshot())
@@ -110,7 +110,12 @@ func (i *Ibft) addHeaderSnap(header *types.Header) error {
 		Hash:   header.Hash.String(),
 		Number: header.Number,
 		Votes:  []*Vote{},
-		Set:    extra.Validators,
+		Set:    ValidatorSet{},
+	}
+
+	// the validators are stored in canonical (sorted) order
+	for _, val := range extra.Validators {
+		snap.Set.Add(val)
 	}
 
 	i.store.add(snap)
@@ -284,6 +289,18 @@ func (i *Ibft) getSnapshotMetadata() (*snapshotMetadata, error) {
 	return meta, nil
 }
 
+// GetSnapshot returns the snapshot at the specified block height
+func (i *Ibft) GetSnapshot(num uint64) (*Snapshot, error) {
+	snap, err := i.getSnapshot(num)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("snapshot not found for block %d", num)
+	}
+	return snap, nil
+}
+
 // getSnapshot returns the snapshot at the specified block height
 func (i *Ibft) getSnapshot(num uint64) (*Snapshot, error) {
 	snap := i.store.find(num)
@@ -461,6 +478,9 @@ func (s *snapshotStore) loadFromPath(path string) error {
 		return err
 	}
 	for _, snap := range snaps {
+		// the snapshots stored before the validators were kept
+		// in canonical order might have an unsorted set
+		snap.Set.Sort()
 		s.add(snap)
 	}
 
@@ -1,10 +1,12 @@
 package ibft
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"testing"
 
@@ -78,6 +80,23 @@ func (ap *testerAccountPool) add(accounts ...string) {
 	}
 }
 
+// sortByAddress reassigns the keys of the accounts so that
+// the accounts are sorted by address in insertion order
+func (ap *testerAccountPool) sortByAddress() {
+	keys := make([]*ecdsa.PrivateKey, len(ap.accounts))
+	for indx, acct := range ap.accounts {
+		keys[indx] = acct.priv
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		addrI := crypto.PubKeyToAddress(&keys[i].PublicKey)
+		addrJ := crypto.PubKeyToAddress(&keys[j].PublicKey)
+		return bytes.Compare(addrI.Bytes(), addrJ.Bytes()) < 0
+	})
+	for indx, acct := range ap.accounts {
+		acct.priv = keys[indx]
+	}
+}
+
 func (ap *testerAccountPool) genesis() *chain.Genesis {
 	genesis := &types.Header{
 		MixHash: IstanbulDigest,
@@ -214,6 +233,7 @@ func TestSnapshot_setupSnapshot(t *testing.T) {
 
 	pool := newTesterAccountPool()
 	pool.add(validators...)
+	pool.sortByAddress()
 	validatorSet := pool.ValidatorSet()
 	genesis := pool.genesis()
 
@@ -812,6 +832,22 @@ func TestSnapshot_Store_SaveLoad(t *testing.T) {
 	assert.Equal(t, store0, store1)
 }
 
+func TestSnapshot_Store_LoadUnsorted(t *testing.T) {
+	tmpDir := getTempDir(t)
+
+	// a snapshot stored with the validators in insertion order
+	store0 := newSnapshotStore()
+	store0.add(&Snapshot{
+		Set: ValidatorSet{{0x3}, {0x1}, {0x2}},
+	})
+	assert.NoError(t, store0.saveToPath(tmpDir))
+
+	store1 := newSnapshotStore()
+	assert.NoError(t, store1.loadFromPath(tmpDir))
+
+	assert.Equal(t, ValidatorSet{{0x1}, {0x2}, {0x3}}, store1.list[0].Set)
+}
+
 func TestSnapshot_Store_Find(t *testing.T) {
 	store := newSnapshotStore()
 
@@ -833,3 +869,51 @@ func TestSnapshot_Store_Find(t *testing.T) {
 	check(21, 20)
 	check(1000, 100)
 }
+
+func TestSnapshot_DeterministicValidatorOrder(t *testing.T) {
+	pool := newTesterAccountPool()
+	pool.add("A")
+
+	genesis := pool.genesis()
+
+	// both chains end up with the same validator set (A, B, C) but
+	// the validators are voted in a different order
+	cases := [][]mockHeader{
+		{
+			{action: vote("A", "B", true)},
+			{action: vote("A", "C", true)},
+			{action: vote("B", "C", true)},
+		},
+		{
+			{action: vote("A", "C", true)},
+			{action: vote("A", "B", true)},
+			{action: vote("C", "B", true)},
+		},
+	}
+
+	sets := []ValidatorSet{}
+	for _, mockHeaders := range cases {
+		ibft := &Ibft{
+			epochSize:  10,
+			blockchain: blockchain.TestBlockchain(t, genesis),
+			config:     &consensus.Config{},
+		}
+		assert.NoError(t, ibft.setupSnapshot())
+
+		headers := buildHeaders(pool, genesis, mockHeaders)
+		assert.NoError(t, ibft.processHeaders(headers))
+
+		snap, err := ibft.getLatestSnapshot()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, snap.Set.Len())
+
+		sets = append(sets, snap.Set)
+	}
+
+	assert.Equal(t, sets[0], sets[1])
+
+	// the validators are sorted by address
+	for i := 1; i < len(sets[0]); i++ {
+		assert.Equal(t, -1, bytes.Compare(sets[0][i-1].Bytes(), sets[0][i].Bytes()))
+	}
+}
@@ -1,8 +1,10 @@
 package ibft
 
 import (
+	"bytes"
 	"fmt"
 	"math"
+	"sort"
 	"sync/atomic"
 
 	"github.com/0xPolygon/minimal/consensus/ibft/proto"
@@ -232,9 +234,24 @@ func (v *ValidatorSet) CalcProposer(round uint64, lastProposer types.Address) ty
 	return (*v)[pick]
 }
 
-// Add adds a new address to the validator set
+// Add adds a new address to the validator set.
+// The set is kept sorted by address so that every node
+// has the same canonical ordering for the proposer selection
 func (v *ValidatorSet) Add(addr types.Address) {
-	*v = append(*v, addr)
+	indx := sort.Search(len(*v), func(i int) bool {
+		return bytes.Compare((*v)[i].Bytes(), addr.Bytes()) >= 0
+	})
+
+	*v = append(*v, types.Address{})
+	copy((*v)[indx+1:], (*v)[indx:])
+	(*v)[indx] = addr
+}
+
+// Sort sorts the validator set by address, the canonical order of the set
+func (v *ValidatorSet) Sort() {
+	sort.Slice(*v, func(i, j int) bool {
+		return bytes.Compare((*v)[i].Bytes(), (*v)[j].Bytes()) < 0
+	})
 }
 
 // Del removes an address from the validator set
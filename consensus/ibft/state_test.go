@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/0xPolygon/minimal/consensus/ibft/proto"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,3 +64,24 @@ func TestState_AddMessages(t *testing.T) {
 
 	assert.Equal(t, c.numPrepared(), 2)
 }
+
+func TestState_ValidatorSetSorted(t *testing.T) {
+	addrs := []types.Address{{0x3}, {0x1}, {0x4}, {0x2}}
+
+	v0 := ValidatorSet{}
+	for _, addr := range addrs {
+		v0.Add(addr)
+	}
+
+	v1 := ValidatorSet{}
+	for i := len(addrs) - 1; i >= 0; i-- {
+		v1.Add(addrs[i])
+	}
+
+	expected := ValidatorSet{{0x1}, {0x2}, {0x3}, {0x4}}
+	assert.Equal(t, expected, v0)
+	assert.Equal(t, expected, v1)
+
+	// the proposer is the same for both sets
+	assert.Equal(t, v0.CalcProposer(0, types.ZeroAddress), v1.CalcProposer(0, types.ZeroAddress))
+}
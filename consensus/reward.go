@@ -0,0 +1,57 @@
+package consensus
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// blockRewardKey is the key of the block reward in the engine config
+const blockRewardKey = "blockReward"
+
+// BlockReward returns the reward (in wei) credited to the creator of every
+// block. It is set in the engine config of the chain as a decimal or hex
+// string so that all the nodes agree on it and it is zero if not set. JSON numbers
+// are rejected since they are decoded as float64 and lose precision
+func (c *Config) BlockReward() (*big.Int, error) {
+	raw, ok := c.Config[blockRewardKey]
+	if !ok {
+		return big.NewInt(0), nil
+	}
+
+	var reward *big.Int
+	switch obj := raw.(type) {
+	case string:
+		val, err := types.ParseUint256orHex(&obj)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block reward %s: %v", obj, err)
+		}
+		reward = val
+	default:
+		return nil, fmt.Errorf("block reward must be a decimal or hex string, found %v", raw)
+	}
+	if reward.Sign() < 0 {
+		return nil, fmt.Errorf("negative block reward %s", reward)
+	}
+	return reward, nil
+}
+
+// SetupBlockReward makes the executor credit the block reward to the creator of
+// every block, after the transactions of the block are applied. The chains
+// without a block reward are not affected
+func SetupBlockReward(config *Config, executor *state.Executor) error {
+	reward, err := config.BlockReward()
+	if err != nil {
+		return err
+	}
+	if reward.Sign() == 0 {
+		return nil
+	}
+
+	executor.FinalizeHook = func(t *state.Transition) {
+		t.Txn().AddBalance(t.GetTxContext().Coinbase, reward)
+	}
+	return nil
+}
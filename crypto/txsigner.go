@@ -3,6 +3,7 @@ package crypto
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"math/big"
 	"math/bits"
 
 	"github.com/0xPolygon/minimal/chain"
@@ -69,6 +70,36 @@ func calcTxHash(tx *types.Transaction, chainID uint64) types.Hash {
 	return types.BytesToHash(hash)
 }
 
+// calcTypedTxHash calculates the signing hash of an EIP-2930 or an EIP-1559 transaction
+// (keccak256 hash of the type followed by the RLP value of the unsigned payload)
+func calcTypedTxHash(tx *types.Transaction) types.Hash {
+	a := signerPool.Get()
+
+	v := a.NewArray()
+	v.Set(a.NewBigInt(tx.ChainID))
+	v.Set(a.NewUint(tx.Nonce))
+	if tx.Type == types.DynamicFeeTx {
+		v.Set(a.NewBigInt(tx.GasTipCap))
+		v.Set(a.NewBigInt(tx.GasFeeCap))
+	} else {
+		v.Set(a.NewBigInt(tx.GasPrice))
+	}
+	v.Set(a.NewUint(tx.Gas))
+	if tx.To == nil {
+		v.Set(a.NewNull())
+	} else {
+		v.Set(a.NewCopyBytes((*tx.To).Bytes()))
+	}
+	v.Set(a.NewBigInt(tx.Value))
+	v.Set(a.NewCopyBytes(tx.Input))
+	v.Set(tx.AccessList.MarshalRLPWith(a))
+
+	hash := keccak.Keccak256(nil, v.MarshalTo([]byte{byte(tx.Type)}))
+	signerPool.Put(a)
+
+	return types.BytesToHash(hash)
+}
+
 // Hash is a wrapper function for the calcTxHash, with chainID 0
 func (f *FrontierSigner) Hash(tx *types.Transaction) types.Hash {
 	return calcTxHash(tx, 0)
@@ -123,11 +154,18 @@ type EIP155Signer struct {
 
 // Hash is a wrapper function that calls calcTxHash with the EIP155Signer's chainID
 func (e *EIP155Signer) Hash(tx *types.Transaction) types.Hash {
+	if tx.Type != types.LegacyTx {
+		return calcTypedTxHash(tx)
+	}
 	return calcTxHash(tx, e.chainID)
 }
 
 // Sender returns the transaction sender
 func (e *EIP155Signer) Sender(tx *types.Transaction) (types.Address, error) {
+	if tx.Type != types.LegacyTx {
+		return e.typedSender(tx)
+	}
+
 	protected := true
 
 	if vv := uint(tx.V); bits.Len(vv) <= 8 {
@@ -157,6 +195,28 @@ func (e *EIP155Signer) Sender(tx *types.Transaction) (types.Address, error) {
 	return types.BytesToAddress(buf), nil
 }
 
+// typedSender returns the sender of a typed transaction. The chain id is
+// part of the payload and the V value is the y parity of the signature
+func (e *EIP155Signer) typedSender(tx *types.Transaction) (types.Address, error) {
+	if tx.ChainID == nil || !tx.ChainID.IsUint64() || tx.ChainID.Uint64() != e.chainID {
+		return types.Address{}, fmt.Errorf("invalid chain id %v, expected %d", tx.ChainID, e.chainID)
+	}
+
+	sig, err := encodeSignature(tx.R, tx.S, tx.V)
+	if err != nil {
+		return types.Address{}, err
+	}
+
+	pub, err := Ecrecover(e.Hash(tx).Bytes(), sig)
+	if err != nil {
+		return types.Address{}, err
+	}
+
+	buf := Keccak256(pub[1:])[12:]
+
+	return types.BytesToAddress(buf), nil
+}
+
 // SignTx signs the transaction using the passed in private key
 func (e *EIP155Signer) SignTx(
 	tx *types.Transaction,
@@ -164,6 +224,10 @@ func (e *EIP155Signer) SignTx(
 ) (*types.Transaction, error) {
 	tx = tx.Copy()
 
+	if tx.Type != types.LegacyTx && tx.ChainID == nil {
+		tx.ChainID = new(big.Int).SetUint64(e.chainID)
+	}
+
 	h := e.Hash(tx)
 
 	sig, err := Sign(privateKey, h[:])
@@ -173,7 +237,11 @@ func (e *EIP155Signer) SignTx(
 
 	tx.R = sig[:32]
 	tx.S = sig[32:64]
-	tx.V = byte(sig[64]+35) + (byte(e.chainID) * 2)
+	if tx.Type != types.LegacyTx {
+		tx.V = sig[64]
+	} else {
+		tx.V = byte(sig[64]+35) + (byte(e.chainID) * 2)
+	}
 
 	return tx, nil
 }
@@ -52,3 +52,67 @@ func TestEIP1155Signer(t *testing.T) {
 	_, err = signer2.Sender(txn)
 	assert.Error(t, err)
 }
+
+func TestEIP155Signer_DynamicFeeTx(t *testing.T) {
+	signer1 := NewEIP155Signer(1)
+
+	addr0 := types.Address{0x1}
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	txn := &types.Transaction{
+		Type:      types.DynamicFeeTx,
+		To:        &addr0,
+		Value:     big.NewInt(10),
+		GasPrice:  big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+	}
+	txn, err = signer1.SignTx(txn, key)
+	assert.NoError(t, err)
+
+	// the chain id is set in the payload and v is the y parity
+	assert.Equal(t, big.NewInt(1), txn.ChainID)
+	assert.LessOrEqual(t, txn.V, byte(1))
+
+	from, err := signer1.Sender(txn)
+	assert.NoError(t, err)
+	assert.Equal(t, from, PubKeyToAddress(&key.PublicKey))
+
+	// try to use a signer with another chain id
+	signer2 := NewEIP155Signer(2)
+	_, err = signer2.Sender(txn)
+	assert.Error(t, err)
+
+	// the access list is signed
+	txn.AccessList = types.AccessList{{Address: addr0}}
+	from, err = signer1.Sender(txn)
+	assert.NoError(t, err)
+	assert.NotEqual(t, from, PubKeyToAddress(&key.PublicKey))
+}
+
+func TestEIP155Signer_AccessListTx(t *testing.T) {
+	signer := NewEIP155Signer(1)
+
+	addr0 := types.Address{0x1}
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	txn := &types.Transaction{
+		Type:     types.AccessListTx,
+		To:       &addr0,
+		Value:    big.NewInt(10),
+		GasPrice: big.NewInt(1),
+		AccessList: types.AccessList{
+			{Address: addr0, StorageKeys: []types.Hash{{0x1}}},
+		},
+	}
+	txn, err = signer.SignTx(txn, key)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), txn.ChainID)
+	assert.LessOrEqual(t, txn.V, byte(1))
+
+	from, err := signer.Sender(txn)
+	assert.NoError(t, err)
+	assert.Equal(t, from, PubKeyToAddress(&key.PublicKey))
+}
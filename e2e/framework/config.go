@@ -32,6 +32,7 @@ type TestServerConfig struct {
 	PremineAccts  []*SrvAccount // Accounts with existing balances (genesis accounts)
 	Consensus     ConsensusType // Consensus Type
 	Bootnodes     []string      // Bootnode Addresses
+	NoGossip      bool          // Flag indicating if the gossip protocol is disabled
 	ShowsLog      bool
 }
 
@@ -72,6 +73,11 @@ func (t *TestServerConfig) SetBootnodes(bootnodes []string) {
 	t.Bootnodes = bootnodes
 }
 
+// SetNoGossip callback disables the gossip protocol
+func (t *TestServerConfig) SetNoGossip(state bool) {
+	t.NoGossip = state
+}
+
 // SetShowsLog sets flag for logging
 func (t *TestServerConfig) SetShowsLog(f bool) {
 	t.ShowsLog = f
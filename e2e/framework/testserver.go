@@ -236,6 +236,10 @@ func (t *TestServer) Start(ctx context.Context) error {
 		args = append(args, "--seal")
 	}
 
+	if t.Config.NoGossip {
+		args = append(args, "--no-gossip")
+	}
+
 	if t.Config.ShowsLog {
 		args = append(args, "--log-level", "debug")
 	}
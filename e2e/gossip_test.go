@@ -0,0 +1,37 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/e2e/framework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoGossip(t *testing.T) {
+	ibftManager := framework.NewIBFTServersManager(t, 1, IBFTDirPrefix, func(i int, config *framework.TestServerConfig) {
+		config.SetSeal(true)
+		config.SetNoGossip(true)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ibftManager.StartServers(ctx)
+
+	srv := ibftManager.GetServer(0)
+
+	// the node keeps sealing blocks and the rpc endpoint responds
+	_, err := framework.RetryUntilTimeout(ctx, func() (interface{}, bool) {
+		num, err := srv.JSONRPC().Eth().BlockNumber()
+		if err != nil {
+			return nil, true
+		}
+		return nil, num < 2
+	})
+	assert.NoError(t, err)
+
+	block, err := srv.JSONRPC().Eth().GetBlockByNumber(1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), block.Number)
+}
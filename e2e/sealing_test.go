@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/e2e/framework"
+	"github.com/0xPolygon/minimal/minimal/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/go-web3"
+)
+
+func TestSetSealing(t *testing.T) {
+	ibftManager := framework.NewIBFTServersManager(t, 1, IBFTDirPrefix, func(i int, config *framework.TestServerConfig) {
+		config.SetSeal(true)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ibftManager.StartServers(ctx)
+
+	srv := ibftManager.GetServer(0)
+	clt := srv.JSONRPC()
+
+	blockNumber := func() uint64 {
+		num, err := clt.Eth().BlockNumber()
+		assert.NoError(t, err)
+		return num
+	}
+
+	waitForBlock := func(num uint64) {
+		_, err := framework.RetryUntilTimeout(ctx, func() (interface{}, bool) {
+			return nil, blockNumber() < num
+		})
+		assert.NoError(t, err)
+	}
+
+	// the chain is advancing
+	waitForBlock(2)
+
+	// stop sealing
+	resp, err := srv.Operator().SetSealing(ctx, &proto.SetSealingRequest{Enabled: false})
+	assert.NoError(t, err)
+	assert.False(t, resp.Enabled)
+
+	// there might be one block in flight, wait for it
+	time.Sleep(5 * time.Second)
+	stopped := blockNumber()
+
+	time.Sleep(5 * time.Second)
+	assert.Equal(t, stopped, blockNumber())
+
+	// resume sealing
+	resp, err = srv.Operator().SetSealing(ctx, &proto.SetSealingRequest{Enabled: true})
+	assert.NoError(t, err)
+	assert.True(t, resp.Enabled)
+
+	waitForBlock(stopped + 2)
+
+	_, err = clt.Eth().GetBlockByNumber(web3.BlockNumber(stopped+1), false)
+	assert.NoError(t, err)
+}
@@ -1,13 +1,19 @@
 package jsonrpc
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/types"
 )
 
+// ErrStateNotFound is returned by the state helpers when the account
+// or the storage slot does not exist in the state
+var ErrStateNotFound = errors.New("given root and slot not found in storage")
+
 // stateHelperInterface Wrapper for these state functions
 // They are implemented by the jsonRPCHub in server.go
 type stateHelperInterface interface {
@@ -16,24 +22,41 @@ type stateHelperInterface interface {
 	GetCode(hash types.Hash) ([]byte, error)
 }
 
+// PendingState is the state after applying the pending transactions of the pool
+// on top of the current header. It only lives in memory, it has no state root
+type PendingState interface {
+	GetBalance(addr types.Address) *big.Int
+	GetState(addr types.Address, key types.Hash) types.Hash
+	GetCode(addr types.Address) []byte
+}
+
 // blockchain is the interface with the blockchain required
 // by the filter manager
 type blockchainInterface interface {
 	// Header returns the current header of the chain (genesis if empty)
 	Header() *types.Header
 
+	// HeadNumber returns the number of the current header
+	HeadNumber() uint64
+
 	// GetReceiptsByHash returns the receipts for a block hash
 	GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error)
 
 	// ReadTxLookup returns a block hash in which a given txn was mined
 	ReadTxLookup(txnHash types.Hash) (types.Hash, bool)
 
+	// GetTransactionByHash returns a sealed txn with its block and its index in the block
+	GetTransactionByHash(txnHash types.Hash) (*types.Transaction, *types.Block, int, bool)
+
 	// SubscribeEvents subscribes for chain head events
 	SubscribeEvents() blockchain.Subscription
 
 	// GetHeaderByNumber returns the header by number
 	GetHeaderByNumber(block uint64) (*types.Header, bool)
 
+	// GetHeaderByHash returns the header by hash
+	GetHeaderByHash(hash types.Hash) (*types.Header, bool)
+
 	// GetAvgGasPrice returns the average gas price
 	GetAvgGasPrice() *big.Int
 
@@ -46,30 +69,162 @@ type blockchainInterface interface {
 	// GetBlockByNumber returns a block using the provided number
 	GetBlockByNumber(num uint64, full bool) (*types.Block, bool)
 
-	// ApplyTxn applies a transaction object to the blockchain
-	ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error)
+	// ApplyTxn applies a transaction object to the blockchain on top
+	// of the state overrides (if any)
+	ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error)
+
+	// PendingState returns the state with the pending transactions
+	// of the pool applied on top of the current header
+	PendingState() (PendingState, error)
+
+	// GetPendingTx returns a transaction from the pool that is not sealed yet
+	GetPendingTx(txHash types.Hash) (*types.Transaction, bool)
+
+	// IsPromotedTx returns true if a transaction from the pool is ready to be sealed
+	IsPromotedTx(txHash types.Hash) bool
+
+	// GetTxs returns the transactions in the pool grouped by sender, both the
+	// ones ready to be sealed (pending) and the ones waiting for a nonce gap (queued)
+	GetTxs() (pending, queued map[types.Address][]*types.Transaction)
+
+	// Rebroadcast broadcasts again a transaction from the pool
+	Rebroadcast(txHash types.Hash) error
+
+	// SubscribePendingTxs subscribes for the hashes of the new transactions in the pool
+	SubscribePendingTxs() (<-chan types.Hash, func())
+
+	// TraceTxn executes again the transaction txIndex of the block with the tracer
+	TraceTxn(block *types.Block, txIndex int, tracer runtime.Tracer) (*runtime.ExecutionResult, error)
+
+	// TraceCall applies a transaction object on top of the state of the header with the tracer
+	TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) (*runtime.ExecutionResult, error)
+
+	// StorageRangeAt returns up to limit storage slots of an account with the state of
+	// a block after its first txIndex transactions, in the order of their hashed keys,
+	// starting at the hashed key start
+	StorageRangeAt(block *types.Block, txIndex int, addr types.Address, start types.Hash, limit int) (*StorageRange, error)
+
+	// PeerCount returns the number of connected peers
+	PeerCount() int64
+
+	// IsListening returns true if the network is accepting connections
+	IsListening() bool
+
+	// PeerScores returns the scores of the peers with a penalty by peer id
+	PeerScores() map[string]int64
 
-	// GetNonce returns the next nonce for this address
-	GetNonce(addr types.Address) (uint64, bool)
+	// GetIBFTSnapshot returns the snapshot of the ibft consensus after the block
+	GetIBFTSnapshot(header *types.Header) (*IBFTSnapshot, error)
 
 	stateHelperInterface
 }
 
+// IBFTSnapshot is the validator set of the ibft consensus after a block
+type IBFTSnapshot struct {
+	// Number and Hash are the block of the snapshot
+	Number uint64
+	Hash   types.Hash
+
+	// Proposer is the validator that sealed the block (empty for the genesis)
+	Proposer types.Address
+
+	Validators []types.Address
+	Votes      []*IBFTVote
+}
+
+// IBFTVote is a vote of a validator to add or remove a candidate
+type IBFTVote struct {
+	Validator types.Address
+	Address   types.Address
+	Authorize bool
+}
+
+// StorageSlot is a storage slot of an account
+type StorageSlot struct {
+	// Key is the hash of the slot
+	Key   types.Hash
+	Value types.Hash
+}
+
+// StorageRange is a range of the storage slots of an account
+type StorageRange struct {
+	Slots []*StorageSlot
+
+	// NextKey is the hashed key of the slot after the range, nil if there are no more slots
+	NextKey *types.Hash
+}
+
 type nullBlockchainInterface struct {
 }
 
-func (b *nullBlockchainInterface) GetNonce(addr types.Address) (uint64, bool) {
-	return 0, false
+func (b *nullBlockchainInterface) PendingState() (PendingState, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) GetPendingTx(txHash types.Hash) (*types.Transaction, bool) {
+	return nil, false
+}
+
+func (b *nullBlockchainInterface) IsPromotedTx(txHash types.Hash) bool {
+	return false
+}
+
+func (b *nullBlockchainInterface) GetTxs() (map[types.Address][]*types.Transaction, map[types.Address][]*types.Transaction) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) Rebroadcast(txHash types.Hash) error {
+	return nil
+}
+
+func (b *nullBlockchainInterface) SubscribePendingTxs() (<-chan types.Hash, func()) {
+	return nil, func() {}
+}
+
+func (b *nullBlockchainInterface) TraceTxn(block *types.Block, txIndex int, tracer runtime.Tracer) (*runtime.ExecutionResult, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) (*runtime.ExecutionResult, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) StorageRangeAt(block *types.Block, txIndex int, addr types.Address, start types.Hash, limit int) (*StorageRange, error) {
+	return nil, nil
+}
+
+func (b *nullBlockchainInterface) PeerCount() int64 {
+	return 0
+}
+
+func (b *nullBlockchainInterface) IsListening() bool {
+	return false
+}
+
+func (b *nullBlockchainInterface) PeerScores() map[string]int64 {
+	return nil
+}
+
+func (b *nullBlockchainInterface) GetIBFTSnapshot(header *types.Header) (*IBFTSnapshot, error) {
+	return nil, nil
 }
 
 func (b *nullBlockchainInterface) Header() *types.Header {
 	return nil
 }
 
+func (b *nullBlockchainInterface) HeadNumber() uint64 {
+	return 0
+}
+
 func (b *nullBlockchainInterface) ReadTxLookup(txnHash types.Hash) (types.Hash, bool) {
 	return types.Hash{}, false
 }
 
+func (b *nullBlockchainInterface) GetTransactionByHash(txnHash types.Hash) (*types.Transaction, *types.Block, int, bool) {
+	return nil, nil, 0, false
+}
+
 func (b *nullBlockchainInterface) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
 	return nil, nil
 }
@@ -82,6 +237,10 @@ func (b *nullBlockchainInterface) GetHeaderByNumber(block uint64) (*types.Header
 	return nil, false
 }
 
+func (b *nullBlockchainInterface) GetHeaderByHash(hash types.Hash) (*types.Header, bool) {
+	return nil, false
+}
+
 func (b *nullBlockchainInterface) GetAvgGasPrice() *big.Int {
 	return nil
 }
@@ -106,8 +265,8 @@ func (b *nullBlockchainInterface) GetBlockByNumber(num uint64, full bool) (*type
 	return nil, false
 }
 
-func (b *nullBlockchainInterface) ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error) {
-	return nil, false, nil
+func (b *nullBlockchainInterface) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
+	return nil, nil
 }
 
 func (b *nullBlockchainInterface) GetCode(hash types.Hash) ([]byte, error) {
@@ -1,6 +1,7 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -78,3 +79,45 @@ func (b *BlockNumber) UnmarshalJSON(buffer []byte) error {
 	*b = num
 	return nil
 }
+
+// BlockNumberOrHash is the block parameter of the state queries. Besides the block
+// number (or tag) it accepts the EIP-1898 object forms: {"blockNumber": "0x1"} and
+// {"blockHash": "0x...", "requireCanonical": true}
+type BlockNumberOrHash struct {
+	BlockNumber      *BlockNumber
+	BlockHash        *types.Hash
+	RequireCanonical bool
+}
+
+// UnmarshalJSON decodes either a block number or an EIP-1898 object
+func (b *BlockNumberOrHash) UnmarshalJSON(buffer []byte) error {
+	buffer = bytes.TrimSpace(buffer)
+	if len(buffer) == 0 || buffer[0] != '{' {
+		var num BlockNumber
+		if err := num.UnmarshalJSON(buffer); err != nil {
+			return err
+		}
+		b.BlockNumber = &num
+		return nil
+	}
+
+	var obj struct {
+		BlockNumber      *BlockNumber `json:"blockNumber"`
+		BlockHash        *types.Hash  `json:"blockHash"`
+		RequireCanonical bool         `json:"requireCanonical"`
+	}
+	if err := json.Unmarshal(buffer, &obj); err != nil {
+		return err
+	}
+	if (obj.BlockNumber == nil) == (obj.BlockHash == nil) {
+		return fmt.Errorf("either blockNumber or blockHash expected")
+	}
+	if obj.BlockNumber != nil && obj.RequireCanonical {
+		return fmt.Errorf("requireCanonical is only valid with blockHash")
+	}
+
+	b.BlockNumber = obj.BlockNumber
+	b.BlockHash = obj.BlockHash
+	b.RequireCanonical = obj.RequireCanonical
+	return nil
+}
@@ -0,0 +1,119 @@
+package jsonrpc
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// Debug is the debug jsonrpc endpoint
+type Debug struct {
+	d *Dispatcher
+}
+
+// DecodeRawTransaction decodes a signed raw transaction and recovers its sender
+// without sending it to the pool (debug_decodeRawTransaction)
+func (d *Debug) DecodeRawTransaction(input string) (interface{}, error) {
+	buf, err := hex.DecodeHex(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex input: %v", err)
+	}
+
+	tx := &types.Transaction{}
+	if err := tx.UnmarshalRLP(buf); err != nil {
+		return nil, fmt.Errorf("failed to decode the transaction: %v", err)
+	}
+	tx.ComputeHash()
+
+	from, err := crypto.NewEIP155Signer(d.d.chainID).Sender(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover the sender: %v", err)
+	}
+	tx.From = from
+
+	return toTransaction(tx, nil, 0), nil
+}
+
+type storageEntry struct {
+	// Key is the preimage of the hashed key, it is always null since the
+	// preimages are not stored
+	Key   *types.Hash `json:"key"`
+	Value types.Hash  `json:"value"`
+}
+
+type storageRangeResult struct {
+	Storage map[types.Hash]storageEntry `json:"storage"`
+	NextKey *types.Hash                 `json:"nextKey"`
+}
+
+// StorageRangeAt returns up to limit storage slots of an account, starting at the
+// hashed key keyStart, with the state of the block before the transaction
+// txIndex is applied (debug_storageRangeAt)
+func (d *Debug) StorageRangeAt(blockHash types.Hash, txIndex int, address types.Address, keyStart argBytes, limit int) (interface{}, error) {
+	block, ok := d.d.store.GetBlockByHash(blockHash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", blockHash)
+	}
+	if txIndex < 0 || txIndex > len(block.Transactions) {
+		return nil, fmt.Errorf("transaction index %d out of range for block %s", txIndex, blockHash)
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+
+	storageRange, err := d.d.store.StorageRangeAt(block, txIndex, address, types.BytesToHash(keyStart), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &storageRangeResult{
+		Storage: map[types.Hash]storageEntry{},
+		NextKey: storageRange.NextKey,
+	}
+	for _, slot := range storageRange.Slots {
+		res.Storage[slot.Key] = storageEntry{Value: slot.Value}
+	}
+	return res, nil
+}
+
+// TraceTransaction executes again a sealed transaction in the context of its
+// block and returns the steps of the execution (debug_traceTransaction)
+func (d *Debug) TraceTransaction(hash types.Hash, config *traceConfig) (interface{}, error) {
+	_, block, index, ok := d.d.store.GetTransactionByHash(hash)
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", hash)
+	}
+	tracer, err := newTracer(config)
+	if err != nil {
+		return nil, err
+	}
+	result, err := d.d.store.TraceTxn(block, index, tracer)
+	if err != nil {
+		return nil, err
+	}
+	return tracer.result(result), nil
+}
+
+// TraceCall executes a call on top of the state of the given block without
+// committing it and returns the steps of the execution (debug_traceCall)
+func (d *Debug) TraceCall(arg *txnArgs, number BlockNumberOrHash, config *traceConfig) (interface{}, error) {
+	header, err := d.d.getBlockHeaderByNumberOrHash(number)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := d.d.decodeTxn(arg, header)
+	if err != nil {
+		return nil, err
+	}
+	tracer, err := newTracer(config)
+	if err != nil {
+		return nil, err
+	}
+	result, err := d.d.store.TraceCall(header, transaction, tracer)
+	if err != nil {
+		return nil, err
+	}
+	return tracer.result(result), nil
+}
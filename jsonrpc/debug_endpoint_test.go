@@ -0,0 +1,483 @@
+package jsonrpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugEndpoint_DecodeRawTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	sender := crypto.PubKeyToAddress(&key.PublicKey)
+
+	signer := crypto.NewEIP155Signer(100)
+	txn, err := signer.SignTx(&types.Transaction{
+		Nonce:    5,
+		To:       &addr1,
+		Value:    big.NewInt(10),
+		GasPrice: big.NewInt(1),
+		Gas:      21000,
+		Input:    []byte{0x1},
+	}, key)
+	assert.NoError(t, err)
+	txn.ComputeHash()
+
+	s := newDispatcher(hclog.NewNullLogger(), nil, 100, nil)
+
+	resp, err := s.Handle([]byte(`{
+		"method": "debug_decodeRawTransaction",
+		"params": ["` + hex.EncodeToHex(txn.MarshalRLP()) + `"]
+	}`))
+	assert.NoError(t, err)
+
+	var res struct {
+		From     types.Address
+		To       types.Address
+		Hash     types.Hash
+		Nonce    string
+		Gas      string
+		GasPrice string
+		Value    string
+		Input    string
+	}
+	assert.NoError(t, expectJSONResult(resp, &res))
+	assert.Equal(t, sender, res.From)
+	assert.Equal(t, addr1, res.To)
+	assert.Equal(t, txn.Hash, res.Hash)
+	assert.Equal(t, "0x5", res.Nonce)
+	assert.Equal(t, "0x5208", res.Gas)
+	assert.Equal(t, "0x1", res.GasPrice)
+	assert.Equal(t, "0xa", res.Value)
+	assert.Equal(t, "0x01", res.Input)
+
+	// malformed inputs return an error
+	for _, input := range []string{"0xzz", "0x0102"} {
+		_, err := s.endpoints.Debug.DecodeRawTransaction(input)
+		assert.Error(t, err)
+	}
+}
+
+type mockStorageRangeStore struct {
+	nullBlockchainInterface
+
+	block *types.Block
+
+	// the arguments of the last storage range request
+	txIndex int
+	start   types.Hash
+	limit   int
+}
+
+func (m *mockStorageRangeStore) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
+	if hash != m.block.Hash() {
+		return nil, false
+	}
+	return m.block, true
+}
+
+func (m *mockStorageRangeStore) StorageRangeAt(block *types.Block, txIndex int, addr types.Address, start types.Hash, limit int) (*StorageRange, error) {
+	m.txIndex, m.start, m.limit = txIndex, start, limit
+
+	next := types.Hash{0x3}
+	return &StorageRange{
+		Slots: []*StorageSlot{
+			{Key: types.Hash{0x1}, Value: types.Hash{0x11}},
+			{Key: types.Hash{0x2}, Value: types.Hash{0x12}},
+		},
+		NextKey: &next,
+	}, nil
+}
+
+func TestDebugEndpoint_StorageRangeAt(t *testing.T) {
+	block := &types.Block{
+		Header:       &types.Header{Number: 1},
+		Transactions: []*types.Transaction{{Nonce: 0}, {Nonce: 1}},
+	}
+	block.Header.ComputeHash()
+
+	store := &mockStorageRangeStore{
+		block: block,
+	}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	call := func(hash types.Hash, txIndex string) ([]byte, error) {
+		return dispatcher.Handle([]byte(`{
+			"method": "debug_storageRangeAt",
+			"params": ["` + hash.String() + `", ` + txIndex + `, "` + addr0.String() + `", "0x01", 2]
+		}`))
+	}
+
+	resp, err := call(block.Hash(), "1")
+	assert.NoError(t, err)
+
+	var res struct {
+		Storage map[types.Hash]struct {
+			Key   *types.Hash
+			Value types.Hash
+		}
+		NextKey *types.Hash
+	}
+	assert.NoError(t, expectJSONResult(resp, &res))
+
+	// the range is read from the state before the transaction
+	assert.Equal(t, 1, store.txIndex)
+	assert.Equal(t, types.BytesToHash([]byte{0x1}), store.start)
+	assert.Equal(t, 2, store.limit)
+
+	assert.Len(t, res.Storage, 2)
+	assert.Nil(t, res.Storage[types.Hash{0x1}].Key)
+	assert.Equal(t, types.Hash{0x11}, res.Storage[types.Hash{0x1}].Value)
+	assert.Equal(t, types.Hash{0x12}, res.Storage[types.Hash{0x2}].Value)
+	assert.Equal(t, &types.Hash{0x3}, res.NextKey)
+
+	// the state after all the transactions of the block
+	_, err = call(block.Hash(), "2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, store.txIndex)
+
+	// the transaction index is out of range
+	_, err = call(block.Hash(), "3")
+	assert.Error(t, err)
+
+	// unknown block
+	_, err = call(types.Hash{0x1}, "0")
+	assert.Error(t, err)
+}
+
+// mockTraceStore executes the transactions of a single block with a real executor
+type mockTraceStore struct {
+	nullBlockchainInterface
+
+	executor *state.Executor
+	root     types.Hash
+	block    *types.Block
+}
+
+func (m *mockTraceStore) GetTransactionByHash(hash types.Hash) (*types.Transaction, *types.Block, int, bool) {
+	for i, txn := range m.block.Transactions {
+		if txn.Hash == hash {
+			return txn, m.block, i, true
+		}
+	}
+	return nil, nil, 0, false
+}
+
+func (m *mockTraceStore) TraceTxn(block *types.Block, txIndex int, tracer runtime.Tracer) (*runtime.ExecutionResult, error) {
+	transition, err := m.executor.BeginTxn(m.root, block.Header, types.ZeroAddress)
+	if err != nil {
+		return nil, err
+	}
+	transition.SetTracer(tracer)
+
+	gasUsed, _, err := transition.Apply(block.Transactions[txIndex])
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.ExecutionResult{
+		ReturnValue: transition.ReturnValue(),
+		GasUsed:     gasUsed,
+		Err:         transition.ReturnErr(),
+	}, nil
+}
+
+func (m *mockTraceStore) Header() *types.Header {
+	return m.block.Header
+}
+
+func (m *mockTraceStore) TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) (*runtime.ExecutionResult, error) {
+	transition, err := m.executor.BeginTxn(header.StateRoot, header, types.ZeroAddress)
+	if err != nil {
+		return nil, err
+	}
+	transition.SetTracer(tracer)
+
+	gasUsed, _, err := transition.Apply(txn)
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.ExecutionResult{
+		ReturnValue: transition.ReturnValue(),
+		GasUsed:     gasUsed,
+		Err:         transition.ReturnErr(),
+	}, nil
+}
+
+// newTraceTestStore returns a trace store with the sender
+// funded and the code deployed at the contract address
+func newTraceTestStore(t *testing.T, sender, contract types.Address, code []byte) *mockTraceStore {
+	executor := state.NewExecutor(&chain.Params{
+		Forks: chain.AllForksEnabled,
+	}, itrie.NewState(itrie.NewMemoryStorage()))
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash {
+			return types.ZeroHash
+		}
+	}
+	executor.SetRuntime(evm.NewEVM())
+
+	root, err := executor.WriteGenesis(&chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender:   {Balance: big.NewInt(1000000)},
+			contract: {Code: code},
+		},
+	})
+	assert.NoError(t, err)
+
+	return &mockTraceStore{
+		executor: executor,
+		root:     root,
+		block: &types.Block{
+			Header: &types.Header{Number: 1, GasLimit: 1000000, StateRoot: root},
+		},
+	}
+}
+
+func TestDebugEndpoint_TraceTransaction(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	// stores 2 in the slot 1, loads it and returns it
+	code := []byte{
+		0x60, 0x02, 0x60, 0x01, 0x55,
+		0x60, 0x01, 0x54,
+		0x60, 0x00, 0x52,
+		0x60, 0x20, 0x60, 0x00, 0xf3,
+	}
+	store := newTraceTestStore(t, sender, contract, code)
+
+	txn := &types.Transaction{
+		From:     sender,
+		To:       &contract,
+		Value:    big.NewInt(0),
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+	}
+	txn.ComputeHash()
+
+	store.block.Transactions = []*types.Transaction{txn}
+	debug := newTestDispatcher(hclog.NewNullLogger(), store).endpoints.Debug
+
+	res, err := debug.TraceTransaction(txn.Hash, nil)
+	assert.NoError(t, err)
+
+	trace := res.(*traceResult)
+	assert.False(t, trace.Failed)
+	assert.Equal(t, types.BytesToHash([]byte{0x2}).Bytes(), []byte(trace.ReturnValue))
+
+	ops := []string{}
+	for _, log := range trace.StructLogs {
+		ops = append(ops, log.Op)
+	}
+	assert.Equal(t, []string{
+		"PUSH1", "PUSH1", "SSTORE",
+		"PUSH1", "SLOAD",
+		"PUSH1", "MSTORE",
+		"PUSH1", "PUSH1", "RETURN",
+	}, ops)
+
+	// the steps before the opcode is executed
+	sstore := trace.StructLogs[2]
+	assert.Equal(t, uint64(4), sstore.PC)
+	assert.Equal(t, []string{"0x2", "0x1"}, sstore.Stack)
+	assert.Equal(t, sstore.Gas-trace.StructLogs[3].Gas, sstore.GasCost)
+
+	slot := hex.EncodeToString(types.BytesToHash([]byte{0x1}).Bytes())
+	value := hex.EncodeToString(types.BytesToHash([]byte{0x2}).Bytes())
+	assert.Equal(t, map[string]string{slot: value}, sstore.Storage)
+	assert.Equal(t, map[string]string{slot: value}, trace.StructLogs[4].Storage)
+
+	ret := trace.StructLogs[9]
+	assert.Equal(t, []string{value}, ret.Memory)
+
+	// the stack and the memory are not captured if disabled
+	res, err = debug.TraceTransaction(txn.Hash, &traceConfig{DisableStack: true, DisableMemory: true})
+	assert.NoError(t, err)
+
+	for _, log := range res.(*traceResult).StructLogs {
+		assert.Empty(t, log.Stack)
+		assert.Empty(t, log.Memory)
+	}
+
+	// the vm does not copy them either, unless the stack is needed for the storage
+	for _, config := range []*traceConfig{
+		{DisableStack: true, DisableMemory: true},
+		{DisableStack: true, DisableMemory: true, DisableStorage: true},
+	} {
+		tracer := newStructTracer(config)
+		_, err = store.TraceTxn(store.block, 0, tracer)
+		assert.NoError(t, err)
+
+		for _, step := range tracer.steps {
+			assert.Nil(t, step.Memory)
+			assert.Equal(t, config.DisableStorage, step.Stack == nil)
+		}
+	}
+
+	// unknown transaction
+	_, err = debug.TraceTransaction(types.StringToHash("1"), nil)
+	assert.Error(t, err)
+}
+
+func TestDebugEndpoint_TraceCall(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	// jumps to the store of the slot 0 if the first byte
+	// of the input is set, otherwise it stops
+	code := []byte{
+		0x60, 0x00, 0x35, 0x60, 0xf8, 0x1c,
+		0x60, 0x0a, 0x57,
+		0x00,
+		0x5b, 0x60, 0x01, 0x60, 0x00, 0x55, 0x00,
+	}
+	store := newTraceTestStore(t, sender, contract, code)
+	debug := newTestDispatcher(hclog.NewNullLogger(), store).endpoints.Debug
+
+	traceCall := func(input []byte) []string {
+		res, err := debug.TraceCall(&txnArgs{
+			From:     argAddrPtr(sender),
+			To:       argAddrPtr(contract),
+			Nonce:    argUintPtr(0),
+			GasPrice: argBytesPtr([]byte{0x0}),
+			Gas:      argUintPtr(100000),
+			Data:     argBytesPtr(input),
+		}, blockNum(LatestBlockNumber), &traceConfig{DisableStack: true})
+		assert.NoError(t, err)
+
+		trace := res.(*traceResult)
+		assert.False(t, trace.Failed)
+
+		ops := []string{}
+		for _, log := range trace.StructLogs {
+			assert.Empty(t, log.Stack)
+			ops = append(ops, log.Op)
+		}
+		return ops
+	}
+
+	prefix := []string{"PUSH1", "CALLDATALOAD", "PUSH1", "SHR", "PUSH1", "JUMPI"}
+	assert.Equal(t, append(prefix, "STOP"), traceCall([]byte{0x0}))
+	assert.Equal(t, append(prefix, "JUMPDEST", "PUSH1", "PUSH1", "SSTORE", "STOP"), traceCall([]byte{0x1}))
+
+	// unknown block
+	_, err := debug.TraceCall(&txnArgs{
+		From:  argAddrPtr(sender),
+		To:    argAddrPtr(contract),
+		Nonce: argUintPtr(0),
+	}, blockNum(BlockNumber(10)), nil)
+	assert.Error(t, err)
+}
+
+func TestDebugEndpoint_TraceTransaction_CallTracer(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+	child := types.BytesToAddress([]byte{0xff})
+
+	// calls the child contract with 5 wei and 10000 gas
+	code := []byte{
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00,
+		0x60, 0x05, 0x60, 0xff, 0x61, 0x27, 0x10,
+		0xf1, 0x50, 0x00,
+	}
+	// returns 0x2a
+	childCode := []byte{0x60, 0x2a, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+
+	store := newTraceTestStore(t, sender, contract, code)
+
+	// deploy the child contract and fund the caller
+	transition, err := store.executor.BeginTxn(store.root, store.block.Header, types.ZeroAddress)
+	assert.NoError(t, err)
+	transition.Txn().SetCode(child, childCode)
+	transition.Txn().AddBalance(contract, big.NewInt(10))
+	_, store.root = transition.Commit()
+
+	txn := &types.Transaction{
+		From:     sender,
+		To:       &contract,
+		Value:    big.NewInt(0),
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+	}
+	txn.ComputeHash()
+	store.block.Transactions = []*types.Transaction{txn}
+
+	debug := newTestDispatcher(hclog.NewNullLogger(), store).endpoints.Debug
+
+	res, err := debug.TraceTransaction(txn.Hash, &traceConfig{Tracer: "callTracer"})
+	assert.NoError(t, err)
+
+	root := res.(*callFrame)
+	assert.Equal(t, "CALL", root.Type)
+	assert.Equal(t, sender, root.From)
+	assert.Equal(t, contract, root.To)
+	assert.Empty(t, root.Error)
+
+	// the root call uses the gas of the transaction
+	transition, err = store.executor.BeginTxn(store.root, store.block.Header, types.ZeroAddress)
+	assert.NoError(t, err)
+	gasUsed, _, err := transition.Apply(txn.Copy())
+	assert.NoError(t, err)
+	assert.Equal(t, argUint64(gasUsed), root.GasUsed)
+
+	// the child call with its value and the gas stipend of the value transfer
+	assert.Len(t, root.Calls, 1)
+	call := root.Calls[0]
+	assert.Equal(t, "CALL", call.Type)
+	assert.Equal(t, contract, call.From)
+	assert.Equal(t, child, call.To)
+	assert.Equal(t, argBigPtr(big.NewInt(5)), call.Value)
+	assert.Equal(t, argUint64(10000+2300), call.Gas)
+	assert.Equal(t, argUint64(18), call.GasUsed)
+	assert.Equal(t, argBytes(types.BytesToHash([]byte{0x2a}).Bytes()), call.Output)
+	assert.Empty(t, call.Calls)
+
+	// unknown tracer
+	_, err = debug.TraceTransaction(txn.Hash, &traceConfig{Tracer: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestDebugEndpoint_TraceTransaction_CallTracer_Create2(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	// creates a contract with an empty init code and the salt 1
+	code := []byte{
+		0x60, 0x01, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00,
+		0xf5, 0x50, 0x00,
+	}
+	store := newTraceTestStore(t, sender, contract, code)
+
+	txn := &types.Transaction{
+		From:     sender,
+		To:       &contract,
+		Value:    big.NewInt(0),
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+	}
+	txn.ComputeHash()
+	store.block.Transactions = []*types.Transaction{txn}
+
+	debug := newTestDispatcher(hclog.NewNullLogger(), store).endpoints.Debug
+
+	res, err := debug.TraceTransaction(txn.Hash, &traceConfig{Tracer: "callTracer"})
+	assert.NoError(t, err)
+
+	root := res.(*callFrame)
+	assert.Len(t, root.Calls, 1)
+
+	create := root.Calls[0]
+	assert.Equal(t, "CREATE2", create.Type)
+	assert.Equal(t, contract, create.From)
+	assert.Equal(t, crypto.CreateAddress2(contract, types.BytesToHash([]byte{0x1}), []byte{}), create.To)
+}
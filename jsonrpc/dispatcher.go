@@ -2,14 +2,18 @@ package jsonrpc
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
+	"sort"
 	"strings"
 	"unicode"
 
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/go-hclog"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 var (
@@ -17,6 +21,10 @@ var (
 	internalError      = &ErrorObject{Code: -32603, Message: "internal error"}
 )
 
+func senderNotAllowed(from types.Address) error {
+	return &ErrorObject{Code: -32003, Message: fmt.Sprintf("transaction rejected: sender %s is not allowed to send transactions", from)}
+}
+
 func invalidMethod(method string) error {
 	return &ErrorObject{Code: -32601, Message: fmt.Sprintf("The method %s does not exist/is not available", method)}
 }
@@ -38,9 +46,12 @@ func (f *funcData) numParams() int {
 }
 
 type endpoints struct {
-	Eth  *Eth
-	Web3 *Web3
-	Net  *Net
+	Eth    *Eth
+	Web3   *Web3
+	Net    *Net
+	Debug  *Debug
+	TxPool *TxPool
+	IBFT   *IBFT
 }
 
 // Dispatcher handles jsonrpc requests
@@ -51,13 +62,55 @@ type Dispatcher struct {
 	endpoints     endpoints
 	filterManager *FilterManager
 	chainID       uint64
+
+	// cache of the eth_call results (optional)
+	callCache *lru.Cache
+
+	// maximum number of blocks queried by eth_getLogs
+	maxLogBlockRange uint64
+
+	// maximum number of addresses of an eth_getLogs filter
+	maxLogAddresses int
+
+	// maximum number of logs returned by an eth_getLogs that is not paginated
+	maxLogs int
+
+	// maximum number of logs per page of a paginated eth_getLogs
+	maxLogsPerPage int
+
+	// maximum gas limit searched by eth_estimateGas
+	gasEstimationCap uint64
+
+	// rate limits per namespace (optional)
+	rateLimits map[string]*tokenBucket
+
+	// senders allowed to send transactions, all of them if empty
+	senderAllowlist map[types.Address]struct{}
+
+	// forks of the chain, the typed transactions are rejected if not set
+	forks *chain.Forks
 }
 
+const defaultMaxLogBlockRange = 1000
+
+const defaultMaxLogsPerPage = 1000
+
+const defaultMaxLogAddresses = 256
+
+const defaultMaxLogs = 10000
+
+var defaultGasEstimationCap = types.GasCap.Uint64()
+
 // newTestDispatcher returns a dispatcher without the filter manager, used for testing
 func newTestDispatcher(logger hclog.Logger, store blockchainInterface) *Dispatcher {
 	d := &Dispatcher{
-		logger: logger.Named("dispatcher"),
-		store:  store,
+		logger:           logger.Named("dispatcher"),
+		store:            store,
+		maxLogBlockRange: defaultMaxLogBlockRange,
+		maxLogAddresses:  defaultMaxLogAddresses,
+		maxLogs:          defaultMaxLogs,
+		maxLogsPerPage:   defaultMaxLogsPerPage,
+		gasEstimationCap: defaultGasEstimationCap,
 	}
 
 	d.registerEndpoints()
@@ -65,28 +118,115 @@ func newTestDispatcher(logger hclog.Logger, store blockchainInterface) *Dispatch
 	return d
 }
 
-func newDispatcher(logger hclog.Logger, store blockchainInterface, chainID uint64) *Dispatcher {
+func newDispatcher(logger hclog.Logger, store blockchainInterface, chainID uint64, filterConfig *FilterManagerConfig) *Dispatcher {
 	d := &Dispatcher{
-		logger:  logger.Named("dispatcher"),
-		store:   store,
-		chainID: chainID,
+		logger:           logger.Named("dispatcher"),
+		store:            store,
+		chainID:          chainID,
+		maxLogBlockRange: defaultMaxLogBlockRange,
+		maxLogAddresses:  defaultMaxLogAddresses,
+		maxLogs:          defaultMaxLogs,
+		maxLogsPerPage:   defaultMaxLogsPerPage,
+		gasEstimationCap: defaultGasEstimationCap,
 	}
 	d.registerEndpoints()
 	if store != nil {
-		d.filterManager = NewFilterManager(logger, store)
+		d.filterManager = NewFilterManager(logger, store, filterConfig)
 		go d.filterManager.Run()
 	}
 	return d
 }
 
+// logQueryLimits returns the limits of the eth_getLogs queries
+func (d *Dispatcher) logQueryLimits() *logQueryLimits {
+	return &logQueryLimits{
+		maxBlockRange: d.maxLogBlockRange,
+		maxAddresses:  d.maxLogAddresses,
+		maxLogs:       d.maxLogs,
+	}
+}
+
+// setupCallCache enables the cache of the eth_call results with the given size
+func (d *Dispatcher) setupCallCache(size int) error {
+	cache, err := lru.New(size)
+	if err != nil {
+		return err
+	}
+	d.callCache = cache
+	return nil
+}
+
+// setupRateLimits enables the rate limits of the given namespaces
+func (d *Dispatcher) setupRateLimits(limits map[string]*RateLimit) error {
+	rateLimits := map[string]*tokenBucket{}
+	for namespace, limit := range limits {
+		if _, ok := d.serviceMap[namespace]; !ok {
+			return fmt.Errorf("rate limit for unknown namespace '%s'", namespace)
+		}
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("invalid rate limit for namespace '%s': %v", namespace, err)
+		}
+		rateLimits[namespace] = newTokenBucket(limit)
+	}
+	d.rateLimits = rateLimits
+	return nil
+}
+
+// setupSenderAllowlist restricts the senders of the transactions to the given addresses
+func (d *Dispatcher) setupSenderAllowlist(addrs []types.Address) {
+	allowlist := map[types.Address]struct{}{}
+	for _, addr := range addrs {
+		allowlist[addr] = struct{}{}
+	}
+	d.senderAllowlist = allowlist
+}
+
+// checkSender returns an error if the sender is not allowed to send transactions
+func (d *Dispatcher) checkSender(from types.Address) error {
+	if len(d.senderAllowlist) == 0 {
+		return nil
+	}
+	if _, ok := d.senderAllowlist[from]; ok {
+		return nil
+	}
+	return senderNotAllowed(from)
+}
+
+// acceptsTx returns true if the forks of the next block enable the type
+// and the access list of the transaction
+func (d *Dispatcher) acceptsTx(tx *types.Transaction) bool {
+	if d.forks == nil {
+		return tx.Type == types.LegacyTx && len(tx.AccessList) == 0
+	}
+	return d.forks.At(d.store.HeadNumber() + 1).AcceptsTx(tx)
+}
+
+// checkRateLimit returns an error if the namespace of the method has exhausted its rate limit
+func (d *Dispatcher) checkRateLimit(method string) error {
+	namespace := strings.SplitN(method, "_", 2)[0]
+
+	bucket, ok := d.rateLimits[namespace]
+	if !ok || bucket.allow() {
+		return nil
+	}
+	metricRateLimited.Add(namespace, 1)
+	return rateLimitExceeded(namespace)
+}
+
 func (d *Dispatcher) registerEndpoints() {
 	d.endpoints.Eth = &Eth{d}
 	d.endpoints.Net = &Net{d}
 	d.endpoints.Web3 = &Web3{d}
+	d.endpoints.Debug = &Debug{d}
+	d.endpoints.TxPool = &TxPool{d}
+	d.endpoints.IBFT = &IBFT{d}
 
 	d.registerService("eth", d.endpoints.Eth)
 	d.registerService("net", d.endpoints.Net)
 	d.registerService("web3", d.endpoints.Web3)
+	d.registerService("debug", d.endpoints.Debug)
+	d.registerService("txpool", d.endpoints.TxPool)
+	d.registerService("ibft", d.endpoints.IBFT)
 }
 
 func (d *Dispatcher) getFnHandler(req Request) (*serviceData, *funcData, error) {
@@ -127,21 +267,33 @@ func (d *Dispatcher) handleSubscribe(req Request, conn wsConn) (string, error) {
 	}
 
 	var filterID string
+	var err error
 	if subscribeMethod == "newHeads" {
-		filterID = d.filterManager.NewBlockFilter(conn)
+		filterID, err = d.filterManager.NewBlockFilter(conn)
 
 	} else if subscribeMethod == "logs" {
-		logFilter, err := decodeLogFilterFromInterface(params[1])
-		if err != nil {
-			return "", err
+		// the filter object is optional, all the logs match without it
+		var rawFilter interface{} = map[string]interface{}{}
+		if len(params) > 1 {
+			rawFilter = params[1]
+		}
+		logFilter, decodeErr := decodeLogFilterFromInterface(rawFilter)
+		if decodeErr != nil {
+			return "", decodeErr
 		}
-		filterID = d.filterManager.NewLogFilter(logFilter, conn)
+		filterID, err = d.filterManager.NewLogFilter(logFilter, conn)
+
+	} else if subscribeMethod == "reorg" {
+		filterID, err = d.filterManager.NewReorgFilter(conn)
+
+	} else if subscribeMethod == "newPendingTransactions" {
+		filterID, err = d.filterManager.NewPendingTxFilter(conn)
 
 	} else {
 		return "", fmt.Errorf("subscribe method %s not found", subscribeMethod)
 	}
 
-	return filterID, nil
+	return filterID, err
 }
 
 func (d *Dispatcher) handleUnsubscribe(req Request) (bool, error) {
@@ -161,12 +313,63 @@ func (d *Dispatcher) handleUnsubscribe(req Request) (bool, error) {
 	return d.filterManager.Uninstall(filterID), nil
 }
 
+// RemoveFilterByWs uninstalls the subscriptions of a closed websocket connection
+func (d *Dispatcher) RemoveFilterByWs(conn wsConn) {
+	if d.filterManager != nil {
+		d.filterManager.RemoveFilterByWs(conn)
+	}
+}
+
+// newResponse encodes a response with the given result
+func newResponse(id interface{}, result interface{}) ([]byte, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Response{
+		ID:      id,
+		JSONRPC: "2.0",
+		Result:  data,
+	})
+}
+
+// errorResponse is a response with an error, it has no result
+type errorResponse struct {
+	ID      interface{}  `json:"id"`
+	JSONRPC string       `json:"jsonrpc"`
+	Error   *ErrorObject `json:"error"`
+}
+
+// newErrorResponse encodes a response with the given error. The errors
+// that are not a jsonrpc error object are reported as server errors
+func newErrorResponse(id interface{}, err error) ([]byte, error) {
+	obj, ok := err.(*ErrorObject)
+	if !ok {
+		obj = &ErrorObject{Code: -32000, Message: err.Error()}
+	}
+	return json.Marshal(errorResponse{
+		ID:      id,
+		JSONRPC: "2.0",
+		Error:   obj,
+	})
+}
+
+// HandleWs handles a request of a websocket connection. The errors
+// of the request are returned as a response with the request id
 func (d *Dispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
 	var req Request
 	if err := json.Unmarshal(reqBody, &req); err != nil {
-		return nil, invalidJSONRequest
+		return newErrorResponse(nil, invalidJSONRequest)
 	}
 
+	resp, err := d.handleWsReq(req, conn)
+	if err != nil {
+		return newErrorResponse(req.ID, err)
+	}
+	return resp, nil
+}
+
+func (d *Dispatcher) handleWsReq(req Request, conn wsConn) ([]byte, error) {
 	// if the request method is eth_subscribe we need to create a
 	// new filter with ws connection
 	if req.Method == "eth_subscribe" {
@@ -174,9 +377,7 @@ func (d *Dispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-
-		resp := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, filterID)
-		return []byte(resp), nil
+		return newResponse(req.ID, filterID)
 	}
 
 	if req.Method == "eth_unsubscribe" {
@@ -184,13 +385,7 @@ func (d *Dispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-
-		res := "false"
-		if ok {
-			res = "true"
-		}
-		resp := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, res)
-		return []byte(resp), nil
+		return newResponse(req.ID, ok)
 	}
 
 	// its a normal query that we handle with the dispatcher
@@ -216,6 +411,9 @@ func (d *Dispatcher) handleReq(req Request) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := d.checkRateLimit(req.Method); err != nil {
+		return nil, err
+	}
 
 	inArgs := make([]reflect.Value, fd.inNum)
 	inArgs[0] = service.sv
@@ -234,6 +432,10 @@ func (d *Dispatcher) handleReq(req Request) ([]byte, error) {
 	output := fd.fv.Call(inArgs)
 	err = getError(output[1])
 	if err != nil {
+		if obj, ok := err.(*ErrorObject); ok {
+			// the endpoint returned a jsonrpc error for the client
+			return nil, obj
+		}
 		return nil, d.internalError(req.Method, err)
 	}
 
@@ -360,6 +562,9 @@ func lowerCaseFirst(str string) string {
 	return ""
 }
 
+// getBlockHeaderImpl resolves the block number to a header. The head of the chain
+// is only read once, callers must use the returned header for all the reads
+// of the request to get a consistent view of the state
 func (d *Dispatcher) getBlockHeaderImpl(number BlockNumber) (*types.Header, error) {
 	switch number {
 	case LatestBlockNumber:
@@ -381,18 +586,72 @@ func (d *Dispatcher) getBlockHeaderImpl(number BlockNumber) (*types.Header, erro
 	}
 }
 
+// isPendingBlock returns true if the block parameter is the pending tag. The endpoints
+// that read the state resolve it with the pending state of the store instead of a header
+func isPendingBlock(block BlockNumberOrHash) bool {
+	return block.BlockHash == nil && block.BlockNumber != nil && *block.BlockNumber == PendingBlockNumber
+}
+
+// getBlockHeaderByNumberOrHash resolves an EIP-1898 block parameter to a header.
+// The latest header is used if the parameter is not set
+func (d *Dispatcher) getBlockHeaderByNumberOrHash(block BlockNumberOrHash) (*types.Header, error) {
+	if block.BlockHash == nil {
+		if block.BlockNumber == nil {
+			return d.getBlockHeaderImpl(LatestBlockNumber)
+		}
+		return d.getBlockHeaderImpl(*block.BlockNumber)
+	}
+
+	header, ok := d.store.GetHeaderByHash(*block.BlockHash)
+	if !ok {
+		return nil, fmt.Errorf("header %s not found", block.BlockHash)
+	}
+	if block.RequireCanonical {
+		canonical, ok := d.store.GetHeaderByNumber(header.Number)
+		if !ok || canonical.Hash != header.Hash {
+			return nil, fmt.Errorf("header %s is not canonical", block.BlockHash)
+		}
+	}
+	return header, nil
+}
+
 func (d *Dispatcher) getNextNonce(address types.Address, number BlockNumber) (uint64, error) {
 	if number == PendingBlockNumber {
-		res, ok := d.store.GetNonce(address)
-		if ok {
-			return res, nil
-		}
-		number = LatestBlockNumber
+		return d.getPendingNonce(address)
 	}
 	header, err := d.getBlockHeaderImpl(number)
 	if err != nil {
 		return 0, err
 	}
+	return d.getNonceAt(address, header)
+}
+
+// getPendingNonce returns the nonce of the account in the latest state (zero if the
+// account does not exist yet) plus the pending transactions that follow it without a gap
+func (d *Dispatcher) getPendingNonce(address types.Address) (uint64, error) {
+	nonce, err := d.getNonceAt(address, d.store.Header())
+	if err != nil {
+		if !errors.Is(err, ErrStateNotFound) {
+			return 0, err
+		}
+		nonce = 0
+	}
+
+	pending, _ := d.store.GetTxs()
+	txs := append([]*types.Transaction{}, pending[address]...)
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Nonce < txs[j].Nonce
+	})
+	for _, txn := range txs {
+		if txn.Nonce == nonce {
+			nonce++
+		}
+	}
+	return nonce, nil
+}
+
+// getNonceAt returns the nonce of the account in the state of the given header
+func (d *Dispatcher) getNonceAt(address types.Address, header *types.Header) (uint64, error) {
 	acc, err := d.store.GetAccount(header.StateRoot, address)
 	if err != nil {
 		return 0, err
@@ -400,7 +659,10 @@ func (d *Dispatcher) getNextNonce(address types.Address, number BlockNumber) (ui
 	return acc.Nonce, nil
 }
 
-func (d *Dispatcher) decodeTxn(arg *txnArgs) (*types.Transaction, error) {
+// decodeTxn builds a transaction out of the call arguments. If header is set, the
+// missing values are resolved against that block so that the whole request
+// observes a single consistent state. Otherwise, the latest block is used
+func (d *Dispatcher) decodeTxn(arg *txnArgs, header *types.Header) (*types.Transaction, error) {
 	// set default values
 	if arg.From == nil {
 		return nil, fmt.Errorf("from is empty")
@@ -409,8 +671,15 @@ func (d *Dispatcher) decodeTxn(arg *txnArgs) (*types.Transaction, error) {
 		return nil, fmt.Errorf("both input and data cannot be set")
 	}
 	if arg.Nonce == nil {
-		// get nonce from the pool
-		nonce, err := d.getNextNonce(*arg.From, LatestBlockNumber)
+		var (
+			nonce uint64
+			err   error
+		)
+		if header != nil {
+			nonce, err = d.getNonceAt(*arg.From, header)
+		} else {
+			nonce, err = d.getNextNonce(*arg.From, LatestBlockNumber)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -455,6 +724,9 @@ func (d *Dispatcher) decodeTxn(arg *txnArgs) (*types.Transaction, error) {
 	if arg.To != nil {
 		txn.To = arg.To
 	}
+	if arg.AccessList != nil {
+		txn.AccessList = arg.AccessList.toTypes()
+	}
 	txn.ComputeHash()
 	return txn, nil
 }
@@ -25,10 +25,26 @@ func expectJSONResult(data []byte, v interface{}) error {
 	return nil
 }
 
+func TestDispatcherWebsocket_LogsWithoutFilter(t *testing.T) {
+	s := newDispatcher(hclog.NewNullLogger(), newMockStore(), 0, nil)
+
+	// the filter object of a logs subscription is optional
+	req := []byte(`{
+		"method": "eth_subscribe",
+		"params": ["logs"]
+	}`)
+	if _, err := s.HandleWs(req, &mockWsConn{}); err != nil {
+		t.Fatal(err)
+	}
+	if s.filterManager.Count() != 1 {
+		t.Fatal("logs subscription not installed")
+	}
+}
+
 func TestDispatcherWebsocket(t *testing.T) {
 	store := newMockStore()
 
-	s := newDispatcher(hclog.NewNullLogger(), store, 0)
+	s := newDispatcher(hclog.NewNullLogger(), store, 0, nil)
 	s.registerEndpoints()
 
 	mock := &mockWsConn{
@@ -60,6 +76,38 @@ func TestDispatcherWebsocket(t *testing.T) {
 	}
 }
 
+func TestDispatcherWebsocket_Error(t *testing.T) {
+	s := newDispatcher(hclog.NewNullLogger(), newMockStore(), 0, nil)
+	s.registerEndpoints()
+
+	handle := func(req string) map[string]interface{} {
+		resp, err := s.HandleWs([]byte(req), &mockWsConn{})
+		assert.NoError(t, err)
+
+		var obj map[string]interface{}
+		assert.NoError(t, json.Unmarshal(resp, &obj))
+		assert.Equal(t, "2.0", obj["jsonrpc"])
+		assert.NotContains(t, obj, "result")
+		return obj
+	}
+
+	// the errors of the endpoints keep their code
+	resp := handle(`{"id": 1, "method": "eth_unknown"}`)
+	assert.Equal(t, float64(1), resp["id"])
+	assert.Equal(t, float64(-32601), resp["error"].(map[string]interface{})["code"])
+
+	// the other errors are server errors
+	resp = handle(`{"id": "a", "method": "eth_subscribe", "params": ["unknown"]}`)
+	assert.Equal(t, "a", resp["id"])
+	assert.Equal(t, float64(-32000), resp["error"].(map[string]interface{})["code"])
+	assert.Contains(t, resp["error"].(map[string]interface{})["message"], "unknown")
+
+	// a request that cannot be decoded has no id
+	resp = handle(`{"id": 1,`)
+	assert.Nil(t, resp["id"])
+	assert.Equal(t, float64(-32600), resp["error"].(map[string]interface{})["code"])
+}
+
 type mockService struct {
 	msgCh chan interface{}
 }
@@ -91,7 +139,7 @@ func (m *mockService) Filter(f LogFilter) (interface{}, error) {
 func TestDispatcherFuncDecode(t *testing.T) {
 	srv := &mockService{msgCh: make(chan interface{}, 10)}
 
-	s := newDispatcher(hclog.NewNullLogger(), newMockStore(), 0)
+	s := newDispatcher(hclog.NewNullLogger(), newMockStore(), 0, nil)
 	s.registerService("mock", srv)
 
 	handleReq := func(typ string, msg string) interface{} {
@@ -1,19 +1,30 @@
 package jsonrpc
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 
+	"github.com/0xPolygon/minimal/crypto"
 	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/helper/keccak"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/types"
 )
 
+// ErrTypedTxNotSupported is returned when the type or the access list of a transaction
+// is sent before its fork is enabled (berlin for the access lists, london for the dynamic fees)
+var ErrTypedTxNotSupported = errors.New("transaction type not supported by the forks")
+
+// emptyCodeHash is the code hash of the accounts without code
+var emptyCodeHash = types.BytesToHash(crypto.Keccak256(nil))
+
 // Eth is the eth jsonrpc endpoint
 type Eth struct {
 	d *Dispatcher
 }
 
-// ChainId returns the chain id of the client
+// ChainId returns the chain id of the client (eth_chainId) as a hex quantity
 func (e *Eth) ChainId() (interface{}, error) {
 	return argUintPtr(e.d.chainID), nil
 }
@@ -35,29 +46,27 @@ func (e *Eth) GetBlockByNumber(number BlockNumber, full bool) (interface{}, erro
 		num = uint64(number)
 	}
 
-	block, ok := e.d.store.GetBlockByNumber(num, full)
+	// the body is always required, either for the full transactions or the hashes
+	block, ok := e.d.store.GetBlockByNumber(num, true)
 	if !ok {
 		return nil, fmt.Errorf("unable to get block by num %v", num)
 	}
-	return toBlock(block), nil
+	return toBlock(block, full), nil
 }
 
-// GetBlockByHash returns information about a block by hash
+// GetBlockByHash returns information about a block by hash. The result
+// is null if the block is not known
 func (e *Eth) GetBlockByHash(hash types.Hash, full bool) (interface{}, error) {
-	block, ok := e.d.store.GetBlockByHash(hash, full)
+	block, ok := e.d.store.GetBlockByHash(hash, true)
 	if !ok {
-		return nil, fmt.Errorf("unable to get block by hash %v", hash)
+		return nil, nil
 	}
-	return toBlock(block), nil
+	return toBlock(block, full), nil
 }
 
 // BlockNumber returns current block number
 func (e *Eth) BlockNumber() (interface{}, error) {
-	h := e.d.store.Header()
-	if h == nil {
-		return nil, fmt.Errorf("header has a nil value")
-	}
-	return argUintPtr(h.Number), nil
+	return argUintPtr(e.d.store.HeadNumber()), nil
 }
 
 // SendRawTransaction sends a raw transaction
@@ -68,8 +77,27 @@ func (e *Eth) SendRawTransaction(input string) (interface{}, error) {
 	if err := tx.UnmarshalRLP(buf); err != nil {
 		return nil, err
 	}
+	if !e.d.acceptsTx(tx) {
+		return nil, ErrTypedTxNotSupported
+	}
 	tx.ComputeHash()
 
+	if len(e.d.senderAllowlist) != 0 {
+		from, err := crypto.NewEIP155Signer(e.d.chainID).Sender(tx)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.d.checkSender(from); err != nil {
+			return nil, err
+		}
+	}
+
+	// the txn is already in the pool, return the same hash
+	// so that the retries from the clients are idempotent
+	if _, ok := e.d.store.GetPendingTx(tx.Hash); ok {
+		return tx.Hash.String(), nil
+	}
+
 	if err := e.d.store.AddTx(tx); err != nil {
 		return nil, err
 	}
@@ -78,35 +106,74 @@ func (e *Eth) SendRawTransaction(input string) (interface{}, error) {
 
 // SendTransaction creates new message call transaction or a contract creation, if the data field contains code.
 func (e *Eth) SendTransaction(arg *txnArgs) (interface{}, error) {
-	transaction, err := e.d.decodeTxn(arg)
+	transaction, err := e.d.decodeTxn(arg, nil)
 	if err != nil {
 		return nil, err
 	}
+	if err := e.d.checkSender(transaction.From); err != nil {
+		return nil, err
+	}
 	if err := e.d.store.AddTx(transaction); err != nil {
 		return nil, err
 	}
 	return transaction.Hash.String(), nil
 }
 
+// ResendTransaction broadcasts again to the peers a transaction that is still in the pool
+func (e *Eth) ResendTransaction(hash types.Hash) (interface{}, error) {
+	if _, ok := e.d.store.ReadTxLookup(hash); ok {
+		return nil, fmt.Errorf("transaction %s already sealed", hash)
+	}
+	if err := e.d.store.Rebroadcast(hash); err != nil {
+		return nil, err
+	}
+	return hash.String(), nil
+}
+
 // GetTransactionByHash returns a transaction by his hash
 func (e *Eth) GetTransactionByHash(hash types.Hash) (interface{}, error) {
-	blockHash, ok := e.d.store.ReadTxLookup(hash)
+	txn, block, indx, ok := e.d.store.GetTransactionByHash(hash)
 	if !ok {
+		// the txn is not sealed yet, check the pool
+		if txn, ok := e.d.store.GetPendingTx(hash); ok {
+			return toTransaction(txn, nil, 0), nil
+		}
 		// txn not found
 		return nil, nil
 	}
-	block, ok := e.d.store.GetBlockByHash(blockHash, true)
+	return toTransaction(txn, block, indx), nil
+}
+
+// GetTransactionByBlockHashAndIndex returns a transaction by the hash of its block
+// and its index in the block. The result is null if the transaction is not known
+func (e *Eth) GetTransactionByBlockHashAndIndex(hash types.Hash, index argUint64) (interface{}, error) {
+	block, ok := e.d.store.GetBlockByHash(hash, true)
 	if !ok {
-		// block receipts not found
 		return nil, nil
 	}
-	for idx, txn := range block.Transactions {
-		if txn.Hash == hash {
-			return toTransaction(txn, block, idx), nil
-		}
+	return toBlockTransaction(block, index), nil
+}
+
+// GetTransactionByBlockNumberAndIndex returns a transaction by the number of its block
+// and its index in the block. The result is null if the transaction is not known
+func (e *Eth) GetTransactionByBlockNumberAndIndex(number BlockNumber, index argUint64) (interface{}, error) {
+	header, err := e.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	block, ok := e.d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, nil
 	}
-	// txn not found (this should not happen)
-	return nil, nil
+	return toBlockTransaction(block, index), nil
+}
+
+// toBlockTransaction returns the transaction of the block at the index, nil if out of range
+func toBlockTransaction(block *types.Block, index argUint64) *transaction {
+	if uint64(index) >= uint64(len(block.Transactions)) {
+		return nil
+	}
+	return toTransaction(block.Transactions[index], block, int(index))
 }
 
 // GetTransactionReceipt returns a transaction receipt by his hash
@@ -119,20 +186,17 @@ func (e *Eth) GetTransactionReceipt(hash types.Hash) (interface{}, error) {
 
 	block, ok := e.d.store.GetBlockByHash(blockHash, true)
 	if !ok {
-		fmt.Println("CCCC")
 		// block not found
 		return nil, nil
 	}
 
 	receipts, err := e.d.store.GetReceiptsByHash(blockHash)
 	if err != nil {
-		fmt.Println("AAAA", err)
 		// block receipts not found
 		return nil, nil
 	}
-	if len(receipts) == 0 {
-		// receitps not written yet on the db
-		fmt.Println("BBBB")
+	if len(receipts) != len(block.Transactions) {
+		// receipts not written yet on the db
 		return nil, nil
 	}
 	// find the transaction in the body
@@ -148,45 +212,59 @@ func (e *Eth) GetTransactionReceipt(hash types.Hash) (interface{}, error) {
 		return nil, nil
 	}
 
-	txn := block.Transactions[indx]
-	raw := receipts[indx]
-
-	logs := make([]*Log, len(raw.Logs))
-	for indx, elem := range raw.Logs {
-		logs[indx] = &Log{
-			Address:     elem.Address,
-			Topics:      elem.Topics,
-			Data:        argBytes(elem.Data),
-			BlockHash:   block.Hash(),
-			BlockNumber: argUint64(block.Number()),
-			TxHash:      txn.Hash,
-			TxIndex:     argUint64(indx),
-			LogIndex:    argUint64(indx),
-			Removed:     false,
-		}
+	// the log index is the position of the log in the block
+	logIndex := 0
+	for _, receipt := range receipts[:indx] {
+		logIndex += len(receipt.Logs)
+	}
+	return toReceipt(receipts[indx], block, indx, logIndex), nil
+}
+
+// GetBlockReceipts returns the receipts of all the transactions of a block.
+// The result is null if the block or its receipts are not known
+func (e *Eth) GetBlockReceipts(number BlockNumberOrHash) (interface{}, error) {
+	header, err := e.d.getBlockHeaderByNumberOrHash(number)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := e.d.store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		// block not found
+		return nil, nil
+	}
+
+	receipts, err := e.d.store.GetReceiptsByHash(header.Hash)
+	if err != nil {
+		// block receipts not found
+		return nil, nil
+	}
+	if len(receipts) != len(block.Transactions) {
+		// receipts not written yet on the db
+		return nil, nil
 	}
-	res := &receipt{
-		Root:              raw.Root,
-		CumulativeGasUsed: argUint64(raw.CumulativeGasUsed),
-		LogsBloom:         raw.LogsBloom,
-		Status:            raw.Status,
-		TxHash:            txn.Hash,
-		TxIndex:           argUint64(indx),
-		BlockHash:         block.Hash(),
-		BlockNumber:       argUint64(block.Number()),
-		GasUsed:           argUint64(raw.GasUsed),
-		ContractAddress:   raw.ContractAddress,
-		FromAddr:          txn.From,
-		ToAddr:            txn.To,
-		Logs:              logs,
+
+	res := make([]*receipt, len(receipts))
+	logIndex := 0
+	for indx, raw := range receipts {
+		res[indx] = toReceipt(raw, block, indx, logIndex)
+		logIndex += len(raw.Logs)
 	}
 	return res, nil
 }
 
 // GetStorageAt returns the contract storage at the index position
-func (e *Eth) GetStorageAt(address types.Address, index types.Hash, number BlockNumber) (interface{}, error) {
+func (e *Eth) GetStorageAt(address types.Address, index types.Hash, number BlockNumberOrHash) (interface{}, error) {
+	if isPendingBlock(number) {
+		pending, err := e.d.store.PendingState()
+		if err != nil {
+			return nil, err
+		}
+		return argBytesPtr(pending.GetState(address, index).Bytes()), nil
+	}
+
 	// Fetch the requested header
-	header, err := e.d.getBlockHeaderImpl(number)
+	header, err := e.d.getBlockHeaderByNumberOrHash(number)
 	if err != nil {
 		return nil, err
 	}
@@ -194,9 +272,14 @@ func (e *Eth) GetStorageAt(address types.Address, index types.Hash, number Block
 	// Get the storage for the passed in location
 	result, err := e.d.store.GetStorage(header.StateRoot, address, index)
 	if err != nil {
+		if errors.Is(err, ErrStateNotFound) {
+			// the account or the slot does not exist, the value is zero
+			return argBytesPtr(types.ZeroHash.Bytes()), nil
+		}
 		return nil, err
 	}
-	return argBytesPtr(result), nil
+	// the value is returned as a zero padded 32 bytes word
+	return argBytesPtr(types.BytesToHash(result).Bytes()), nil
 }
 
 // GasPrice returns the average gas price based on the last x blocks
@@ -208,37 +291,94 @@ func (e *Eth) GasPrice() (interface{}, error) {
 	return avgGasPrice, nil
 }
 
-// Call executes a smart contract call using the transaction object data
-func (e *Eth) Call(arg *txnArgs, number BlockNumber) (interface{}, error) {
-	transaction, err := e.d.decodeTxn(arg)
+// FeeHistory returns the gas used ratio, the base fees and the rewards
+// at the given percentiles of the blockCount blocks up to newestBlock
+func (e *Eth) FeeHistory(blockCount argUint64, newestBlock BlockNumber, rewardPercentiles []float64) (interface{}, error) {
+	if err := validateRewardPercentiles(rewardPercentiles); err != nil {
+		return nil, err
+	}
+
+	header, err := e.d.getBlockHeaderImpl(newestBlock)
 	if err != nil {
 		return nil, err
 	}
+	if blockCount == 0 {
+		return &feeHistory{}, nil
+	}
+	return getFeeHistory(e.d.store, header, uint64(blockCount), rewardPercentiles)
+}
+
+// Call executes a smart contract call using the transaction object data
+func (e *Eth) Call(arg *txnArgs, number BlockNumberOrHash, override *stateOverride) (interface{}, error) {
 	// Fetch the requested header
-	header, err := e.d.getBlockHeaderImpl(number)
+	header, err := e.d.getBlockHeaderByNumberOrHash(number)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := e.d.decodeTxn(arg, header)
 	if err != nil {
 		return nil, err
 	}
 
+	var stateOverride types.StateOverride
+	if override != nil {
+		stateOverride = override.toStateOverride()
+	}
+
+	// the result of a call at a given block never changes, unless
+	// the state is overridden
+	cache := e.d.callCache != nil && stateOverride == nil
+
+	var key types.Hash
+	if cache {
+		key = callCacheKey(header, transaction)
+		if returnValue, ok := e.d.callCache.Get(key); ok {
+			return argBytesPtr(returnValue.([]byte)), nil
+		}
+	}
+
 	// The return value of the execution is saved in the transition (returnValue field)
-	returnValue, failed, err := e.d.store.ApplyTxn(header, transaction)
+	result, err := e.d.store.ApplyTxn(header, transaction, stateOverride)
 	if err != nil {
 		return nil, err
 	}
 
-	if failed {
-		return nil, fmt.Errorf("unable to execute call")
+	if result.Reverted() {
+		return nil, newRevertError(result.ReturnValue)
+	}
+	if result.Failed() {
+		return nil, fmt.Errorf("unable to execute call: %v", result.Err)
 	}
-	return argBytesPtr(returnValue), nil
+	if cache {
+		e.d.callCache.Add(key, result.ReturnValue)
+	}
+	return argBytesPtr(result.ReturnValue), nil
 }
 
-// EstimateGas estimates the gas needed to execute a transaction
-func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error) {
-	transaction, err := e.d.decodeTxn(arg)
-	if err != nil {
-		return nil, err
+// callCacheKey returns the key of a call in the eth_call cache. The key includes
+// the block hash, so new blocks do not hit the results of the previous ones
+func callCacheKey(header *types.Header, txn *types.Transaction) types.Hash {
+	// all the fields but the input have a fixed size so that
+	// different calls cannot produce the same preimage
+	k := keccak.NewKeccak256()
+	k.Write(header.Hash.Bytes())
+	k.Write(txn.From.Bytes())
+	if txn.To == nil {
+		k.Write([]byte{0x0})
+	} else {
+		k.Write([]byte{0x1})
+		k.Write(txn.To.Bytes())
 	}
+	k.Write(types.BytesToHash(txn.Value.Bytes()).Bytes())
+	k.Write(types.BytesToHash(txn.GasPrice.Bytes()).Bytes())
+	k.Write(types.BytesToHash(new(big.Int).SetUint64(txn.Gas).Bytes()).Bytes())
+	k.Write(txn.Input)
+
+	return types.BytesToHash(k.Sum(nil))
+}
 
+// EstimateGas estimates the gas needed to execute a transaction
+func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error) {
 	const standardGas uint64 = 21000
 
 	number := LatestBlockNumber
@@ -251,6 +391,10 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 	if err != nil {
 		return nil, err
 	}
+	transaction, err := e.d.decodeTxn(arg, header)
+	if err != nil {
+		return nil, err
+	}
 
 	var (
 		lowEnd  = standardGas
@@ -296,37 +440,62 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 		}
 	}
 
-	if highEnd > types.GasCap.Uint64() {
-		// The high end is greater than the environment gas cap
-		highEnd = types.GasCap.Uint64()
+	if highEnd > e.d.gasEstimationCap {
+		// The high end is greater than the gas estimation cap
+		highEnd = e.d.gasEstimationCap
 	}
 
 	gasCap = highEnd
 
+	// A plain value transfer to an EOA always costs the intrinsic gas.
+	// Transfers to a contract execute its receive/fallback function
+	// and the access lists add to the intrinsic gas, so they need to go
+	// through the estimation
+	if transaction.To != nil && len(transaction.Input) == 0 && len(transaction.AccessList) == 0 {
+		isContract, err := e.isContract(header.StateRoot, *transaction.To)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isContract {
+			if gasCap < standardGas {
+				return 0, fmt.Errorf("gas required exceeds allowance (%d)", gasCap)
+			}
+
+			// without a gas price the balance was not checked against the value
+			if gasPriceInt.BitLen() == 0 && valueInt.BitLen() != 0 {
+				acc, err := e.d.store.GetAccount(header.StateRoot, transaction.From)
+				if err != nil && !errors.Is(err, ErrStateNotFound) {
+					return nil, err
+				}
+				if acc == nil || valueInt.Cmp(acc.Balance) > 0 {
+					return 0, fmt.Errorf("insufficient funds for transfer")
+				}
+			}
+
+			return hex.EncodeUint64(standardGas), nil
+		}
+	}
+
 	// Run the transaction with the estimated gas
-	testTransaction := func(gas uint64) (bool, error) {
+	testTransaction := func(gas uint64) (*runtime.ExecutionResult, error) {
 		// Create a dummy transaction with the new gas
 		txn := transaction.Copy()
 		txn.Gas = gas
 
-		_, failed, err := e.d.store.ApplyTxn(header, txn)
-		if err != nil {
-			return failed, err
-		}
-
-		return failed, nil
+		return e.d.store.ApplyTxn(header, txn, nil)
 	}
 
 	// Start the binary search for the lowest possible gas price
 	for lowEnd <= highEnd {
 		mid := (lowEnd + highEnd) / 2
 
-		failed, err := testTransaction(mid)
+		result, err := testTransaction(mid)
 		if err != nil {
 			return 0, err
 		}
 
-		if failed {
+		if result.Failed() {
 			// If the transaction failed => increase the gas
 			lowEnd = mid + 1
 		} else {
@@ -340,14 +509,17 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 	highEnd += 1
 
 	// Check the edge case if even the highest cap is not enough to complete the transaction
-	if highEnd == gasCap {
-		failed, err := testTransaction(gasCap)
+	if highEnd > gasCap {
+		result, err := testTransaction(gasCap)
 
 		if err != nil {
 			return 0, err
 		}
 
-		if failed {
+		if result.Reverted() {
+			return 0, newRevertError(result.ReturnValue)
+		}
+		if result.Failed() {
 			return 0, fmt.Errorf("gas required exceeds allowance (%d)", gasCap)
 		}
 	}
@@ -355,82 +527,53 @@ func (e *Eth) EstimateGas(arg *txnArgs, rawNum *BlockNumber) (interface{}, error
 	return hex.EncodeUint64(highEnd), nil
 }
 
-// GetLogs returns an array of logs matching the filter options
-func (e *Eth) GetLogs(filterOptions *LogFilter) (interface{}, error) {
-	var result []*Log
-	parseReceipts := func(header *types.Header) error {
-		receipts, err := e.d.store.GetReceiptsByHash(header.Hash)
-		if err != nil {
-			return err
-		}
+// logsPage is a page of the logs of a paginated eth_getLogs
+type logsPage struct {
+	Logs []*Log `json:"logs"`
 
-		for indx, receipt := range receipts {
-			for logIndx, log := range receipt.Logs {
-				if filterOptions.Match(log) {
-					result = append(result, &Log{
-						Address:     log.Address,
-						Topics:      log.Topics,
-						Data:        argBytes(log.Data),
-						BlockNumber: argUint64(header.Number),
-						BlockHash:   header.Hash,
-						TxHash:      receipt.TxHash,
-						TxIndex:     argUint64(indx),
-						LogIndex:    argUint64(logIndx),
-					})
-				}
-			}
-		}
-		return nil
-	}
+	// Cursor is the continuation token of the next page, empty if it is the last page
+	Cursor string `json:"cursor,omitempty"`
+}
 
-	if filterOptions.BlockHash != nil {
-		block, ok := e.d.store.GetBlockByHash(*filterOptions.BlockHash, false)
-		if !ok {
-			return nil, fmt.Errorf("not found")
-		}
-		if err := parseReceipts(block.Header); err != nil {
-			return nil, err
-		}
-		return result, nil
+// GetLogs returns an array of logs matching the filter options. If the filter
+// includes a cursor the logs are returned in pages with the cursor of the next one
+func (e *Eth) GetLogs(filterOptions *LogFilter) (interface{}, error) {
+	if filterOptions.Cursor == nil {
+		return getLogs(e.d.store, filterOptions, e.d.logQueryLimits())
 	}
 
-	head := e.d.store.Header().Number
-
-	resolveNum := func(num BlockNumber) uint64 {
-		if num == PendingBlockNumber || num == EarliestBlockNumber {
-			num = LatestBlockNumber
-		}
-		if num == LatestBlockNumber {
-			return head
-		}
-		return uint64(num)
+	start, err := decodeLogCursor(*filterOptions.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	logs, next, err := queryLogs(e.d.store, filterOptions, e.d.logQueryLimits(), start, e.d.maxLogsPerPage)
+	if err != nil {
+		return nil, err
 	}
 
-	from := resolveNum(filterOptions.fromBlock)
-	to := resolveNum(filterOptions.toBlock)
-
-	if to < from {
-		return nil, fmt.Errorf("incorrect range")
+	page := &logsPage{
+		Logs: logs,
 	}
-	for i := from; i < to; i++ {
-		header, ok := e.d.store.GetHeaderByNumber(i)
-		if !ok {
-			break
-		}
-		if header.Number == 0 {
-			// do not check logs in genesis
-			continue
-		}
-		if err := parseReceipts(header); err != nil {
-			return nil, err
-		}
+	if page.Logs == nil {
+		page.Logs = []*Log{}
 	}
-	return result, nil
+	if next != nil {
+		page.Cursor = next.encode()
+	}
+	return page, nil
 }
 
 // GetBalance returns the account's balance at the referenced block
-func (e *Eth) GetBalance(address types.Address, number BlockNumber) (interface{}, error) {
-	header, err := e.d.getBlockHeaderImpl(number)
+func (e *Eth) GetBalance(address types.Address, number BlockNumberOrHash) (interface{}, error) {
+	if isPendingBlock(number) {
+		pending, err := e.d.store.PendingState()
+		if err != nil {
+			return nil, err
+		}
+		return argBigPtr(pending.GetBalance(address)), nil
+	}
+
+	header, err := e.d.getBlockHeaderByNumberOrHash(number)
 	if err != nil {
 		return nil, err
 	}
@@ -454,8 +597,21 @@ func (e *Eth) GetTransactionCount(address types.Address, number BlockNumber) (in
 }
 
 // GetCode returns account code at given block number
-func (e *Eth) GetCode(address types.Address, number BlockNumber) (interface{}, error) {
-	header, err := e.d.getBlockHeaderImpl(number)
+func (e *Eth) GetCode(address types.Address, number BlockNumberOrHash) (interface{}, error) {
+	emptySlice := []byte{}
+
+	if isPendingBlock(number) {
+		pending, err := e.d.store.PendingState()
+		if err != nil {
+			return nil, err
+		}
+		if code := pending.GetCode(address); code != nil {
+			return argBytesPtr(code), nil
+		}
+		return argBytesPtr(emptySlice), nil
+	}
+
+	header, err := e.d.getBlockHeaderByNumberOrHash(number)
 	if err != nil {
 		return nil, err
 	}
@@ -465,7 +621,6 @@ func (e *Eth) GetCode(address types.Address, number BlockNumber) (interface{}, e
 		return nil, err
 	}
 
-	emptySlice := []byte{}
 	code, err := e.d.store.GetCode(types.BytesToHash(acc.CodeHash))
 	if err != nil {
 		// TODO This is just a workaround. Figure out why CodeHash is populated for regular accounts
@@ -475,14 +630,43 @@ func (e *Eth) GetCode(address types.Address, number BlockNumber) (interface{}, e
 	return argBytesPtr(code), nil
 }
 
+// isContract returns true if there is code deployed at the address
+func (e *Eth) isContract(root types.Hash, address types.Address) (bool, error) {
+	acc, err := e.d.store.GetAccount(root, address)
+	if err != nil {
+		if errors.Is(err, ErrStateNotFound) {
+			// the account does not exist
+			return false, nil
+		}
+		return false, err
+	}
+
+	codeHash := types.BytesToHash(acc.CodeHash)
+	if codeHash == types.ZeroHash || codeHash == emptyCodeHash {
+		return false, nil
+	}
+
+	code, err := e.d.store.GetCode(codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	return len(code) != 0, nil
+}
+
 // NewFilter creates a filter object, based on filter options, to notify when the state changes (logs).
 func (e *Eth) NewFilter(filter *LogFilter) (interface{}, error) {
-	return e.d.filterManager.NewLogFilter(filter, nil), nil
+	return e.d.filterManager.NewLogFilter(filter, nil)
 }
 
 // NewBlockFilter creates a filter in the node, to notify when a new block arrives
 func (e *Eth) NewBlockFilter() (interface{}, error) {
-	return e.d.filterManager.NewBlockFilter(nil), nil
+	return e.d.filterManager.NewBlockFilter(nil)
+}
+
+// NewPendingTransactionFilter creates a filter in the node, to notify when new pending transactions arrive
+func (e *Eth) NewPendingTransactionFilter() (interface{}, error) {
+	return e.d.filterManager.NewPendingTxFilter(nil)
 }
 
 // GetFilterChanges is a polling method for a filter, which returns an array of logs which occurred since last poll.
@@ -490,6 +674,11 @@ func (e *Eth) GetFilterChanges(id string) (interface{}, error) {
 	return e.d.filterManager.GetFilterChanges(id)
 }
 
+// GetFilterLogs returns an array of all the logs matching the filter with the given id
+func (e *Eth) GetFilterLogs(id string) (interface{}, error) {
+	return e.d.filterManager.GetFilterLogs(id)
+}
+
 // UninstallFilter uninstalls a filter with given ID
 func (e *Eth) UninstallFilter(id string) (bool, error) {
 	ok := e.d.filterManager.Uninstall(id)
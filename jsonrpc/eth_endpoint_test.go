@@ -2,15 +2,21 @@ package jsonrpc
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/crypto"
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/types"
 )
 
@@ -66,7 +72,7 @@ func (m *mockAccountStore) Header() *types.Header {
 func (m *mockAccountStore) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
 	acct, ok := m.accounts[addr]
 	if !ok {
-		return nil, fmt.Errorf("not found")
+		return nil, ErrStateNotFound
 	}
 	return acct.account, nil
 }
@@ -83,13 +89,14 @@ func (m *mockAccountStore) GetCode(hash types.Hash) ([]byte, error) {
 func (m *mockAccountStore) GetStorage(root types.Hash, addr types.Address, slot types.Hash) ([]byte, error) {
 	acct, ok := m.accounts[addr]
 	if !ok {
-		return nil, fmt.Errorf("not found")
+		return nil, ErrStateNotFound
 	}
 	val, ok := acct.storage[slot]
 	if !ok {
-		return nil, fmt.Errorf("not found")
+		return nil, ErrStateNotFound
 	}
-	return val.Bytes(), nil
+	// the values are stored without the leading zeros
+	return bytes.TrimLeft(val.Bytes(), "\x00"), nil
 }
 
 type mockBlockStore2 struct {
@@ -138,6 +145,10 @@ func (m *mockBlockStore2) Header() *types.Header {
 	return m.blocks[len(m.blocks)-1].Header
 }
 
+func (m *mockBlockStore2) HeadNumber() uint64 {
+	return m.Header().Number
+}
+
 func TestEth_Block_GetBlockByNumber(t *testing.T) {
 	store := &mockBlockStore2{}
 	for i := 0; i < 10; i++ {
@@ -172,21 +183,92 @@ func TestEth_Block_GetBlockByNumber(t *testing.T) {
 	}
 }
 
+func TestEth_Block_GasFields(t *testing.T) {
+	store := &mockBlockStore2{}
+	store.add(&types.Block{
+		Header: &types.Header{
+			Number:   1,
+			GasLimit: 8000000,
+			GasUsed:  21000,
+		},
+	})
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	res, err := dispatcher.endpoints.Eth.GetBlockByNumber(BlockNumber(1), false)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(res)
+	assert.NoError(t, err)
+
+	var obj map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &obj))
+	assert.Equal(t, "0x5208", obj["gasUsed"])
+	assert.Equal(t, "0x7a1200", obj["gasLimit"])
+}
+
 func TestEth_Block_GetBlockByHash(t *testing.T) {
+	txn := &types.Transaction{
+		Value:    big.NewInt(1),
+		GasPrice: big.NewInt(1),
+		Hash:     hash3,
+	}
+
 	store := &mockBlockStore2{}
 	store.add(&types.Block{
 		Header: &types.Header{
 			Hash: hash1,
 		},
+		Transactions: []*types.Transaction{txn},
 	})
 
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
 
-	_, err := dispatcher.endpoints.Eth.GetBlockByHash(hash1, false)
-	assert.NoError(t, err)
+	cases := []struct {
+		hash   types.Hash
+		fullTx bool
+		found  bool
+	}{
+		{hash1, false, true},
+		{hash1, true, true},
+		{hash2, false, false},
+	}
 
-	_, err = dispatcher.endpoints.Eth.GetBlockByHash(hash2, false)
-	assert.Error(t, err)
+	for _, c := range cases {
+		res, err := dispatcher.endpoints.Eth.GetBlockByHash(c.hash, c.fullTx)
+		assert.NoError(t, err)
+
+		if !c.found {
+			// unknown blocks return a null result
+			assert.Nil(t, res)
+			continue
+		}
+
+		data, err := json.Marshal(res)
+		assert.NoError(t, err)
+
+		var obj struct {
+			Hash         types.Hash
+			Transactions []json.RawMessage
+		}
+		assert.NoError(t, json.Unmarshal(data, &obj))
+		assert.Equal(t, c.hash, obj.Hash)
+		assert.Len(t, obj.Transactions, 1)
+
+		if c.fullTx {
+			var full struct {
+				Hash      types.Hash
+				BlockHash types.Hash
+			}
+			assert.NoError(t, json.Unmarshal(obj.Transactions[0], &full))
+			assert.Equal(t, hash3, full.Hash)
+			assert.Equal(t, hash1, full.BlockHash)
+		} else {
+			var hash types.Hash
+			assert.NoError(t, json.Unmarshal(obj.Transactions[0], &hash))
+			assert.Equal(t, hash3, hash)
+		}
+	}
 }
 
 func TestEth_Block_BlockNumber(t *testing.T) {
@@ -203,106 +285,267 @@ func TestEth_Block_BlockNumber(t *testing.T) {
 	assert.Equal(t, argUintPtr(10), num)
 }
 
-func TestEth_Block_GetLogs(t *testing.T) {
+func TestEth_ChainId(t *testing.T) {
+	cases := []struct {
+		chainID uint64
+		res     string
+	}{
+		{1, "0x1"},
+		{100, "0x64"},
+		{1337, "0x539"},
+	}
 
-	/*
-		// Topics we're searching for
-		var topics = [][]types.Hash{
-			{types.StringToHash("topic 4")},
-			{types.StringToHash("topic 5")},
-			{types.StringToHash("topic 6")},
-		}
+	for _, c := range cases {
+		dispatcher := newDispatcher(hclog.NewNullLogger(), nil, c.chainID, nil)
 
-		testTable := []struct {
-			name          string
-			filterOptions *LogFilter
-			shouldFail    bool
-		}{
-			{"Found matching logs",
-				&LogFilter{
-					fromBlock: 1,
-					toBlock:   4,
-					Topics:    topics,
-				},
-				false},
-			{"Invalid block range", &LogFilter{
-				fromBlock: 10,
-				toBlock:   5,
-				Topics:    topics,
-			}, true},
-			{"Reference blocks not found", &LogFilter{
-				fromBlock: 10,
-				toBlock:   20,
-				Topics:    topics,
-			}, true},
-		}
+		// the method is resolved with the exact eth_chainId casing
+		resp, err := dispatcher.Handle([]byte(`{"method": "eth_chainId", "params": []}`))
+		assert.NoError(t, err)
 
-		// Setup //
-		store := newMockBlockStore()
+		var res string
+		assert.NoError(t, expectJSONResult(resp, &res))
+		assert.Equal(t, c.res, res)
+	}
+}
 
-		dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+// mockLogStore is a chain of blocks with one log per block
+type mockLogStore struct {
+	mockBlockStore2
+}
 
-		store.getHeaderByNumberCallback = func(blockNumber uint64) (*types.Header, bool) {
-			return &types.Header{
-				Number:       blockNumber,
-				ReceiptsRoot: types.StringToHash(strconv.FormatUint(blockNumber, 10)),
-			}, true
-		}
+func newMockLogStore(num int) *mockLogStore {
+	store := &mockLogStore{}
+	for i := 0; i < num; i++ {
+		store.add(&types.Block{
+			Header: &types.Header{
+				Number: uint64(i),
+				Hash:   types.BytesToHash([]byte{byte(i + 1)}),
+			},
+		})
+	}
+	return store
+}
+
+func (m *mockLogStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	return []*types.Receipt{
+		{
+			Logs: []*types.Log{
+				{Address: addr1},
+			},
+		},
+	}, nil
+}
+
+func TestEth_Block_GetLogs(t *testing.T) {
+	store := newMockLogStore(11)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	dispatcher.maxLogBlockRange = 5
+
+	cases := []struct {
+		name   string
+		filter string
+		blocks []uint64
+		err    bool
+	}{
+		{
+			"exact range",
+			`{"fromBlock": "0x2", "toBlock": "0x4"}`,
+			[]uint64{2, 3, 4},
+			false,
+		},
+		{
+			"open ended range",
+			`{"fromBlock": "0x8", "toBlock": "latest"}`,
+			[]uint64{8, 9, 10},
+			false,
+		},
+		{
+			"range at the cap",
+			`{"fromBlock": "0x6", "toBlock": "0xa"}`,
+			[]uint64{6, 7, 8, 9, 10},
+			false,
+		},
+		{
+			"range over the cap",
+			`{"fromBlock": "earliest", "toBlock": "latest"}`,
+			nil,
+			true,
+		},
+	}
 
-		store.getReceiptsByHashCallback = func(hash types.Hash) ([]*types.Receipt, error) {
-
-			switch hash {
-			case types.StringToHash(strconv.FormatUint(1, 10)):
-				return store.generateReceipts(
-					generateReceiptsParams{
-						numReceipts: 1,
-						numTopics:   1,
-						numLogs:     2,
-					},
-				), nil
-			case types.StringToHash(strconv.FormatUint(2, 10)):
-				return store.generateReceipts(
-					generateReceiptsParams{
-						numReceipts: 2,
-						numTopics:   5,
-						numLogs:     1,
-					},
-				), nil
-			case types.StringToHash(strconv.FormatUint(3, 10)):
-				return store.generateReceipts(
-					generateReceiptsParams{
-						numReceipts: 3,
-						numTopics:   10,
-						numLogs:     5,
-					},
-				), nil
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			filter := &LogFilter{}
+			assert.NoError(t, json.Unmarshal([]byte(c.filter), filter))
+
+			res, err := dispatcher.endpoints.Eth.GetLogs(filter)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			blocks := []uint64{}
+			for _, log := range res.([]*Log) {
+				blocks = append(blocks, uint64(log.BlockNumber))
 			}
+			assert.Equal(t, c.blocks, blocks)
+		})
+	}
+}
 
-			return nil, nil
+func TestEth_Block_GetLogs_Limits(t *testing.T) {
+	store := newMockLogStore(11)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	dispatcher.maxLogBlockRange = 5
+	dispatcher.maxLogAddresses = 2
+	dispatcher.maxLogs = 3
+
+	addrs := func(num int) string {
+		list := []string{}
+		for i := 0; i < num; i++ {
+			list = append(list, `"`+types.Address{byte(i)}.String()+`"`)
 		}
+		list[0] = `"` + addr1.String() + `"`
+		return "[" + strings.Join(list, ",") + "]"
+	}
+
+	cases := []struct {
+		name   string
+		filter string
+		logs   int
+		err    string
+	}{
+		{
+			"within the limits",
+			`{"fromBlock": "0x1", "toBlock": "0x3", "address": ` + addrs(2) + `}`,
+			3,
+			"",
+		},
+		{
+			"block range over the limit",
+			`{"fromBlock": "0x1", "toBlock": "0x6"}`,
+			0,
+			"block range of 6 blocks exceeds the maximum of 5 blocks",
+		},
+		{
+			"addresses over the limit",
+			`{"fromBlock": "0x1", "toBlock": "0x3", "address": ` + addrs(3) + `}`,
+			0,
+			"filter of 3 addresses exceeds the maximum of 2 addresses",
+		},
+		{
+			"logs over the limit",
+			`{"fromBlock": "0x1", "toBlock": "0x4"}`,
+			0,
+			"query returned more than 3 logs, narrow the query or paginate it",
+		},
+		{
+			"paginated query over the logs limit",
+			`{"fromBlock": "0x1", "toBlock": "0x5", "cursor": ""}`,
+			5,
+			"",
+		},
+	}
 
-		for index, testCase := range testTable {
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			filter := &LogFilter{}
+			assert.NoError(t, json.Unmarshal([]byte(c.filter), filter))
+
+			res, err := dispatcher.endpoints.Eth.GetLogs(filter)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			assert.NoError(t, err)
 
-			if index == 2 {
-				store.getHeaderByNumberCallback = func(blockNumber uint64) (*types.Header, bool) {
-					return nil, false
-				}
+			if page, ok := res.(*logsPage); ok {
+				assert.Len(t, page.Logs, c.logs)
+			} else {
+				assert.Len(t, res.([]*Log), c.logs)
 			}
+		})
+	}
+}
 
-			t.Run(testCase.name, func(t *testing.T) {
-				foundLogs, logError := dispatcher.endpoints.Eth.GetLogs(
-					testCase.filterOptions,
-				)
-
-				if logError != nil && !testCase.shouldFail {
-					// If there is an error, and the test shouldn't fail
-					t.Fatalf("Error: %v", logError)
-				} else if !testCase.shouldFail {
-					assert.Lenf(t, foundLogs, 17, "Invalid number of logs found")
-				}
-			})
+// mockManyLogsStore is a chain of blocks with several receipts and logs per block
+type mockManyLogsStore struct {
+	mockBlockStore2
+}
+
+func (m *mockManyLogsStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	receipts := []*types.Receipt{}
+	for i := 0; i < 3; i++ {
+		receipts = append(receipts, &types.Receipt{
+			TxHash: types.BytesToHash([]byte{byte(i + 1)}),
+			Logs: []*types.Log{
+				{Address: addr1},
+				{Address: addr0},
+				{Address: addr1},
+			},
+		})
+	}
+	return receipts, nil
+}
+
+func TestEth_Block_GetLogs_Pagination(t *testing.T) {
+	store := &mockManyLogsStore{}
+	for i := 0; i < 10; i++ {
+		store.add(&types.Block{
+			Header: &types.Header{
+				Number: uint64(i),
+				Hash:   types.BytesToHash([]byte{byte(i + 1)}),
+			},
+		})
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	dispatcher.maxLogsPerPage = 4
+
+	decodeFilter := func(raw string) *LogFilter {
+		filter := &LogFilter{}
+		assert.NoError(t, json.Unmarshal([]byte(raw), filter))
+		return filter
+	}
+
+	// the whole set in a single query
+	res, err := dispatcher.endpoints.Eth.GetLogs(decodeFilter(`{"fromBlock": "0x1", "toBlock": "0x9", "address": "` + addr1.String() + `"}`))
+	assert.NoError(t, err)
+	expected := res.([]*Log)
+	assert.Len(t, expected, 9*6)
+
+	// the same set in pages
+	logs := []*Log{}
+	cursor := ""
+	pages := 0
+	for {
+		res, err := dispatcher.endpoints.Eth.GetLogs(decodeFilter(`{"fromBlock": "0x1", "toBlock": "0x9", "address": "` + addr1.String() + `", "cursor": "` + cursor + `"}`))
+		assert.NoError(t, err)
+
+		page := res.(*logsPage)
+		assert.LessOrEqual(t, len(page.Logs), 4)
+
+		logs = append(logs, page.Logs...)
+		pages++
+
+		if page.Cursor == "" {
+			break
 		}
-	*/
+		cursor = page.Cursor
+	}
+	assert.Equal(t, 14, pages)
+	assert.Equal(t, expected, logs)
+
+	// a malformed cursor or out of the range fails
+	_, err = dispatcher.endpoints.Eth.GetLogs(decodeFilter(`{"fromBlock": "0x1", "toBlock": "0x9", "cursor": "0x01"}`))
+	assert.Error(t, err)
+
+	outOfRange := (&logCursor{block: 20}).encode()
+	_, err = dispatcher.endpoints.Eth.GetLogs(decodeFilter(`{"fromBlock": "0x1", "toBlock": "0x9", "cursor": "` + outOfRange + `"}`))
+	assert.Error(t, err)
 }
 
 var (
@@ -317,12 +560,12 @@ func TestEth_State_GetBalance(t *testing.T) {
 
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
 
-	balance, err := dispatcher.endpoints.Eth.GetBalance(addr0, LatestBlockNumber)
+	balance, err := dispatcher.endpoints.Eth.GetBalance(addr0, blockNum(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, balance, argBigPtr(big.NewInt(100)))
 
 	// address not found
-	balance, err = dispatcher.endpoints.Eth.GetBalance(addr1, LatestBlockNumber)
+	balance, err = dispatcher.endpoints.Eth.GetBalance(addr1, blockNum(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, balance, argUintPtr(0))
 }
@@ -355,7 +598,7 @@ func TestEth_State_GetCode(t *testing.T) {
 	acct0.Code(code0)
 
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
-	code, err := dispatcher.endpoints.Eth.GetCode(acct0.address, LatestBlockNumber)
+	code, err := dispatcher.endpoints.Eth.GetCode(acct0.address, blockNum(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, code, argBytesPtr(code0))
 }
@@ -366,13 +609,31 @@ func TestEth_State_GetStorageAt(t *testing.T) {
 	acct0 := store.AddAccount(addr0)
 	acct0.Storage(hash1, hash1)
 
+	// a short value is zero padded
+	value := types.BytesToHash([]byte{0x1, 0x2})
+	acct0.Storage(hash2, value)
+
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
-	res, err := dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash1, LatestBlockNumber)
+	res, err := dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash1, blockNum(LatestBlockNumber))
 	assert.NoError(t, err)
 	assert.Equal(t, res, argBytesPtr(hash1.Bytes()))
 
+	res, err = dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash2, blockNum(LatestBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, res, argBytesPtr(value.Bytes()))
+
 	// slot not found
-	_, err = dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash2, LatestBlockNumber)
+	res, err = dispatcher.endpoints.Eth.GetStorageAt(acct0.address, types.StringToHash("3"), blockNum(LatestBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, res, argBytesPtr(types.ZeroHash.Bytes()))
+
+	// account not found
+	res, err = dispatcher.endpoints.Eth.GetStorageAt(addr1, hash1, blockNum(LatestBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, res, argBytesPtr(types.ZeroHash.Bytes()))
+
+	// block not found
+	_, err = dispatcher.endpoints.Eth.GetStorageAt(acct0.address, hash1, blockNum(BlockNumber(10)))
 	assert.Error(t, err)
 }
 
@@ -387,8 +648,310 @@ func (m *mockStoreTxn) AddTx(tx *types.Transaction) error {
 	return nil
 }
 
-func (m *mockStoreTxn) GetNonce(addr types.Address) (uint64, bool) {
-	return 1, false
+// mockReceiptStore stores a single sealed block with its receipts
+type mockReceiptStore struct {
+	nullBlockchainInterface
+
+	block    *types.Block
+	receipts []*types.Receipt
+}
+
+func (m *mockReceiptStore) ReadTxLookup(txnHash types.Hash) (types.Hash, bool) {
+	for _, txn := range m.block.Transactions {
+		if txn.Hash == txnHash {
+			return m.block.Hash(), true
+		}
+	}
+	return types.Hash{}, false
+}
+
+func (m *mockReceiptStore) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
+	if hash != m.block.Hash() {
+		return nil, false
+	}
+	return m.block, true
+}
+
+func (m *mockReceiptStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	return m.receipts, nil
+}
+
+func (m *mockReceiptStore) Header() *types.Header {
+	return m.block.Header
+}
+
+func (m *mockReceiptStore) GetHeaderByNumber(num uint64) (*types.Header, bool) {
+	if num != m.block.Number() {
+		return nil, false
+	}
+	return m.block.Header, true
+}
+
+func (m *mockReceiptStore) GetHeaderByHash(hash types.Hash) (*types.Header, bool) {
+	if hash != m.block.Hash() {
+		return nil, false
+	}
+	return m.block.Header, true
+}
+
+func TestEth_TxnPool_GetTransactionReceipt(t *testing.T) {
+	contract := types.StringToAddress("100")
+
+	store := &mockReceiptStore{
+		block: &types.Block{
+			Header: &types.Header{
+				Number: 10,
+				Hash:   hash3,
+			},
+			Transactions: []*types.Transaction{
+				// contract creation
+				{Hash: hash1, From: addr0, GasPrice: big.NewInt(10)},
+				// contract call
+				{Hash: hash2, From: addr0, To: &contract, GasPrice: big.NewInt(20)},
+			},
+		},
+		receipts: []*types.Receipt{
+			{
+				CumulativeGasUsed: 100,
+				GasUsed:           100,
+				ContractAddress:   contract,
+				EffectiveGasPrice: big.NewInt(10),
+				Logs: []*types.Log{
+					{Address: contract},
+				},
+			},
+			{
+				CumulativeGasUsed: 150,
+				GasUsed:           50,
+				Logs: []*types.Log{
+					{Address: contract},
+				},
+			},
+		},
+	}
+	store.receipts[0].SetStatus(types.ReceiptSuccess)
+	store.receipts[1].SetStatus(types.ReceiptFailed)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	getReceipt := func(hash types.Hash) map[string]interface{} {
+		res, err := eth.GetTransactionReceipt(hash)
+		assert.NoError(t, err)
+		if res == nil {
+			return nil
+		}
+
+		data, err := json.Marshal(res)
+		assert.NoError(t, err)
+
+		var obj map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &obj))
+		return obj
+	}
+
+	// the contract address is set for contract creations
+	receipt := getReceipt(hash1)
+	assert.Equal(t, hash1.String(), receipt["transactionHash"])
+	assert.Equal(t, "0x0", receipt["transactionIndex"])
+	assert.Equal(t, hash3.String(), receipt["blockHash"])
+	assert.Equal(t, "0xa", receipt["blockNumber"])
+	assert.Equal(t, "0x64", receipt["gasUsed"])
+	assert.Equal(t, "0x64", receipt["cumulativeGasUsed"])
+	assert.Equal(t, "0x1", receipt["status"])
+	assert.Equal(t, contract.String(), receipt["contractAddress"])
+	assert.Equal(t, "0xa", receipt["effectiveGasPrice"])
+
+	// and null otherwise
+	receipt = getReceipt(hash2)
+	assert.Equal(t, "0x1", receipt["transactionIndex"])
+	assert.Equal(t, "0x32", receipt["gasUsed"])
+	assert.Equal(t, "0x96", receipt["cumulativeGasUsed"])
+	assert.Equal(t, "0x0", receipt["status"])
+	assert.Contains(t, receipt, "contractAddress")
+	assert.Nil(t, receipt["contractAddress"])
+
+	// the receipts without the effective gas price report the gas price of the legacy transaction
+	assert.Equal(t, "0x14", receipt["effectiveGasPrice"])
+
+	// the logs are indexed within the block
+	logs := receipt["logs"].([]interface{})
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "0x1", logs[0].(map[string]interface{})["transactionIndex"])
+	assert.Equal(t, "0x1", logs[0].(map[string]interface{})["logIndex"])
+
+	// unknown transactions return a null result
+	assert.Nil(t, getReceipt(types.StringToHash("4")))
+}
+
+func TestEth_GetTransactionReceipt_RevertReason(t *testing.T) {
+	contract := types.StringToAddress("100")
+
+	// Error("boom")
+	reason := []byte{0x08, 0xc3, 0x79, 0xa0}
+	reason = append(reason, types.BytesToHash([]byte{0x20}).Bytes()...)
+	reason = append(reason, types.BytesToHash([]byte{0x4}).Bytes()...)
+	reason = append(reason, append([]byte("boom"), make([]byte, 28)...)...)
+
+	store := &mockReceiptStore{
+		block: &types.Block{
+			Header: &types.Header{
+				Number: 10,
+				Hash:   types.StringToHash("10"),
+			},
+			Transactions: []*types.Transaction{
+				{Hash: hash1, From: addr0, To: &contract, GasPrice: big.NewInt(10)},
+				{Hash: hash2, From: addr0, To: &contract, GasPrice: big.NewInt(10)},
+				{Hash: hash3, From: addr0, To: &contract, GasPrice: big.NewInt(10)},
+			},
+		},
+		receipts: []*types.Receipt{
+			{GasUsed: 100, RevertReason: reason},
+			{GasUsed: 100, RevertReason: []byte{0x1, 0x2}},
+			{GasUsed: 100},
+		},
+	}
+	store.receipts[0].SetStatus(types.ReceiptFailed)
+	store.receipts[1].SetStatus(types.ReceiptFailed)
+	store.receipts[2].SetStatus(types.ReceiptSuccess)
+
+	eth := newTestDispatcher(hclog.NewNullLogger(), store).endpoints.Eth
+
+	getReceipt := func(hash types.Hash) map[string]interface{} {
+		res, err := eth.GetTransactionReceipt(hash)
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(res)
+		assert.NoError(t, err)
+
+		var obj map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &obj))
+		return obj
+	}
+
+	// the Error(string) reason is decoded
+	receipt := getReceipt(hash1)
+	assert.Equal(t, "0x0", receipt["status"])
+	assert.Equal(t, "boom", receipt["revertReason"])
+
+	// and any other revert data is returned in hex
+	receipt = getReceipt(hash2)
+	assert.Equal(t, "0x0", receipt["status"])
+	assert.Equal(t, "0x0102", receipt["revertReason"])
+
+	// the successful receipts do not have a revert reason
+	receipt = getReceipt(hash3)
+	assert.Equal(t, "0x1", receipt["status"])
+	assert.NotContains(t, receipt, "revertReason")
+}
+
+func TestEth_GetBlockReceipts(t *testing.T) {
+	contract := types.StringToAddress("100")
+
+	store := &mockReceiptStore{
+		block: &types.Block{
+			Header: &types.Header{
+				Number: 10,
+				Hash:   hash3,
+			},
+			Transactions: []*types.Transaction{
+				{Hash: hash1, From: addr0, To: &contract, GasPrice: big.NewInt(10)},
+				{Hash: hash2, From: addr0, To: &contract, GasPrice: big.NewInt(20)},
+				{Hash: types.StringToHash("4"), From: addr0, GasPrice: big.NewInt(30)},
+			},
+		},
+		receipts: []*types.Receipt{
+			{
+				CumulativeGasUsed: 100,
+				GasUsed:           100,
+				LogsBloom:         types.Bloom{0x1},
+				Logs: []*types.Log{
+					{Address: contract},
+					{Address: contract},
+				},
+			},
+			{
+				CumulativeGasUsed: 150,
+				GasUsed:           50,
+				EffectiveGasPrice: big.NewInt(15),
+			},
+			{
+				CumulativeGasUsed: 250,
+				GasUsed:           100,
+				ContractAddress:   types.StringToAddress("200"),
+				Logs: []*types.Log{
+					{Address: contract},
+				},
+			},
+		},
+	}
+	store.receipts[0].SetStatus(types.ReceiptSuccess)
+	store.receipts[1].SetStatus(types.ReceiptFailed)
+	store.receipts[2].SetStatus(types.ReceiptSuccess)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	getReceipts := func(block BlockNumberOrHash) []map[string]interface{} {
+		res, err := eth.GetBlockReceipts(block)
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(res)
+		assert.NoError(t, err)
+
+		var obj []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &obj))
+		return obj
+	}
+
+	hash := hash3
+	blocks := []BlockNumberOrHash{
+		blockNum(BlockNumber(10)),
+		blockNum(LatestBlockNumber),
+		{BlockHash: &hash},
+	}
+	for _, block := range blocks {
+		receipts := getReceipts(block)
+		assert.Len(t, receipts, 3)
+
+		// the receipts are in the order of the transactions
+		for indx, receipt := range receipts {
+			txn := store.block.Transactions[indx]
+			assert.Equal(t, txn.Hash.String(), receipt["transactionHash"])
+			assert.Equal(t, fmt.Sprintf("0x%x", indx), receipt["transactionIndex"])
+			assert.Equal(t, hash3.String(), receipt["blockHash"])
+			assert.Equal(t, "0xa", receipt["blockNumber"])
+			assert.Equal(t, addr0.String(), receipt["from"])
+		}
+
+		assert.Equal(t, "0x1", receipts[0]["status"])
+		assert.Equal(t, "0x0", receipts[1]["status"])
+		assert.Equal(t, "0x64", receipts[0]["gasUsed"])
+		assert.Equal(t, "0xfa", receipts[2]["cumulativeGasUsed"])
+		assert.Equal(t, types.Bloom{0x1}.String(), receipts[0]["logsBloom"])
+
+		// the effective gas price falls back to the gas price of the transaction
+		assert.Equal(t, "0xa", receipts[0]["effectiveGasPrice"])
+		assert.Equal(t, "0xf", receipts[1]["effectiveGasPrice"])
+
+		// the contract address is only set for contract creations
+		assert.Nil(t, receipts[0]["contractAddress"])
+		assert.Equal(t, types.StringToAddress("200").String(), receipts[2]["contractAddress"])
+
+		// the logs are indexed within the block
+		logs := receipts[2]["logs"].([]interface{})
+		assert.Len(t, logs, 1)
+		assert.Equal(t, "0x2", logs[0].(map[string]interface{})["logIndex"])
+		assert.Equal(t, "0x2", logs[0].(map[string]interface{})["transactionIndex"])
+	}
+
+	// unknown block
+	_, err := eth.GetBlockReceipts(blockNum(BlockNumber(11)))
+	assert.Error(t, err)
+
+	unknown := types.StringToHash("5")
+	_, err = eth.GetBlockReceipts(BlockNumberOrHash{BlockHash: &unknown})
+	assert.Error(t, err)
 }
 
 func TestEth_TxnPool_SendRawTransaction(t *testing.T) {
@@ -412,6 +975,90 @@ func TestEth_TxnPool_SendRawTransaction(t *testing.T) {
 	}
 }
 
+func TestEth_TxnPool_SendRawTransaction_Typed(t *testing.T) {
+	store := &mockStoreTxn{}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	dispatcher.chainID = 100
+	eth := dispatcher.endpoints.Eth
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	signer := crypto.NewEIP155Signer(dispatcher.chainID)
+	sign := func(txn *types.Transaction) string {
+		txn, err := signer.SignTx(txn, key)
+		assert.NoError(t, err)
+		return hex.EncodeToHex(txn.MarshalRLP())
+	}
+
+	legacy := sign(&types.Transaction{
+		To:       &addr0,
+		Value:    big.NewInt(1),
+		GasPrice: big.NewInt(1),
+		Gas:      21000,
+	})
+	dynamicFee := sign(&types.Transaction{
+		Type:      types.DynamicFeeTx,
+		Nonce:     1,
+		To:        &addr0,
+		Value:     big.NewInt(1),
+		GasPrice:  big.NewInt(2),
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(5),
+		Gas:       21000,
+	})
+	accessList := sign(&types.Transaction{
+		Type:     types.AccessListTx,
+		Nonce:    2,
+		To:       &addr0,
+		Value:    big.NewInt(1),
+		GasPrice: big.NewInt(1),
+		Gas:      30000,
+		AccessList: types.AccessList{
+			{Address: addr0, StorageKeys: []types.Hash{{}}},
+		},
+	})
+
+	// the typed transactions are rejected before london
+	_, err = eth.SendRawTransaction(dynamicFee)
+	assert.Equal(t, ErrTypedTxNotSupported, err)
+
+	dispatcher.forks = &chain.Forks{
+		London: chain.NewFork(0),
+	}
+
+	// both transactions are decoded and their sender recovered
+	_, err = eth.SendRawTransaction(legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, types.LegacyTx, store.txn.Type)
+
+	from, err := signer.Sender(store.txn)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.PubKeyToAddress(&key.PublicKey), from)
+
+	hash, err := eth.SendRawTransaction(dynamicFee)
+	assert.NoError(t, err)
+	assert.Equal(t, store.txn.Hash.String(), hash)
+	assert.Equal(t, types.DynamicFeeTx, store.txn.Type)
+	assert.Equal(t, big.NewInt(5), store.txn.GasFeeCap)
+	assert.Equal(t, big.NewInt(2), store.txn.GasTipCap)
+
+	from, err = signer.Sender(store.txn)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.PubKeyToAddress(&key.PublicKey), from)
+
+	// the access lists are rejected before berlin
+	_, err = eth.SendRawTransaction(accessList)
+	assert.Equal(t, ErrTypedTxNotSupported, err)
+
+	dispatcher.forks.Berlin = chain.NewFork(0)
+
+	_, err = eth.SendRawTransaction(accessList)
+	assert.NoError(t, err)
+	assert.Equal(t, types.AccessListTx, store.txn.Type)
+	assert.Len(t, store.txn.AccessList, 1)
+}
+
 func TestEth_TxnPool_SendTransaction(t *testing.T) {
 	store := &mockStoreTxn{}
 	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
@@ -426,3 +1073,908 @@ func TestEth_TxnPool_SendTransaction(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEqual(t, store.txn.Hash, types.ZeroHash)
 }
+
+func TestEth_TxnPool_SenderAllowlist(t *testing.T) {
+	store := &mockStoreTxn{}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	allowedKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	allowed := crypto.PubKeyToAddress(&allowedKey.PublicKey)
+
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	other := crypto.PubKeyToAddress(&otherKey.PublicKey)
+
+	dispatcher.setupSenderAllowlist([]types.Address{allowed})
+
+	signer := crypto.NewEIP155Signer(dispatcher.chainID)
+	sendRaw := func(key *ecdsa.PrivateKey) error {
+		txn, err := signer.SignTx(&types.Transaction{
+			To:       &addr0,
+			Value:    big.NewInt(1),
+			GasPrice: big.NewInt(1),
+			Gas:      21000,
+		}, key)
+		assert.NoError(t, err)
+
+		_, err = eth.SendRawTransaction(hex.EncodeToHex(txn.MarshalRLP()))
+		return err
+	}
+	send := func(from types.Address) error {
+		_, err := eth.SendTransaction(&txnArgs{
+			From:     argAddrPtr(from),
+			To:       argAddrPtr(addr0),
+			Nonce:    argUintPtr(0),
+			GasPrice: argBytesPtr([]byte{0x1}),
+		})
+		return err
+	}
+
+	// the allowlisted sender is accepted
+	assert.NoError(t, sendRaw(allowedKey))
+	assert.NoError(t, send(allowed))
+
+	// any other sender is rejected before reaching the pool
+	store.txn = nil
+
+	err = sendRaw(otherKey)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+
+	err = send(other)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+
+	assert.Nil(t, store.txn)
+}
+
+type mockEstimateStore struct {
+	mockAccountStore
+
+	// fallbackGas is the gas consumed by the fallback function of the contracts
+	fallbackGas uint64
+
+	// maxGas is the highest gas limit applied
+	maxGas uint64
+
+	// accountErr is returned by GetAccount if set
+	accountErr error
+}
+
+func (m *mockEstimateStore) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
+	if m.accountErr != nil {
+		return nil, m.accountErr
+	}
+	return m.mockAccountStore.GetAccount(root, addr)
+}
+
+func (m *mockEstimateStore) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
+	if txn.Gas > m.maxGas {
+		m.maxGas = txn.Gas
+	}
+	cost := uint64(21000) + uint64(len(txn.AccessList))*2400
+	if acct, ok := m.accounts[*txn.To]; ok && len(acct.code) != 0 {
+		cost += m.fallbackGas
+	}
+	result := &runtime.ExecutionResult{}
+	if txn.Gas < cost {
+		result.Err = runtime.ErrGasConsumed
+	}
+	return result, nil
+}
+
+func TestEth_EstimateGas_Transfer(t *testing.T) {
+	store := &mockEstimateStore{fallbackGas: 5000}
+
+	eoa := types.Address{0x10}
+	store.AddAccount(eoa)
+
+	contract := types.Address{0x20}
+	store.AddAccount(contract).Code(code0)
+
+	store.AddAccount(addr0).Balance(1)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	args := func(to types.Address, value byte) *txnArgs {
+		return &txnArgs{
+			From:     argAddrPtr(addr0),
+			To:       argAddrPtr(to),
+			Nonce:    argUintPtr(0),
+			GasPrice: argBytesPtr([]byte{}),
+			Value:    argBytesPtr([]byte{value}),
+		}
+	}
+	estimate := func(arg *txnArgs) string {
+		res, err := dispatcher.endpoints.Eth.EstimateGas(arg, nil)
+		assert.NoError(t, err)
+		return res.(string)
+	}
+
+	// plain transfer to an EOA
+	assert.Equal(t, hex.EncodeUint64(21000), estimate(args(eoa, 0x1)))
+
+	// transfer to an account that does not exist yet
+	assert.Equal(t, hex.EncodeUint64(21000), estimate(args(types.Address{0x30}, 0x1)))
+
+	// transfer to a contract runs the fallback function
+	assert.Equal(t, hex.EncodeUint64(21000+5000), estimate(args(contract, 0x1)))
+
+	// the access list adds to the intrinsic gas
+	withList := args(eoa, 0x1)
+	withList.AccessList = &accessList{{Address: eoa}}
+	assert.Equal(t, hex.EncodeUint64(21000+2400), estimate(withList))
+
+	// the sender cannot pay for the transfer
+	_, err := dispatcher.endpoints.Eth.EstimateGas(args(eoa, 0x2), nil)
+	assert.EqualError(t, err, "insufficient funds for transfer")
+
+	// the errors of the state are not taken as an EOA
+	store.accountErr = fmt.Errorf("state not available")
+	_, err = dispatcher.endpoints.Eth.EstimateGas(args(eoa, 0x1), nil)
+	assert.EqualError(t, err, "state not available")
+}
+
+func TestEth_EstimateGas_Cap(t *testing.T) {
+	store := &mockEstimateStore{}
+
+	contract := types.Address{0x20}
+	store.AddAccount(contract).Code(code0)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	dispatcher.gasEstimationCap = 50000
+
+	estimate := func(fallbackGas uint64, gas *argUint64) (interface{}, error) {
+		store.fallbackGas = fallbackGas
+		store.maxGas = 0
+
+		arg := &txnArgs{
+			From:     argAddrPtr(addr0),
+			To:       argAddrPtr(contract),
+			Nonce:    argUintPtr(0),
+			Gas:      gas,
+			GasPrice: argBytesPtr([]byte{}),
+			Value:    argBytesPtr([]byte{0x1}),
+		}
+		return dispatcher.endpoints.Eth.EstimateGas(arg, nil)
+	}
+
+	// the search is bounded by the cap
+	res, err := estimate(10000, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeUint64(31000), res)
+	assert.LessOrEqual(t, store.maxGas, uint64(50000))
+
+	// the exact cap is enough
+	res, err = estimate(29000, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeUint64(50000), res)
+
+	// the transaction needs more gas than the cap
+	_, err = estimate(30000, nil)
+	assert.EqualError(t, err, "gas required exceeds allowance (50000)")
+	assert.LessOrEqual(t, store.maxGas, uint64(50000))
+
+	// the gas of the transaction is an upper bound set by the caller
+	_, err = estimate(10000, argUintPtr(30000))
+	assert.EqualError(t, err, "gas required exceeds allowance (30000)")
+	assert.LessOrEqual(t, store.maxGas, uint64(30000))
+
+	// the caller bound cannot exceed the cap
+	res, err = estimate(10000, argUintPtr(100000))
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeUint64(31000), res)
+	assert.LessOrEqual(t, store.maxGas, uint64(50000))
+}
+
+// mockPinnedStore keeps a separate account store per state root
+type mockPinnedStore struct {
+	nullBlockchainInterface
+
+	headers []*types.Header
+	uncles  []*types.Header
+	states  map[types.Hash]*mockAccountStore
+}
+
+func blockNum(num BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockNumber: &num}
+}
+
+func (m *mockPinnedStore) importBlock(root types.Hash) *mockAccountStore {
+	if m.states == nil {
+		m.states = map[types.Hash]*mockAccountStore{}
+	}
+	m.headers = append(m.headers, &types.Header{
+		Number:    uint64(len(m.headers)),
+		StateRoot: root,
+		Hash:      types.BytesToHash(root.Bytes()),
+	})
+	m.states[root] = &mockAccountStore{}
+	return m.states[root]
+}
+
+func (m *mockPinnedStore) Header() *types.Header {
+	return m.headers[len(m.headers)-1]
+}
+
+func (m *mockPinnedStore) HeadNumber() uint64 {
+	return m.Header().Number
+}
+
+func (m *mockPinnedStore) GetHeaderByNumber(num uint64) (*types.Header, bool) {
+	if num >= uint64(len(m.headers)) {
+		return nil, false
+	}
+	return m.headers[num], true
+}
+
+func (m *mockPinnedStore) GetHeaderByHash(hash types.Hash) (*types.Header, bool) {
+	for _, header := range append(m.headers, m.uncles...) {
+		if header.Hash == hash {
+			return header, true
+		}
+	}
+	return nil, false
+}
+
+func (m *mockPinnedStore) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
+	return m.states[root].GetAccount(root, addr)
+}
+
+func (m *mockPinnedStore) GetStorage(root types.Hash, addr types.Address, slot types.Hash) ([]byte, error) {
+	return m.states[root].GetStorage(root, addr, slot)
+}
+
+func (m *mockPinnedStore) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
+	// return the nonce the call was executed with
+	return &runtime.ExecutionResult{ReturnValue: []byte{byte(txn.Nonce)}}, nil
+}
+
+func TestEth_State_PinnedBlock(t *testing.T) {
+	store := &mockPinnedStore{}
+
+	acct := store.importBlock(types.Hash{0x1}).AddAccount(addr0)
+	acct.Balance(100)
+	acct.Nonce(1)
+	acct.Storage(hash1, hash1)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	// resolve the block number at the start of the read sequence
+	res, err := eth.BlockNumber()
+	assert.NoError(t, err)
+	num := BlockNumber(*res.(*argUint64))
+
+	balance, err := eth.GetBalance(addr0, blockNum(num))
+	assert.NoError(t, err)
+	assert.Equal(t, argBigPtr(big.NewInt(100)), balance)
+
+	// a new block is imported in the middle of the sequence
+	acct = store.importBlock(types.Hash{0x2}).AddAccount(addr0)
+	acct.Balance(200)
+	acct.Nonce(2)
+	acct.Storage(hash1, hash2)
+
+	// the pinned reads still observe the same block
+	storage, err := eth.GetStorageAt(addr0, hash1, blockNum(num))
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr(hash1.Bytes()), storage)
+
+	nonce, err := eth.GetTransactionCount(addr0, num)
+	assert.NoError(t, err)
+	assert.Equal(t, argUintPtr(1), nonce)
+
+	// eth_call resolves the default nonce at the requested block
+	callArgs := func() *txnArgs {
+		return &txnArgs{
+			From:     argAddrPtr(addr0),
+			To:       argAddrPtr(addr0),
+			GasPrice: argBytesPtr([]byte{}),
+		}
+	}
+	ret, err := eth.Call(callArgs(), blockNum(num), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr([]byte{0x1}), ret)
+
+	// latest observes the new head
+	storage, err = eth.GetStorageAt(addr0, hash1, blockNum(LatestBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr(hash2.Bytes()), storage)
+
+	ret, err = eth.Call(callArgs(), blockNum(LatestBlockNumber), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr([]byte{0x2}), ret)
+}
+
+// mockPendingState is an in memory pending state
+type mockPendingState struct {
+	balances map[types.Address]*big.Int
+	storage  map[types.Address]map[types.Hash]types.Hash
+	code     map[types.Address][]byte
+}
+
+func (m *mockPendingState) GetBalance(addr types.Address) *big.Int {
+	if balance, ok := m.balances[addr]; ok {
+		return balance
+	}
+	return big.NewInt(0)
+}
+
+func (m *mockPendingState) GetState(addr types.Address, key types.Hash) types.Hash {
+	return m.storage[addr][key]
+}
+
+func (m *mockPendingState) GetCode(addr types.Address) []byte {
+	return m.code[addr]
+}
+
+// mockPendingStore is a pinned store with transactions in the pool
+// and a pending state on top of the latest block
+type mockPendingStore struct {
+	mockPinnedStore
+
+	pending      map[types.Address][]*types.Transaction
+	pendingState *mockPendingState
+}
+
+func (m *mockPendingStore) GetTxs() (map[types.Address][]*types.Transaction, map[types.Address][]*types.Transaction) {
+	return m.pending, nil
+}
+
+func (m *mockPendingStore) PendingState() (PendingState, error) {
+	return m.pendingState, nil
+}
+
+func TestEth_State_Pending(t *testing.T) {
+	store := &mockPendingStore{}
+
+	acct := store.importBlock(types.Hash{0x1}).AddAccount(addr0)
+	acct.Balance(100)
+	acct.Nonce(1)
+
+	// the pending state has the transactions of the pool applied
+	store.pendingState = &mockPendingState{
+		balances: map[types.Address]*big.Int{
+			addr0: big.NewInt(50),
+		},
+		storage: map[types.Address]map[types.Hash]types.Hash{
+			addr0: {hash1: hash1},
+		},
+		code: map[types.Address][]byte{
+			addr0: {0x2},
+		},
+	}
+
+	store.pending = map[types.Address][]*types.Transaction{
+		addr0: {
+			{Nonce: 2},
+			{Nonce: 1},
+			// a transaction after a nonce gap is not counted
+			{Nonce: 4},
+		},
+		addr1: {
+			{Nonce: 0},
+		},
+	}
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	nonce, err := eth.GetTransactionCount(addr0, PendingBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, argUintPtr(3), nonce)
+
+	nonce, err = eth.GetTransactionCount(addr0, LatestBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, argUintPtr(1), nonce)
+
+	balance, err := eth.GetBalance(addr0, blockNum(PendingBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, argBigPtr(big.NewInt(50)), balance)
+
+	balance, err = eth.GetBalance(addr0, blockNum(LatestBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, argBigPtr(big.NewInt(100)), balance)
+
+	storage, err := eth.GetStorageAt(addr0, hash1, blockNum(PendingBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr(hash1.Bytes()), storage)
+
+	storage, err = eth.GetStorageAt(addr0, hash2, blockNum(PendingBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr(types.ZeroHash.Bytes()), storage)
+
+	code, err := eth.GetCode(addr0, blockNum(PendingBlockNumber))
+	assert.NoError(t, err)
+	assert.Equal(t, argBytesPtr([]byte{0x2}), code)
+
+	// a sender without an account in the latest state starts at zero
+	nonce, err = eth.GetTransactionCount(addr1, PendingBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, argUintPtr(1), nonce)
+
+	nonce, err = eth.GetTransactionCount(addr2, PendingBlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, argUintPtr(0), nonce)
+}
+
+func TestEth_State_BlockHash(t *testing.T) {
+	store := &mockPinnedStore{}
+
+	store.importBlock(types.Hash{0x1}).AddAccount(addr0).Balance(100)
+	store.importBlock(types.Hash{0x2}).AddAccount(addr0).Balance(200)
+
+	// a block with the same number than the head but out of the canonical chain
+	uncle := &types.Header{
+		Number:    1,
+		StateRoot: types.Hash{0x3},
+		Hash:      types.Hash{0x3},
+	}
+	store.uncles = append(store.uncles, uncle)
+	store.states[uncle.StateRoot] = &mockAccountStore{}
+	store.states[uncle.StateRoot].AddAccount(addr0).Balance(300)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	byHash := func(hash types.Hash, canonical bool) BlockNumberOrHash {
+		return BlockNumberOrHash{BlockHash: &hash, RequireCanonical: canonical}
+	}
+
+	balance, err := eth.GetBalance(addr0, byHash(store.headers[0].Hash, true))
+	assert.NoError(t, err)
+	assert.Equal(t, argBigPtr(big.NewInt(100)), balance)
+
+	balance, err = eth.GetBalance(addr0, byHash(uncle.Hash, false))
+	assert.NoError(t, err)
+	assert.Equal(t, argBigPtr(big.NewInt(300)), balance)
+
+	_, err = eth.GetBalance(addr0, byHash(uncle.Hash, true))
+	assert.Error(t, err)
+
+	_, err = eth.GetBalance(addr0, byHash(types.Hash{0x4}, false))
+	assert.Error(t, err)
+}
+
+type mockPendingTxStore struct {
+	nullBlockchainInterface
+
+	pending map[types.Hash]*types.Transaction
+	lookup  map[types.Hash]types.Hash
+	blocks  map[types.Hash]*types.Block
+
+	rebroadcast []types.Hash
+}
+
+func (m *mockPendingTxStore) AddTx(tx *types.Transaction) error {
+	if _, ok := m.pending[tx.Hash]; ok {
+		return fmt.Errorf("already known")
+	}
+	m.pending[tx.Hash] = tx
+	return nil
+}
+
+func (m *mockPendingTxStore) GetPendingTx(txHash types.Hash) (*types.Transaction, bool) {
+	tx, ok := m.pending[txHash]
+	return tx, ok
+}
+
+func (m *mockPendingTxStore) Rebroadcast(txHash types.Hash) error {
+	if _, ok := m.pending[txHash]; !ok {
+		return fmt.Errorf("not found")
+	}
+	m.rebroadcast = append(m.rebroadcast, txHash)
+	return nil
+}
+
+func (m *mockPendingTxStore) ReadTxLookup(txnHash types.Hash) (types.Hash, bool) {
+	hash, ok := m.lookup[txnHash]
+	return hash, ok
+}
+
+func (m *mockPendingTxStore) GetTransactionByHash(txnHash types.Hash) (*types.Transaction, *types.Block, int, bool) {
+	b, ok := m.blocks[m.lookup[txnHash]]
+	if !ok {
+		return nil, nil, 0, false
+	}
+	for indx, txn := range b.Transactions {
+		if txn.Hash == txnHash {
+			return txn, b, indx, true
+		}
+	}
+	return nil, nil, 0, false
+}
+
+func (m *mockPendingTxStore) GetBlockByHash(hash types.Hash, full bool) (*types.Block, bool) {
+	b, ok := m.blocks[hash]
+	return b, ok
+}
+
+func (m *mockPendingTxStore) GetHeaderByNumber(num uint64) (*types.Header, bool) {
+	for _, b := range m.blocks {
+		if b.Number() == num {
+			return b.Header, true
+		}
+	}
+	return nil, false
+}
+
+// seal moves all the pending transactions into a new block
+func (m *mockPendingTxStore) seal(num uint64) *types.Block {
+	b := &types.Block{
+		Header: &types.Header{
+			Number: num,
+		},
+	}
+	b.Header.ComputeHash()
+	for hash, tx := range m.pending {
+		b.Transactions = append(b.Transactions, tx)
+		m.lookup[hash] = b.Hash()
+	}
+	m.blocks[b.Hash()] = b
+	m.pending = map[types.Hash]*types.Transaction{}
+	return b
+}
+
+func TestEth_TxnPool_GetTransactionByHash_Pending(t *testing.T) {
+	store := &mockPendingTxStore{
+		pending: map[types.Hash]*types.Transaction{},
+		lookup:  map[types.Hash]types.Hash{},
+		blocks:  map[types.Hash]*types.Block{},
+	}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	txn := &types.Transaction{
+		From:     addr0,
+		Nonce:    0,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(1),
+		V:        1,
+	}
+	txn.ComputeHash()
+	assert.NoError(t, store.AddTx(txn))
+
+	// the transaction is in the pool, the block fields are empty
+	res, err := eth.GetTransactionByHash(txn.Hash)
+	assert.NoError(t, err)
+
+	pending := res.(*transaction)
+	assert.Equal(t, txn.Hash, pending.Hash)
+	assert.Nil(t, pending.BlockHash)
+	assert.Nil(t, pending.BlockNumber)
+	assert.Nil(t, pending.TxIndex)
+
+	data, err := json.Marshal(pending)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"blockHash":null`)
+
+	// the transaction is sealed, the block fields are set
+	block := store.seal(5)
+
+	res, err = eth.GetTransactionByHash(txn.Hash)
+	assert.NoError(t, err)
+
+	sealed := res.(*transaction)
+	assert.Equal(t, txn.Hash, sealed.Hash)
+	assert.Equal(t, block.Hash(), *sealed.BlockHash)
+	assert.Equal(t, argUintPtr(5), sealed.BlockNumber)
+	assert.Equal(t, argUintPtr(0), sealed.TxIndex)
+
+	// unknown transaction
+	res, err = eth.GetTransactionByHash(hash2)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestEth_GetTransactionByBlockAndIndex(t *testing.T) {
+	store := &mockPendingTxStore{
+		pending: map[types.Hash]*types.Transaction{},
+		lookup:  map[types.Hash]types.Hash{},
+		blocks:  map[types.Hash]*types.Block{},
+	}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	for i := 0; i < 3; i++ {
+		txn := &types.Transaction{
+			From:     addr0,
+			Nonce:    uint64(i),
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(1),
+			V:        1,
+		}
+		txn.ComputeHash()
+		assert.NoError(t, store.AddTx(txn))
+	}
+	block := store.seal(5)
+
+	for indx, txn := range block.Transactions {
+		results := []func() (interface{}, error){
+			func() (interface{}, error) {
+				return eth.GetTransactionByHash(txn.Hash)
+			},
+			func() (interface{}, error) {
+				return eth.GetTransactionByBlockHashAndIndex(block.Hash(), argUint64(indx))
+			},
+			func() (interface{}, error) {
+				return eth.GetTransactionByBlockNumberAndIndex(BlockNumber(5), argUint64(indx))
+			},
+		}
+		for _, result := range results {
+			res, err := result()
+			assert.NoError(t, err)
+
+			found := res.(*transaction)
+			assert.Equal(t, txn.Hash, found.Hash)
+			assert.Equal(t, block.Hash(), *found.BlockHash)
+			assert.Equal(t, argUintPtr(5), found.BlockNumber)
+			assert.Equal(t, argUintPtr(uint64(indx)), found.TxIndex)
+		}
+	}
+
+	// index out of range
+	res, err := eth.GetTransactionByBlockHashAndIndex(block.Hash(), argUint64(3))
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	res, err = eth.GetTransactionByBlockNumberAndIndex(BlockNumber(5), argUint64(3))
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	// unknown block
+	res, err = eth.GetTransactionByBlockHashAndIndex(hash2, argUint64(0))
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	_, err = eth.GetTransactionByBlockNumberAndIndex(BlockNumber(6), argUint64(0))
+	assert.Error(t, err)
+}
+
+func TestEth_TxnPool_ResendTransaction(t *testing.T) {
+	store := &mockPendingTxStore{
+		pending: map[types.Hash]*types.Transaction{},
+		lookup:  map[types.Hash]types.Hash{},
+		blocks:  map[types.Hash]*types.Block{},
+	}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	txn := &types.Transaction{
+		From:     addr0,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(1),
+	}
+	txn.ComputeHash()
+	assert.NoError(t, store.AddTx(txn))
+
+	// unknown txn
+	_, err := eth.ResendTransaction(types.Hash{0x1})
+	assert.Error(t, err)
+
+	res, err := eth.ResendTransaction(txn.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, txn.Hash.String(), res)
+	assert.Equal(t, []types.Hash{txn.Hash}, store.rebroadcast)
+
+	// the txn is sealed, it cannot be resent anymore
+	store.seal(1)
+	_, err = eth.ResendTransaction(txn.Hash)
+	assert.Error(t, err)
+}
+
+func TestEth_TxnPool_SendRawTransaction_Duplicate(t *testing.T) {
+	store := &mockPendingTxStore{
+		pending: map[types.Hash]*types.Transaction{},
+		lookup:  map[types.Hash]types.Hash{},
+		blocks:  map[types.Hash]*types.Block{},
+	}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	txn := &types.Transaction{
+		To:       &addr0,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(1),
+		V:        27,
+	}
+	raw := hex.EncodeToHex(txn.MarshalRLP())
+
+	hash0, err := eth.SendRawTransaction(raw)
+	assert.NoError(t, err)
+
+	// sending the same txn again returns the same hash
+	hash1, err := eth.SendRawTransaction(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, hash0, hash1)
+	assert.Len(t, store.pending, 1)
+}
+
+type mockCallCacheStore struct {
+	mockPinnedStore
+
+	calls int
+}
+
+func (m *mockCallCacheStore) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
+	m.calls++
+	return m.mockPinnedStore.ApplyTxn(header, txn, override)
+}
+
+func TestEth_Call_Cache(t *testing.T) {
+	store := &mockCallCacheStore{}
+	importBlock := func(root types.Hash) {
+		store.importBlock(root).AddAccount(addr0)
+		store.Header().ComputeHash()
+	}
+	importBlock(types.Hash{0x1})
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	assert.NoError(t, dispatcher.setupCallCache(16))
+	eth := dispatcher.endpoints.Eth
+
+	call := func(input []byte) {
+		_, err := eth.Call(&txnArgs{
+			From:     argAddrPtr(addr0),
+			To:       argAddrPtr(addr0),
+			GasPrice: argBytesPtr([]byte{}),
+			Data:     argBytesPtr(input),
+		}, blockNum(LatestBlockNumber), nil)
+		assert.NoError(t, err)
+	}
+
+	// the repeated call hits the cache
+	call([]byte{0x1})
+	call([]byte{0x1})
+	assert.Equal(t, 1, store.calls)
+
+	// a different call is executed
+	call([]byte{0x2})
+	assert.Equal(t, 2, store.calls)
+
+	// a new block invalidates the previous results
+	importBlock(types.Hash{0x2})
+	call([]byte{0x1})
+	assert.Equal(t, 3, store.calls)
+}
+
+type mockOverrideStore struct {
+	mockCallCacheStore
+}
+
+func (m *mockOverrideStore) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
+	m.calls++
+	// return the code of the receiver as the output of the call
+	if account, ok := override[*txn.To]; ok && account.Code != nil {
+		return &runtime.ExecutionResult{ReturnValue: account.Code}, nil
+	}
+	return &runtime.ExecutionResult{ReturnValue: []byte{0x1}}, nil
+}
+
+func TestEth_Call_StateOverride(t *testing.T) {
+	store := &mockOverrideStore{}
+	store.importBlock(types.Hash{0x1}).AddAccount(addr0)
+	store.Header().ComputeHash()
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	assert.NoError(t, dispatcher.setupCallCache(16))
+
+	call := func(params string) string {
+		resp, err := dispatcher.Handle([]byte(`{"method": "eth_call", "params": ` + params + `}`))
+		assert.NoError(t, err)
+
+		var res Response
+		assert.NoError(t, json.Unmarshal(resp, &res))
+		return string(res.Result)
+	}
+
+	args := `{"from": "` + addr0.String() + `", "to": "` + addr1.String() + `", "gasPrice": "0x0"}`
+
+	// without overrides the call is executed on the original state
+	assert.Equal(t, `"0x01"`, call(`[`+args+`, "latest"]`))
+	assert.Equal(t, 1, store.calls)
+
+	// the overridden code is executed
+	override := `{"` + addr1.String() + `": {"code": "0x2a2b", "balance": "0x10", "nonce": "0x1"}}`
+	assert.Equal(t, `"0x2a2b"`, call(`[`+args+`, "latest", `+override+`]`))
+
+	// the calls with overrides are not cached
+	assert.Equal(t, `"0x2a2b"`, call(`[`+args+`, "latest", `+override+`]`))
+	assert.Equal(t, 3, store.calls)
+
+	// the overrides do not persist
+	assert.Equal(t, `"0x01"`, call(`[`+args+`, "latest"]`))
+}
+
+type mockFeeStore struct {
+	mockBlockStore2
+
+	receipts map[types.Hash][]*types.Receipt
+}
+
+func (m *mockFeeStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error) {
+	return m.receipts[hash], nil
+}
+
+func TestEth_FeeHistory(t *testing.T) {
+	store := &mockFeeStore{
+		receipts: map[types.Hash][]*types.Receipt{},
+	}
+
+	addBlock := func(gasLimit uint64, prices []int64, gasUsed []uint64) {
+		block := &types.Block{
+			Header: &types.Header{
+				Number:   uint64(len(store.blocks)),
+				Hash:     types.BytesToHash([]byte{byte(len(store.blocks) + 1)}),
+				GasLimit: gasLimit,
+			},
+		}
+		receipts := []*types.Receipt{}
+		for i, price := range prices {
+			block.Transactions = append(block.Transactions, &types.Transaction{GasPrice: big.NewInt(price)})
+			receipts = append(receipts, &types.Receipt{
+				GasUsed:           gasUsed[i],
+				EffectiveGasPrice: big.NewInt(price),
+			})
+			block.Header.GasUsed += gasUsed[i]
+		}
+		store.add(block)
+		store.receipts[block.Hash()] = receipts
+	}
+
+	addBlock(100, nil, nil)
+	addBlock(100, []int64{10, 20}, []uint64{30, 20})
+	addBlock(200, []int64{5, 1}, []uint64{100, 50})
+
+	// the receipts stored without the effective gas price use the gas price of the transaction
+	store.receipts[store.blocks[1].Hash()][0].EffectiveGasPrice = nil
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+	eth := dispatcher.endpoints.Eth
+
+	history := func(count uint64, newest BlockNumber, percentiles []float64) string {
+		res, err := eth.FeeHistory(argUint64(count), newest, percentiles)
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(res)
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	assert.JSONEq(t, `{
+		"oldestBlock": "0x0",
+		"baseFeePerGas": ["0x0", "0x0", "0x0", "0x0"],
+		"gasUsedRatio": [0, 0.5, 0.75],
+		"reward": [
+			["0x0", "0x0", "0x0"],
+			["0xa", "0xa", "0x14"],
+			["0x1", "0x5", "0x5"]
+		]
+	}`, history(3, LatestBlockNumber, []float64{0, 50, 100}))
+
+	// the block count is clamped to the blocks available
+	assert.JSONEq(t, `{
+		"oldestBlock": "0x0",
+		"baseFeePerGas": ["0x0", "0x0", "0x0"],
+		"gasUsedRatio": [0, 0.5]
+	}`, history(10, 1, nil))
+
+	// the percentiles are optional
+	resp, err := dispatcher.Handle([]byte(`{"method": "eth_feeHistory", "params": ["0x1", "latest"]}`))
+	assert.NoError(t, err)
+
+	var res feeHistory
+	assert.NoError(t, expectJSONResult(resp, &res))
+	assert.Equal(t, argUint64(2), res.OldestBlock)
+	assert.Equal(t, []float64{0.75}, res.GasUsedRatio)
+	assert.Nil(t, res.Reward)
+
+	// the percentiles must be sorted and in range
+	_, err = eth.FeeHistory(1, LatestBlockNumber, []float64{50, 10})
+	assert.Error(t, err)
+	_, err = eth.FeeHistory(1, LatestBlockNumber, []float64{101})
+	assert.Error(t, err)
+}
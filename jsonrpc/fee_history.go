@@ -0,0 +1,133 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// maxFeeHistory is the maximum number of blocks of an eth_feeHistory query
+const maxFeeHistory = 1024
+
+// feeHistory is the result of eth_feeHistory
+type feeHistory struct {
+	OldestBlock   argUint64   `json:"oldestBlock"`
+	BaseFeePerGas []*argBig   `json:"baseFeePerGas"`
+	GasUsedRatio  []float64   `json:"gasUsedRatio"`
+	Reward        [][]*argBig `json:"reward,omitempty"`
+}
+
+func validateRewardPercentiles(percentiles []float64) error {
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("reward percentile %f out of range [0, 100]", p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return fmt.Errorf("reward percentiles are not sorted: %f after %f", p, percentiles[i-1])
+		}
+	}
+	return nil
+}
+
+// getFeeHistory walks the chain backwards from the newest header for count blocks
+func getFeeHistory(store blockchainInterface, newest *types.Header, count uint64, percentiles []float64) (*feeHistory, error) {
+	if count > maxFeeHistory {
+		count = maxFeeHistory
+	}
+	if count > newest.Number+1 {
+		count = newest.Number + 1
+	}
+
+	oldest := newest.Number + 1 - count
+	res := &feeHistory{
+		OldestBlock:   argUint64(oldest),
+		BaseFeePerGas: make([]*argBig, 0, count+1),
+		GasUsedRatio:  make([]float64, 0, count),
+	}
+	if len(percentiles) != 0 {
+		res.Reward = make([][]*argBig, 0, count)
+	}
+
+	for num := oldest; num <= newest.Number; num++ {
+		header, ok := store.GetHeaderByNumber(num)
+		if !ok {
+			return nil, fmt.Errorf("header %d not found", num)
+		}
+
+		// there is no base fee before london
+		res.BaseFeePerGas = append(res.BaseFeePerGas, argBigPtr(big.NewInt(0)))
+
+		ratio := float64(0)
+		if header.GasLimit != 0 {
+			ratio = float64(header.GasUsed) / float64(header.GasLimit)
+		}
+		res.GasUsedRatio = append(res.GasUsedRatio, ratio)
+
+		if len(percentiles) != 0 {
+			rewards, err := blockRewards(store, header, percentiles)
+			if err != nil {
+				return nil, err
+			}
+			res.Reward = append(res.Reward, rewards)
+		}
+	}
+
+	// the base fee of the next block
+	res.BaseFeePerGas = append(res.BaseFeePerGas, argBigPtr(big.NewInt(0)))
+	return res, nil
+}
+
+// blockRewards returns the effective gas prices at the percentiles of the gas used in the block
+func blockRewards(store blockchainInterface, header *types.Header, percentiles []float64) ([]*argBig, error) {
+	rewards := make([]*argBig, len(percentiles))
+	for i := range rewards {
+		rewards[i] = argBigPtr(big.NewInt(0))
+	}
+	if header.GasUsed == 0 {
+		return rewards, nil
+	}
+
+	block, ok := store.GetBlockByHash(header.Hash, true)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", header.Number)
+	}
+	receipts, err := store.GetReceiptsByHash(header.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("receipts of block %d not found", header.Number)
+	}
+
+	type txnReward struct {
+		gasUsed uint64
+		reward  *big.Int
+	}
+	txns := make([]txnReward, len(receipts))
+	for i, receipt := range receipts {
+		price := receipt.EffectiveGasPrice
+		if price == nil {
+			price = block.Transactions[i].GasPrice
+		}
+		txns[i] = txnReward{gasUsed: receipt.GasUsed, reward: price}
+	}
+	sort.Slice(txns, func(i, j int) bool {
+		return txns[i].reward.Cmp(txns[j].reward) < 0
+	})
+
+	// the reward at a percentile is the one of the transaction
+	// that reaches that fraction of the gas used in the block
+	var indx int
+	sumGasUsed := txns[0].gasUsed
+	for i, p := range percentiles {
+		threshold := uint64(float64(header.GasUsed) * p / 100)
+		for sumGasUsed < threshold && indx < len(txns)-1 {
+			indx++
+			sumGasUsed += txns[indx].gasUsed
+		}
+		rewards[i] = argBigPtr(txns[indx].reward)
+	}
+	return rewards, nil
+}
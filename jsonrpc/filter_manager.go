@@ -3,6 +3,7 @@ package jsonrpc
 import (
 	"container/heap"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"strings"
 	"sync"
@@ -26,6 +27,18 @@ type Filter struct {
 	// log filter
 	logFilter *LogFilter
 
+	// logs already emitted per block, used to notify
+	// them as removed if the block is rolled back
+	emitted []*emittedLogs
+
+	// reorg filter
+	reorg  bool
+	reorgs []*reorgUpdate
+
+	// pending transactions filter
+	pending  bool
+	txHashes []types.Hash
+
 	// index of the filter in the timer array
 	index int
 
@@ -48,15 +61,83 @@ func (f *Filter) getFilterUpdates() (string, error) {
 		}
 		return fmt.Sprintf("[\"%s\"]", strings.Join(updates, "\",\"")), nil
 	}
+	if f.isPendingTxFilter() {
+		// pending transactions filter
+		res, err := json.Marshal(f.txHashes)
+		if err != nil {
+			return "", err
+		}
+		f.txHashes = []types.Hash{}
+		return string(res), nil
+	}
 	// log filter
 	res, err := json.Marshal(f.logs)
 	if err != nil {
 		return "", err
 	}
+	f.markEmitted(f.logs)
 	f.logs = []*Log{}
 	return string(res), nil
 }
 
+// maxEmittedBlocks is the number of most recent blocks for which a log
+// filter keeps the emitted logs. It bounds the depth of the reorgs
+// that are notified with removed logs
+const maxEmittedBlocks = 128
+
+// emittedLogs are the logs of a block emitted by a log filter
+type emittedLogs struct {
+	hash types.Hash
+	logs []*Log
+}
+
+// markEmitted records the logs that have been delivered to the consumer
+func (f *Filter) markEmitted(logs []*Log) {
+	for _, log := range logs {
+		if log.Removed {
+			continue
+		}
+
+		var entry *emittedLogs
+		if num := len(f.emitted); num != 0 && f.emitted[num-1].hash == log.BlockHash {
+			entry = f.emitted[num-1]
+		} else {
+			entry = &emittedLogs{hash: log.BlockHash}
+			f.emitted = append(f.emitted, entry)
+		}
+		entry.logs = append(entry.logs, log)
+	}
+	if num := len(f.emitted); num > maxEmittedBlocks {
+		f.emitted = f.emitted[num-maxEmittedBlocks:]
+	}
+}
+
+// rollbackBlock handles a block removed from the chain. The logs of the block that
+// are still in the cache are dropped and the ones already delivered are added
+// again as removed. Thus, a consumer is notified once of each reverted log
+func (f *Filter) rollbackBlock(hash types.Hash) {
+	logs := f.logs[:0]
+	for _, log := range f.logs {
+		if log.BlockHash != hash || log.Removed {
+			logs = append(logs, log)
+		}
+	}
+	f.logs = logs
+
+	for i, entry := range f.emitted {
+		if entry.hash != hash {
+			continue
+		}
+		for _, log := range entry.logs {
+			removed := *log
+			removed.Removed = true
+			f.logs = append(f.logs, &removed)
+		}
+		f.emitted = append(f.emitted[:i], f.emitted[i+1:]...)
+		return
+	}
+}
+
 func (f *Filter) isWS() bool {
 	return f.ws != nil
 }
@@ -85,7 +166,7 @@ func (f *Filter) flush() error {
 		f.block = newHead
 
 		for _, block := range updates {
-			raw, err := json.Marshal(block)
+			raw, err := json.Marshal(toHeader(block))
 			if err != nil {
 				return err
 			}
@@ -93,6 +174,26 @@ func (f *Filter) flush() error {
 				return err
 			}
 		}
+	} else if f.isReorgFilter() {
+		// send each reorg independently
+		for _, update := range f.reorgs {
+			res, err := json.Marshal(update)
+			if err != nil {
+				return err
+			}
+			if err := f.sendMessage(string(res)); err != nil {
+				return err
+			}
+		}
+		f.reorgs = []*reorgUpdate{}
+	} else if f.isPendingTxFilter() {
+		// send each transaction hash independently
+		for _, hash := range f.txHashes {
+			if err := f.sendMessage(fmt.Sprintf("\"%s\"", hash.String())); err != nil {
+				return err
+			}
+		}
+		f.txHashes = []types.Hash{}
 	} else {
 		// log filter
 		for _, log := range f.logs {
@@ -104,11 +205,16 @@ func (f *Filter) flush() error {
 				return err
 			}
 		}
+		f.markEmitted(f.logs)
 		f.logs = []*Log{}
 	}
 	return nil
 }
 
+func (f *Filter) isReorgFilter() bool {
+	return f.reorg
+}
+
 func (f *Filter) isLogFilter() bool {
 	return f.logFilter != nil
 }
@@ -117,7 +223,63 @@ func (f *Filter) isBlockFilter() bool {
 	return f.block != nil
 }
 
-var defaultTimeout = 1 * time.Minute
+func (f *Filter) isPendingTxFilter() bool {
+	return f.pending
+}
+
+// match returns true if the log matches a log filter
+func (f *Filter) match(log *types.Log) bool {
+	return f.isLogFilter() && f.logFilter.Match(log)
+}
+
+// reorgUpdate is the notification sent to the reorg filters
+type reorgUpdate struct {
+	Removed []*header `json:"removed"`
+	Added   []*header `json:"added"`
+}
+
+// newReorgUpdate returns the notification of a reorg with the headers in their json form
+func newReorgUpdate(oldChain, newChain []*types.Header) *reorgUpdate {
+	update := &reorgUpdate{
+		Removed: make([]*header, 0, len(oldChain)),
+		Added:   make([]*header, 0, len(newChain)),
+	}
+	for _, h := range oldChain {
+		update.Removed = append(update.Removed, toHeader(h))
+	}
+	for _, h := range newChain {
+		update.Added = append(update.Added, toHeader(h))
+	}
+	return update
+}
+
+// defaultTimeout is the time a filter is kept without being polled
+const defaultTimeout = 1 * time.Minute
+
+// defaultMaxFilters is the maximum number of active filters
+const defaultMaxFilters = 10000
+
+// FilterManagerConfig is the configuration of the filter manager
+type FilterManagerConfig struct {
+	// Timeout is the time a filter is kept without being polled
+	Timeout time.Duration
+
+	// MaxFilters is the maximum number of active filters
+	MaxFilters int
+}
+
+// DefaultFilterManagerConfig returns the default configuration of the filter manager
+func DefaultFilterManagerConfig() *FilterManagerConfig {
+	return &FilterManagerConfig{
+		Timeout:    defaultTimeout,
+		MaxFilters: defaultMaxFilters,
+	}
+}
+
+var errFilterLimit = fmt.Errorf("too many filters")
+
+// metricFilters is the number of active filters
+var metricFilters = expvar.NewInt("jsonrpc_filters")
 
 type FilterManager struct {
 	logger hclog.Logger
@@ -134,10 +296,34 @@ type FilterManager struct {
 	timer    timeHeapImpl
 	timeout  time.Duration
 
+	// maximum number of active filters
+	maxFilters int
+
+	// limits of the queries of the log filters
+	logLimits *logQueryLimits
+
 	blockStream *blockStream
+
+	// subscription to the pending transactions of the pool. It is only
+	// open while there is at least one pending transactions filter
+	pendingCh      <-chan types.Hash
+	pendingCancel  func()
+	pendingFilters int
 }
 
-func NewFilterManager(logger hclog.Logger, store blockchainInterface) *FilterManager {
+func NewFilterManager(logger hclog.Logger, store blockchainInterface, config *FilterManagerConfig) *FilterManager {
+	if config == nil {
+		config = DefaultFilterManagerConfig()
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	maxFilters := config.MaxFilters
+	if maxFilters == 0 {
+		maxFilters = defaultMaxFilters
+	}
+
 	m := &FilterManager{
 		logger:      logger.Named("filter"),
 		store:       store,
@@ -146,7 +332,14 @@ func NewFilterManager(logger hclog.Logger, store blockchainInterface) *FilterMan
 		updateCh:    make(chan struct{}),
 		timer:       timeHeapImpl{},
 		blockStream: &blockStream{},
-		timeout:     defaultTimeout,
+		timeout:     timeout,
+		maxFilters:  maxFilters,
+
+		logLimits: &logQueryLimits{
+			maxBlockRange: defaultMaxLogBlockRange,
+			maxAddresses:  defaultMaxLogAddresses,
+			maxLogs:       defaultMaxLogs,
+		},
 	}
 
 	// start blockstream with the current header
@@ -183,6 +376,12 @@ func (f *FilterManager) Run() {
 			timeoutCh = time.After(time.Until(filter.timestamp))
 		}
 
+		// the pending txs channel is nil (and blocks forever)
+		// if there are no pending transactions filters
+		f.lock.Lock()
+		pendingCh := f.pendingCh
+		f.lock.Unlock()
+
 		select {
 		case evnt := <-watchCh:
 			// new blockchain event
@@ -190,6 +389,10 @@ func (f *FilterManager) Run() {
 				f.logger.Error("failed to dispatch event", "err", err)
 			}
 
+		case hash := <-pendingCh:
+			// new transaction in the pool
+			f.dispatchPendingTx(hash)
+
 		case <-timeoutCh:
 			// timeout for filter
 			if !f.Uninstall(filter.id) {
@@ -208,7 +411,7 @@ func (f *FilterManager) Run() {
 
 func (f *FilterManager) nextTimeoutFilter() *Filter {
 	f.lock.Lock()
-	if len(f.filters) == 0 {
+	if len(f.timer) == 0 {
 		f.lock.Unlock()
 		return nil
 	}
@@ -228,45 +431,63 @@ func (f *FilterManager) dispatchEvent(evnt *blockchain.Event) error {
 		f.blockStream.push(header)
 	}
 
-	processBlock := func(h *types.Header, removed bool) error {
+	processBlock := func(h *types.Header) error {
 		// get the logs from the transaction
 		receipts, err := f.store.GetReceiptsByHash(h.Hash)
 		if err != nil {
 			return err
 		}
 
+		// the log index is the position of the log in the block
+		logIndx := 0
 		for indx, receipt := range receipts {
 			// check the logs with the filters
 			for _, log := range receipt.Logs {
+				logIndx++
 				for _, f := range f.filters {
-					if f.isLogFilter() {
-						if f.logFilter.Match(log) {
-							nn := &Log{
-								Address:     log.Address,
-								Topics:      log.Topics,
-								Data:        argBytes(log.Data),
-								BlockNumber: argUint64(h.Number),
-								BlockHash:   h.Hash,
-								TxHash:      receipt.TxHash,
-								TxIndex:     argUint64(indx),
-								Removed:     removed,
-							}
-							f.logs = append(f.logs, nn)
-						}
+					if !f.match(log) {
+						continue
+					}
+					nn := &Log{
+						Address:     log.Address,
+						Topics:      log.Topics,
+						Data:        argBytes(log.Data),
+						BlockNumber: argUint64(h.Number),
+						BlockHash:   h.Hash,
+						TxHash:      receipt.TxHash,
+						TxIndex:     argUint64(indx),
+						LogIndex:    argUint64(logIndx - 1),
 					}
+					f.logs = append(f.logs, nn)
 				}
 			}
 		}
 		return nil
 	}
 
+	// notify the reorg filters with the removed and added headers
+	if evnt.Type == blockchain.EventReorg {
+		update := newReorgUpdate(evnt.OldChain, evnt.NewChain)
+		for _, f := range f.filters {
+			if f.isReorgFilter() {
+				f.reorgs = append(f.reorgs, update)
+			}
+		}
+	}
+
 	// process old chain
 	for _, i := range evnt.OldChain {
-		processBlock(i, true)
+		for _, f := range f.filters {
+			if f.isLogFilter() {
+				f.rollbackBlock(i.Hash)
+			}
+		}
 	}
 	// process new chain
 	for _, i := range evnt.NewChain {
-		processBlock(i, false)
+		if err := processBlock(i); err != nil {
+			return err
+		}
 	}
 
 	// flush all the websocket values
@@ -278,6 +499,21 @@ func (f *FilterManager) dispatchEvent(evnt *blockchain.Event) error {
 	return nil
 }
 
+func (f *FilterManager) dispatchPendingTx(hash types.Hash) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, f := range f.filters {
+		if !f.isPendingTxFilter() {
+			continue
+		}
+		f.txHashes = append(f.txHashes, hash)
+		if f.isWS() {
+			f.flush()
+		}
+	}
+}
+
 func (f *FilterManager) Exists(id string) bool {
 	f.lock.Lock()
 	_, ok := f.filters[id]
@@ -307,34 +543,124 @@ func (f *FilterManager) GetFilterChanges(id string) (string, error) {
 	return res, nil
 }
 
+var errNotLogFilter = fmt.Errorf("filter is not a log filter")
+
+// GetFilterLogs returns all the logs that match a log filter. If the filter has
+// a block range the range is queried again, otherwise it returns the logs
+// received since the last poll. Unlike GetFilterChanges it does not drain the filter
+func (f *FilterManager) GetFilterLogs(id string) ([]*Log, error) {
+	f.lock.Lock()
+	item, ok := f.filters[id]
+	if !ok || item.isWS() {
+		f.lock.Unlock()
+		return nil, errFilterDoesNotExists
+	}
+	if !item.isLogFilter() {
+		f.lock.Unlock()
+		return nil, errNotLogFilter
+	}
+	if !item.logFilter.hasBlockRange() {
+		logs := make([]*Log, len(item.logs))
+		copy(logs, item.logs)
+		f.lock.Unlock()
+		return logs, nil
+	}
+	logFilter := item.logFilter
+	f.lock.Unlock()
+
+	// query the chain without holding the lock
+	logs, err := getLogs(f.store, logFilter, f.logLimits)
+	if err != nil {
+		return nil, err
+	}
+	if logs == nil {
+		logs = []*Log{}
+	}
+	return logs, nil
+}
+
 func (f *FilterManager) Uninstall(id string) bool {
 	f.lock.Lock()
+	defer f.lock.Unlock()
 
 	item, ok := f.filters[id]
 	if !ok {
 		return false
 	}
+	f.removeFilterLocked(item)
+	return true
+}
+
+// RemoveFilterByWs uninstalls all the filters of a websocket connection
+func (f *FilterManager) RemoveFilterByWs(ws wsConn) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, item := range f.filters {
+		if item.ws == ws {
+			f.removeFilterLocked(item)
+		}
+	}
+}
 
-	delete(f.filters, id)
-	heap.Remove(&f.timer, item.index)
+func (f *FilterManager) removeFilterLocked(item *Filter) {
+	delete(f.filters, item.id)
+	if !item.isWS() {
+		heap.Remove(&f.timer, item.index)
+	}
 
-	f.lock.Unlock()
-	return true
+	if item.isPendingTxFilter() {
+		f.pendingFilters--
+		if f.pendingFilters == 0 {
+			// detach from the txpool
+			f.pendingCancel()
+			f.pendingCh = nil
+			f.pendingCancel = nil
+		}
+	}
+
+	metricFilters.Set(int64(len(f.filters)))
 }
 
-func (f *FilterManager) NewBlockFilter(ws wsConn) string {
+// Count returns the number of active filters
+func (f *FilterManager) Count() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return len(f.filters)
+}
+
+func (f *FilterManager) NewBlockFilter(ws wsConn) (string, error) {
 	return f.addFilter(nil, ws)
 }
 
-func (f *FilterManager) NewLogFilter(logFilter *LogFilter, ws wsConn) string {
+func (f *FilterManager) NewLogFilter(logFilter *LogFilter, ws wsConn) (string, error) {
+	if err := f.logLimits.checkAddresses(logFilter); err != nil {
+		return "", err
+	}
 	return f.addFilter(logFilter, ws)
 }
 
-func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) string {
-	f.lock.Lock()
+// NewReorgFilter creates a websocket filter that is notified
+// with the removed and added headers on each chain reorg
+func (f *FilterManager) NewReorgFilter(ws wsConn) (string, error) {
+	return f.installFilter(&Filter{
+		reorg: true,
+		ws:    ws,
+	})
+}
 
+// NewPendingTxFilter creates a filter that is notified
+// with the hashes of the new transactions in the pool
+func (f *FilterManager) NewPendingTxFilter(ws wsConn) (string, error) {
+	return f.installFilter(&Filter{
+		pending: true,
+		ws:      ws,
+	})
+}
+
+func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) (string, error) {
 	filter := &Filter{
-		id: uuid.New().String(),
 		ws: ws,
 	}
 
@@ -346,11 +672,35 @@ func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) string {
 		// log filter
 		filter.logFilter = logFilter
 	}
+	return f.installFilter(filter)
+}
+
+func (f *FilterManager) installFilter(filter *Filter) (string, error) {
+	f.lock.Lock()
+
+	if len(f.filters) >= f.maxFilters {
+		f.lock.Unlock()
+		return "", errFilterLimit
+	}
 
+	if filter.isPendingTxFilter() {
+		if f.pendingFilters == 0 {
+			// attach to the txpool with the first pending transactions filter
+			f.pendingCh, f.pendingCancel = f.store.SubscribePendingTxs()
+		}
+		f.pendingFilters++
+	}
+
+	filter.id = uuid.New().String()
 	f.filters[filter.id] = filter
-	filter.timestamp = time.Now().Add(f.timeout)
-	heap.Push(&f.timer, filter)
+	if !filter.isWS() {
+		// the websocket filters do not timeout, they are removed
+		// with an unsubscribe or when the connection is closed
+		filter.timestamp = time.Now().Add(f.timeout)
+		heap.Push(&f.timer, filter)
+	}
 
+	metricFilters.Set(int64(len(f.filters)))
 	f.lock.Unlock()
 
 	select {
@@ -358,10 +708,17 @@ func (f *FilterManager) addFilter(logFilter *LogFilter, ws wsConn) string {
 	default:
 	}
 
-	return filter.id
+	return filter.id, nil
 }
 
 func (f *FilterManager) Close() {
+	f.lock.Lock()
+	if f.pendingCancel != nil {
+		f.pendingCancel()
+		f.pendingCancel = nil
+	}
+	f.lock.Unlock()
+
 	close(f.closeCh)
 }
 
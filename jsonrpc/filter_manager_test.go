@@ -1,12 +1,14 @@
 package jsonrpc
 
 import (
+	"encoding/json"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
@@ -15,14 +17,15 @@ import (
 func TestFilterLog(t *testing.T) {
 	store := newMockStore()
 
-	m := NewFilterManager(hclog.NewNullLogger(), store)
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
 	go m.Run()
 
-	id := m.addFilter(&LogFilter{
+	id, err := m.addFilter(&LogFilter{
 		Topics: [][]types.Hash{
 			{hash1},
 		},
 	}, nil)
+	assert.NoError(t, err)
 
 	store.emitEvent(&mockEvent{
 		NewChain: []*mockHeader{
@@ -68,14 +71,106 @@ func TestFilterLog(t *testing.T) {
 	m.GetFilterChanges(id)
 }
 
+func TestFilterLog_Reorg(t *testing.T) {
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
+	go m.Run()
+	defer m.Close()
+
+	id, err := m.NewLogFilter(&LogFilter{
+		Topics: [][]types.Hash{
+			{hash1},
+		},
+	}, nil)
+	assert.NoError(t, err)
+
+	block := func(name string, num uint64) *mockHeader {
+		return &mockHeader{
+			header: &types.Header{
+				Number: num,
+				Hash:   types.StringToHash(name),
+			},
+			receipts: []*types.Receipt{
+				{
+					Logs: []*types.Log{
+						{Topics: []types.Hash{hash1}},
+					},
+				},
+			},
+		}
+	}
+
+	type change struct {
+		block   string
+		removed bool
+	}
+	changes := func() []change {
+		// wait for the manager to process the events
+		time.Sleep(200 * time.Millisecond)
+
+		res, err := m.GetFilterChanges(id)
+		assert.NoError(t, err)
+
+		var logs []*Log
+		assert.NoError(t, json.Unmarshal([]byte(res), &logs))
+
+		result := []change{}
+		for _, log := range logs {
+			result = append(result, change{log.BlockHash.String(), log.Removed})
+		}
+		return result
+	}
+	hash := func(name string) string {
+		return types.StringToHash(name).String()
+	}
+
+	// the block 1 is added and its log is read
+	store.emitEvent(&mockEvent{
+		NewChain: []*mockHeader{block("1", 1)},
+		Type:     blockchain.EventHead,
+	})
+	assert.Equal(t, []change{{hash("1"), false}}, changes())
+
+	// the block 1 is replaced with 1'. The log of 1 is emitted as removed
+	store.emitEvent(&mockEvent{
+		OldChain: []*mockHeader{block("1", 1)},
+		NewChain: []*mockHeader{block("11", 1)},
+		Type:     blockchain.EventReorg,
+	})
+	assert.Equal(t, []change{{hash("1"), true}, {hash("11"), false}}, changes())
+
+	// the block 2' is added but it is rolled back before the log is read,
+	// the log is never emitted
+	store.emitEvent(&mockEvent{
+		NewChain: []*mockHeader{block("12", 2)},
+		Type:     blockchain.EventHead,
+	})
+	store.emitEvent(&mockEvent{
+		OldChain: []*mockHeader{block("12", 2)},
+		NewChain: []*mockHeader{block("2", 2)},
+		Type:     blockchain.EventReorg,
+	})
+	assert.Equal(t, []change{{hash("2"), false}}, changes())
+
+	// the removed logs are emitted only once
+	store.emitEvent(&mockEvent{
+		OldChain: []*mockHeader{block("1", 1), block("2", 2)},
+		NewChain: []*mockHeader{block("21", 1)},
+		Type:     blockchain.EventReorg,
+	})
+	assert.Equal(t, []change{{hash("2"), true}, {hash("21"), false}}, changes())
+}
+
 func TestFilterBlock(t *testing.T) {
 	store := newMockStore()
 
-	m := NewFilterManager(hclog.NewNullLogger(), store)
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
 	go m.Run()
 
 	// add block filter
-	id := m.addFilter(nil, nil)
+	id, err := m.addFilter(nil, nil)
+	assert.NoError(t, err)
 
 	// emit two events
 	store.emitEvent(&mockEvent{
@@ -128,19 +223,108 @@ func TestFilterBlock(t *testing.T) {
 func TestFilterTimeout(t *testing.T) {
 	store := newMockStore()
 
-	m := NewFilterManager(hclog.NewNullLogger(), store)
-	m.timeout = 2 * time.Second
-
+	m := NewFilterManager(hclog.NewNullLogger(), store, &FilterManagerConfig{
+		Timeout: 500 * time.Millisecond,
+	})
 	go m.Run()
+	defer m.Close()
 
 	// add block filter
-	id := m.addFilter(nil, nil)
+	id, err := m.addFilter(nil, nil)
+	assert.NoError(t, err)
 
 	assert.True(t, m.Exists(id))
-	time.Sleep(3 * time.Second)
+	time.Sleep(1 * time.Second)
 	assert.False(t, m.Exists(id))
 }
 
+func TestFilterManagerConfig_Defaults(t *testing.T) {
+	m := NewFilterManager(hclog.NewNullLogger(), newMockStore(), nil)
+	assert.Equal(t, defaultTimeout, m.timeout)
+	assert.Equal(t, defaultMaxFilters, m.maxFilters)
+
+	// the zero fields fall back to the defaults
+	m = NewFilterManager(hclog.NewNullLogger(), newMockStore(), &FilterManagerConfig{MaxFilters: 5})
+	assert.Equal(t, defaultTimeout, m.timeout)
+	assert.Equal(t, 5, m.maxFilters)
+}
+
+func TestFilterPendingTx(t *testing.T) {
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
+	go m.Run()
+	defer m.Close()
+
+	// there is no subscription to the pool without pending filters
+	assert.False(t, store.emitPendingTx(hash1))
+
+	id, err := m.NewPendingTxFilter(nil)
+	assert.NoError(t, err)
+
+	assert.True(t, store.emitPendingTx(hash1))
+	assert.True(t, store.emitPendingTx(hash2))
+
+	time.Sleep(500 * time.Millisecond)
+
+	res, err := m.GetFilterChanges(id)
+	assert.NoError(t, err)
+
+	var hashes []types.Hash
+	assert.NoError(t, json.Unmarshal([]byte(res), &hashes))
+	assert.Equal(t, []types.Hash{hash1, hash2}, hashes)
+
+	// the hashes are only returned once
+	res, err = m.GetFilterChanges(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", res)
+
+	// the pending transactions are not included in the block filters
+	blockID, err := m.NewBlockFilter(nil)
+	assert.NoError(t, err)
+
+	assert.True(t, store.emitPendingTx(hash3))
+	time.Sleep(500 * time.Millisecond)
+
+	res, err = m.GetFilterChanges(blockID)
+	assert.NoError(t, err)
+	assert.NotContains(t, res, hash3.String())
+
+	// uninstalling the last pending filter closes the subscription
+	assert.True(t, m.Uninstall(id))
+	assert.False(t, store.emitPendingTx(hash1))
+}
+
+func TestFilterWebsocket_PendingTx(t *testing.T) {
+	store := newMockStore()
+
+	mock := &mockWsConn{
+		msgCh: make(chan []byte, 1),
+	}
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
+	go m.Run()
+	defer m.Close()
+
+	_, err := m.NewPendingTxFilter(mock)
+	assert.NoError(t, err)
+
+	assert.True(t, store.emitPendingTx(hash1))
+
+	select {
+	case msg := <-mock.msgCh:
+		var notification struct {
+			Params struct {
+				Result types.Hash
+			}
+		}
+		assert.NoError(t, json.Unmarshal(msg, &notification))
+		assert.Equal(t, hash1, notification.Params.Result)
+	case <-time.After(2 * time.Second):
+		t.Fatal("pending txn notification not received")
+	}
+}
+
 func TestFilterWebsocket(t *testing.T) {
 	store := newMockStore()
 
@@ -148,13 +332,14 @@ func TestFilterWebsocket(t *testing.T) {
 		msgCh: make(chan []byte, 1),
 	}
 
-	m := NewFilterManager(hclog.NewNullLogger(), store)
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
 	go m.Run()
 
-	id := m.NewBlockFilter(mock)
+	id, err := m.NewBlockFilter(mock)
+	assert.NoError(t, err)
 
 	// we cannot call get filter changes for a websocket filter
-	_, err := m.GetFilterChanges(id)
+	_, err = m.GetFilterChanges(id)
 	assert.Equal(t, err, errFilterDoesNotExists)
 
 	// emit two events
@@ -175,6 +360,184 @@ func TestFilterWebsocket(t *testing.T) {
 	}
 }
 
+func TestFilterWebsocket_Reorg(t *testing.T) {
+	store := newMockStore()
+
+	mock := &mockWsConn{
+		msgCh: make(chan []byte, 1),
+	}
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
+	go m.Run()
+
+	_, err := m.NewReorgFilter(mock)
+	assert.NoError(t, err)
+
+	// a new head does not notify the reorg filter
+	store.emitEvent(&mockEvent{
+		NewChain: []*mockHeader{
+			{header: &types.Header{Number: 1, Hash: types.StringToHash("1")}},
+		},
+		Type: blockchain.EventHead,
+	})
+
+	// the block 1 is replaced with 1' and 2'
+	store.emitEvent(&mockEvent{
+		OldChain: []*mockHeader{
+			{header: &types.Header{Number: 1, Hash: types.StringToHash("1")}},
+		},
+		NewChain: []*mockHeader{
+			{header: &types.Header{Number: 1, Hash: types.StringToHash("11")}},
+			{header: &types.Header{Number: 2, Hash: types.StringToHash("12")}},
+		},
+		Type: blockchain.EventReorg,
+	})
+
+	var msg []byte
+	select {
+	case msg = <-mock.msgCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("reorg notification not received")
+	}
+
+	// the headers have the json form of the eth endpoints
+	type header struct {
+		Hash   types.Hash `json:"hash"`
+		Number string     `json:"number"`
+	}
+	var resp struct {
+		Params struct {
+			Result struct {
+				Removed []*header
+				Added   []*header
+			}
+		}
+	}
+	assert.NoError(t, json.Unmarshal(msg, &resp))
+
+	result := resp.Params.Result
+	assert.Len(t, result.Removed, 1)
+	assert.Equal(t, types.StringToHash("1"), result.Removed[0].Hash)
+	assert.Equal(t, "0x1", result.Removed[0].Number)
+
+	assert.Len(t, result.Added, 2)
+	assert.Equal(t, types.StringToHash("11"), result.Added[0].Hash)
+	assert.Equal(t, types.StringToHash("12"), result.Added[1].Hash)
+	assert.Equal(t, "0x2", result.Added[1].Number)
+}
+
+func TestFilterLimit(t *testing.T) {
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, &FilterManagerConfig{
+		MaxFilters: 2,
+	})
+
+	ids := []string{}
+	for i := 0; i < 2; i++ {
+		id, err := m.NewBlockFilter(nil)
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// the surplus filters are rejected
+	_, err := m.NewBlockFilter(nil)
+	assert.Equal(t, errFilterLimit, err)
+	_, err = m.NewLogFilter(&LogFilter{}, nil)
+	assert.Equal(t, errFilterLimit, err)
+
+	assert.Equal(t, 2, m.Count())
+	assert.Equal(t, "2", metricFilters.String())
+
+	// there is room for a new filter after an uninstall
+	assert.True(t, m.Uninstall(ids[0]))
+	assert.Equal(t, 1, m.Count())
+	assert.Equal(t, "1", metricFilters.String())
+
+	_, err = m.NewBlockFilter(nil)
+	assert.NoError(t, err)
+}
+
+func TestFilterWebsocket_LogReorg(t *testing.T) {
+	store := newMockStore()
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
+	go m.Run()
+	defer m.Close()
+
+	logFilter := &LogFilter{
+		Topics: [][]types.Hash{
+			{hash1},
+		},
+	}
+
+	block := func(name string) *mockHeader {
+		return &mockHeader{
+			header: &types.Header{
+				Number: 1,
+				Hash:   types.StringToHash(name),
+			},
+			receipts: []*types.Receipt{
+				{
+					Logs: []*types.Log{
+						{Topics: []types.Hash{hash1}},
+					},
+				},
+			},
+		}
+	}
+
+	type delivery struct {
+		block   types.Hash
+		removed bool
+	}
+	next := func(conn *mockWsConn) delivery {
+		select {
+		case msg := <-conn.msgCh:
+			var n struct {
+				Params struct {
+					Result *Log
+				}
+			}
+			assert.NoError(t, json.Unmarshal(msg, &n))
+			return delivery{n.Params.Result.BlockHash, n.Params.Result.Removed}
+		case <-time.After(2 * time.Second):
+			t.Fatal("log not delivered")
+		}
+		return delivery{}
+	}
+
+	// the first subscription receives the log of the canonical block
+	first := &mockWsConn{msgCh: make(chan []byte, 10)}
+	_, err := m.NewLogFilter(logFilter, first)
+	assert.NoError(t, err)
+
+	store.emitEvent(&mockEvent{
+		NewChain: []*mockHeader{block("1")},
+		Type:     blockchain.EventHead,
+	})
+	assert.Equal(t, delivery{types.StringToHash("1"), false}, next(first))
+
+	// the second subscription starts after the log was delivered
+	second := &mockWsConn{msgCh: make(chan []byte, 10)}
+	_, err = m.NewLogFilter(logFilter, second)
+	assert.NoError(t, err)
+
+	store.emitEvent(&mockEvent{
+		OldChain: []*mockHeader{block("1")},
+		NewChain: []*mockHeader{block("11")},
+		Type:     blockchain.EventReorg,
+	})
+
+	// the log is delivered again as removed only to the subscription that received it
+	assert.Equal(t, delivery{types.StringToHash("1"), true}, next(first))
+	assert.Equal(t, delivery{types.StringToHash("11"), false}, next(first))
+	assert.Equal(t, delivery{types.StringToHash("11"), false}, next(second))
+
+	assert.Len(t, first.msgCh, 0)
+	assert.Len(t, second.msgCh, 0)
+}
+
 type mockWsConn struct {
 	msgCh chan []byte
 }
@@ -213,9 +576,12 @@ type mockStore struct {
 	subscription *blockchain.MockSubscription
 	receiptsLock sync.Mutex
 	receipts     map[types.Hash][]*types.Receipt
+
+	pendingLock sync.Mutex
+	pendingCh   chan types.Hash
 }
 
-func (m *mockStore) ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error) {
+func (m *mockStore) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
 	panic("implement me")
 }
 
@@ -235,6 +601,7 @@ func newMockStore() *mockStore {
 	return &mockStore{
 		header:       &types.Header{Number: 0},
 		subscription: blockchain.NewMockSubscription(),
+		receipts:     map[types.Hash][]*types.Receipt{},
 	}
 }
 
@@ -246,16 +613,15 @@ type mockHeader struct {
 type mockEvent struct {
 	OldChain []*mockHeader
 	NewChain []*mockHeader
+	Type     blockchain.EventType
 }
 
 func (m *mockStore) emitEvent(evnt *mockEvent) {
-	if m.receipts == nil {
-		m.receipts = map[types.Hash][]*types.Receipt{}
-	}
-
+	m.receiptsLock.Lock()
 	bEvnt := &blockchain.Event{
 		NewChain: []*types.Header{},
 		OldChain: []*types.Header{},
+		Type:     evnt.Type,
 	}
 	for _, i := range evnt.NewChain {
 		m.receipts[i.header.Hash] = i.receipts
@@ -265,6 +631,8 @@ func (m *mockStore) emitEvent(evnt *mockEvent) {
 		m.receipts[i.header.Hash] = i.receipts
 		bEvnt.OldChain = append(bEvnt.OldChain, i.header)
 	}
+	m.receiptsLock.Unlock()
+
 	m.subscription.Push(bEvnt)
 }
 
@@ -284,3 +652,114 @@ func (m *mockStore) GetReceiptsByHash(hash types.Hash) ([]*types.Receipt, error)
 func (m *mockStore) SubscribeEvents() blockchain.Subscription {
 	return m.subscription
 }
+
+func (m *mockStore) SubscribePendingTxs() (<-chan types.Hash, func()) {
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	ch := make(chan types.Hash, 10)
+	m.pendingCh = ch
+
+	cancel := func() {
+		m.pendingLock.Lock()
+		if m.pendingCh == ch {
+			m.pendingCh = nil
+		}
+		m.pendingLock.Unlock()
+	}
+	return ch, cancel
+}
+
+// emitPendingTx notifies a new pending transaction, it returns
+// false if there is no subscription open
+func (m *mockStore) emitPendingTx(hash types.Hash) bool {
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	if m.pendingCh == nil {
+		return false
+	}
+	m.pendingCh <- hash
+	return true
+}
+
+func TestFilterLogs_GetFilterLogs(t *testing.T) {
+	store := newMockLogStore(5)
+
+	m := NewFilterManager(hclog.NewNullLogger(), store, nil)
+
+	newFilter := func(raw string) string {
+		logFilter := &LogFilter{}
+		assert.NoError(t, json.Unmarshal([]byte(raw), logFilter))
+
+		id, err := m.NewLogFilter(logFilter, nil)
+		assert.NoError(t, err)
+		return id
+	}
+
+	filterChanges := func(id string) []*Log {
+		res, err := m.GetFilterChanges(id)
+		assert.NoError(t, err)
+
+		var logs []*Log
+		assert.NoError(t, json.Unmarshal([]byte(res), &logs))
+		return logs
+	}
+
+	filterLogs := func(id string) []uint64 {
+		logs, err := m.GetFilterLogs(id)
+		assert.NoError(t, err)
+
+		blocks := []uint64{}
+		for _, log := range logs {
+			blocks = append(blocks, uint64(log.BlockNumber))
+		}
+		return blocks
+	}
+
+	rangeID := newFilter(`{"fromBlock": "0x1", "toBlock": "0x3"}`)
+	headID := newFilter(`{}`)
+
+	// a new block is notified to both filters
+	assert.NoError(t, m.dispatchEvent(&blockchain.Event{
+		NewChain: []*types.Header{store.Header()},
+	}))
+
+	// the range filter returns the whole range on every call
+	assert.Equal(t, []uint64{1, 2, 3}, filterLogs(rangeID))
+	assert.Len(t, filterChanges(rangeID), 1)
+	assert.Len(t, filterChanges(rangeID), 0)
+	assert.Equal(t, []uint64{1, 2, 3}, filterLogs(rangeID))
+
+	// the head filter returns the logs received without draining them
+	assert.Equal(t, []uint64{4}, filterLogs(headID))
+	assert.Equal(t, []uint64{4}, filterLogs(headID))
+	assert.Len(t, filterChanges(headID), 1)
+	assert.Equal(t, []uint64{}, filterLogs(headID))
+
+	// only log filters are supported
+	blockID, err := m.NewBlockFilter(nil)
+	assert.NoError(t, err)
+
+	_, err = m.GetFilterLogs(blockID)
+	assert.Equal(t, errNotLogFilter, err)
+
+	_, err = m.GetFilterLogs("not-found")
+	assert.Equal(t, errFilterDoesNotExists, err)
+}
+
+func TestFilterManager_NewLogFilter_MaxAddresses(t *testing.T) {
+	m := NewFilterManager(hclog.NewNullLogger(), newMockStore(), nil)
+	m.logLimits.maxAddresses = 2
+
+	// the installed filters share the address limit of eth_getLogs
+	_, err := m.NewLogFilter(&LogFilter{
+		Addresses: []types.Address{{0x1}, {0x2}, {0x3}},
+	}, nil)
+	assert.Error(t, err)
+
+	_, err = m.NewLogFilter(&LogFilter{
+		Addresses: []types.Address{{0x1}, {0x2}},
+	}, nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package jsonrpc
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// IBFT is the ibft jsonrpc endpoint
+type IBFT struct {
+	d *Dispatcher
+}
+
+type ibftVote struct {
+	Validator types.Address `json:"validator"`
+	Address   types.Address `json:"address"`
+	Authorize bool          `json:"authorize"`
+}
+
+type ibftSnapshot struct {
+	Number     argUint64       `json:"number"`
+	Hash       types.Hash      `json:"hash"`
+	Proposer   types.Address   `json:"proposer"`
+	Validators []types.Address `json:"validators"`
+	Votes      []*ibftVote     `json:"votes"`
+}
+
+func (i *IBFT) getSnapshot(number BlockNumber) (*IBFTSnapshot, error) {
+	header, err := i.d.getBlockHeaderImpl(number)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := i.d.store.GetIBFTSnapshot(header)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("snapshot not found for block %d", header.Number)
+	}
+	return snap, nil
+}
+
+// GetSnapshot returns the validator set, the pending votes and the
+// proposer of the block at the given number (ibft_getSnapshot)
+func (i *IBFT) GetSnapshot(number BlockNumber) (interface{}, error) {
+	snap, err := i.getSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ibftSnapshot{
+		Number:     argUint64(snap.Number),
+		Hash:       snap.Hash,
+		Proposer:   snap.Proposer,
+		Validators: toValidators(snap),
+		Votes:      []*ibftVote{},
+	}
+	for _, vote := range snap.Votes {
+		res.Votes = append(res.Votes, &ibftVote{
+			Validator: vote.Validator,
+			Address:   vote.Address,
+			Authorize: vote.Authorize,
+		})
+	}
+	return res, nil
+}
+
+// GetValidators returns the validator set at the given block number (ibft_getValidators)
+func (i *IBFT) GetValidators(number BlockNumber) (interface{}, error) {
+	snap, err := i.getSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return toValidators(snap), nil
+}
+
+func toValidators(snap *IBFTSnapshot) []types.Address {
+	if snap.Validators == nil {
+		return []types.Address{}
+	}
+	return snap.Validators
+}
@@ -0,0 +1,99 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockIBFTStore struct {
+	nullBlockchainInterface
+
+	headers []*types.Header
+	snaps   map[uint64]*IBFTSnapshot
+}
+
+func (m *mockIBFTStore) Header() *types.Header {
+	return m.headers[len(m.headers)-1]
+}
+
+func (m *mockIBFTStore) GetHeaderByNumber(num uint64) (*types.Header, bool) {
+	if num >= uint64(len(m.headers)) {
+		return nil, false
+	}
+	return m.headers[num], true
+}
+
+func (m *mockIBFTStore) GetIBFTSnapshot(header *types.Header) (*IBFTSnapshot, error) {
+	snap, ok := m.snaps[header.Number]
+	if !ok {
+		return nil, fmt.Errorf("snapshot not found")
+	}
+	return snap, nil
+}
+
+func TestIBFTEndpoint(t *testing.T) {
+	validators := []types.Address{addr0, addr1}
+
+	store := &mockIBFTStore{
+		headers: []*types.Header{
+			{Number: 0, Hash: hash1},
+			{Number: 1, Hash: hash2},
+		},
+		snaps: map[uint64]*IBFTSnapshot{
+			0: {
+				Number:     0,
+				Hash:       hash1,
+				Validators: validators[:1],
+			},
+			1: {
+				Number:     1,
+				Hash:       hash2,
+				Proposer:   addr0,
+				Validators: validators,
+				Votes: []*IBFTVote{
+					{Validator: addr0, Address: addr2, Authorize: true},
+				},
+			},
+		},
+	}
+
+	d := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	call := func(method string, number string, res interface{}) error {
+		resp, err := d.Handle([]byte(`{"method": "` + method + `", "params": ["` + number + `"]}`))
+		if err != nil {
+			return err
+		}
+		return expectJSONResult(resp, res)
+	}
+
+	t.Run("snapshot at latest", func(t *testing.T) {
+		var snap ibftSnapshot
+		assert.NoError(t, call("ibft_getSnapshot", "latest", &snap))
+
+		assert.Equal(t, argUint64(1), snap.Number)
+		assert.Equal(t, hash2, snap.Hash)
+		assert.Equal(t, addr0, snap.Proposer)
+		assert.Equal(t, validators, snap.Validators)
+		assert.Equal(t, []*ibftVote{{Validator: addr0, Address: addr2, Authorize: true}}, snap.Votes)
+	})
+
+	t.Run("validators at number", func(t *testing.T) {
+		var res []types.Address
+		assert.NoError(t, call("ibft_getValidators", "0x0", &res))
+		assert.Equal(t, validators[:1], res)
+
+		assert.NoError(t, call("ibft_getValidators", "latest", &res))
+		assert.Equal(t, validators, res)
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		var res []types.Address
+		assert.Error(t, call("ibft_getValidators", "0x5", &res))
+		assert.Error(t, call("ibft_getSnapshot", "pending", &res))
+	})
+}
@@ -1,11 +1,19 @@
 package jsonrpc
 
 import (
+	"compress/gzip"
+	"context"
+	"expvar"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/types"
 	"github.com/gorilla/websocket"
 	"github.com/hashicorp/go-hclog"
 )
@@ -38,25 +46,116 @@ type JSONRPC struct {
 	logger     hclog.Logger
 	config     *Config
 	dispatcher dispatcherImpl
+	server     *http.Server
 }
 
 type dispatcherImpl interface {
 	HandleWs(reqBody []byte, conn wsConn) ([]byte, error)
 	Handle([]byte) ([]byte, error)
+	RemoveFilterByWs(conn wsConn)
 }
 
 type Config struct {
 	Store   blockchainInterface
 	Addr    *net.TCPAddr
 	ChainID uint64
+
+	// Ready reports whether the node is ready to serve requests.
+	// It backs the /ready endpoint, a nil value means always ready
+	Ready func() error
+
+	// Gzip enables the gzip compression of the http responses
+	// for the clients that accept it
+	Gzip bool
+
+	// GzipMinSize is the minimum size (in bytes) of a response to be compressed
+	GzipMinSize int
+
+	// Metrics serves the expvar metrics on /debug/vars. It is disabled by default
+	// since the endpoint shares the public listener of the JSON-RPC
+	Metrics bool
+
+	// CallCacheSize is the number of eth_call results cached. The cache is disabled if zero
+	CallCacheSize int
+
+	// MaxLogBlockRange is the maximum number of blocks queried by eth_getLogs
+	MaxLogBlockRange uint64
+
+	// MaxLogAddresses is the maximum number of addresses of a log filter, used by
+	// eth_getLogs as well as by the installed filters and the logs subscriptions
+	MaxLogAddresses int
+
+	// MaxLogs is the maximum number of logs returned by an eth_getLogs that is not paginated
+	MaxLogs int
+
+	// MaxLogsPerPage is the maximum number of logs returned by a paginated eth_getLogs
+	MaxLogsPerPage int
+
+	// GasEstimationCap is the maximum gas limit searched by eth_estimateGas
+	GasEstimationCap uint64
+
+	// RateLimits are the rate limits of the requests per namespace (i.e. debug).
+	// The namespaces without a rate limit are not limited
+	RateLimits map[string]*RateLimit
+
+	// FilterManager is the configuration of the filters, the defaults are used if nil
+	FilterManager *FilterManagerConfig
+
+	// SenderAllowlist are the only senders allowed to send transactions
+	// through eth_sendRawTransaction and eth_sendTransaction. All the senders
+	// are allowed if empty
+	SenderAllowlist []types.Address
+
+	// Forks are the forks of the chain. The typed transactions are
+	// only accepted by eth_sendRawTransaction after the london fork
+	Forks *chain.Forks
 }
 
+const defaultGzipMinSize = 1024
+
+// shutdownTimeout is the time given to the in-flight requests to complete on close
+const shutdownTimeout = 5 * time.Second
+
 // NewJSONRPC returns the JsonRPC http server
 func NewJSONRPC(logger hclog.Logger, config *Config) (*JSONRPC, error) {
+	dispatcher := newDispatcher(logger, config.Store, config.ChainID, config.FilterManager)
+	if config.MaxLogBlockRange != 0 {
+		dispatcher.maxLogBlockRange = config.MaxLogBlockRange
+	}
+	if config.MaxLogAddresses != 0 {
+		dispatcher.maxLogAddresses = config.MaxLogAddresses
+	}
+	if config.MaxLogs != 0 {
+		dispatcher.maxLogs = config.MaxLogs
+	}
+	if dispatcher.filterManager != nil {
+		dispatcher.filterManager.logLimits = dispatcher.logQueryLimits()
+	}
+	if config.MaxLogsPerPage != 0 {
+		dispatcher.maxLogsPerPage = config.MaxLogsPerPage
+	}
+	if config.GasEstimationCap != 0 {
+		dispatcher.gasEstimationCap = config.GasEstimationCap
+	}
+	if len(config.RateLimits) != 0 {
+		if err := dispatcher.setupRateLimits(config.RateLimits); err != nil {
+			return nil, err
+		}
+	}
+	if len(config.SenderAllowlist) != 0 {
+		dispatcher.setupSenderAllowlist(config.SenderAllowlist)
+	}
+	dispatcher.forks = config.Forks
+	if config.CallCacheSize != 0 {
+		if err := dispatcher.setupCallCache(config.CallCacheSize); err != nil {
+			return nil, err
+		}
+	}
+
 	srv := &JSONRPC{
 		logger:     logger.Named("jsonrpc"),
 		config:     config,
-		dispatcher: newDispatcher(logger, config.Store, config.ChainID),
+		dispatcher: dispatcher,
 	}
 
 	// start http server
@@ -74,27 +173,79 @@ func (j *JSONRPC) setupHTTP() error {
 		return err
 	}
 
-	mux := http.DefaultServeMux
-	mux.HandleFunc("/", j.handle)
-	mux.HandleFunc("/ws", j.handleWs)
-
-	srv := http.Server{
-		Handler: mux,
+	j.server = &http.Server{
+		Handler: j.newMux(),
 	}
 	go func() {
-		if err := srv.Serve(lis); err != nil {
+		if err := j.server.Serve(lis); err != nil && err != http.ErrServerClosed {
 			j.logger.Error("closed http connection", "err", err)
 		}
 	}()
 	return nil
 }
 
+// newMux returns the handlers of the http server
+func (j *JSONRPC) newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", j.handle)
+	mux.HandleFunc("/ws", j.handleWs)
+	mux.HandleFunc("/ready", j.handleReady)
+	if j.config.Metrics {
+		mux.HandleFunc("/debug/vars", handleMetrics)
+	}
+	return mux
+}
+
+// processMetrics are the variables published by expvar about the process itself.
+// They are left out of the metrics since the command line can carry secrets
+var processMetrics = map[string]struct{}{
+	"cmdline":  {},
+	"memstats": {},
+}
+
+// handleMetrics writes the expvar metrics of the node as a json object
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	fmt.Fprintf(w, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if _, ok := processMetrics[kv.Key]; ok {
+			return
+		}
+		if !first {
+			fmt.Fprintf(w, ",\n")
+		}
+		first = false
+		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprintf(w, "\n}\n")
+}
+
+// Close stops the http server. It stops accepting new connections and waits
+// (up to shutdownTimeout) for the in-flight requests to complete
+func (j *JSONRPC) Close() error {
+	if j.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return j.server.Shutdown(ctx)
+}
+
 type wrapWsConn struct {
+	// the websocket connection supports one concurrent writer
+	lock sync.Mutex
 	conn *websocket.Conn
 }
 
 func (w *wrapWsConn) WriteMessage(b []byte) error {
-	return w.conn.WriteMessage(0, b)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.conn.WriteMessage(websocket.TextMessage, b)
 }
 
 func (j *JSONRPC) handleWs(w http.ResponseWriter, req *http.Request) {
@@ -105,22 +256,38 @@ func (j *JSONRPC) handleWs(w http.ResponseWriter, req *http.Request) {
 	defer c.Close()
 
 	wrapConn := &wrapWsConn{conn: c}
+
+	// free the subscriptions of the connection once it is closed
+	defer j.dispatcher.RemoveFilterByWs(wrapConn)
+
 	for {
 		_, message, err := c.ReadMessage()
 		if err != nil {
 			break
 		}
 		go func() {
+			// the errors of the request are part of the response
 			resp, err := j.dispatcher.HandleWs(message, wrapConn)
 			if err != nil {
-				wrapConn.WriteMessage(resp)
-			} else {
-				wrapConn.WriteMessage([]byte(fmt.Sprintf(err.Error())))
+				j.logger.Error("failed to handle ws request", "err", err)
+				return
 			}
+			wrapConn.WriteMessage(resp)
 		}()
 	}
 }
 
+func (j *JSONRPC) handleReady(w http.ResponseWriter, req *http.Request) {
+	if j.config.Ready != nil {
+		if err := j.config.Ready(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+	w.Write([]byte("OK"))
+}
+
 func (j *JSONRPC) handle(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
@@ -151,5 +318,29 @@ func (j *JSONRPC) handle(w http.ResponseWriter, req *http.Request) {
 		handleErr(err)
 		return
 	}
-	w.Write(resp)
+	j.writeResponse(w, req, resp)
+}
+
+// writeResponse writes the response, compressed with gzip if
+// it is enabled and the response is big enough
+func (j *JSONRPC) writeResponse(w http.ResponseWriter, req *http.Request, resp []byte) {
+	minSize := j.config.GzipMinSize
+	if minSize == 0 {
+		minSize = defaultGzipMinSize
+	}
+	if !j.config.Gzip || len(resp) < minSize || !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		w.Write(resp)
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	gw := gzip.NewWriter(w)
+	if _, err := gw.Write(resp); err != nil {
+		j.logger.Error("failed to write gzip response", "err", err)
+	}
+	if err := gw.Close(); err != nil {
+		j.logger.Error("failed to close gzip response", "err", err)
+	}
 }
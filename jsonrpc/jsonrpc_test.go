@@ -1,10 +1,22 @@
 package jsonrpc
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/gorilla/websocket"
 	"github.com/hashicorp/go-hclog"
 )
 
@@ -18,5 +30,424 @@ func TestHTTPServer(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer srv.Close()
 	fmt.Println(srv)
 }
+
+func TestHTTPServer_Ready(t *testing.T) {
+	ready := errors.New("not ready")
+	srv := &JSONRPC{
+		config: &Config{
+			Ready: func() error {
+				return ready
+			},
+		},
+	}
+
+	check := func(code int) {
+		rec := httptest.NewRecorder()
+		srv.handleReady(rec, httptest.NewRequest("GET", "/ready", nil))
+		if rec.Code != code {
+			t.Fatalf("expected status %d but found %d", code, rec.Code)
+		}
+	}
+
+	check(http.StatusServiceUnavailable)
+
+	ready = nil
+	check(http.StatusOK)
+}
+
+type mockDispatcher struct {
+	resp []byte
+}
+
+func (m *mockDispatcher) HandleWs(reqBody []byte, conn wsConn) ([]byte, error) {
+	return m.resp, nil
+}
+
+func (m *mockDispatcher) Handle([]byte) ([]byte, error) {
+	return m.resp, nil
+}
+
+func (m *mockDispatcher) RemoveFilterByWs(conn wsConn) {
+}
+
+func TestHTTPServer_Metrics(t *testing.T) {
+	get := func(metrics bool) *httptest.ResponseRecorder {
+		srv := &JSONRPC{
+			logger: hclog.NewNullLogger(),
+			config: &Config{
+				Metrics: metrics,
+			},
+			dispatcher: &mockDispatcher{},
+		}
+		rec := httptest.NewRecorder()
+		srv.newMux().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+		return rec
+	}
+
+	// the metrics are not served unless enabled
+	rec := get(false)
+	if strings.Contains(rec.Body.String(), "jsonrpc_filters") {
+		t.Fatal("expected the metrics to be disabled")
+	}
+
+	rec = get(true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d but found %d", http.StatusOK, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "jsonrpc_filters") {
+		t.Fatal("expected the metrics to be served")
+	}
+
+	// the metrics of the process are left out
+	var metrics map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &metrics); err != nil {
+		t.Fatal(err)
+	}
+	for name := range processMetrics {
+		if _, ok := metrics[name]; ok {
+			t.Fatalf("expected the metric %s to be left out", name)
+		}
+	}
+}
+
+func TestHTTPServer_Gzip(t *testing.T) {
+	resp := bytes.Repeat([]byte{'a'}, 2048)
+
+	srv := &JSONRPC{
+		logger: hclog.NewNullLogger(),
+		config: &Config{
+			Gzip: true,
+		},
+		dispatcher: &mockDispatcher{resp: resp},
+	}
+
+	send := func(acceptGzip bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/", bytes.NewReader([]byte("{}")))
+		if acceptGzip {
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+		rec := httptest.NewRecorder()
+		srv.handle(rec, req)
+		return rec
+	}
+
+	// the response is compressed if the client accepts it
+	rec := send(true)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("expected gzip encoding")
+	}
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, resp) {
+		t.Fatal("bad gzip response")
+	}
+
+	// the response is not compressed otherwise
+	rec = send(false)
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Fatal("unexpected encoding")
+	}
+	if !bytes.Equal(rec.Body.Bytes(), resp) {
+		t.Fatal("bad response")
+	}
+
+	// small responses are not compressed
+	srv.dispatcher = &mockDispatcher{resp: []byte("{}")}
+	rec = send(true)
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Fatal("unexpected encoding")
+	}
+}
+
+type blockingDispatcher struct {
+	mockDispatcher
+
+	startCh   chan struct{}
+	releaseCh chan struct{}
+}
+
+func (b *blockingDispatcher) Handle(req []byte) ([]byte, error) {
+	close(b.startCh)
+	<-b.releaseCh
+	return b.mockDispatcher.Handle(req)
+}
+
+func TestHTTPServer_GracefulShutdown(t *testing.T) {
+	// find a free port
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().(*net.TCPAddr)
+	lis.Close()
+
+	dispatcher := &blockingDispatcher{
+		mockDispatcher: mockDispatcher{resp: []byte("{}")},
+		startCh:        make(chan struct{}),
+		releaseCh:      make(chan struct{}),
+	}
+	srv := &JSONRPC{
+		logger:     hclog.NewNullLogger(),
+		config:     &Config{Addr: addr},
+		dispatcher: dispatcher,
+	}
+	if err := srv.setupHTTP(); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	respCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Post("http://"+addr.String(), "application/json", bytes.NewReader([]byte("{}")))
+		if err != nil {
+			respCh <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		respCh <- result{body: body, err: err}
+	}()
+
+	// wait for the request to be in-flight
+	select {
+	case <-dispatcher.startCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request not received")
+	}
+
+	closeCh := make(chan error, 1)
+	go func() {
+		closeCh <- srv.Close()
+	}()
+
+	// close waits for the in-flight request
+	select {
+	case <-closeCh:
+		t.Fatal("close did not wait for the in-flight request")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(dispatcher.releaseCh)
+
+	res := <-respCh
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if string(res.body) != "{}" {
+		t.Fatalf("bad response %s", string(res.body))
+	}
+	if err := <-closeCh; err != nil {
+		t.Fatal(err)
+	}
+
+	// the listener is released
+	lis, err = net.Listen("tcp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis.Close()
+}
+
+// testWsClient is a websocket client of a test server
+type testWsClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// newTestWsClient starts a websocket server with the dispatcher and connects to it
+func newTestWsClient(t *testing.T, dispatcher dispatcherImpl) (*testWsClient, func()) {
+	srv := &JSONRPC{
+		logger:     hclog.NewNullLogger(),
+		config:     &Config{},
+		dispatcher: dispatcher,
+	}
+	s := httptest.NewServer(http.HandlerFunc(srv.handleWs))
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	closeFn := func() {
+		conn.Close()
+		s.Close()
+	}
+	return &testWsClient{t: t, conn: conn}, closeFn
+}
+
+// read returns the next message received
+func (c *testWsClient) read() []byte {
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, msg, err := c.conn.ReadMessage()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+// call sends a request and decodes the result of the response
+func (c *testWsClient) call(req string, result interface{}) {
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(req)); err != nil {
+		c.t.Fatal(err)
+	}
+	if err := expectJSONResult(c.read(), result); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// waitFilters waits until the dispatcher has the given number of filters
+func waitFilters(t *testing.T, dispatcher *Dispatcher, count int) {
+	for i := 0; dispatcher.filterManager.Count() != count; i++ {
+		if i == 20 {
+			t.Fatalf("expected %d filters but found %d", count, dispatcher.filterManager.Count())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestWebsocket_NewHeads(t *testing.T) {
+	store := newMockStore()
+
+	dispatcher := newDispatcher(hclog.NewNullLogger(), store, 0, nil)
+	defer dispatcher.filterManager.Close()
+
+	client, closeFn := newTestWsClient(t, dispatcher)
+	defer closeFn()
+
+	var subID string
+	client.call(`{"id": 1, "method": "eth_subscribe", "params": ["newHeads"]}`, &subID)
+
+	store.emitEvent(&mockEvent{
+		NewChain: []*mockHeader{
+			{header: &types.Header{Number: 10, GasLimit: 100, Hash: hash1}},
+		},
+	})
+
+	msg := client.read()
+
+	var notification struct {
+		Method string
+		Params struct {
+			Subscription string
+			Result       map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(msg, &notification); err != nil {
+		t.Fatal(err)
+	}
+	if notification.Method != "eth_subscription" || notification.Params.Subscription != subID {
+		t.Fatalf("unexpected notification %s", string(msg))
+	}
+	result := notification.Params.Result
+	if result["number"] != "0xa" || result["gasLimit"] != "0x64" || result["hash"] != hash1.String() {
+		t.Fatalf("unexpected header %s", string(msg))
+	}
+
+	// unsubscribe stops the stream
+	var ok bool
+	client.call(`{"id": 2, "method": "eth_unsubscribe", "params": ["`+subID+`"]}`, &ok)
+	if !ok || dispatcher.filterManager.Count() != 0 {
+		t.Fatal("subscription not removed")
+	}
+
+	// the subscriptions are removed when the connection is closed
+	client.call(`{"id": 3, "method": "eth_subscribe", "params": ["newHeads"]}`, &subID)
+	waitFilters(t, dispatcher, 1)
+
+	closeFn()
+	waitFilters(t, dispatcher, 0)
+}
+
+func TestWebsocket_Logs(t *testing.T) {
+	store := newMockStore()
+
+	dispatcher := newDispatcher(hclog.NewNullLogger(), store, 0, nil)
+	defer dispatcher.filterManager.Close()
+
+	client, closeFn := newTestWsClient(t, dispatcher)
+	defer closeFn()
+
+	var subID string
+	client.call(`{"id": 1, "method": "eth_subscribe", "params": ["logs", {"address": "`+addr1.String()+`", "topics": ["`+hash1.String()+`"]}]}`, &subID)
+
+	receipts := []*types.Receipt{
+		{
+			TxHash: hash3,
+			Logs: []*types.Log{
+				// matching log
+				{Address: addr1, Topics: []types.Hash{hash1, hash2}, Data: []byte{0x1}},
+				// different address
+				{Address: addr2, Topics: []types.Hash{hash1}},
+				// different topic
+				{Address: addr1, Topics: []types.Hash{hash2}},
+			},
+		},
+	}
+	block := &mockHeader{
+		header:   &types.Header{Number: 1, Hash: types.StringToHash("1")},
+		receipts: receipts,
+	}
+
+	type notification struct {
+		Params struct {
+			Subscription string
+			Result       *Log
+		}
+	}
+	next := func() *Log {
+		var n notification
+		if err := json.Unmarshal(client.read(), &n); err != nil {
+			t.Fatal(err)
+		}
+		if n.Params.Subscription != subID {
+			t.Fatalf("unexpected subscription %s", n.Params.Subscription)
+		}
+		return n.Params.Result
+	}
+
+	store.emitEvent(&mockEvent{
+		NewChain: []*mockHeader{block},
+	})
+
+	// only the matching log is pushed
+	log := next()
+	if log.Address != addr1 || log.TxHash != hash3 || log.BlockHash != block.header.Hash || log.Removed {
+		t.Fatalf("unexpected log %v", log)
+	}
+	if log.LogIndex != 0 || !bytes.Equal(log.Data, []byte{0x1}) {
+		t.Fatalf("unexpected log %v", log)
+	}
+
+	// the block is replaced, the log is pushed as removed and then the one of the new block
+	newBlock := &mockHeader{
+		header:   &types.Header{Number: 1, Hash: types.StringToHash("11")},
+		receipts: receipts,
+	}
+	store.emitEvent(&mockEvent{
+		OldChain: []*mockHeader{block},
+		NewChain: []*mockHeader{newBlock},
+		Type:     blockchain.EventReorg,
+	})
+
+	if log := next(); log.BlockHash != block.header.Hash || !log.Removed {
+		t.Fatalf("expected removed log but found %v", log)
+	}
+	if log := next(); log.BlockHash != newBlock.header.Hash || log.Removed {
+		t.Fatalf("expected new log but found %v", log)
+	}
+
+	// the subscription is removed with the connection
+	closeFn()
+	waitFilters(t, dispatcher, 0)
+}
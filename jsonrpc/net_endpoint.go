@@ -1,22 +1,31 @@
 package jsonrpc
 
+import (
+	"strconv"
+)
+
 // Net is the net jsonrpc endpoint
 type Net struct {
 	d *Dispatcher
 }
 
-// Version returns the current network id
+// Version returns the current network id as a decimal string
 func (n *Net) Version() (interface{}, error) {
-	//return fmt.Sprintf("%x", n.d.chainID), nil
-	return argUintPtr(n.d.chainID), nil
+	return strconv.FormatUint(n.d.chainID, 10), nil
 }
 
 // Listening returns true if client is actively listening for network connections
 func (n *Net) Listening() (interface{}, error) {
-	return true, nil
+	return n.d.store.IsListening(), nil
 }
 
 // PeerCount returns number of peers currently connected to the client
 func (n *Net) PeerCount() (interface{}, error) {
-	return argUintPtr(0), nil
+	return argUintPtr(uint64(n.d.store.PeerCount())), nil
+}
+
+// PeerScores returns the score of the peers with a penalty by peer id. Peers
+// with a score below the minimum are not dialed until the score recovers
+func (n *Net) PeerScores() (interface{}, error) {
+	return n.d.store.PeerScores(), nil
 }
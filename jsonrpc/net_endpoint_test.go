@@ -0,0 +1,57 @@
+package jsonrpc
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNetStore struct {
+	nullBlockchainInterface
+
+	peers     int64
+	listening bool
+	scores    map[string]int64
+}
+
+func (m *mockNetStore) PeerCount() int64 {
+	return m.peers
+}
+
+func (m *mockNetStore) IsListening() bool {
+	return m.listening
+}
+
+func (m *mockNetStore) PeerScores() map[string]int64 {
+	return m.scores
+}
+
+func TestNetEndpoint(t *testing.T) {
+	store := &mockNetStore{peers: 12, listening: true, scores: map[string]int64{"a": -5}}
+
+	d := newTestDispatcher(hclog.NewNullLogger(), store)
+	d.chainID = 100
+
+	call := func(method string, res interface{}) {
+		resp, err := d.Handle([]byte(`{"method": "` + method + `", "params": []}`))
+		assert.NoError(t, err)
+		assert.NoError(t, expectJSONResult(resp, res))
+	}
+
+	var count string
+	call("net_peerCount", &count)
+	assert.Equal(t, "0xc", count)
+
+	var listening bool
+	call("net_listening", &listening)
+	assert.True(t, listening)
+
+	var version string
+	call("net_version", &version)
+	assert.Equal(t, "100", version)
+
+	var scores map[string]int64
+	call("net_peerScores", &scores)
+	assert.Equal(t, map[string]int64{"a": -5}, scores)
+}
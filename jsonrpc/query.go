@@ -1,12 +1,23 @@
 package jsonrpc
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 
+	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/types"
 )
 
+const (
+	// maxFilterTopics is the maximum number of topic positions in a filter,
+	// a log has at most four topics
+	maxFilterTopics = 4
+
+	// maxFilterTopicAlternatives is the maximum number of alternatives per topic position
+	maxFilterTopicAlternatives = 256
+)
+
 // LogFilter is a filter for logs
 type LogFilter struct {
 	BlockHash *types.Hash
@@ -16,6 +27,10 @@ type LogFilter struct {
 
 	Addresses []types.Address
 	Topics    [][]types.Hash
+
+	// Cursor is the continuation token of a paginated query, an
+	// empty token starts it. The query is not paginated if nil
+	Cursor *string
 }
 
 // addTopicSet adds specific topics to the log filter topics
@@ -77,6 +92,7 @@ func (l *LogFilter) UnmarshalJSON(data []byte) error {
 		ToBlock   string        `json:"toBlock"`
 		Address   interface{}   `json:"address"`
 		Topics    []interface{} `json:"topics"`
+		Cursor    *string       `json:"cursor"`
 	}
 	err := json.Unmarshal(data, &obj)
 	if err != nil {
@@ -84,6 +100,7 @@ func (l *LogFilter) UnmarshalJSON(data []byte) error {
 	}
 
 	l.BlockHash = obj.BlockHash
+	l.Cursor = obj.Cursor
 
 	if obj.FromBlock == "" {
 		l.fromBlock = LatestBlockNumber
@@ -128,6 +145,10 @@ func (l *LogFilter) UnmarshalJSON(data []byte) error {
 	}
 
 	if obj.Topics != nil {
+		if len(obj.Topics) > maxFilterTopics {
+			return fmt.Errorf("too many topics, max %d", maxFilterTopics)
+		}
+
 		// decode topics, either "" or ["", ""] or null
 		for _, item := range obj.Topics {
 			switch raw := item.(type) {
@@ -139,6 +160,9 @@ func (l *LogFilter) UnmarshalJSON(data []byte) error {
 
 			case []interface{}:
 				// ["", ""]
+				if len(raw) > maxFilterTopicAlternatives {
+					return fmt.Errorf("too many topic alternatives, max %d", maxFilterTopicAlternatives)
+				}
 				res := []string{}
 				for _, i := range raw {
 					if item, ok := i.(string); ok {
@@ -199,3 +223,174 @@ func (l *LogFilter) Match(log *types.Log) bool {
 	}
 	return true
 }
+
+// hasBlockRange returns true if the filter queries a specific
+// block or range of blocks instead of following the head
+func (l *LogFilter) hasBlockRange() bool {
+	return l.BlockHash != nil || l.fromBlock != LatestBlockNumber || l.toBlock != LatestBlockNumber
+}
+
+// getLogs returns the logs in the chain that match the filter. The range
+// of blocks queried is limited to maxBlockRange blocks
+func getLogs(store blockchainInterface, filterOptions *LogFilter, limits *logQueryLimits) ([]*Log, error) {
+	logs, _, err := queryLogs(store, filterOptions, limits, nil, 0)
+	return logs, err
+}
+
+// logQueryLimits are the limits of a log query
+type logQueryLimits struct {
+	// maxBlockRange is the maximum number of blocks queried
+	maxBlockRange uint64
+
+	// maxAddresses is the maximum number of addresses in the filter (unlimited if zero)
+	maxAddresses int
+
+	// maxLogs is the maximum number of logs matched by a query
+	// that is not paginated (unlimited if zero)
+	maxLogs int
+}
+
+// checkAddresses returns an error if the filter has more than maxAddresses addresses
+func (l *logQueryLimits) checkAddresses(filter *LogFilter) error {
+	if num := len(filter.Addresses); l.maxAddresses != 0 && num > l.maxAddresses {
+		return fmt.Errorf("filter of %d addresses exceeds the maximum of %d addresses", num, l.maxAddresses)
+	}
+	return nil
+}
+
+// logCursor is the position of a log in the chain
+type logCursor struct {
+	block    uint64
+	logIndex uint64
+}
+
+// encode returns the continuation token of the cursor
+func (c *logCursor) encode() string {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[0:], c.block)
+	binary.BigEndian.PutUint64(buf[8:], c.logIndex)
+	return hex.EncodeToHex(buf)
+}
+
+// decodeLogCursor decodes a continuation token. An empty token is the start of the query
+func decodeLogCursor(str string) (*logCursor, error) {
+	if str == "" {
+		return nil, nil
+	}
+	buf, err := hex.DecodeHex(str)
+	if err != nil || len(buf) != 16 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &logCursor{
+		block:    binary.BigEndian.Uint64(buf[0:]),
+		logIndex: binary.BigEndian.Uint64(buf[8:]),
+	}, nil
+}
+
+// queryLogs returns the logs in the chain that match the filter starting from the
+// cursor (if any). If limit is not zero it returns at most limit logs and the
+// cursor of the next log, which is nil if there are no more logs
+func queryLogs(store blockchainInterface, filterOptions *LogFilter, limits *logQueryLimits, start *logCursor, limit int) ([]*Log, *logCursor, error) {
+	var result []*Log
+	var next *logCursor
+
+	if err := limits.checkAddresses(filterOptions); err != nil {
+		return nil, nil, err
+	}
+
+	parseReceipts := func(header *types.Header) error {
+		receipts, err := store.GetReceiptsByHash(header.Hash)
+		if err != nil {
+			return err
+		}
+
+		// the log index is the position of the log in the block
+		logIndx := 0
+		for indx, receipt := range receipts {
+			for _, log := range receipt.Logs {
+				logIndx++
+				if start != nil && header.Number == start.block && uint64(logIndx-1) < start.logIndex {
+					continue
+				}
+				if !filterOptions.Match(log) {
+					continue
+				}
+				if limit != 0 && len(result) == limit {
+					// the page is full, resume from this log
+					next = &logCursor{block: header.Number, logIndex: uint64(logIndx - 1)}
+					return nil
+				}
+				if limit == 0 && limits.maxLogs != 0 && len(result) == limits.maxLogs {
+					return fmt.Errorf("query returned more than %d logs, narrow the query or paginate it", limits.maxLogs)
+				}
+				result = append(result, &Log{
+					Address:     log.Address,
+					Topics:      log.Topics,
+					Data:        argBytes(log.Data),
+					BlockNumber: argUint64(header.Number),
+					BlockHash:   header.Hash,
+					TxHash:      receipt.TxHash,
+					TxIndex:     argUint64(indx),
+					LogIndex:    argUint64(logIndx - 1),
+				})
+			}
+		}
+		return nil
+	}
+
+	if filterOptions.BlockHash != nil {
+		block, ok := store.GetBlockByHash(*filterOptions.BlockHash, false)
+		if !ok {
+			return nil, nil, fmt.Errorf("not found")
+		}
+		if start != nil && start.block != block.Number() {
+			return nil, nil, fmt.Errorf("cursor out of the block")
+		}
+		if err := parseReceipts(block.Header); err != nil {
+			return nil, nil, err
+		}
+		return result, next, nil
+	}
+
+	head := store.Header().Number
+
+	resolveNum := func(num BlockNumber) uint64 {
+		switch num {
+		case EarliestBlockNumber:
+			return 0
+		case LatestBlockNumber, PendingBlockNumber:
+			return head
+		}
+		return uint64(num)
+	}
+
+	from := resolveNum(filterOptions.fromBlock)
+	to := resolveNum(filterOptions.toBlock)
+
+	if to < from {
+		return nil, nil, fmt.Errorf("incorrect range")
+	}
+	if blocks := to - from + 1; blocks > limits.maxBlockRange {
+		return nil, nil, fmt.Errorf("block range of %d blocks exceeds the maximum of %d blocks", blocks, limits.maxBlockRange)
+	}
+	if start != nil {
+		if start.block < from || start.block > to {
+			return nil, nil, fmt.Errorf("cursor out of the block range")
+		}
+		from = start.block
+	}
+	for i := from; i <= to && next == nil; i++ {
+		header, ok := store.GetHeaderByNumber(i)
+		if !ok {
+			break
+		}
+		if header.Number == 0 {
+			// do not check logs in genesis
+			continue
+		}
+		if err := parseReceipts(header); err != nil {
+			return nil, nil, err
+		}
+	}
+	return result, next, nil
+}
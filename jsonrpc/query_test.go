@@ -2,6 +2,7 @@ package jsonrpc
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/0xPolygon/minimal/types"
@@ -73,8 +74,7 @@ func TestFilterDecode(t *testing.T) {
 						"` + hash1.String() + `",
 						"` + hash2.String() + `"
 					],
-					null,
-					"` + hash1.String() + `"
+					null
 				]
 			}`,
 			&LogFilter{
@@ -92,9 +92,6 @@ func TestFilterDecode(t *testing.T) {
 						hash2,
 					},
 					{},
-					{
-						hash1,
-					},
 				},
 			},
 		},
@@ -137,6 +134,62 @@ func TestFilterDecode(t *testing.T) {
 	}
 }
 
+func TestFilterDecode_Limits(t *testing.T) {
+	list := func(item string, n int) string {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = `"` + item + `"`
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+
+	cases := []struct {
+		name string
+		str  string
+		err  bool
+	}{
+		{
+			// the addresses are limited by the configurable maxLogAddresses
+			"addresses not limited",
+			`{"address": ` + list(addr1.String(), defaultMaxLogAddresses+1) + `}`,
+			false,
+		},
+		{
+			"topics at the limit",
+			`{"topics": ` + list(hash1.String(), maxFilterTopics) + `}`,
+			false,
+		},
+		{
+			"topics over the limit",
+			`{"topics": ` + list(hash1.String(), maxFilterTopics+1) + `}`,
+			true,
+		},
+		{
+			"topic alternatives at the limit",
+			`{"topics": [` + list(hash1.String(), maxFilterTopicAlternatives) + `]}`,
+			false,
+		},
+		{
+			"topic alternatives over the limit",
+			`{"topics": [` + list(hash1.String(), maxFilterTopicAlternatives+1) + `]}`,
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := &LogFilter{}
+			err := res.UnmarshalJSON([]byte(c.str))
+			if c.err && err == nil {
+				t.Fatal("it should fail")
+			}
+			if !c.err && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func TestFilterMatch(t *testing.T) {
 	cases := []struct {
 		filter LogFilter
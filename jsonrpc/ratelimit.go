@@ -0,0 +1,80 @@
+package jsonrpc
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimit is the token bucket limit of the requests to a namespace
+type RateLimit struct {
+	// Rate is the number of requests per second
+	Rate float64 `json:"rate"`
+
+	// Burst is the maximum number of requests at once
+	Burst uint64 `json:"burst"`
+}
+
+func (r *RateLimit) validate() error {
+	if r.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if r.Burst == 0 {
+		return fmt.Errorf("burst must be positive")
+	}
+	return nil
+}
+
+// metricRateLimited is the number of requests rejected per namespace
+var metricRateLimited = expvar.NewMap("jsonrpc_rate_limited")
+
+func rateLimitExceeded(namespace string) error {
+	return &ErrorObject{Code: -32005, Message: fmt.Sprintf("server busy: rate limit exceeded for the %s namespace", namespace)}
+}
+
+// tokenBucket is a token bucket rate limiter. The bucket starts full and
+// it is refilled at a constant rate up to its capacity
+type tokenBucket struct {
+	lock sync.Mutex
+
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+
+	// now returns the current time, it can be replaced for testing
+	now func() time.Time
+}
+
+func newTokenBucket(limit *RateLimit) *tokenBucket {
+	b := &tokenBucket{
+		rate:     limit.Rate,
+		capacity: float64(limit.Burst),
+		tokens:   float64(limit.Burst),
+		now:      time.Now,
+	}
+	b.last = b.now()
+	return b
+}
+
+// allow takes a token from the bucket, it returns false if the bucket is empty
+func (b *tokenBucket) allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,92 @@
+package jsonrpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_TokenBucket(t *testing.T) {
+	now := time.Now()
+
+	b := newTokenBucket(&RateLimit{Rate: 2, Burst: 3})
+	b.now = func() time.Time {
+		return now
+	}
+	b.last = now
+
+	// the bucket starts full
+	for i := 0; i < 3; i++ {
+		assert.True(t, b.allow())
+	}
+	assert.False(t, b.allow())
+
+	// two tokens are added per second
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	// the tokens do not exceed the burst
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.True(t, b.allow())
+	}
+	assert.False(t, b.allow())
+}
+
+func TestRateLimit_Namespace(t *testing.T) {
+	store := &mockBlockStore2{}
+	store.add(&types.Block{
+		Header: &types.Header{
+			Number: 10,
+		},
+	})
+
+	d := newTestDispatcher(hclog.NewNullLogger(), store)
+	assert.NoError(t, d.setupRateLimits(map[string]*RateLimit{
+		"debug": {Rate: 0.001, Burst: 5},
+	}))
+
+	call := func(method string) error {
+		_, err := d.Handle([]byte(`{"method": "` + method + `", "params": ["0x"]}`))
+		return err
+	}
+
+	busy := rateLimitExceeded("debug")
+	for i := 0; i < 5; i++ {
+		assert.NotEqual(t, busy, call("debug_decodeRawTransaction"))
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, busy, call("debug_decodeRawTransaction"))
+	}
+
+	// the eth namespace is not limited
+	for i := 0; i < 100; i++ {
+		resp, err := d.Handle([]byte(`{"method": "eth_blockNumber", "params": []}`))
+		assert.NoError(t, err)
+
+		var res string
+		assert.NoError(t, expectJSONResult(resp, &res))
+		assert.Equal(t, "0xa", res)
+	}
+}
+
+func TestRateLimit_Setup(t *testing.T) {
+	d := newTestDispatcher(hclog.NewNullLogger(), nil)
+
+	assert.Error(t, d.setupRateLimits(map[string]*RateLimit{
+		"trace": {Rate: 1, Burst: 1},
+	}))
+	assert.Error(t, d.setupRateLimits(map[string]*RateLimit{
+		"debug": {Rate: 1, Burst: 0},
+	}))
+	assert.Error(t, d.setupRateLimits(map[string]*RateLimit{
+		"debug": {Rate: 0, Burst: 1},
+	}))
+	assert.NoError(t, d.setupRateLimits(map[string]*RateLimit{
+		"debug": {Rate: 1, Burst: 1},
+	}))
+}
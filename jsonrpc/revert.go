@@ -0,0 +1,91 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygon/minimal/helper/hex"
+)
+
+var (
+	// revertErrorSelector is the selector of Error(string)
+	revertErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+	// revertPanicSelector is the selector of Panic(uint256)
+	revertPanicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// panicReasons are the descriptions of the panic codes of solidity
+var panicReasons = map[uint64]string{
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// newRevertError returns the error of a reverted execution. The data of the
+// error is the decoded revert reason or, if the revert data does not follow
+// any of the standard encodings, the raw revert data in hex
+func newRevertError(data []byte) *ErrorObject {
+	reason, ok := decodeRevertReason(data)
+	if !ok {
+		msg := "execution reverted"
+		if len(data) == 0 {
+			return &ErrorObject{Code: 3, Message: msg}
+		}
+		return &ErrorObject{Code: 3, Message: msg, Data: hex.EncodeToHex(data)}
+	}
+	return &ErrorObject{Code: 3, Message: "execution reverted: " + reason, Data: reason}
+}
+
+// decodeRevertReason decodes the revert data encoded as Error(string) or Panic(uint256)
+func decodeRevertReason(data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	selector, args := data[:4], data[4:]
+
+	switch {
+	case bytes.Equal(selector, revertErrorSelector):
+		// offset of the string and its length
+		if len(args) < 64 {
+			return "", false
+		}
+		offset, ok := decodeAbiUint(args[:32])
+		if !ok || offset+32 > uint64(len(args)) {
+			return "", false
+		}
+		size, ok := decodeAbiUint(args[offset : offset+32])
+		if !ok || size > uint64(len(args))-offset-32 {
+			return "", false
+		}
+		start := offset + 32
+		return string(args[start : start+size]), true
+
+	case bytes.Equal(selector, revertPanicSelector):
+		if len(args) != 32 {
+			return "", false
+		}
+		code := new(big.Int).SetBytes(args)
+		if reason, ok := panicReasons[code.Uint64()]; ok && code.IsUint64() {
+			return fmt.Sprintf("panic: %s (0x%x)", reason, code), true
+		}
+		return fmt.Sprintf("panic: unknown code 0x%x", code), true
+	}
+	return "", false
+}
+
+// decodeAbiUint decodes an abi encoded uint256 that fits in a uint64
+func decodeAbiUint(word []byte) (uint64, bool) {
+	n := new(big.Int).SetBytes(word)
+	if !n.IsUint64() {
+		return 0, false
+	}
+	return n.Uint64(), true
+}
@@ -0,0 +1,107 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeRevertError encodes the reason as Error(string)
+func encodeRevertError(reason string) []byte {
+	data := append([]byte{}, revertErrorSelector...)
+	data = append(data, types.BytesToHash([]byte{0x20}).Bytes()...)
+	data = append(data, types.BytesToHash(big.NewInt(int64(len(reason))).Bytes()).Bytes()...)
+
+	padded := make([]byte, (len(reason)+31)/32*32)
+	copy(padded, reason)
+	return append(data, padded...)
+}
+
+func encodeRevertPanic(code uint64) []byte {
+	data := append([]byte{}, revertPanicSelector...)
+	return append(data, types.BytesToHash(new(big.Int).SetUint64(code).Bytes()).Bytes()...)
+}
+
+func TestRevert_DecodeReason(t *testing.T) {
+	cases := []struct {
+		data   []byte
+		reason string
+		ok     bool
+	}{
+		{encodeRevertError("not enough balance"), "not enough balance", true},
+		{encodeRevertError(""), "", true},
+		{encodeRevertPanic(0x11), "panic: arithmetic underflow or overflow (0x11)", true},
+		{encodeRevertPanic(0x99), "panic: unknown code 0x99", true},
+		// truncated string
+		{encodeRevertError("not enough balance")[:70], "", false},
+		// unknown selector
+		{[]byte{0x1, 0x2, 0x3, 0x4, 0x5}, "", false},
+		{nil, "", false},
+	}
+	for _, c := range cases {
+		reason, ok := decodeRevertReason(c.data)
+		assert.Equal(t, c.ok, ok)
+		assert.Equal(t, c.reason, reason)
+	}
+}
+
+type mockRevertStore struct {
+	mockPinnedStore
+
+	revertData []byte
+}
+
+func (m *mockRevertStore) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
+	return &runtime.ExecutionResult{
+		ReturnValue: m.revertData,
+		Err:         runtime.ErrExecutionReverted,
+	}, nil
+}
+
+func TestRevert_Call(t *testing.T) {
+	store := &mockRevertStore{}
+	store.importBlock(types.Hash{0x1}).AddAccount(addr0)
+	store.Header().ComputeHash()
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	call := func(method string) *ErrorObject {
+		req := `{"method": "` + method + `", "params": [{"from": "` + addr0.String() + `", "to": "` + addr1.String() + `", "data": "0x01", "gasPrice": "0x0"}, "latest"]}`
+		_, err := dispatcher.Handle([]byte(req))
+
+		// the error reaches the client as is
+		obj, ok := err.(*ErrorObject)
+		assert.True(t, ok)
+		return obj
+	}
+
+	// the reason is decoded
+	store.revertData = encodeRevertError("not enough balance")
+	for _, method := range []string{"eth_call", "eth_estimateGas"} {
+		obj := call(method)
+		assert.Equal(t, 3, obj.Code)
+		assert.Equal(t, "execution reverted: not enough balance", obj.Message)
+		assert.Equal(t, "not enough balance", obj.Data)
+	}
+
+	// a non standard payload is returned as hex
+	store.revertData = []byte{0x1, 0x2}
+	obj := call("eth_call")
+	assert.Equal(t, "execution reverted", obj.Message)
+	assert.Equal(t, "0x0102", obj.Data)
+
+	data, err := json.Marshal(obj)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"code": 3, "message": "execution reverted", "data": "0x0102"}`, string(data))
+
+	// without data there is no data field
+	store.revertData = nil
+	obj = call("eth_call")
+	assert.Equal(t, "execution reverted", obj.Message)
+	assert.Nil(t, obj.Data)
+}
@@ -0,0 +1,256 @@
+package jsonrpc
+
+import (
+	"fmt"
+
+	"github.com/0xPolygon/minimal/helper/hex"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/types"
+)
+
+// traceConfig are the options of debug_traceTransaction
+type traceConfig struct {
+	// Tracer is the output of the trace, either the struct logger (default) or callTracer
+	Tracer string `json:"tracer"`
+
+	DisableStack   bool `json:"disableStack"`
+	DisableMemory  bool `json:"disableMemory"`
+	DisableStorage bool `json:"disableStorage"`
+}
+
+// txTracer is a tracer that builds the output of a trace
+type txTracer interface {
+	runtime.Tracer
+
+	// result returns the output of the trace of an execution
+	result(res *runtime.ExecutionResult) interface{}
+}
+
+// newTracer returns the tracer selected by the config
+func newTracer(config *traceConfig) (txTracer, error) {
+	if config == nil {
+		config = &traceConfig{}
+	}
+	switch config.Tracer {
+	case "":
+		return newStructTracer(config), nil
+	case "callTracer":
+		return &callTracer{}, nil
+	default:
+		return nil, fmt.Errorf("tracer '%s' not supported", config.Tracer)
+	}
+}
+
+type structLog struct {
+	PC      uint64            `json:"pc"`
+	Op      string            `json:"op"`
+	Gas     uint64            `json:"gas"`
+	GasCost uint64            `json:"gasCost"`
+	Depth   int               `json:"depth"`
+	Stack   []string          `json:"stack,omitempty"`
+	Memory  []string          `json:"memory,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+type traceResult struct {
+	Gas         uint64       `json:"gas"`
+	Failed      bool         `json:"failed"`
+	ReturnValue argBytes     `json:"returnValue"`
+	StructLogs  []*structLog `json:"structLogs"`
+}
+
+// structTracer collects the steps of the execution in the struct logger format
+type structTracer struct {
+	config *traceConfig
+
+	steps []*runtime.ExecutionStep
+
+	// storage of the steps that access the storage (SLOAD and SSTORE)
+	storage map[*runtime.ExecutionStep]map[types.Hash]types.Hash
+
+	// accessed storage slots of each account
+	accounts map[types.Address]map[types.Hash]types.Hash
+}
+
+func newStructTracer(config *traceConfig) *structTracer {
+	return &structTracer{
+		config:   config,
+		storage:  map[*runtime.ExecutionStep]map[types.Hash]types.Hash{},
+		accounts: map[types.Address]map[types.Hash]types.Hash{},
+	}
+}
+
+// CaptureState implements the runtime.Tracer interface
+func (s *structTracer) CaptureState(host runtime.Host, step *runtime.ExecutionStep) {
+	s.steps = append(s.steps, step)
+
+	if s.config.DisableStorage || (step.Op != "SLOAD" && step.Op != "SSTORE") {
+		return
+	}
+	size := len(step.Stack)
+	if (step.Op == "SLOAD" && size < 1) || (step.Op == "SSTORE" && size < 2) {
+		// the opcode fails with a stack underflow
+		return
+	}
+
+	slots, ok := s.accounts[step.Address]
+	if !ok {
+		slots = map[types.Hash]types.Hash{}
+		s.accounts[step.Address] = slots
+	}
+	key := types.BytesToHash(step.Stack[size-1].Bytes())
+	if step.Op == "SLOAD" {
+		slots[key] = host.GetStorage(step.Address, key)
+	} else {
+		slots[key] = types.BytesToHash(step.Stack[size-2].Bytes())
+	}
+
+	storage := make(map[types.Hash]types.Hash, len(slots))
+	for k, v := range slots {
+		storage[k] = v
+	}
+	s.storage[step] = storage
+}
+
+// CaptureEnter implements the runtime.Tracer interface
+func (s *structTracer) CaptureEnter(callType runtime.CallType, c *runtime.Contract) {
+}
+
+// CaptureExit implements the runtime.Tracer interface
+func (s *structTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// Options implements the runtime.Tracer interface. The stack is
+// still needed to capture the storage of SLOAD and SSTORE
+func (s *structTracer) Options() runtime.TracerOptions {
+	return runtime.TracerOptions{
+		DisableStack:  s.config.DisableStack && s.config.DisableStorage,
+		DisableMemory: s.config.DisableMemory,
+	}
+}
+
+// result returns the trace of an execution with its captured steps
+func (s *structTracer) result(res *runtime.ExecutionResult) interface{} {
+	return &traceResult{
+		Gas:         res.GasUsed,
+		Failed:      res.Failed(),
+		ReturnValue: argBytes(res.ReturnValue),
+		StructLogs:  s.logs(),
+	}
+}
+
+// logs returns the captured steps in the struct logger format
+func (s *structTracer) logs() []*structLog {
+	logs := make([]*structLog, 0, len(s.steps))
+	for _, step := range s.steps {
+		log := &structLog{
+			PC:      step.PC,
+			Op:      step.Op,
+			Gas:     step.Gas,
+			GasCost: step.GasCost,
+			Depth:   step.Depth,
+		}
+		if !s.config.DisableStack {
+			log.Stack = make([]string, len(step.Stack))
+			for i, v := range step.Stack {
+				log.Stack[i] = hex.EncodeBig(v)
+			}
+		}
+		if !s.config.DisableMemory {
+			// the memory is split in words of 32 bytes
+			for i := 0; i+32 <= len(step.Memory); i += 32 {
+				log.Memory = append(log.Memory, hex.EncodeToString(step.Memory[i:i+32]))
+			}
+		}
+		if storage, ok := s.storage[step]; ok {
+			log.Storage = map[string]string{}
+			for k, v := range storage {
+				log.Storage[hex.EncodeToString(k.Bytes())] = hex.EncodeToString(v.Bytes())
+			}
+		}
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+type callFrame struct {
+	Type    string        `json:"type"`
+	From    types.Address `json:"from"`
+	To      types.Address `json:"to"`
+	Value   *argBig       `json:"value,omitempty"`
+	Gas     argUint64     `json:"gas"`
+	GasUsed argUint64     `json:"gasUsed"`
+	Input   argBytes      `json:"input"`
+	Output  argBytes      `json:"output,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Calls   []*callFrame  `json:"calls,omitempty"`
+}
+
+// callTracer builds the tree of the calls and the contract creations of an execution
+type callTracer struct {
+	root *callFrame
+
+	// frames of the calls in progress
+	stack []*callFrame
+}
+
+// CaptureState implements the runtime.Tracer interface
+func (c *callTracer) CaptureState(host runtime.Host, step *runtime.ExecutionStep) {
+}
+
+// Options implements the runtime.Tracer interface. The calls
+// are captured by the executor so the steps are not needed
+func (c *callTracer) Options() runtime.TracerOptions {
+	return runtime.TracerOptions{DisableSteps: true}
+}
+
+// CaptureEnter implements the runtime.Tracer interface
+func (c *callTracer) CaptureEnter(callType runtime.CallType, contract *runtime.Contract) {
+	frame := &callFrame{
+		Type:  callType.String(),
+		From:  contract.Caller,
+		To:    contract.Address,
+		Gas:   argUint64(contract.Gas),
+		Input: argBytes(contract.Input),
+	}
+	if callType == runtime.Create || callType == runtime.Create2 {
+		// the input of a contract creation is the init code
+		frame.Input = argBytes(contract.Code)
+	}
+	if callType != runtime.DelegateCall && callType != runtime.StaticCall && contract.Value != nil {
+		frame.Value = argBigPtr(contract.Value)
+	}
+
+	if len(c.stack) == 0 {
+		c.root = frame
+	} else {
+		parent := c.stack[len(c.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	c.stack = append(c.stack, frame)
+}
+
+// CaptureExit implements the runtime.Tracer interface
+func (c *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if len(c.stack) == 0 {
+		return
+	}
+	frame := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+
+	frame.GasUsed = argUint64(gasUsed)
+	frame.Output = append(argBytes{}, output...)
+	if err != nil {
+		frame.Error = err.Error()
+	}
+}
+
+// result returns the root call of the execution. The gas used by the root
+// call is the one of the transaction, including its intrinsic gas
+func (c *callTracer) result(res *runtime.ExecutionResult) interface{} {
+	if c.root == nil {
+		return nil
+	}
+	c.root.GasUsed = argUint64(res.GasUsed)
+	return c.root
+}
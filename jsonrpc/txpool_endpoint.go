@@ -0,0 +1,102 @@
+package jsonrpc
+
+import (
+	"strconv"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// TxPool is the txpool jsonrpc endpoint
+type TxPool struct {
+	d *Dispatcher
+}
+
+const (
+	// txStatusPending is a transaction in the pool ready to be sealed
+	txStatusPending = "pending"
+	// txStatusQueued is a transaction in the pool waiting for a nonce gap
+	txStatusQueued = "queued"
+	// txStatusIncluded is a transaction sealed in a block
+	txStatusIncluded = "included"
+	// txStatusUnknown is a transaction never seen or dropped from the pool
+	txStatusUnknown = "unknown"
+)
+
+type txStatus struct {
+	Status      string      `json:"status"`
+	BlockHash   *types.Hash `json:"blockHash,omitempty"`
+	BlockNumber *argUint64  `json:"blockNumber,omitempty"`
+}
+
+// TransactionStatus returns whether a transaction is pending or queued in the
+// pool, included in a block or unknown (txpool_transactionStatus)
+func (t *TxPool) TransactionStatus(hash types.Hash) (interface{}, error) {
+	if blockHash, ok := t.d.store.ReadTxLookup(hash); ok {
+		if header, ok := t.d.store.GetHeaderByHash(blockHash); ok {
+			return &txStatus{
+				Status:      txStatusIncluded,
+				BlockHash:   &header.Hash,
+				BlockNumber: argUintPtr(header.Number),
+			}, nil
+		}
+	}
+
+	if _, ok := t.d.store.GetPendingTx(hash); ok {
+		if t.d.store.IsPromotedTx(hash) {
+			return &txStatus{Status: txStatusPending}, nil
+		}
+		return &txStatus{Status: txStatusQueued}, nil
+	}
+	return &txStatus{Status: txStatusUnknown}, nil
+}
+
+// txPoolTxs are the transactions in the pool grouped by sender and nonce
+type txPoolTxs map[types.Address]map[string]*transaction
+
+func toTxPoolTxs(txns map[types.Address][]*types.Transaction) txPoolTxs {
+	res := txPoolTxs{}
+	for addr, list := range txns {
+		res[addr] = map[string]*transaction{}
+		for _, txn := range list {
+			res[addr][strconv.FormatUint(txn.Nonce, 10)] = toTransaction(txn, nil, 0)
+		}
+	}
+	return res
+}
+
+type txPoolContent struct {
+	Pending txPoolTxs `json:"pending"`
+	Queued  txPoolTxs `json:"queued"`
+}
+
+// Content returns the transactions in the pool ready to be sealed (pending)
+// and the ones waiting for a nonce gap (queued) (txpool_content)
+func (t *TxPool) Content() (interface{}, error) {
+	pending, queued := t.d.store.GetTxs()
+
+	return &txPoolContent{
+		Pending: toTxPoolTxs(pending),
+		Queued:  toTxPoolTxs(queued),
+	}, nil
+}
+
+type txPoolStatus struct {
+	Pending argUint64 `json:"pending"`
+	Queued  argUint64 `json:"queued"`
+}
+
+// Status returns the number of pending and queued transactions in the pool (txpool_status)
+func (t *TxPool) Status() (interface{}, error) {
+	pending, queued := t.d.store.GetTxs()
+
+	count := func(txns map[types.Address][]*types.Transaction) (num argUint64) {
+		for _, list := range txns {
+			num += argUint64(len(list))
+		}
+		return
+	}
+	return &txPoolStatus{
+		Pending: count(pending),
+		Queued:  count(queued),
+	}, nil
+}
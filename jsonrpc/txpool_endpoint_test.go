@@ -0,0 +1,167 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTxStatusStore struct {
+	nullBlockchainInterface
+
+	// transactions in the pool and whether they are promoted
+	pool map[types.Hash]bool
+
+	lookup  map[types.Hash]types.Hash
+	headers map[types.Hash]*types.Header
+}
+
+func (m *mockTxStatusStore) GetPendingTx(txHash types.Hash) (*types.Transaction, bool) {
+	if _, ok := m.pool[txHash]; !ok {
+		return nil, false
+	}
+	return &types.Transaction{Hash: txHash}, true
+}
+
+func (m *mockTxStatusStore) IsPromotedTx(txHash types.Hash) bool {
+	return m.pool[txHash]
+}
+
+func (m *mockTxStatusStore) ReadTxLookup(txnHash types.Hash) (types.Hash, bool) {
+	hash, ok := m.lookup[txnHash]
+	return hash, ok
+}
+
+func (m *mockTxStatusStore) GetHeaderByHash(hash types.Hash) (*types.Header, bool) {
+	header, ok := m.headers[hash]
+	return header, ok
+}
+
+func TestTxPoolEndpoint_TransactionStatus(t *testing.T) {
+	var (
+		pendingTx  = types.Hash{0x1}
+		queuedTx   = types.Hash{0x2}
+		includedTx = types.Hash{0x3}
+		evictedTx  = types.Hash{0x4}
+		unknownTx  = types.Hash{0x5}
+	)
+
+	header := &types.Header{Number: 10, Hash: hash1}
+	store := &mockTxStatusStore{
+		pool: map[types.Hash]bool{
+			pendingTx: true,
+			queuedTx:  false,
+			evictedTx: true,
+		},
+		lookup: map[types.Hash]types.Hash{
+			includedTx: header.Hash,
+		},
+		headers: map[types.Hash]*types.Header{
+			header.Hash: header,
+		},
+	}
+
+	// the transaction is evicted from the pool before it is sealed
+	delete(store.pool, evictedTx)
+
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	type result struct {
+		Status      string
+		BlockHash   *types.Hash
+		BlockNumber *string
+	}
+	status := func(hash types.Hash) *result {
+		resp, err := dispatcher.Handle([]byte(`{"method": "txpool_transactionStatus", "params": ["` + hash.String() + `"]}`))
+		assert.NoError(t, err)
+
+		res := &result{}
+		assert.NoError(t, expectJSONResult(resp, res))
+		return res
+	}
+
+	assert.Equal(t, &result{Status: "pending"}, status(pendingTx))
+	assert.Equal(t, &result{Status: "queued"}, status(queuedTx))
+	assert.Equal(t, &result{Status: "unknown"}, status(evictedTx))
+	assert.Equal(t, &result{Status: "unknown"}, status(unknownTx))
+
+	num := "0xa"
+	assert.Equal(t, &result{Status: "included", BlockHash: &hash1, BlockNumber: &num}, status(includedTx))
+}
+
+type mockTxPoolStore struct {
+	nullBlockchainInterface
+
+	pending map[types.Address][]*types.Transaction
+	queued  map[types.Address][]*types.Transaction
+}
+
+func (m *mockTxPoolStore) GetTxs() (map[types.Address][]*types.Transaction, map[types.Address][]*types.Transaction) {
+	return m.pending, m.queued
+}
+
+func TestTxPoolEndpoint_ContentAndStatus(t *testing.T) {
+	var (
+		addr1 = types.Address{0x1}
+		addr2 = types.Address{0x2}
+	)
+
+	newTxn := func(from types.Address, nonce uint64) *types.Transaction {
+		txn := &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(0),
+		}
+		txn.ComputeHash()
+		return txn
+	}
+
+	store := &mockTxPoolStore{
+		pending: map[types.Address][]*types.Transaction{
+			addr1: {newTxn(addr1, 0), newTxn(addr1, 1)},
+			addr2: {newTxn(addr2, 5)},
+		},
+		queued: map[types.Address][]*types.Transaction{
+			addr1: {newTxn(addr1, 3)},
+		},
+	}
+	dispatcher := newTestDispatcher(hclog.NewNullLogger(), store)
+
+	// content
+	resp, err := dispatcher.Handle([]byte(`{"method": "txpool_content", "params": []}`))
+	assert.NoError(t, err)
+
+	type txn struct {
+		Hash  types.Hash
+		From  types.Address
+		Nonce string
+	}
+	content := map[string]map[types.Address]map[string]*txn{}
+	assert.NoError(t, expectJSONResult(resp, &content))
+
+	expected := func(txns map[types.Address][]*types.Transaction) map[types.Address]map[string]*txn {
+		res := map[types.Address]map[string]*txn{}
+		for addr, list := range txns {
+			res[addr] = map[string]*txn{}
+			for _, tx := range list {
+				res[addr][fmt.Sprintf("%d", tx.Nonce)] = &txn{Hash: tx.Hash, From: tx.From, Nonce: fmt.Sprintf("0x%x", tx.Nonce)}
+			}
+		}
+		return res
+	}
+	assert.Equal(t, expected(store.pending), content["pending"])
+	assert.Equal(t, expected(store.queued), content["queued"])
+
+	// status
+	resp, err = dispatcher.Handle([]byte(`{"method": "txpool_status", "params": []}`))
+	assert.NoError(t, err)
+
+	status := map[string]string{}
+	assert.NoError(t, expectJSONResult(resp, &status))
+	assert.Equal(t, map[string]string{"pending": "0x3", "queued": "0x1"}, status)
+}
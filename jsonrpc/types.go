@@ -21,53 +21,109 @@ type transaction struct {
 	S           argBytes       `json:"s"`
 	Hash        types.Hash     `json:"hash"`
 	From        types.Address  `json:"from"`
-	BlockHash   types.Hash     `json:"blockHash"`
-	BlockNumber argUint64      `json:"blockNumber"`
-	TxIndex     argUint64      `json:"transactionIndex"`
+	BlockHash   *types.Hash    `json:"blockHash"`
+	BlockNumber *argUint64     `json:"blockNumber"`
+	TxIndex     *argUint64     `json:"transactionIndex"`
+	Type        argUint64      `json:"type"`
+
+	// the chain id and the access list of the typed transactions
+	ChainID    *argBig     `json:"chainId,omitempty"`
+	AccessList *accessList `json:"accessList,omitempty"`
+
+	// the fee caps of the dynamic fee transactions
+	MaxFeePerGas         *argBig `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *argBig `json:"maxPriorityFeePerGas,omitempty"`
+}
+
+// accessList is the json form of the access list of a transaction
+type accessList []accessTuple
+
+type accessTuple struct {
+	Address     types.Address `json:"address"`
+	StorageKeys []types.Hash  `json:"storageKeys"`
+}
+
+// toAccessList converts the access list to its json form
+func toAccessList(list types.AccessList) *accessList {
+	res := make(accessList, len(list))
+	for i, tuple := range list {
+		res[i] = accessTuple{
+			Address:     tuple.Address,
+			StorageKeys: append([]types.Hash{}, tuple.StorageKeys...),
+		}
+	}
+	return &res
 }
 
+// toTypes converts the access list from its json form
+func (a accessList) toTypes() types.AccessList {
+	res := make(types.AccessList, len(a))
+	for i, tuple := range a {
+		res[i] = types.AccessTuple{
+			Address:     tuple.Address,
+			StorageKeys: append([]types.Hash{}, tuple.StorageKeys...),
+		}
+	}
+	return res
+}
+
+// toTransaction converts the transaction to its json form. If the block
+// is nil the transaction is pending and the block fields are left empty
 func toTransaction(t *types.Transaction, b *types.Block, txIndex int) *transaction {
-	return &transaction{
-		Nonce:       argUint64(t.Nonce),
-		GasPrice:    argBig(*t.GasPrice),
-		Gas:         argUint64(t.Gas),
-		To:          t.To,
-		Value:       argBig(*t.Value),
-		Input:       argBytes(t.Input),
-		V:           argByte(t.V),
-		R:           argBytes(t.R),
-		S:           argBytes(t.S),
-		Hash:        t.Hash,
-		From:        t.From,
-		BlockHash:   b.Hash(),
-		BlockNumber: argUint64(b.Number()),
-		TxIndex:     argUint64(txIndex),
+	res := &transaction{
+		Nonce:    argUint64(t.Nonce),
+		GasPrice: argBig(*t.GasPrice),
+		Gas:      argUint64(t.Gas),
+		To:       t.To,
+		Value:    argBig(*t.Value),
+		Input:    argBytes(t.Input),
+		V:        argByte(t.V),
+		R:        argBytes(t.R),
+		S:        argBytes(t.S),
+		Hash:     t.Hash,
+		From:     t.From,
+		Type:     argUint64(t.Type),
+	}
+	if t.Type != types.LegacyTx {
+		res.ChainID = argBigPtr(t.ChainID)
+		res.AccessList = toAccessList(t.AccessList)
 	}
+	if t.Type == types.DynamicFeeTx {
+		res.MaxFeePerGas = argBigPtr(t.GasFeeCap)
+		res.MaxPriorityFeePerGas = argBigPtr(t.GasTipCap)
+	}
+	if b != nil {
+		blockHash := b.Hash()
+
+		res.BlockHash = &blockHash
+		res.BlockNumber = argUintPtr(b.Number())
+		res.TxIndex = argUintPtr(uint64(txIndex))
+	}
+	return res
 }
 
-type block struct {
-	ParentHash   types.Hash     `json:"parentHash"`
-	Sha3Uncles   types.Hash     `json:"sha3Uncles"`
-	Miner        types.Address  `json:"miner"`
-	StateRoot    types.Hash     `json:"stateRoot"`
-	TxRoot       types.Hash     `json:"transactionsRoot"`
-	ReceiptsRoot types.Hash     `json:"receiptsRoot"`
-	LogsBloom    types.Bloom    `json:"logsBloom"`
-	Difficulty   argUint64      `json:"difficulty"`
-	Number       argUint64      `json:"number"`
-	GasLimit     argUint64      `json:"gasLimit"`
-	GasUsed      argUint64      `json:"gasUsed"`
-	Timestamp    argUint64      `json:"timestamp"`
-	ExtraData    argBytes       `json:"extraData"`
-	MixHash      types.Hash     `json:"mixHash"`
-	Nonce        types.Nonce    `json:"nonce"`
-	Hash         types.Hash     `json:"hash"`
-	Transactions []*transaction `json:"transactions"`
-}
-
-func toBlock(b *types.Block) *block {
-	h := b.Header
-	res := &block{
+type header struct {
+	ParentHash   types.Hash    `json:"parentHash"`
+	Sha3Uncles   types.Hash    `json:"sha3Uncles"`
+	Miner        types.Address `json:"miner"`
+	StateRoot    types.Hash    `json:"stateRoot"`
+	TxRoot       types.Hash    `json:"transactionsRoot"`
+	ReceiptsRoot types.Hash    `json:"receiptsRoot"`
+	LogsBloom    types.Bloom   `json:"logsBloom"`
+	Difficulty   argUint64     `json:"difficulty"`
+	Number       argUint64     `json:"number"`
+	GasLimit     argUint64     `json:"gasLimit"`
+	GasUsed      argUint64     `json:"gasUsed"`
+	Timestamp    argUint64     `json:"timestamp"`
+	ExtraData    argBytes      `json:"extraData"`
+	MixHash      types.Hash    `json:"mixHash"`
+	Nonce        types.Nonce   `json:"nonce"`
+	Hash         types.Hash    `json:"hash"`
+}
+
+// toHeader converts the header to its json form
+func toHeader(h *types.Header) *header {
+	return &header{
 		ParentHash:   h.ParentHash,
 		Sha3Uncles:   h.Sha3Uncles,
 		Miner:        h.Miner,
@@ -84,28 +140,125 @@ func toBlock(b *types.Block) *block {
 		MixHash:      h.MixHash,
 		Nonce:        h.Nonce,
 		Hash:         h.Hash,
-		Transactions: []*transaction{},
+	}
+}
+
+type block struct {
+	header
+	Transactions []transactionOrHash `json:"transactions"`
+}
+
+// transactionOrHash is the entry of the transactions of a block,
+// either the full transaction or only its hash
+type transactionOrHash interface {
+	getHash() types.Hash
+}
+
+func (t *transaction) getHash() types.Hash {
+	return t.Hash
+}
+
+type transactionHash types.Hash
+
+func (h transactionHash) getHash() types.Hash {
+	return types.Hash(h)
+}
+
+func (h transactionHash) MarshalText() ([]byte, error) {
+	return types.Hash(h).MarshalText()
+}
+
+// toBlock converts the block to its json form. The transactions are
+// included as full objects if fullTx is set or as hashes otherwise
+func toBlock(b *types.Block, fullTx bool) *block {
+	res := &block{
+		header:       *toHeader(b.Header),
+		Transactions: []transactionOrHash{},
 	}
 	for idx, txn := range b.Transactions {
-		res.Transactions = append(res.Transactions, toTransaction(txn, b, idx))
+		if fullTx {
+			res.Transactions = append(res.Transactions, toTransaction(txn, b, idx))
+		} else {
+			res.Transactions = append(res.Transactions, transactionHash(txn.Hash))
+		}
 	}
 	return res
 }
 
 type receipt struct {
-	Root              types.Hash           `json:"root"`
-	CumulativeGasUsed argUint64            `json:"cumulativeGasUsed"`
-	LogsBloom         types.Bloom          `json:"logsBloom"`
-	Logs              []*Log               `json:"logs"`
-	Status            *types.ReceiptStatus `json:"status"`
-	TxHash            types.Hash           `json:"transactionHash"`
-	TxIndex           argUint64            `json:"transactionIndex"`
-	BlockHash         types.Hash           `json:"blockHash"`
-	BlockNumber       argUint64            `json:"blockNumber"`
-	GasUsed           argUint64            `json:"gasUsed"`
-	ContractAddress   types.Address        `json:"contractAddress"`
-	FromAddr          types.Address        `json:"from"`
-	ToAddr            *types.Address       `json:"to"`
+	Root              types.Hash     `json:"root"`
+	CumulativeGasUsed argUint64      `json:"cumulativeGasUsed"`
+	LogsBloom         types.Bloom    `json:"logsBloom"`
+	Logs              []*Log         `json:"logs"`
+	Status            *argUint64     `json:"status"`
+	TxHash            types.Hash     `json:"transactionHash"`
+	TxIndex           argUint64      `json:"transactionIndex"`
+	BlockHash         types.Hash     `json:"blockHash"`
+	BlockNumber       argUint64      `json:"blockNumber"`
+	GasUsed           argUint64      `json:"gasUsed"`
+	ContractAddress   *types.Address `json:"contractAddress"`
+	FromAddr          types.Address  `json:"from"`
+	ToAddr            *types.Address `json:"to"`
+	EffectiveGasPrice *argBig        `json:"effectiveGasPrice"`
+	RevertReason      string         `json:"revertReason,omitempty"`
+}
+
+// toReceipt converts the receipt of the transaction at the index of the block to
+// its json form. The logIndex is the position of the first log of the receipt in the block
+func toReceipt(raw *types.Receipt, block *types.Block, indx int, logIndex int) *receipt {
+	txn := block.Transactions[indx]
+
+	logs := make([]*Log, len(raw.Logs))
+	for i, elem := range raw.Logs {
+		logs[i] = &Log{
+			Address:     elem.Address,
+			Topics:      elem.Topics,
+			Data:        argBytes(elem.Data),
+			BlockHash:   block.Hash(),
+			BlockNumber: argUint64(block.Number()),
+			TxHash:      txn.Hash,
+			TxIndex:     argUint64(indx),
+			LogIndex:    argUint64(logIndex + i),
+			Removed:     false,
+		}
+	}
+	res := &receipt{
+		Root:              raw.Root,
+		CumulativeGasUsed: argUint64(raw.CumulativeGasUsed),
+		LogsBloom:         raw.LogsBloom,
+		TxHash:            txn.Hash,
+		TxIndex:           argUint64(indx),
+		BlockHash:         block.Hash(),
+		BlockNumber:       argUint64(block.Number()),
+		GasUsed:           argUint64(raw.GasUsed),
+		FromAddr:          txn.From,
+		ToAddr:            txn.To,
+		Logs:              logs,
+	}
+	if raw.Status != nil {
+		res.Status = argUintPtr(uint64(*raw.Status))
+	}
+	if raw.EffectiveGasPrice != nil {
+		res.EffectiveGasPrice = argBigPtr(raw.EffectiveGasPrice)
+	} else if txn.GasPrice != nil {
+		// the receipts stored before the field was added only
+		// have legacy transactions that pay the gas price
+		res.EffectiveGasPrice = argBigPtr(txn.GasPrice)
+	}
+	if len(raw.RevertReason) != 0 {
+		// the decoded reason or the raw revert data if it cannot be decoded
+		reason, ok := decodeRevertReason(raw.RevertReason)
+		if !ok {
+			reason = hex.EncodeToHex(raw.RevertReason)
+		}
+		res.RevertReason = reason
+	}
+	if txn.To == nil {
+		// only set for contract creations
+		contractAddress := raw.ContractAddress
+		res.ContractAddress = &contractAddress
+	}
+	return res
 }
 
 type Log struct {
@@ -226,4 +379,41 @@ type txnArgs struct {
 	Input    *argBytes
 	Data     *argBytes
 	Nonce    *argUint64
+
+	// AccessList are the entries that are warm from the start of the call
+	AccessList *accessList
+}
+
+// stateOverride is the set of accounts overridden for an eth_call
+type stateOverride map[types.Address]overrideAccount
+
+// overrideAccount are the fields of an overridden account
+type overrideAccount struct {
+	Nonce     *argUint64                `json:"nonce"`
+	Balance   *argBig                   `json:"balance"`
+	Code      *argBytes                 `json:"code"`
+	State     map[types.Hash]types.Hash `json:"state"`
+	StateDiff map[types.Hash]types.Hash `json:"stateDiff"`
+}
+
+func (s stateOverride) toStateOverride() types.StateOverride {
+	override := types.StateOverride{}
+	for addr, acct := range s {
+		account := types.OverrideAccount{
+			State:     acct.State,
+			StateDiff: acct.StateDiff,
+		}
+		if acct.Nonce != nil {
+			nonce := uint64(*acct.Nonce)
+			account.Nonce = &nonce
+		}
+		if acct.Balance != nil {
+			account.Balance = (*big.Int)(acct.Balance)
+		}
+		if acct.Code != nil {
+			account.Code = []byte(*acct.Code)
+		}
+		override[addr] = account
+	}
+	return override
 }
@@ -92,3 +92,50 @@ func TestDecode_TxnArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestDecode_BlockNumberOrHash(t *testing.T) {
+	var (
+		num  = BlockNumber(1)
+		hash = types.StringToHash("0x1")
+	)
+
+	cases := []struct {
+		data string
+		res  *BlockNumberOrHash
+	}{
+		{
+			data: `"0x1"`,
+			res:  &BlockNumberOrHash{BlockNumber: &num},
+		},
+		{
+			data: `{"blockNumber": "0x1"}`,
+			res:  &BlockNumberOrHash{BlockNumber: &num},
+		},
+		{
+			data: `{"blockHash": "` + hash.String() + `", "requireCanonical": true}`,
+			res:  &BlockNumberOrHash{BlockHash: &hash, RequireCanonical: true},
+		},
+		{
+			// either the number or the hash
+			data: `{"blockNumber": "0x1", "blockHash": "` + hash.String() + `"}`,
+		},
+		{
+			data: `{}`,
+		},
+		{
+			// requireCanonical only applies to the hash
+			data: `{"blockNumber": "0x1", "requireCanonical": true}`,
+		},
+	}
+
+	for _, c := range cases {
+		res := &BlockNumberOrHash{}
+		err := json.Unmarshal([]byte(c.data), res)
+		if c.res == nil {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, c.res, res)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	consensusIBFT "github.com/0xPolygon/minimal/consensus/ibft"
 
 	"github.com/0xPolygon/minimal/consensus"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
 )
 
 var consensusBackends = map[string]consensus.Factory{
@@ -14,3 +16,9 @@ var consensusBackends = map[string]consensus.Factory{
 	"ibft":  consensusIBFT.Factory,
 	"dummy": consensusDummy.Factory,
 }
+
+var interpreterBackends = map[string]func() runtime.Runtime{
+	"evm": func() runtime.Runtime {
+		return evm.NewEVM()
+	},
+}
@@ -2,14 +2,23 @@ package minimal
 
 import (
 	"net"
+	"time"
 
+	"github.com/0xPolygon/minimal/blockchain/storage/leveldb"
 	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/jsonrpc"
 	"github.com/0xPolygon/minimal/network"
+	"github.com/0xPolygon/minimal/txpool"
+	"github.com/0xPolygon/minimal/types"
 )
 
 const DefaultGRPCPort int = 9632
 const DefaultJSONRPCPort int = 8545
 
+// DefaultReadyMaxSyncDistance is the default maximum number of blocks
+// the node can be behind its best peer to be reported as ready
+const DefaultReadyMaxSyncDistance uint64 = 10
+
 // Config is used to parametrize the minimal client
 type Config struct {
 	Chain *chain.Chain
@@ -19,8 +28,65 @@ type Config struct {
 	LibP2PAddr  *net.TCPAddr
 
 	Network *network.Config
+	TxPool  *txpool.Config
+	LevelDB *leveldb.Config
 	DataDir string
 	Seal    bool
+
+	// ReadyMinPeers is the minimum number of connected peers
+	// required to report the node as ready
+	ReadyMinPeers uint64
+
+	// ReadyMaxSyncDistance is the maximum number of blocks the node can be behind
+	// its best peer to be reported as ready (DefaultReadyMaxSyncDistance if zero)
+	ReadyMaxSyncDistance uint64
+
+	// JSONRPCGzip enables the gzip compression of the JSON-RPC http responses
+	JSONRPCGzip bool
+
+	// JSONRPCMetrics serves the expvar metrics on the /debug/vars endpoint of the JSON-RPC
+	JSONRPCMetrics bool
+
+	// JSONRPCCallCache is the number of eth_call results cached (disabled if zero)
+	JSONRPCCallCache int
+
+	// JSONRPCMaxLogBlockRange is the maximum number of blocks queried by eth_getLogs
+	JSONRPCMaxLogBlockRange uint64
+
+	// JSONRPCMaxLogAddresses is the maximum number of addresses of a JSON-RPC log filter
+	JSONRPCMaxLogAddresses int
+
+	// JSONRPCMaxLogs is the maximum number of logs returned by an eth_getLogs that is not paginated
+	JSONRPCMaxLogs int
+
+	// JSONRPCGasEstimationCap is the maximum gas limit searched by eth_estimateGas
+	JSONRPCGasEstimationCap uint64
+
+	// JSONRPCRateLimits are the rate limits of the JSON-RPC requests per namespace
+	JSONRPCRateLimits map[string]*jsonrpc.RateLimit
+
+	// JSONRPCSenderAllowlist are the only senders allowed to send
+	// transactions through the JSON-RPC, all of them if empty
+	JSONRPCSenderAllowlist []types.Address
+
+	// MaxTxsPerBlock is the maximum number of transactions sealed in a block (unlimited if zero)
+	MaxTxsPerBlock uint64
+
+	// BlockGasTarget is the gas limit of the sealed blocks (consensus.DefaultBlockGasTarget if zero)
+	BlockGasTarget uint64
+
+	// BlockGasElasticity moves the gas limit of the sealed blocks towards the target gradually
+	BlockGasElasticity bool
+
+	// IBFTRoundTimeout is the base timeout of an IBFT round (consensus.DefaultIBFTRoundTimeout if zero)
+	IBFTRoundTimeout time.Duration
+
+	// PruneRetention is the number of recent blocks whose state is kept,
+	// the older states are pruned. Archive mode (no pruning) if zero
+	PruneRetention uint64
+
+	// ParallelExecution enables the experimental parallel execution of the block transactions
+	ParallelExecution bool
 }
 
 // DefaultConfig returns the default config for JSON-RPC, GRPC (ports) and Networking
@@ -29,5 +95,7 @@ func DefaultConfig() *Config {
 		JSONRPCAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultJSONRPCPort},
 		GRPCAddr:    &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultGRPCPort},
 		Network:     network.DefaultConfig(),
+		TxPool:      txpool.DefaultConfig(),
+		LevelDB:     leveldb.DefaultConfig(),
 	}
 }
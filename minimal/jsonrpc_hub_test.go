@@ -0,0 +1,189 @@
+package minimal
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/helper/keccak"
+	"github.com/0xPolygon/minimal/jsonrpc"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStorageRange(t *testing.T) {
+	st := itrie.NewState(itrie.NewMemoryStorage())
+
+	contract := types.Address{0x1}
+	storage := map[types.Hash]types.Hash{}
+	for i := byte(1); i <= 5; i++ {
+		storage[types.Hash{i}] = types.Hash{0x10 + i}
+	}
+
+	root, err := state.NewExecutor(&chain.Params{}, st).WriteGenesis(&chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			contract: {
+				Code:    []byte{0x1},
+				Storage: storage,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	// the slots are sorted by the hash of their key
+	expected := []types.Hash{}
+	values := map[types.Hash]types.Hash{}
+	for key, value := range storage {
+		hashed := types.BytesToHash(keccak.Keccak256(nil, key.Bytes()))
+		expected = append(expected, hashed)
+		values[hashed] = value
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return bytes.Compare(expected[i].Bytes(), expected[j].Bytes()) < 0
+	})
+
+	keys := []types.Hash{}
+	start := types.Hash{}
+	for {
+		res, err := getStorageRange(st, root, contract, start, 2)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(res.Slots), 2)
+
+		for _, slot := range res.Slots {
+			assert.Equal(t, values[slot.Key], slot.Value)
+			keys = append(keys, slot.Key)
+		}
+		if res.NextKey == nil {
+			break
+		}
+		start = *res.NextKey
+	}
+	assert.Equal(t, expected, keys)
+
+	// a range that starts at the last slot
+	res, err := getStorageRange(st, root, contract, expected[4], 10)
+	assert.NoError(t, err)
+	assert.Len(t, res.Slots, 1)
+	assert.Nil(t, res.NextKey)
+}
+
+func TestJSONRPCHub_StorageRangeAt(t *testing.T) {
+	storage := itrie.NewMemoryStorage()
+	st := itrie.NewState(storage)
+
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	executor := state.NewExecutor(&chain.Params{Forks: &chain.Forks{}}, st)
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash {
+			return types.ZeroHash
+		}
+	}
+	executor.SetRuntime(evm.NewEVM())
+
+	// stores the value of the call in the slot 0
+	root, err := executor.WriteGenesis(&chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender:   {Balance: big.NewInt(10)},
+			contract: {Code: []byte{0x34, 0x60, 0x00, 0x55, 0x00}},
+		},
+	})
+	assert.NoError(t, err)
+
+	b := blockchain.TestBlockchain(t, &chain.Genesis{StateRoot: root})
+
+	block := &types.Block{
+		Header: &types.Header{
+			ParentHash: b.Header().Hash,
+			Number:     1,
+			GasLimit:   1000000,
+		},
+	}
+	for i := 0; i < 2; i++ {
+		block.Transactions = append(block.Transactions, &types.Transaction{
+			From:     sender,
+			To:       &contract,
+			Nonce:    uint64(i),
+			Value:    big.NewInt(int64(i + 1)),
+			GasPrice: big.NewInt(0),
+			Gas:      100000,
+		})
+	}
+
+	hub := &jsonRPCHub{
+		state:      st,
+		Blockchain: b,
+		Executor:   executor,
+	}
+
+	countKeys := func() int {
+		count := 0
+		assert.NoError(t, storage.Keys(func([]byte) {
+			count++
+		}))
+		return count
+	}
+	keys := countKeys()
+
+	slot := types.BytesToHash(keccak.Keccak256(nil, types.Hash{}.Bytes()))
+	for txIndex := 0; txIndex <= len(block.Transactions); txIndex++ {
+		res, err := hub.StorageRangeAt(block, txIndex, contract, types.Hash{}, 10)
+		assert.NoError(t, err)
+
+		if txIndex == 0 {
+			assert.Empty(t, res.Slots)
+			continue
+		}
+		// the slot holds the value of the last transaction applied,
+		// including the last one of the block
+		assert.Len(t, res.Slots, 1)
+		assert.Equal(t, slot, res.Slots[0].Key)
+		assert.Equal(t, types.BytesToHash([]byte{byte(txIndex)}), res.Slots[0].Value)
+	}
+
+	// nothing is written to the storage
+	assert.Equal(t, keys, countKeys())
+}
+
+func TestJSONRPCHub_GetStorage(t *testing.T) {
+	st := itrie.NewState(itrie.NewMemoryStorage())
+
+	contract := types.Address{0x1}
+	root, err := state.NewExecutor(&chain.Params{}, st).WriteGenesis(&chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			contract: {
+				Code: []byte{0x1},
+				Storage: map[types.Hash]types.Hash{
+					{0x1}: types.BytesToHash([]byte{0x1}),
+					{0x2}: types.BytesToHash([]byte{0x80, 0x1}),
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	hub := &jsonRPCHub{state: st}
+
+	// the values are decoded without the leading zeros
+	value, err := hub.GetStorage(root, contract, types.Hash{0x1})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x1}, value)
+
+	value, err = hub.GetStorage(root, contract, types.Hash{0x2})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x80, 0x1}, value)
+
+	// the slot and the account do not exist
+	_, err = hub.GetStorage(root, contract, types.Hash{0x3})
+	assert.ErrorIs(t, err, jsonrpc.ErrStateNotFound)
+
+	_, err = hub.GetStorage(root, types.Address{0x2}, types.Hash{0x1})
+	assert.ErrorIs(t, err, jsonrpc.ErrStateNotFound)
+}
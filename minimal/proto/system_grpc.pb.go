@@ -29,6 +29,8 @@ type SystemClient interface {
 	PeersStatus(ctx context.Context, in *PeersStatusRequest, opts ...grpc.CallOption) (*Peer, error)
 	// Subscribe subscribes to blockchain events
 	Subscribe(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (System_SubscribeClient, error)
+	// SetSealing enables or disables the sealing of new blocks
+	SetSealing(ctx context.Context, in *SetSealingRequest, opts ...grpc.CallOption) (*SetSealingResponse, error)
 }
 
 type systemClient struct {
@@ -107,6 +109,15 @@ func (x *systemSubscribeClient) Recv() (*BlockchainEvent, error) {
 	return m, nil
 }
 
+func (c *systemClient) SetSealing(ctx context.Context, in *SetSealingRequest, opts ...grpc.CallOption) (*SetSealingResponse, error) {
+	out := new(SetSealingResponse)
+	err := c.cc.Invoke(ctx, "/v1.System/SetSealing", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SystemServer is the server API for System service.
 // All implementations must embed UnimplementedSystemServer
 // for forward compatibility
@@ -121,6 +132,8 @@ type SystemServer interface {
 	PeersStatus(context.Context, *PeersStatusRequest) (*Peer, error)
 	// Subscribe subscribes to blockchain events
 	Subscribe(*empty.Empty, System_SubscribeServer) error
+	// SetSealing enables or disables the sealing of new blocks
+	SetSealing(context.Context, *SetSealingRequest) (*SetSealingResponse, error)
 	mustEmbedUnimplementedSystemServer()
 }
 
@@ -143,6 +156,9 @@ func (UnimplementedSystemServer) PeersStatus(context.Context, *PeersStatusReques
 func (UnimplementedSystemServer) Subscribe(*empty.Empty, System_SubscribeServer) error {
 	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
 }
+func (UnimplementedSystemServer) SetSealing(context.Context, *SetSealingRequest) (*SetSealingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSealing not implemented")
+}
 func (UnimplementedSystemServer) mustEmbedUnimplementedSystemServer() {}
 
 // UnsafeSystemServer may be embedded to opt out of forward compatibility for this service.
@@ -249,6 +265,24 @@ func (x *systemSubscribeServer) Send(m *BlockchainEvent) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _System_SetSealing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetSealingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemServer).SetSealing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.System/SetSealing",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemServer).SetSealing(ctx, req.(*SetSealingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // System_ServiceDesc is the grpc.ServiceDesc for System service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -272,6 +306,10 @@ var System_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PeersStatus",
 			Handler:    _System_PeersStatus_Handler,
 		},
+		{
+			MethodName: "SetSealing",
+			Handler:    _System_SetSealing_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1,12 +1,16 @@
 package minimal
 
 import (
+	"bytes"
 	"context"
-	"errors"
+	"expvar"
 	"fmt"
+	"math/big"
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/0xPolygon/minimal/chain"
@@ -20,14 +24,16 @@ import (
 	"github.com/0xPolygon/minimal/types"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/fastrlp"
 	"google.golang.org/grpc"
 
 	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
-	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/state/runtime"
 	"github.com/0xPolygon/minimal/state/runtime/precompiled"
 
 	"github.com/0xPolygon/minimal/blockchain"
 	"github.com/0xPolygon/minimal/consensus"
+	consensusIBFT "github.com/0xPolygon/minimal/consensus/ibft"
 )
 
 // Minimal is the central manager of the blockchain client
@@ -45,6 +51,9 @@ type Server struct {
 	// state executor
 	executor *state.Executor
 
+	// pruner of the old states, nil in archive mode
+	pruner *statePruner
+
 	// jsonrpc stack
 	jsonrpcServer *jsonrpc.JSONRPC
 
@@ -56,6 +65,9 @@ type Server struct {
 
 	// transaction pool
 	txpool *txpool.TxPool
+
+	// startTime is the time the server started
+	startTime time.Time
 }
 
 var dirPaths = []string{
@@ -73,7 +85,9 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		config:     config,
 		chain:      config.Chain,
 		grpcServer: grpc.NewServer(),
+		startTime:  time.Now(),
 	}
+	m.setupMetrics()
 
 	m.logger.Info("Data dir", "path", config.DataDir)
 
@@ -96,7 +110,7 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 	}
 
 	// start blockchain object
-	stateStorage, err := itrie.NewLevelDBStorage(filepath.Join(m.config.DataDir, "trie"), logger)
+	stateStorage, err := itrie.NewLevelDBStorage(filepath.Join(m.config.DataDir, "trie"), m.config.LevelDB, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -105,34 +119,45 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 	m.state = st
 
 	m.executor = state.NewExecutor(config.Chain.Params, st)
-	m.executor.SetRuntime(precompiled.NewPrecompiled())
-	m.executor.SetRuntime(evm.NewEVM())
+	m.executor.Parallel = config.ParallelExecution
+	if err := m.setupRuntimes(); err != nil {
+		return nil, err
+	}
 
 	// compute the genesis root state
-	genesisRoot := m.executor.WriteGenesis(config.Chain.Genesis.Alloc)
+	genesisRoot, err := m.executor.WriteGenesis(config.Chain.Genesis)
+	if err != nil {
+		return nil, err
+	}
 	config.Chain.Genesis.StateRoot = genesisRoot
 
 	// blockchain object
-	m.blockchain, err = blockchain.NewBlockchain(logger, m.config.DataDir, config.Chain, nil, m.executor)
+	m.blockchain, err = blockchain.NewBlockchain(logger, m.config.DataDir, m.config.LevelDB, config.Chain, nil, m.executor)
 	if err != nil {
 		return nil, err
 	}
 
 	m.executor.GetHash = m.blockchain.GetHashHelper
 
+	if config.PruneRetention != 0 {
+		m.pruner = newStatePruner(logger, st, m.blockchain, config.PruneRetention)
+		go m.pruner.run()
+	}
+
 	{
 		hub := &txpoolHub{
 			state:      m.state,
 			Blockchain: m.blockchain,
 		}
 		// start transaction pool
-		if m.txpool, err = txpool.NewTxPool(logger, m.config.Seal, hub, m.grpcServer, m.network); err != nil {
+		if m.txpool, err = txpool.NewTxPool(logger, m.config.Seal, m.config.TxPool, hub, m.grpcServer, m.network); err != nil {
 			return nil, err
 		}
 
 		// use the eip155 signer
 		signer := crypto.NewEIP155Signer(uint64(m.config.Chain.Params.ChainID))
 		m.txpool.AddSigner(signer)
+		m.txpool.SetForks(m.config.Chain.Params.Forks)
 	}
 
 	{
@@ -150,6 +175,11 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		return nil, err
 	}
 
+	// replay the local transactions from the txpool journal
+	if err := m.txpool.LoadJournal(); err != nil {
+		return nil, err
+	}
+
 	// setup grpc server
 	if err := m.setupGRPC(); err != nil {
 		return nil, err
@@ -204,6 +234,11 @@ func (s *Server) setupConsensus() error {
 		Params: s.config.Chain.Params,
 		Config: engineConfig,
 		Path:   filepath.Join(s.config.DataDir, "consensus"),
+
+		MaxTxsPerBlock:     s.config.MaxTxsPerBlock,
+		BlockGasTarget:     s.config.BlockGasTarget,
+		BlockGasElasticity: s.config.BlockGasElasticity,
+		IBFTRoundTimeout:   s.config.IBFTRoundTimeout,
 	}
 	consensus, err := engine(context.Background(), s.config.Seal, config, s.txpool, s.network, s.blockchain, s.executor, s.grpcServer, s.logger.Named("consensus"))
 	if err != nil {
@@ -220,27 +255,46 @@ type jsonRPCHub struct {
 	*blockchain.Blockchain
 	*txpool.TxPool
 	*state.Executor
+
+	network   *network.Server
+	consensus consensus.Consensus
+
+	// pending is the last pending state built on top of pendingHead
+	pending     *pendingState
+	pendingHead types.Hash
+	pendingTime time.Time
+	pendingLock sync.Mutex
 }
 
 // HELPER + WRAPPER METHODS //
 
 func (j *jsonRPCHub) getState(root types.Hash, slot []byte) ([]byte, error) {
+	return readState(j.state, root, slot)
+}
+
+// readState returns the value of a key in the trie of the state st at root
+func readState(st state.State, root types.Hash, slot []byte) ([]byte, error) {
 	// the values in the trie are the hashed objects of the keys
 	key := keccak.Keccak256(nil, slot)
 
-	snap, err := j.state.NewSnapshotAt(root)
+	snap, err := st.NewSnapshotAt(root)
 	if err != nil {
 		return nil, err
 	}
 	result, ok := snap.Get(key)
 	if !ok {
-		return nil, errors.New("given root and slot not found in storage")
+		return nil, jsonrpc.ErrStateNotFound
 	}
 	return result, nil
 }
 
 func (j *jsonRPCHub) GetAccount(root types.Hash, addr types.Address) (*state.Account, error) {
-	obj, err := j.getState(root, addr.Bytes())
+	return readAccount(j.state, root, addr)
+}
+
+// readAccount returns an account of the state st at root
+func readAccount(st state.State, root types.Hash, addr types.Address) (*state.Account, error) {
+	obj, err := readState(st, root, addr.Bytes())
 	if err != nil {
 		return nil, err
 	}
@@ -264,7 +318,271 @@ func (j *jsonRPCHub) GetStorage(root types.Hash, addr types.Address, slot types.
 		return nil, err
 	}
 
-	return obj, nil
+	// the values are stored rlp encoded
+	var p fastrlp.Parser
+	v, err := p.Parse(obj)
+	if err != nil {
+		return nil, err
+	}
+	return v.Bytes()
+}
+
+// StorageRangeAt returns up to limit storage slots of an account with the state
+// of a block after its first txIndex transactions. The transactions are applied
+// on top of an overlay of the state, nothing is written to the storage
+func (j *jsonRPCHub) StorageRangeAt(block *types.Block, txIndex int, addr types.Address, start types.Hash, limit int) (*jsonrpc.StorageRange, error) {
+	st, ok := j.state.(*itrie.State)
+	if !ok {
+		return nil, fmt.Errorf("the state does not support storage iteration")
+	}
+	overlay := st.NewOverlay()
+
+	transition, err := j.beginTxnAt(j.Executor.WithState(overlay), block, txIndex)
+	if err != nil {
+		return nil, err
+	}
+	_, root := transition.Commit()
+
+	return getStorageRange(overlay, root, addr, start, limit)
+}
+
+// TraceTxn executes again the transaction txIndex of the block on top of
+// the state of the block before it, the steps are sent to the tracer
+func (j *jsonRPCHub) TraceTxn(block *types.Block, txIndex int, tracer runtime.Tracer) (*runtime.ExecutionResult, error) {
+	transition, err := j.beginTxnAt(j.Executor, block, txIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	transition.SetTracer(tracer)
+	if err := transition.Write(block.Transactions[txIndex]); err != nil {
+		return nil, err
+	}
+
+	receipts := transition.Receipts()
+	return &runtime.ExecutionResult{
+		ReturnValue: transition.ReturnValue(),
+		GasUsed:     receipts[len(receipts)-1].GasUsed,
+		Err:         transition.ReturnErr(),
+	}, nil
+}
+
+// beginTxnAt returns a transition of the block with its first txIndex transactions applied
+func (j *jsonRPCHub) beginTxnAt(executor *state.Executor, block *types.Block, txIndex int) (*state.Transition, error) {
+	parent, ok := j.GetHeaderByHash(block.ParentHash())
+	if !ok {
+		return nil, fmt.Errorf("parent block %s not found", block.ParentHash())
+	}
+	blockCreator, err := j.GetConsensus().GetBlockCreator(block.Header)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := executor.BeginTxn(parent.StateRoot, block.Header, blockCreator)
+	if err != nil {
+		return nil, err
+	}
+	for _, txn := range block.Transactions[:txIndex] {
+		if err := transition.Write(txn); err != nil {
+			return nil, err
+		}
+	}
+	return transition, nil
+}
+
+// pendingStateTTL is how long the pending state is reused while the head does not change
+const pendingStateTTL = time.Second
+
+// pendingState is the state after applying the promoted transactions of the pool
+// on top of the current header. It is kept in memory and it is never committed
+type pendingState struct {
+	// the reads of the txn load the tries lazily so they are serialized
+	lock sync.Mutex
+	txn  *state.Txn
+}
+
+func (p *pendingState) GetBalance(addr types.Address) *big.Int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.txn.GetBalance(addr)
+}
+
+func (p *pendingState) GetState(addr types.Address, key types.Hash) types.Hash {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.txn.GetState(addr, key)
+}
+
+func (p *pendingState) GetCode(addr types.Address) []byte {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.txn.GetCode(addr)
+}
+
+// PendingState returns the state after applying the promoted transactions of the
+// pool on top of the current header. The block fees go to the creator of the current
+// block. The state is reused for pendingStateTTL while the head does not change
+func (j *jsonRPCHub) PendingState() (jsonrpc.PendingState, error) {
+	j.pendingLock.Lock()
+	defer j.pendingLock.Unlock()
+
+	head := j.Header()
+	if j.pending != nil && j.pendingHead == head.Hash && time.Since(j.pendingTime) < pendingStateTTL {
+		return j.pending, nil
+	}
+
+	blockCreator, err := j.GetConsensus().GetBlockCreator(head)
+	if err != nil {
+		return nil, err
+	}
+
+	header := head.Copy()
+	header.ParentHash = head.Hash
+	header.Number = head.Number + 1
+	header.Timestamp = uint64(time.Now().Unix())
+
+	transition, err := j.BeginTxn(head.StateRoot, header, blockCreator)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the transactions of each sender in nonce order, the senders
+	// are sorted so that the pending state does not depend on the map order
+	pending, _ := j.GetTxs()
+	senders := make([]types.Address, 0, len(pending))
+	for addr := range pending {
+		senders = append(senders, addr)
+	}
+	sort.Slice(senders, func(i, k int) bool {
+		return bytes.Compare(senders[i].Bytes(), senders[k].Bytes()) < 0
+	})
+	for _, addr := range senders {
+		txs := pending[addr]
+		sort.Slice(txs, func(i, k int) bool {
+			return txs[i].Nonce < txs[k].Nonce
+		})
+		for _, txn := range txs {
+			// like the sealer, a transaction that cannot be written is skipped
+			// along with the rest of the transactions of the sender
+			if err := transition.Write(txn); err != nil {
+				break
+			}
+		}
+	}
+
+	j.pending = &pendingState{txn: transition.Txn()}
+	j.pendingHead = head.Hash
+	j.pendingTime = time.Now()
+	return j.pending, nil
+}
+
+// getStorageRange returns up to limit storage slots of an account of the state st at root,
+// in the order of their hashed keys, starting at the hashed key start
+func getStorageRange(st state.State, root types.Hash, addr types.Address, start types.Hash, limit int) (*jsonrpc.StorageRange, error) {
+	account, err := readAccount(st, root, addr)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := st.NewSnapshotAt(account.Root)
+	if err != nil {
+		return nil, err
+	}
+	trie, ok := snap.(*itrie.Trie)
+	if !ok {
+		return nil, fmt.Errorf("the state does not support storage iteration")
+	}
+
+	var (
+		p         fastrlp.Parser
+		decodeErr error
+	)
+
+	res := &jsonrpc.StorageRange{
+		Slots: []*jsonrpc.StorageSlot{},
+	}
+	err = trie.Iterate(start.Bytes(), func(key, value []byte) bool {
+		if len(res.Slots) == limit {
+			next := types.BytesToHash(key)
+			res.NextKey = &next
+			return false
+		}
+
+		// the values are stored rlp encoded
+		v, err := p.Parse(value)
+		if err != nil {
+			decodeErr = err
+			return false
+		}
+		buf, err := v.Bytes()
+		if err != nil {
+			decodeErr = err
+			return false
+		}
+		res.Slots = append(res.Slots, &jsonrpc.StorageSlot{
+			Key:   types.BytesToHash(key),
+			Value: types.BytesToHash(buf),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return res, nil
+}
+
+// PeerCount returns the number of connected peers
+func (j *jsonRPCHub) PeerCount() int64 {
+	return j.network.PeerCount()
+}
+
+// IsListening returns true if the network is accepting connections
+func (j *jsonRPCHub) IsListening() bool {
+	return j.network.IsListening()
+}
+
+// PeerScores returns the scores of the peers with a penalty
+func (j *jsonRPCHub) PeerScores() map[string]int64 {
+	scores := map[string]int64{}
+	for id, score := range j.network.PeerScores() {
+		scores[id.String()] = score
+	}
+	return scores
+}
+
+func (j *jsonRPCHub) GetIBFTSnapshot(header *types.Header) (*jsonrpc.IBFTSnapshot, error) {
+	engine, ok := j.consensus.(*consensusIBFT.Ibft)
+	if !ok {
+		return nil, fmt.Errorf("the consensus engine is not ibft")
+	}
+	snap, err := engine.GetSnapshot(header.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &jsonrpc.IBFTSnapshot{
+		Number:     header.Number,
+		Hash:       header.Hash,
+		Validators: snap.Set,
+	}
+	for _, vote := range snap.Votes {
+		res.Votes = append(res.Votes, &jsonrpc.IBFTVote{
+			Validator: vote.Validator,
+			Address:   vote.Address,
+			Authorize: vote.Authorize,
+		})
+	}
+	if header.Number != 0 {
+		// the genesis is not sealed
+		if res.Proposer, err = engine.GetBlockCreator(header); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
 }
 
 func (j *jsonRPCHub) GetCode(hash types.Hash) ([]byte, error) {
@@ -277,29 +595,71 @@ func (j *jsonRPCHub) GetCode(hash types.Hash) ([]byte, error) {
 	return res, nil
 }
 
-func (j *jsonRPCHub) ApplyTxn(header *types.Header, txn *types.Transaction) ([]byte, bool, error) {
+func (j *jsonRPCHub) ApplyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride) (*runtime.ExecutionResult, error) {
+	return j.applyTxn(header, txn, override, nil)
+}
+
+// TraceCall applies a transaction object on top of the state of the
+// header without committing it, the steps are sent to the tracer
+func (j *jsonRPCHub) TraceCall(header *types.Header, txn *types.Transaction, tracer runtime.Tracer) (*runtime.ExecutionResult, error) {
+	return j.applyTxn(header, txn, nil, tracer)
+}
+
+func (j *jsonRPCHub) applyTxn(header *types.Header, txn *types.Transaction, override types.StateOverride, tracer runtime.Tracer) (*runtime.ExecutionResult, error) {
 	blockCreator, err := j.GetConsensus().GetBlockCreator(header)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
 	transition, err := j.BeginTxn(header.StateRoot, header, blockCreator)
 
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	_, failed, err := transition.Apply(txn)
+	// the transition is never committed so the overrides do not persist
+	if err := transition.WithStateOverride(override); err != nil {
+		return nil, err
+	}
+	transition.SetTracer(tracer)
+
+	gasUsed, _, err := transition.Apply(txn)
 
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	return transition.ReturnValue(), failed, nil
+	return &runtime.ExecutionResult{
+		ReturnValue: transition.ReturnValue(),
+		GasUsed:     gasUsed,
+		Err:         transition.ReturnErr(),
+	}, nil
 }
 
 // SETUP //
 
+// setupRuntimes sets up the precompiled contracts and the interpreter of the executor
+func (s *Server) setupRuntimes() error {
+	params := s.config.Chain.Params
+
+	precompiles := precompiled.NewPrecompiled()
+	for _, addr := range params.DisabledPrecompiles {
+		precompiles.Disable(addr)
+	}
+	s.executor.SetRuntime(precompiles)
+
+	name := params.Interpreter
+	if name == "" {
+		name = "evm"
+	}
+	interpreter, ok := interpreterBackends[name]
+	if !ok {
+		return fmt.Errorf("interpreter '%s' not found", name)
+	}
+	s.executor.SetRuntime(interpreter())
+	return nil
+}
+
 // setupJSONRCP sets up the JSONRPC server, using the set configuration
 func (s *Server) setupJSONRPC() error {
 	hub := &jsonRPCHub{
@@ -307,12 +667,26 @@ func (s *Server) setupJSONRPC() error {
 		Blockchain: s.blockchain,
 		TxPool:     s.txpool,
 		Executor:   s.executor,
+		network:    s.network,
+		consensus:  s.consensus,
 	}
 
 	conf := &jsonrpc.Config{
 		Store:   hub,
 		Addr:    s.config.JSONRPCAddr,
 		ChainID: uint64(s.config.Chain.Params.ChainID),
+		Ready:   s.isReady,
+		Gzip:    s.config.JSONRPCGzip,
+		Metrics: s.config.JSONRPCMetrics,
+
+		CallCacheSize:    s.config.JSONRPCCallCache,
+		MaxLogBlockRange: s.config.JSONRPCMaxLogBlockRange,
+		MaxLogAddresses:  s.config.JSONRPCMaxLogAddresses,
+		MaxLogs:          s.config.JSONRPCMaxLogs,
+		RateLimits:       s.config.JSONRPCRateLimits,
+		GasEstimationCap: s.config.JSONRPCGasEstimationCap,
+		SenderAllowlist:  s.config.JSONRPCSenderAllowlist,
+		Forks:            s.config.Chain.Params.Forks,
 	}
 
 	srv, err := jsonrpc.NewJSONRPC(s.logger, conf)
@@ -324,6 +698,32 @@ func (s *Server) setupJSONRPC() error {
 	return nil
 }
 
+// isReady returns an error if the node is not ready to serve requests,
+// either because it has not enough peers or because it is still syncing
+func (s *Server) isReady() error {
+	if peers := uint64(len(s.network.Peers())); peers < s.config.ReadyMinPeers {
+		return fmt.Errorf("not enough peers: %d, expected at least %d", peers, s.config.ReadyMinPeers)
+	}
+
+	syncer, ok := s.consensus.(consensus.Syncer)
+	if !ok {
+		return nil
+	}
+	best, ok := syncer.BestPeerNumber()
+	if !ok {
+		return nil
+	}
+
+	maxDistance := s.config.ReadyMaxSyncDistance
+	if maxDistance == 0 {
+		maxDistance = DefaultReadyMaxSyncDistance
+	}
+	if head := s.blockchain.Header().Number; best > head && best-head > maxDistance {
+		return fmt.Errorf("syncing: head %d, best peer %d", head, best)
+	}
+	return nil
+}
+
 // setupGRPC sets up the grpc server and listens on tcp
 func (s *Server) setupGRPC() error {
 	proto.RegisterSystemServer(s.grpcServer, &systemService{s: s})
@@ -349,12 +749,70 @@ func (s *Server) Chain() *chain.Chain {
 	return s.chain
 }
 
-func (s *Server) Join(addr0 string, dur time.Duration) error {
-	return s.network.JoinAddr(addr0, dur)
+func (s *Server) Join(ctx context.Context, addr0 string, dur time.Duration) error {
+	return s.network.JoinAddr(ctx, addr0, dur)
+}
+
+// Uptime returns the time since the server started
+func (s *Server) Uptime() time.Duration {
+	return time.Since(s.startTime)
+}
+
+var (
+	metricsOnce sync.Once
+
+	// metricsServer is the server reported by the process wide metrics
+	metricsServer     *Server
+	metricsServerLock sync.RWMutex
+)
+
+// setupMetrics publishes the metrics of the server. The metrics are
+// process wide so they report the last server created in the process
+func (s *Server) setupMetrics() {
+	metricsServerLock.Lock()
+	metricsServer = s
+	metricsServerLock.Unlock()
+
+	metricsOnce.Do(func() {
+		expvar.Publish("node_uptime_seconds", expvar.Func(func() interface{} {
+			metricsServerLock.RLock()
+			defer metricsServerLock.RUnlock()
+
+			if metricsServer == nil {
+				return int64(0)
+			}
+			return int64(metricsServer.Uptime() / time.Second)
+		}))
+	})
+}
+
+// releaseMetrics stops reporting the metrics of the server
+func (s *Server) releaseMetrics() {
+	metricsServerLock.Lock()
+	defer metricsServerLock.Unlock()
+
+	if metricsServer == s {
+		metricsServer = nil
+	}
 }
 
 // Close closes the Minimal server (blockchain, networking, consensus)
 func (s *Server) Close() {
+	s.releaseMetrics()
+
+	// Close the jsonrpc server first so that the in-flight requests
+	// complete before the rest of the layers go away
+	if s.jsonrpcServer != nil {
+		if err := s.jsonrpcServer.Close(); err != nil {
+			s.logger.Error("failed to close jsonrpc server", "err", err.Error())
+		}
+	}
+
+	// Stop pruning the state before the blockchain goes away
+	if s.pruner != nil {
+		s.pruner.close()
+	}
+
 	// Close the blockchain layer
 	if err := s.blockchain.Close(); err != nil {
 		s.logger.Error("failed to close blockchain", "err", err.Error())
@@ -369,6 +827,11 @@ func (s *Server) Close() {
 	if err := s.consensus.Close(); err != nil {
 		s.logger.Error("failed to close consensus", "err", err.Error())
 	}
+
+	// Close the transaction pool
+	if err := s.txpool.Close(); err != nil {
+		s.logger.Error("failed to close txpool", "err", err.Error())
+	}
 }
 
 // Entry is a backend configuration entry
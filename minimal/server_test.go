@@ -0,0 +1,83 @@
+package minimal
+
+import (
+	"testing"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/consensus"
+	"github.com/0xPolygon/minimal/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_IsReady_MinPeers(t *testing.T) {
+	conf := func(c *network.Config) {
+		c.NoDiscover = true
+		// use a random port to avoid conflicts with the network tests
+		c.Addr.Port = 0
+	}
+	srv0 := network.CreateServer(t, conf)
+	defer srv0.Close()
+
+	srv1 := network.CreateServer(t, conf)
+	defer srv1.Close()
+
+	s := &Server{
+		network: srv0,
+		config: &Config{
+			ReadyMinPeers: 1,
+		},
+	}
+	assert.Error(t, s.isReady())
+
+	network.MultiJoin(t, srv0, srv1)
+	assert.NoError(t, s.isReady())
+}
+
+type mockSyncConsensus struct {
+	consensus.Consensus
+
+	best   uint64
+	behind bool
+}
+
+func (m *mockSyncConsensus) BestPeerNumber() (uint64, bool) {
+	return m.best, m.behind
+}
+
+func TestServer_IsReady_SyncDistance(t *testing.T) {
+	srv := network.CreateServer(t, func(c *network.Config) {
+		c.NoDiscover = true
+		c.Addr.Port = 0
+	})
+	defer srv.Close()
+
+	b := blockchain.NewTestBlockchain(t, blockchain.NewTestHeaderChain(21))
+
+	syncer := &mockSyncConsensus{}
+	s := &Server{
+		network:    srv,
+		blockchain: b,
+		consensus:  syncer,
+		config: &Config{
+			ReadyMaxSyncDistance: 5,
+		},
+	}
+	assert.Equal(t, uint64(20), b.Header().Number)
+
+	// no peer is ahead of the node
+	assert.NoError(t, s.isReady())
+
+	// the best peer is within the distance of the head
+	syncer.best, syncer.behind = 25, true
+	assert.NoError(t, s.isReady())
+
+	syncer.best = 26
+	assert.Error(t, s.isReady())
+
+	// the default distance
+	s.config.ReadyMaxSyncDistance = 0
+	assert.NoError(t, s.isReady())
+
+	syncer.best = 20 + DefaultReadyMaxSyncDistance + 1
+	assert.Error(t, s.isReady())
+}
@@ -0,0 +1,79 @@
+package minimal
+
+import (
+	"github.com/0xPolygon/minimal/blockchain"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+// statePruner prunes the state of the blocks out of the retention window.
+// The state is pruned every retention blocks, so that at most two windows
+// of states are stored at any given time
+type statePruner struct {
+	logger     hclog.Logger
+	state      *itrie.State
+	blockchain *blockchain.Blockchain
+	retention  uint64
+
+	// sub is the subscription to the new heads
+	sub blockchain.Subscription
+
+	// last is the number of the head at the last prune
+	last uint64
+}
+
+func newStatePruner(logger hclog.Logger, st *itrie.State, b *blockchain.Blockchain, retention uint64) *statePruner {
+	st.EnablePruning()
+
+	return &statePruner{
+		logger:     logger.Named("pruner"),
+		state:      st,
+		blockchain: b,
+		retention:  retention,
+		sub:        b.SubscribeEvents(),
+		last:       b.Header().Number,
+	}
+}
+
+func (p *statePruner) run() {
+	for {
+		evnt := p.sub.GetEvent()
+		if evnt == nil {
+			return
+		}
+		if evnt.Type == blockchain.EventFork {
+			continue
+		}
+		head := evnt.Header()
+		if head.Number < p.last+p.retention {
+			continue
+		}
+		p.prune(head)
+	}
+}
+
+// prune removes the state of the blocks older than the retention window of the head
+func (p *statePruner) prune(head *types.Header) {
+	roots := []types.Hash{}
+	for i := uint64(0); i < p.retention && i <= head.Number; i++ {
+		header, ok := p.blockchain.GetHeaderByNumber(head.Number - i)
+		if !ok {
+			p.logger.Error("failed to find header", "number", head.Number-i)
+			return
+		}
+		roots = append(roots, header.StateRoot)
+	}
+
+	pruned, err := p.state.Prune(roots)
+	if err != nil {
+		p.logger.Error("failed to prune state", "err", err)
+		return
+	}
+	p.last = head.Number
+	p.logger.Info("state pruned", "number", head.Number, "nodes", pruned)
+}
+
+func (p *statePruner) close() {
+	p.sub.Close()
+}
@@ -23,16 +23,29 @@ type systemService struct {
 // Current: { Number: <blockNumber>; Hash: <headerHash> }
 //
 // P2PAddr: <libp2pAddress>
+//
+// StartTime: <unixSeconds>; Uptime: <seconds>
 func (s *systemService) GetStatus(ctx context.Context, req *empty.Empty) (*proto.ServerStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	header := s.s.blockchain.Header()
 
+	p2pAddr, err := network.AddrInfoToString(s.s.network.AddrInfo())
+	if err != nil {
+		return nil, err
+	}
+
 	status := &proto.ServerStatus{
 		Network: int64(s.s.chain.Params.ChainID),
 		Current: &proto.ServerStatus_Block{
 			Number: int64(header.Number),
 			Hash:   header.Hash.String(),
 		},
-		P2PAddr: network.AddrInfoToString(s.s.network.AddrInfo()),
+		P2PAddr:   p2pAddr,
+		StartTime: s.s.startTime.Unix(),
+		Uptime:    int64(s.s.Uptime() / time.Second),
 	}
 	return status, nil
 }
@@ -41,6 +54,13 @@ func (s *systemService) GetStatus(ctx context.Context, req *empty.Empty) (*proto
 func (s *systemService) Subscribe(req *empty.Empty, stream proto.System_SubscribeServer) error {
 	sub := s.s.blockchain.SubscribeEvents()
 
+	// close the subscription once the client goes away,
+	// this also unblocks any pending GetEvent call
+	go func() {
+		<-stream.Context().Done()
+		sub.Close()
+	}()
+
 	for {
 		evnt := sub.GetEvent()
 		if evnt == nil {
@@ -69,9 +89,17 @@ func (s *systemService) Subscribe(req *empty.Empty, stream proto.System_Subscrib
 		}
 	}
 
-	sub.Close()
+	return stream.Context().Err()
+}
+
+// SetSealing implements the operator service to pause or resume the sealing of blocks
+func (s *systemService) SetSealing(ctx context.Context, req *proto.SetSealingRequest) (*proto.SetSealingResponse, error) {
+	s.s.consensus.SetSealing(req.Enabled)
+
+	enabled := s.s.consensus.IsSealing()
+	s.s.logger.Info("sealing updated", "enabled", enabled)
 
-	return nil
+	return &proto.SetSealingResponse{Enabled: enabled}, nil
 }
 
 // PeersAdd implements the 'peers add' operator service
@@ -81,7 +109,7 @@ func (s *systemService) PeersAdd(ctx context.Context, req *proto.PeersAddRequest
 		dur = network.DefaultJoinTimeout
 	}
 
-	err := s.s.Join(req.Id, dur)
+	err := s.s.Join(ctx, req.Id, dur)
 
 	return &empty.Empty{}, err
 }
@@ -134,6 +162,10 @@ func (s *systemService) PeersList(
 
 	peers := s.s.network.Peers()
 	for _, p := range peers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		peer, err := s.getPeer(p.Info.ID)
 		if err != nil {
 			return nil, err
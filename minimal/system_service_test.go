@@ -0,0 +1,143 @@
+package minimal
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/minimal/proto"
+	"github.com/0xPolygon/minimal/network"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestSystemService_PeersAdd_Cancel(t *testing.T) {
+	srv := network.CreateServer(t, func(c *network.Config) {
+		c.NoDiscover = true
+		// use a random port to avoid conflicts with the network tests
+		c.Addr.Port = 0
+		// the failed dial is retried after the backoff, which is
+		// interrupted by the cancel
+		c.JoinRetries = 1
+		c.JoinBackoff = time.Minute
+	})
+	defer srv.Close()
+
+	service := &systemService{s: &Server{network: srv}}
+
+	// a peer that is never going to be reachable
+	_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	assert.NoError(t, err)
+	id, err := peer.IDFromPublicKey(pub)
+	assert.NoError(t, err)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancelFn()
+	}()
+
+	now := time.Now()
+	_, err = service.PeersAdd(ctx, &proto.PeersAddRequest{
+		Id:      fmt.Sprintf("/ip4/127.0.0.1/tcp/1/p2p/%s", id),
+		Blocked: true,
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Less(t, int64(time.Since(now)), int64(network.DefaultJoinTimeout))
+}
+
+type mockSubscribeStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (m *mockSubscribeStream) Context() context.Context {
+	return m.ctx
+}
+
+func (m *mockSubscribeStream) Send(evnt *proto.BlockchainEvent) error {
+	return nil
+}
+
+func TestSystemService_Subscribe_Cancel(t *testing.T) {
+	service := &systemService{s: &Server{blockchain: blockchain.TestBlockchain(t, nil)}}
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	doneCh := make(chan error)
+	go func() {
+		doneCh <- service.Subscribe(&empty.Empty{}, &mockSubscribeStream{ctx: ctx})
+	}()
+
+	// the subscription blocks until there are new events
+	select {
+	case <-doneCh:
+		t.Fatal("subscription should be blocked waiting for events")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancelFn()
+
+	select {
+	case err := <-doneCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not cancelled")
+	}
+}
+
+func TestSystemService_GetStatus_Cancel(t *testing.T) {
+	service := &systemService{s: &Server{}}
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+
+	_, err := service.GetStatus(ctx, &empty.Empty{})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestSystemService_GetStatus_Uptime(t *testing.T) {
+	srv := network.CreateServer(t, func(c *network.Config) {
+		c.NoDiscover = true
+		// use a random port to avoid conflicts with the network tests
+		c.Addr.Port = 0
+	})
+	defer srv.Close()
+
+	s := &Server{
+		network:    srv,
+		blockchain: blockchain.TestBlockchain(t, nil),
+		chain:      &chain.Chain{Params: &chain.Params{}},
+		startTime:  time.Now(),
+	}
+	s.setupMetrics()
+
+	time.Sleep(1100 * time.Millisecond)
+
+	service := &systemService{s: s}
+	status, err := service.GetStatus(context.Background(), &empty.Empty{})
+	assert.NoError(t, err)
+	assert.Equal(t, s.startTime.Unix(), status.StartTime)
+	assert.NotZero(t, status.Uptime)
+
+	// the uptime is emitted as a metric
+	metric := expvar.Get("node_uptime_seconds")
+	assert.NotNil(t, metric)
+	assert.NotEqual(t, "0", metric.String())
+
+	// a new server in the same process reports its own uptime
+	other := &Server{startTime: time.Now().Add(-time.Hour)}
+	other.setupMetrics()
+	assert.Equal(t, "3600", metric.String())
+
+	// and it stops once the server is released
+	other.releaseMetrics()
+	assert.Equal(t, "0", metric.String())
+}
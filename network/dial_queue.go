@@ -15,6 +15,10 @@ type dialQueue struct {
 	items    map[peer.ID]*dialTask
 	updateCh chan struct{}
 	closeCh  chan struct{}
+
+	// score returns the score of a peer, the peers with the
+	// same priority are dialed in order of score
+	score func(peer.ID) int64
 }
 
 // newDialQueue creates a new DialQueue
@@ -52,9 +56,12 @@ func (d *dialQueue) popImpl() *dialTask {
 
 	if len(d.heap) != 0 {
 		// pop the first value and remove it from the heap
-		tt := heap.Pop(&d.heap)
+		tt := heap.Pop(&d.heap).(*dialTask)
+		if d.items[tt.addr.ID] == tt {
+			delete(d.items, tt.addr.ID)
+		}
 		d.lock.Unlock()
-		return tt.(*dialTask)
+		return tt
 	}
 
 	d.lock.Unlock()
@@ -67,7 +74,7 @@ func (d *dialQueue) del(peer peer.ID) {
 	defer d.lock.Unlock()
 
 	item, ok := d.items[peer]
-	if ok {
+	if ok && item.index >= 0 {
 		heap.Remove(&d.heap, item.index)
 		delete(d.items, peer)
 	}
@@ -81,6 +88,9 @@ func (d *dialQueue) add(addr *peer.AddrInfo, priority uint64) {
 		addr:     addr,
 		priority: priority,
 	}
+	if d.score != nil {
+		task.score = d.score(addr.ID)
+	}
 	d.items[addr.ID] = task
 	heap.Push(&d.heap, task)
 
@@ -96,8 +106,11 @@ type dialTask struct {
 	// info of the task
 	addr *peer.AddrInfo
 
-	// priority of the task (the higher the better)
+	// priority of the task (the lower the sooner)
 	priority uint64
+
+	// score of the peer when the task was added (the higher the sooner)
+	score int64
 }
 
 // The DialQueue is implemented as priority queue which utilizes a heap (standard Go implementation)
@@ -109,9 +122,13 @@ type dialQueueImpl []*dialTask
 // Len returns the length of the queue
 func (t dialQueueImpl) Len() int { return len(t) }
 
-// Less compares the priorities of two items at the passed in indexes (A < B)
+// Less compares the priorities of two items at the passed in indexes (A < B).
+// The tasks with the same priority are sorted by score
 func (t dialQueueImpl) Less(i, j int) bool {
-	return t[i].priority < t[j].priority
+	if t[i].priority != t[j].priority {
+		return t[i].priority < t[j].priority
+	}
+	return t[i].score > t[j].score
 }
 
 // Swap swaps the places of the items at the passed-in indexes
@@ -46,3 +46,18 @@ func TestDialQueue(t *testing.T) {
 		t.Fatal("timeout")
 	}
 }
+
+func TestDialQueue_DelPopped(t *testing.T) {
+	q := newDialQueue()
+
+	info := &peer.AddrInfo{
+		ID: peer.ID("a"),
+	}
+	q.add(info, 1)
+	assert.Equal(t, q.popImpl().addr.ID, peer.ID("a"))
+
+	// a popped peer is not in the queue anymore
+	q.del(info.ID)
+	assert.Empty(t, q.items)
+	assert.Nil(t, q.popImpl())
+}
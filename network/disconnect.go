@@ -0,0 +1,82 @@
+package network
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+var disconnectProtoV1 = "/disconnect/0.1"
+
+const (
+	// disconnectTimeout is the time to deliver the reason of
+	// a disconnection before the connection is closed anyway
+	disconnectTimeout = 2 * time.Second
+
+	// maxDisconnectReasonSize is the maximum size (in bytes) of a disconnect reason
+	maxDisconnectReasonSize = 256
+)
+
+func (s *Server) setupDisconnect() {
+	s.wrapStream(disconnectProtoV1, s.handleDisconnect)
+}
+
+// handleDisconnect reads the reason sent by a peer that is closing the connection
+func (s *Server) handleDisconnect(stream network.Stream) {
+	defer stream.Close()
+
+	if err := stream.SetReadDeadline(time.Now().Add(disconnectTimeout)); err != nil {
+		s.logger.Debug("failed to set the disconnect deadline", "err", err)
+		return
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(stream, maxDisconnectReasonSize))
+	if err != nil {
+		s.logger.Debug("failed to read the disconnect reason", "err", err)
+		return
+	}
+
+	peerID := stream.Conn().RemotePeer()
+	reason := string(data)
+
+	s.logger.Info("Disconnected by peer", "id", peerID, "reason", reason)
+	s.emitEvent(&PeerEvent{
+		PeerID: peerID,
+		Type:   PeerEventDisconnectReason,
+		Desc:   reason,
+	})
+}
+
+// sendDisconnect sends the reason of the disconnection to the peer and
+// waits for the peer to read it, for at most disconnectTimeout
+func (s *Server) sendDisconnect(id peer.ID, reason string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), disconnectTimeout)
+	defer cancelFn()
+
+	stream, err := s.host.NewStream(ctx, id, protocol.ID(s.protocolID(disconnectProtoV1)))
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if err := stream.SetDeadline(time.Now().Add(disconnectTimeout)); err != nil {
+		return err
+	}
+	if len(reason) > maxDisconnectReasonSize {
+		reason = reason[:maxDisconnectReasonSize]
+	}
+	if _, err := stream.Write([]byte(reason)); err != nil {
+		return err
+	}
+	if err := stream.CloseWrite(); err != nil {
+		return err
+	}
+
+	// the peer closes the stream once it has read the reason
+	_, err = ioutil.ReadAll(stream)
+	return err
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func disconnectReasonHandler(reason *string) func(evnt *PeerEvent) bool {
+	return func(evnt *PeerEvent) bool {
+		if evnt.Type != PeerEventDisconnectReason {
+			return false
+		}
+		*reason = evnt.Desc
+		return true
+	}
+}
+
+func TestDisconnect_Reason(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1)
+
+	var reason string
+	reasonCh := asyncWaitForEvent(srv1, 5*time.Second, disconnectReasonHandler(&reason))
+	disconnectedCh := asyncWaitForEvent(srv1, 5*time.Second, disconnectedPeerHandler(srv0.AddrInfo().ID))
+
+	srv0.Disconnect(srv1.AddrInfo().ID, "bad block")
+
+	assert.True(t, <-reasonCh)
+	assert.Equal(t, "bad block", reason)
+	assert.True(t, <-disconnectedCh)
+}
+
+func TestDisconnect_ReasonTruncated(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1)
+
+	var reason string
+	reasonCh := asyncWaitForEvent(srv1, 5*time.Second, disconnectReasonHandler(&reason))
+
+	srv0.Disconnect(srv1.AddrInfo().ID, strings.Repeat("a", 2*maxDisconnectReasonSize))
+
+	assert.True(t, <-reasonCh)
+	assert.Len(t, reason, maxDisconnectReasonSize)
+}
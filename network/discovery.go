@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"sync"
 	"time"
@@ -23,6 +24,17 @@ var discProto = "/disc/0.1"
 
 const defaultBucketSize = 20
 
+const (
+	// maxFindPeers is the maximum number of peers requested and accepted in a find peers call
+	maxFindPeers = 16
+
+	// maxFindPeersRespSize is the maximum size (in bytes) of the response of a find peers call
+	maxFindPeersRespSize = 64 * 1024
+
+	// defaultFindPeersTimeout is the time given to a peer to answer a find peers call
+	defaultFindPeersTimeout = 10 * time.Second
+)
+
 type discovery struct {
 	proto.UnimplementedDiscoveryServer
 	srv          *Server
@@ -35,6 +47,9 @@ type discovery struct {
 	closeCh  chan struct{}
 
 	bootnodes []*peer.AddrInfo
+
+	// findPeersTimeout is the time given to a peer to answer a find peers call
+	findPeersTimeout time.Duration
 }
 
 func (d *discovery) setBootnodes(bootnodes []*peer.AddrInfo) {
@@ -44,6 +59,10 @@ func (d *discovery) setBootnodes(bootnodes []*peer.AddrInfo) {
 func (d *discovery) setup() error {
 	d.notifyCh = make(chan struct{}, 5)
 	d.peers = []peer.ID{}
+	d.findPeersTimeout = d.srv.config.FindPeersTimeout
+	if d.findPeersTimeout == 0 {
+		d.findPeersTimeout = defaultFindPeersTimeout
+	}
 
 	keyID := kb.ConvertPeerID(d.srv.host.ID())
 
@@ -67,8 +86,13 @@ func (d *discovery) setup() error {
 
 	d.srv.Register(discProto, grpc)
 
-	// send all the nodes we connect to the routing table
+	// send all the nodes we connect to the routing table and
+	// remove them once they disconnect
 	err = d.srv.SubscribeFn(func(evnt *PeerEvent) {
+		if evnt.Type == PeerEventDisconnected {
+			d.delPeer(evnt.PeerID)
+			return
+		}
 		if evnt.Type != PeerEventConnected {
 			return
 		}
@@ -99,31 +123,73 @@ func (d *discovery) call(peerID peer.ID) error {
 	if err != nil {
 		return err
 	}
+	d.addPeers(nodes)
+	return nil
+}
+
+// delPeer removes the peer from the routing table and from the local peers
+func (d *discovery) delPeer(id peer.ID) {
+	d.routingTable.RemovePeer(id)
+
+	d.peersLock.Lock()
+	defer d.peersLock.Unlock()
 
+	peers := d.peers[:0]
+	for _, p := range d.peers {
+		if p != id {
+			peers = append(peers, p)
+		}
+	}
+	d.peers = peers
+}
+
+// addPeers includes the peers returned by a find peers call
+// on the routing table. A peer that cannot be added is skipped
+func (d *discovery) addPeers(nodes []*peer.AddrInfo) {
 	// before we include peers on the routing table -> dial queue
 	// we have to add them to the peerstore so that they are
 	// available to all the libp2p services
 	for _, node := range nodes {
-		d.srv.host.Peerstore().AddAddr(node.ID, node.Addrs[0], peerstore.AddressTTL)
+		if len(node.Addrs) == 0 {
+			// the remote peer only knows the id of the node
+			d.srv.logger.Debug("skip peer without addresses", "id", node.ID)
+			continue
+		}
+		if d.srv.IsBanned(node.ID) {
+			d.srv.logger.Debug("skip banned peer", "id", node.ID)
+			continue
+		}
+		d.srv.logger.Trace("discovered node", "node", node.ID, "addrs", node.Addrs)
+
+		d.srv.host.Peerstore().AddAddrs(node.ID, node.Addrs, peerstore.AddressTTL)
 		if _, err := d.routingTable.TryAddPeer(node.ID, false, false); err != nil {
-			return err
+			d.srv.logger.Debug("failed to add peer to routing table", "id", node.ID, "err", err)
 		}
 	}
-
-	return nil
 }
 
+// findPeersCall asks the peer for the nodes it knows. The call fails if the peer
+// does not answer in time or it answers with more nodes than requested
 func (d *discovery) findPeersCall(peerID peer.ID) ([]*peer.AddrInfo, error) {
 	conn, err := d.srv.NewProtoStream(discProto, peerID)
 	if err != nil {
 		return nil, err
 	}
-	clt := proto.NewDiscoveryClient(conn.(*rawGrpc.ClientConn))
+	clientConn := conn.(*rawGrpc.ClientConn)
+	defer clientConn.Close()
+
+	clt := proto.NewDiscoveryClient(clientConn)
 
-	resp, err := clt.FindPeers(context.Background(), &proto.FindPeersReq{Count: 16})
+	ctx, cancelFn := context.WithTimeout(context.Background(), d.findPeersTimeout)
+	defer cancelFn()
+
+	resp, err := clt.FindPeers(ctx, &proto.FindPeersReq{Count: maxFindPeers}, rawGrpc.MaxCallRecvMsgSize(maxFindPeersRespSize))
 	if err != nil {
 		return nil, err
 	}
+	if len(resp.Nodes) > maxFindPeers {
+		return nil, fmt.Errorf("too many peers in the response: %d", len(resp.Nodes))
+	}
 
 	var addrInfo []*peer.AddrInfo
 	for _, node := range resp.Nodes {
@@ -159,10 +225,16 @@ func (d *discovery) handleDiscovery() {
 		}
 	} else {
 		// take a random peer and find peers
+		d.peersLock.Lock()
+		var target peer.ID
 		if len(d.peers) > 0 {
-			target := d.peers[rand.Intn(len(d.peers))]
+			target = d.peers[rand.Intn(len(d.peers))]
+		}
+		d.peersLock.Unlock()
+
+		if target != "" {
 			if err := d.call(target); err != nil {
-				d.srv.logger.Error("failed to dial bootnode", "err", err)
+				d.srv.logger.Error("failed to find peers", "id", target, "err", err)
 			}
 		}
 	}
@@ -174,9 +246,9 @@ func (d *discovery) FindPeers(
 ) (*proto.FindPeersResp, error) {
 	from := ctx.(*grpc.Context).PeerID
 
-	if req.Count > 16 {
+	if req.Count > maxFindPeers {
 		// max limit
-		req.Count = 16
+		req.Count = maxFindPeers
 	}
 	if req.GetKey() == "" {
 		// use peer id if none specified
@@ -190,7 +262,12 @@ func (d *discovery) FindPeers(
 		// do not include himself
 		if id != from {
 			info := d.srv.host.Peerstore().PeerInfo(id)
-			filtered = append(filtered, AddrInfoToString(&info))
+			addr, err := AddrInfoToString(&info)
+			if err != nil {
+				d.srv.logger.Debug("skip peer in find peers response", "id", id, "err", err)
+				continue
+			}
+			filtered = append(filtered, addr)
 		}
 	}
 	resp := &proto.FindPeersResp{
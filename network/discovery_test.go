@@ -1,10 +1,17 @@
 package network
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/0xPolygon/minimal/network/grpc"
+	"github.com/0xPolygon/minimal/network/proto"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -93,3 +100,231 @@ func TestDiscovery_FullNetwork(t *testing.T) {
 		fmt.Println(srv.host.Peerstore().Peers().Len())
 	}
 }
+
+func TestDiscovery_DifferentChainID(t *testing.T) {
+	// nodes from different chains do not share any protocol
+	srv0 := CreateServer(t, nil)
+	srv1 := CreateServer(t, func(c *Config) {
+		c.Chain.Params.ChainID = 2
+	})
+
+	assert.Error(t, srv0.Join(srv1.AddrInfo(), 5*time.Second))
+
+	// the discovery protocol cannot be established
+	_, err := srv0.discovery.findPeersCall(srv1.AddrInfo().ID)
+	assert.Error(t, err)
+
+	assert.Equal(t, srv0.discovery.routingTable.Size(), 0)
+	assert.Equal(t, srv1.discovery.routingTable.Size(), 0)
+}
+
+func TestDiscovery_ProtocolID(t *testing.T) {
+	srv := CreateServer(t, func(c *Config) {
+		c.Chain.Params.ChainID = 100
+	})
+	assert.Equal(t, "/polygon/100/disc/0.1", srv.protocolID(discProto))
+}
+
+func TestDiscovery_SkipPeersWithoutAddrs(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	newPeer := func() peer.ID {
+		_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+		assert.NoError(t, err)
+
+		id, err := peer.IDFromPublicKey(pub)
+		assert.NoError(t, err)
+		return id
+	}
+
+	// a peer that only knows the id of the node
+	empty, err := StringToAddrInfo("/p2p/" + newPeer().String())
+	assert.NoError(t, err)
+	assert.Empty(t, empty.Addrs)
+
+	valid, err := StringToAddrInfo("/ip4/127.0.0.1/tcp/1500/p2p/" + newPeer().String())
+	assert.NoError(t, err)
+
+	srv.discovery.addPeers([]*peer.AddrInfo{empty, valid})
+
+	assert.Equal(t, srv.discovery.routingTable.Size(), 1)
+	assert.NotNil(t, srv.discovery.routingTable.Find(valid.ID))
+	assert.Empty(t, srv.host.Peerstore().Addrs(empty.ID))
+}
+
+// mockDiscovery is a discovery service that stalls or answers with the given nodes
+type mockDiscovery struct {
+	proto.UnimplementedDiscoveryServer
+
+	stall bool
+	nodes []string
+}
+
+func (m *mockDiscovery) FindPeers(ctx context.Context, req *proto.FindPeersReq) (*proto.FindPeersResp, error) {
+	if m.stall {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &proto.FindPeersResp{Nodes: m.nodes}, nil
+}
+
+func registerMockDiscovery(srv *Server, m *mockDiscovery) {
+	grpc := grpc.NewGrpcStream()
+	proto.RegisterDiscoveryServer(grpc.GrpcServer(), m)
+	grpc.Serve()
+
+	srv.Register(discProto, grpc)
+}
+
+func TestDiscovery_FindPeersLimits(t *testing.T) {
+	srv0 := CreateServer(t, func(c *Config) {
+		c.FindPeersTimeout = 2 * time.Second
+	})
+	srv1 := CreateServer(t, nil)
+	srv2 := CreateServer(t, nil)
+
+	MultiJoin(t, srv0, srv1, srv0, srv2)
+
+	// the call to a stalling peer times out
+	registerMockDiscovery(srv1, &mockDiscovery{stall: true})
+
+	now := time.Now()
+	_, err := srv0.discovery.findPeersCall(srv1.AddrInfo().ID)
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(now)), int64(5*time.Second))
+
+	// a peer that answers with too many nodes is skipped
+	nodes := []string{}
+	for i := 0; i < maxFindPeers+1; i++ {
+		node, err := AddrInfoToString(srv0.AddrInfo())
+		assert.NoError(t, err)
+		nodes = append(nodes, node)
+	}
+	registerMockDiscovery(srv2, &mockDiscovery{nodes: nodes})
+
+	_, err = srv0.discovery.findPeersCall(srv2.AddrInfo().ID)
+	assert.Error(t, err)
+
+	// the discovery continues with the peers that answer
+	registerMockDiscovery(srv2, &mockDiscovery{nodes: nodes[:1]})
+
+	resp, err := srv0.discovery.findPeersCall(srv2.AddrInfo().ID)
+	assert.NoError(t, err)
+	assert.Len(t, resp, 1)
+}
+
+func TestDiscovery_FindPeersEncodesPeers(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	newPeer := func(addrs ...string) peer.ID {
+		_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+		assert.NoError(t, err)
+
+		id, err := peer.IDFromPublicKey(pub)
+		assert.NoError(t, err)
+
+		for _, addr := range addrs {
+			maddr, err := multiaddr.NewMultiaddr(addr)
+			assert.NoError(t, err)
+			srv.host.Peerstore().AddAddr(id, maddr, peerstore.PermanentAddrTTL)
+		}
+		_, err = srv.discovery.routingTable.TryAddPeer(id, false, false)
+		assert.NoError(t, err)
+		return id
+	}
+
+	// a peer without addresses cannot be encoded, a peer with
+	// many addresses is encoded with the public one
+	newPeer()
+	valid := newPeer("/ip4/127.0.0.1/tcp/1500", "/ip4/8.8.8.8/tcp/1501")
+
+	ctx := &grpc.Context{Context: context.Background(), PeerID: newPeer()}
+	resp, err := srv.discovery.FindPeers(ctx, &proto.FindPeersReq{Count: maxFindPeers})
+	assert.NoError(t, err)
+
+	assert.Len(t, resp.Nodes, 1)
+	assert.Equal(t, "/ip4/8.8.8.8/tcp/1501/p2p/"+valid.String(), resp.Nodes[0])
+}
+
+func TestDiscovery_UnreachablePeer(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	assert.NoError(t, err)
+
+	id, err := peer.IDFromPublicKey(pub)
+	assert.NoError(t, err)
+
+	// the discovery keeps running if the peer cannot be dialed
+	srv.discovery.peersLock.Lock()
+	srv.discovery.peers = append(srv.discovery.peers, id)
+	srv.discovery.peersLock.Unlock()
+
+	_, err = srv.discovery.routingTable.TryAddPeer(id, false, false)
+	assert.NoError(t, err)
+
+	assert.Error(t, srv.discovery.call(id))
+	srv.discovery.handleDiscovery()
+
+	assert.True(t, srv.IsListening())
+}
+
+func TestDiscovery_DisconnectedPeerRemoved(t *testing.T) {
+	srv0 := CreateServer(t, nil)
+	srv1 := CreateServer(t, nil)
+	defer srv0.Close()
+	defer srv1.Close()
+
+	MultiJoin(t, srv0, srv1)
+	time.Sleep(1 * time.Second)
+
+	id1 := srv1.AddrInfo().ID
+	assert.NotEmpty(t, srv0.discovery.routingTable.Find(id1))
+
+	srv0.discovery.peersLock.Lock()
+	assert.Equal(t, []peer.ID{id1}, srv0.discovery.peers)
+	srv0.discovery.peersLock.Unlock()
+
+	disconnectedCh := asyncWaitForEvent(srv0, 10*time.Second, disconnectedPeerHandler(id1))
+	srv1.Disconnect(srv0.AddrInfo().ID, "bye")
+	assert.True(t, <-disconnectedCh)
+	time.Sleep(500 * time.Millisecond)
+
+	// the peer is not a discovery candidate anymore
+	assert.Empty(t, srv0.discovery.routingTable.Find(id1))
+
+	srv0.discovery.peersLock.Lock()
+	assert.Empty(t, srv0.discovery.peers)
+	srv0.discovery.peersLock.Unlock()
+}
+
+func TestDiscovery_Bootnodes(t *testing.T) {
+	_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	assert.NoError(t, err)
+
+	id, err := peer.IDFromPublicKey(pub)
+	assert.NoError(t, err)
+
+	bootnode := "/ip4/127.0.0.1/tcp/1500/p2p/" + id.String()
+
+	// the invalid bootnodes are skipped. Without slots, the bootnode is never dialed
+	srv := CreateServer(t, func(c *Config) {
+		c.MaxPeers = 0
+		c.Chain.Bootnodes = []string{
+			"enode://8499da03c47d637b20eee24eec3c356c9a2e6148d6fe25ca195c7949ab8ec2c03e3556126b0d7ed644675e78c4318b08691b7b57de10e5f0d40d05b09238fa0a@52.187.207.27:30303",
+			bootnode,
+		}
+	})
+	defer srv.Close()
+
+	srv.dialQueue.lock.Lock()
+	task, ok := srv.dialQueue.items[id]
+	srv.dialQueue.lock.Unlock()
+
+	assert.True(t, ok)
+	assert.Equal(t, bootnode, AddrInfoToStrings(task.addr)[0])
+	assert.Len(t, srv.discovery.bootnodes, 1)
+}
@@ -0,0 +1,65 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+)
+
+// dnsResolver resolves the dns multiaddrs of the peers
+// (i.e. /dns4/<host>/tcp/<port> or /dnsaddr/<host>)
+var dnsResolver = madns.DefaultResolver
+
+// dnsResolveTimeout is the time to resolve a single dns multiaddr
+const dnsResolveTimeout = 10 * time.Second
+
+// resolveAddrs replaces the dns addresses of the peer with the addresses they
+// resolve to. The addresses are resolved on every dial so that the peer is
+// still reachable after its ip changes. It returns an error only if there
+// are no addresses left to dial
+func resolveAddrs(info *peer.AddrInfo) (*peer.AddrInfo, error) {
+	resolved := &peer.AddrInfo{
+		ID: info.ID,
+	}
+
+	var lastErr error
+	for _, addr := range info.Addrs {
+		if !madns.Matches(addr) {
+			resolved.Addrs = append(resolved.Addrs, addr)
+			continue
+		}
+
+		addrs, err := resolveAddr(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, raddr := range addrs {
+			// the dnsaddr records include the id of the peer
+			transport, id := peer.SplitAddr(raddr)
+			if id != "" && id != info.ID {
+				continue
+			}
+			resolved.Addrs = append(resolved.Addrs, transport)
+		}
+	}
+
+	if len(resolved.Addrs) == 0 {
+		if lastErr != nil {
+			return nil, fmt.Errorf("failed to resolve the addresses of %s: %v", info.ID, lastErr)
+		}
+		return nil, fmt.Errorf("no addresses resolved for %s", info.ID)
+	}
+	return resolved, nil
+}
+
+func resolveAddr(addr multiaddr.Multiaddr) ([]multiaddr.Multiaddr, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), dnsResolveTimeout)
+	defer cancelFn()
+
+	return dnsResolver.Resolve(ctx, addr)
+}
@@ -0,0 +1,99 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func setStubResolver(t *testing.T, backend *madns.MockBackend) {
+	dnsResolver = &madns.Resolver{Backend: backend}
+	t.Cleanup(func() {
+		dnsResolver = madns.DefaultResolver
+	})
+}
+
+func newTestPeerID(t *testing.T) peer.ID {
+	key, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	assert.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(key)
+	assert.NoError(t, err)
+	return id
+}
+
+func TestResolveAddrs(t *testing.T) {
+	id := newTestPeerID(t)
+
+	setStubResolver(t, &madns.MockBackend{
+		IP: map[string][]net.IPAddr{
+			"node.test": {{IP: net.ParseIP("127.0.0.1")}},
+		},
+		TXT: map[string][]string{
+			"_dnsaddr.nodes.test": {
+				"dnsaddr=/ip4/127.0.0.2/tcp/1478/p2p/" + id.String(),
+				"dnsaddr=/ip4/127.0.0.3/tcp/1478/p2p/" + newTestPeerID(t).String(),
+			},
+		},
+	})
+
+	resolve := func(addrs ...string) ([]string, error) {
+		info := &peer.AddrInfo{ID: id}
+		for _, addr := range addrs {
+			info.Addrs = append(info.Addrs, multiaddr.StringCast(addr))
+		}
+		resolved, err := resolveAddrs(info)
+		if err != nil {
+			return nil, err
+		}
+		res := []string{}
+		for _, addr := range resolved.Addrs {
+			res = append(res, addr.String())
+		}
+		return res, nil
+	}
+
+	// dns4
+	addrs, err := resolve("/dns4/node.test/tcp/1478")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/ip4/127.0.0.1/tcp/1478"}, addrs)
+
+	// dnsaddr only includes the records of the peer
+	addrs, err = resolve("/dnsaddr/nodes.test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/ip4/127.0.0.2/tcp/1478"}, addrs)
+
+	// the other addresses are kept as is
+	addrs, err = resolve("/ip4/127.0.0.4/tcp/1478", "/dns4/unknown.test/tcp/1478")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/ip4/127.0.0.4/tcp/1478"}, addrs)
+
+	// nothing to dial
+	_, err = resolve("/dns4/unknown.test/tcp/1478")
+	assert.Error(t, err)
+}
+
+func TestJoinAddr_DNS(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	setStubResolver(t, &madns.MockBackend{
+		IP: map[string][]net.IPAddr{
+			"bootnode.test": {{IP: net.ParseIP("127.0.0.1")}},
+		},
+	})
+
+	addr := fmt.Sprintf("/dns4/bootnode.test/tcp/%d/p2p/%s", srv1.config.Addr.Port, srv1.AddrInfo().ID)
+	assert.NoError(t, srv0.JoinAddr(context.Background(), addr, 5*time.Second))
+	assert.Equal(t, int64(1), srv0.PeerCount())
+}
@@ -0,0 +1,71 @@
+package network
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/control"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// connGater is a libp2p connection gater that rejects
+// the inbound and outbound connections of the banned peers
+type connGater struct {
+	lock   sync.RWMutex
+	banned map[peer.ID]struct{}
+}
+
+func newConnGater() *connGater {
+	return &connGater{
+		banned: map[peer.ID]struct{}{},
+	}
+}
+
+func (g *connGater) ban(id peer.ID) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.banned[id] = struct{}{}
+}
+
+func (g *connGater) unban(id peer.ID) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	delete(g.banned, id)
+}
+
+func (g *connGater) isBanned(id peer.ID) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	_, ok := g.banned[id]
+	return ok
+}
+
+// InterceptPeerDial implements the connmgr.ConnectionGater interface
+func (g *connGater) InterceptPeerDial(id peer.ID) bool {
+	return !g.isBanned(id)
+}
+
+// InterceptAddrDial implements the connmgr.ConnectionGater interface
+func (g *connGater) InterceptAddrDial(id peer.ID, addr multiaddr.Multiaddr) bool {
+	return !g.isBanned(id)
+}
+
+// InterceptAccept implements the connmgr.ConnectionGater interface. The
+// remote peer is not known yet, it is checked once the connection is secured
+func (g *connGater) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+// InterceptSecured implements the connmgr.ConnectionGater interface
+func (g *connGater) InterceptSecured(dir network.Direction, id peer.ID, addrs network.ConnMultiaddrs) bool {
+	return !g.isBanned(id)
+}
+
+// InterceptUpgraded implements the connmgr.ConnectionGater interface
+func (g *connGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
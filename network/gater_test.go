@@ -0,0 +1,43 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnGater_BanPeer(t *testing.T) {
+	srv0 := CreateServer(t, nil)
+	srv1 := CreateServer(t, nil)
+	defer srv0.Close()
+	defer srv1.Close()
+
+	MultiJoin(t, srv0, srv1)
+	time.Sleep(1 * time.Second)
+
+	id1 := srv1.AddrInfo().ID
+	assert.NotEmpty(t, srv0.discovery.routingTable.Find(id1))
+
+	// the banned peer is disconnected and removed from the discovery
+	disconnectedCh0 := asyncWaitForEvent(srv0, 10*time.Second, disconnectedPeerHandler(id1))
+	disconnectedCh1 := asyncWaitForEvent(srv1, 10*time.Second, disconnectedPeerHandler(srv0.AddrInfo().ID))
+	srv0.BanPeer(id1)
+	assert.True(t, <-disconnectedCh0)
+	assert.True(t, <-disconnectedCh1)
+
+	assert.True(t, srv0.IsBanned(id1))
+	assert.Empty(t, srv0.discovery.routingTable.Find(id1))
+	assert.NotContains(t, srv0.discovery.peers, id1)
+
+	// both outbound and inbound connections are refused
+	assert.Error(t, srv0.host.Connect(context.Background(), *srv1.AddrInfo()))
+	assert.Error(t, srv1.host.Connect(context.Background(), *srv0.AddrInfo()))
+	assert.False(t, srv0.isConnected(id1))
+
+	// once unbanned, the peer can connect again
+	srv0.UnbanPeer(id1)
+	assert.False(t, srv0.IsBanned(id1))
+	assert.NoError(t, srv0.host.Connect(context.Background(), *srv1.AddrInfo()))
+}
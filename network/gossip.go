@@ -2,31 +2,83 @@ package network
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 )
 
+// ErrNoGossip is returned when creating a topic with the gossip protocol disabled
+var ErrNoGossip = errors.New("gossip is disabled")
+
+// DefaultMaxTopicMsgSize is the maximum size (in bytes) of a message in a topic
+var DefaultMaxTopicMsgSize = 512 * 1024
+
+// Topic is a gossip topic. The messages are encoded with protobuf if the
+// type of the topic is a proto message and with json otherwise. A topic
+// without a type hands the raw bytes of the messages to the subscribers
 type Topic struct {
 	logger hclog.Logger
 
 	topic   *pubsub.Topic
 	typ     reflect.Type
 	closeCh chan struct{}
+
+	// maxSize is the maximum size (in bytes) of a message
+	maxSize int
 }
 
-func (t *Topic) createObj() proto.Message {
-	return reflect.New(t.typ).Interface().(proto.Message)
+func (t *Topic) createObj() interface{} {
+	return reflect.New(t.typ).Interface()
 }
 
-func (t *Topic) Publish(obj proto.Message) error {
-	data, err := proto.Marshal(obj)
+func (t *Topic) marshal(obj interface{}) ([]byte, error) {
+	if msg, ok := obj.(proto.Message); ok {
+		return proto.Marshal(msg)
+	}
+	return json.Marshal(obj)
+}
+
+func (t *Topic) unmarshal(data []byte, obj interface{}) error {
+	if msg, ok := obj.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+	return json.Unmarshal(data, obj)
+}
+
+// validate is the default validator of the topic, the message must
+// be within the size limit and decode into the type of the topic
+func (t *Topic) validate(from peer.ID, data []byte) (interface{}, error) {
+	if len(data) > t.maxSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds the maximum of %d bytes", len(data), t.maxSize)
+	}
+	if t.typ == nil {
+		return data, nil
+	}
+	obj := t.createObj()
+	if err := t.unmarshal(data, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Publish encodes the object and publishes it in the topic
+func (t *Topic) Publish(obj interface{}) error {
+	data, err := t.marshal(obj)
 	if err != nil {
 		return err
 	}
 
+	return t.PublishRaw(data)
+}
+
+// PublishRaw publishes the data in the topic as is
+func (t *Topic) PublishRaw(data []byte) error {
 	return t.topic.Publish(context.Background(), data)
 }
 
@@ -55,26 +107,98 @@ func (t *Topic) readLoop(sub *pubsub.Subscription, handler func(obj interface{})
 			continue
 		}
 
-		obj := t.createObj()
-		if err := proto.Unmarshal(msg.Data, obj); err != nil {
-			t.logger.Error("failed to unmarshal topic", "err", err)
-			continue
-		}
-		handler(obj)
+		// the message is decoded by the validator
+		handler(msg.ValidatorData)
 	}
 }
 
-func (s *Server) NewTopic(protoID string, obj proto.Message) (*Topic, error) {
-	topic, err := s.ps.Join(protoID)
+// IsGossipEnabled returns true if the gossip protocol is running
+func (s *Server) IsGossipEnabled() bool {
+	return s.ps != nil
+}
+
+// NewTopic joins the topic with messages of the type of obj, which must be a
+// pointer. Joining the same topic again returns the existing topic
+func (s *Server) NewTopic(protoID string, obj interface{}) (*Topic, error) {
+	return s.joinTopic(protoID, reflect.TypeOf(obj).Elem())
+}
+
+// SubscribeTopic joins the topic and returns it. The subscribers receive the raw
+// bytes of the messages unless the topic was created before with NewTopic
+func (s *Server) SubscribeTopic(protoID string) (*Topic, error) {
+	return s.joinTopic(protoID, nil)
+}
+
+// PublishTopic publishes the data in the topic, joining the topic if needed
+func (s *Server) PublishTopic(protoID string, data []byte) error {
+	topic, err := s.joinTopic(protoID, nil)
+	if err != nil {
+		return err
+	}
+	return topic.PublishRaw(data)
+}
+
+func (s *Server) joinTopic(protoID string, typ reflect.Type) (*Topic, error) {
+	if !s.IsGossipEnabled() {
+		return nil, ErrNoGossip
+	}
+
+	s.topicsLock.Lock()
+	defer s.topicsLock.Unlock()
+
+	if tt, ok := s.topics[protoID]; ok {
+		if typ != nil && tt.typ != typ {
+			return nil, fmt.Errorf("topic %s already joined with type %v", protoID, tt.typ)
+		}
+		return tt, nil
+	}
+
+	topic, err := s.ps.Join(s.protocolID(protoID))
 	if err != nil {
 		return nil, err
 	}
 
 	tt := &Topic{
-		logger: s.logger.Named(protoID),
-		topic:  topic,
-		typ:    reflect.TypeOf(obj).Elem(),
+		logger:  s.logger.Named(protoID),
+		topic:   topic,
+		typ:     typ,
+		maxSize: DefaultMaxTopicMsgSize,
+	}
+
+	if err := s.RegisterTopicValidator(protoID, tt.validate); err != nil {
+		return nil, err
 	}
 
+	s.topics[protoID] = tt
 	return tt, nil
 }
+
+// TopicValidator validates the data of a message in a topic. It returns the
+// decoded message that is handed to the subscribers or an error if it is invalid
+type TopicValidator func(from peer.ID, data []byte) (interface{}, error)
+
+// RegisterTopicValidator sets the validator of the topic. The invalid messages
+// are dropped before they are delivered or forwarded and the peer that sent them
+// is penalized. A topic created with NewTopic already has a default validator
+func (s *Server) RegisterTopicValidator(protoID string, validator TopicValidator) error {
+	if !s.IsGossipEnabled() {
+		return ErrNoGossip
+	}
+
+	return s.ps.RegisterTopicValidator(s.protocolID(protoID), func(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		return s.validateTopicMsg(protoID, validator, from, msg)
+	})
+}
+
+func (s *Server) validateTopicMsg(protoID string, validator TopicValidator, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	obj, err := validator(from, msg.Data)
+	if err != nil {
+		s.logger.Debug("invalid gossip message", "topic", protoID, "from", from, "err", err)
+		if from != s.host.ID() {
+			s.PenalizePeer(from, InvalidMessagePenalty)
+		}
+		return pubsub.ValidationReject
+	}
+	msg.ValidatorData = obj
+	return pubsub.ValidationAccept
+}
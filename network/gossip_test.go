@@ -1,10 +1,15 @@
 package network
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
 	testproto "github.com/0xPolygon/minimal/network/proto/test"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,3 +43,142 @@ func TestGossip(t *testing.T) {
 		t.Fatal("timeout")
 	}
 }
+
+func TestGossip_Disabled(t *testing.T) {
+	srv := CreateServer(t, func(c *Config) {
+		c.NoGossip = true
+	})
+	defer srv.Close()
+
+	assert.False(t, srv.IsGossipEnabled())
+
+	_, err := srv.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.Equal(t, ErrNoGossip, err)
+}
+
+func TestGossip_Validator(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	topic, err := srv.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+	topic.maxSize = 16
+
+	msgCh := make(chan *testproto.AReq, 10)
+	topic.Subscribe(func(obj interface{}) {
+		msgCh <- obj.(*testproto.AReq)
+	})
+
+	// the local messages are validated too, only the valid one is delivered
+	assert.NoError(t, topic.Publish(&testproto.AReq{Msg: strings.Repeat("a", 32)}))
+	assert.NoError(t, topic.topic.Publish(context.Background(), []byte{0xff}))
+	assert.NoError(t, topic.Publish(&testproto.AReq{Msg: "a"}))
+
+	select {
+	case msg := <-msgCh:
+		assert.Equal(t, "a", msg.Msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	select {
+	case msg := <-msgCh:
+		t.Fatalf("unexpected message %s", msg.Msg)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestGossip_ValidatorPenalizesPeer(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	topic, err := srv.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+
+	from := peer.ID("remote")
+	validate := func(data []byte) pubsub.ValidationResult {
+		msg := &pubsub.Message{Message: &pb.Message{Data: data}}
+		return srv.validateTopicMsg("topic/0.1", topic.validate, from, msg)
+	}
+
+	assert.Equal(t, pubsub.ValidationAccept, validate([]byte{}))
+	assert.Equal(t, int64(0), srv.PeerScore(from))
+
+	assert.Equal(t, pubsub.ValidationReject, validate([]byte{0xff}))
+	assert.Equal(t, -InvalidMessagePenalty, srv.PeerScore(from))
+}
+
+func TestGossip_PublishSubscribe(t *testing.T) {
+	srv0 := CreateServer(t, nil)
+	srv1 := CreateServer(t, nil)
+
+	MultiJoin(t, srv0, srv1)
+
+	topic1, err := srv1.SubscribeTopic("topic/0.1")
+	assert.NoError(t, err)
+
+	msgCh := make(chan []byte, 10)
+	assert.NoError(t, topic1.Subscribe(func(obj interface{}) {
+		msgCh <- obj.([]byte)
+	}))
+
+	// publish until the subscription of srv1 reaches srv0
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, srv0.PublishTopic("topic/0.1", []byte("a")))
+
+		select {
+		case msg := <-msgCh:
+			assert.Equal(t, []byte("a"), msg)
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	t.Fatal("timeout")
+}
+
+func TestGossip_JSONTopic(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	type jsonMsg struct {
+		Msg string
+	}
+
+	topic, err := srv.NewTopic("topic/0.1", &jsonMsg{})
+	assert.NoError(t, err)
+
+	msgCh := make(chan *jsonMsg, 10)
+	assert.NoError(t, topic.Subscribe(func(obj interface{}) {
+		msgCh <- obj.(*jsonMsg)
+	}))
+
+	assert.NoError(t, topic.Publish(&jsonMsg{Msg: "a"}))
+
+	select {
+	case msg := <-msgCh:
+		assert.Equal(t, "a", msg.Msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestGossip_JoinTopicTwice(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	topic0, err := srv.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+
+	// joining again returns the same topic
+	topic1, err := srv.NewTopic("topic/0.1", &testproto.AReq{})
+	assert.NoError(t, err)
+	assert.Equal(t, topic0, topic1)
+
+	topic2, err := srv.SubscribeTopic("topic/0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, topic0, topic2)
+
+	// the topic cannot change its type
+	_, err = srv.NewTopic("topic/0.1", &testproto.AResp{})
+	assert.Error(t, err)
+}
@@ -17,6 +17,13 @@ import (
 
 var identityProtoV1 = "/id/0.1"
 
+// HandshakeVersion is the latest version of the handshake supported by the node
+const HandshakeVersion uint64 = 1
+
+// legacyHandshakeVersion is the version assumed for the peers
+// that do not advertise the handshake versions they support
+const legacyHandshakeVersion uint64 = 1
+
 type identity struct {
 	proto.UnimplementedIdentityServer
 
@@ -57,54 +64,91 @@ func (i *identity) setup() {
 	grpc.Serve()
 
 	i.srv.Register(identityProtoV1, grpc)
+}
+
+// connected starts the handshake with a new connection, the peer
+// is added to the server once the handshake completes
+func (i *identity) connected(conn network.Conn) {
+	peerID := conn.RemotePeer()
+	i.srv.logger.Trace("Conn", "peer", peerID, "direction", conn.Stat().Direction)
+
+	// limit by MaxPeers on incomming requests since we already limit
+	// the outgoing requests
+	if conn.Stat().Direction == network.DirInbound {
+		if i.isPending(peerID) {
+			// handshake has already started
+			return
+		}
+		if i.srv.numOpenSlots() == 0 && !i.srv.IsStaticPeer(peerID) {
+			i.srv.Disconnect(peerID, "no available slots")
+			return
+		}
+	}
 
-	// register callback messages to notify from new peers
-	i.srv.host.Network().Notify(&network.NotifyBundle{
-		ConnectedF: func(net network.Network, conn network.Conn) {
-			peerID := conn.RemotePeer()
-			i.srv.logger.Trace("Conn", "peer", peerID, "direction", conn.Stat().Direction)
-
-			// limit by MaxPeers on incomming requests since we already limit
-			// the outgoing requests
-			if conn.Stat().Direction == network.DirInbound {
-				if i.isPending(peerID) {
-					// handshake has already started
-					return
-				}
-				if i.srv.numOpenSlots() == 0 {
-					i.srv.Disconnect(peerID, "no available slots")
-					return
-				}
+	// pending of handshake
+	i.setPending(peerID)
+
+	go func() {
+		defer func() {
+			if i.isPending(peerID) {
+				i.delPending(peerID)
+				i.srv.emitEvent(&PeerEvent{
+					PeerID: peerID,
+					Type:   PeerEventDialCompleted,
+				})
 			}
+		}()
 
-			// pending of handshake
-			i.setPending(peerID)
-
-			go func() {
-				defer func() {
-					if i.isPending(peerID) {
-						i.delPending(peerID)
-						i.srv.emitEvent(&PeerEvent{
-							PeerID: peerID,
-							Type:   PeerEventDialCompleted,
-						})
-					}
-				}()
-
-				if err := i.handleConnected(peerID); err != nil {
-					i.srv.Disconnect(peerID, err.Error())
-				}
-			}()
-		},
-	})
+		if err := i.handleConnected(peerID); err != nil {
+			if err != errPeerDisconnected {
+				i.srv.PenalizePeer(peerID, HandshakeFailurePenalty)
+				i.srv.Disconnect(peerID, err.Error())
+			}
+			i.srv.emitEvent(&PeerEvent{
+				PeerID: peerID,
+				Type:   PeerEventConnectedFailed,
+			})
+		}
+	}()
 }
 
 func (i *identity) getStatus() *proto.Status {
+	minVersion, maxVersion := i.srv.config.MinHandshakeVersion, i.srv.config.MaxHandshakeVersion
+	if maxVersion == 0 {
+		minVersion, maxVersion = HandshakeVersion, HandshakeVersion
+	}
 	return &proto.Status{
-		Chain: int64(i.srv.config.Chain.Params.ChainID),
+		Chain:      int64(i.srv.config.Chain.Params.ChainID),
+		MinVersion: minVersion,
+		MaxVersion: maxVersion,
 	}
 }
 
+// negotiateVersion returns the highest handshake version supported by both
+// the local and the remote status or an error if there is none
+func negotiateVersion(local, remote *proto.Status) (uint64, error) {
+	remoteMin, remoteMax := remote.MinVersion, remote.MaxVersion
+	if remoteMax == 0 {
+		// the peer does not know about versions
+		remoteMin, remoteMax = legacyHandshakeVersion, legacyHandshakeVersion
+	}
+
+	min, max := local.MinVersion, local.MaxVersion
+	if remoteMin > min {
+		min = remoteMin
+	}
+	if remoteMax < max {
+		max = remoteMax
+	}
+	if min > max {
+		return 0, fmt.Errorf(
+			"incompatible handshake versions: local [%d, %d], remote [%d, %d]",
+			local.MinVersion, local.MaxVersion, remoteMin, remoteMax,
+		)
+	}
+	return max, nil
+}
+
 func (i *identity) handleConnected(peerID peer.ID) error {
 	// we initiated the connection, now we perform the handshake
 	conn, err := i.srv.NewProtoStream(identityProtoV1, peerID)
@@ -123,9 +167,12 @@ func (i *identity) handleConnected(peerID peer.ID) error {
 	if status.Chain != resp.Chain {
 		return fmt.Errorf("incorrect chain id")
 	}
+	version, err := negotiateVersion(status, resp)
+	if err != nil {
+		return err
+	}
 
-	i.srv.addPeer(peerID)
-	return nil
+	return i.srv.addPeer(peerID, version)
 }
 
 func (i *identity) Hello(ctx context.Context, req *proto.Status) (*proto.Status, error) {
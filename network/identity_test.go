@@ -3,6 +3,9 @@ package network
 import (
 	"testing"
 	"time"
+
+	"github.com/0xPolygon/minimal/network/proto"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGrpcStream(t *testing.T) {
@@ -18,3 +21,54 @@ func TestGrpcStream(t *testing.T) {
 }
 
 // Test: Connect maxPeers
+
+func TestHandshakeVersion_Negotiate(t *testing.T) {
+	versions := func(min, max uint64) func(c *Config) {
+		return func(c *Config) {
+			c.NoDiscover = true
+			c.MinHandshakeVersion = min
+			c.MaxHandshakeVersion = max
+		}
+	}
+
+	// overlapping ranges use the highest common version
+	srv0 := CreateServer(t, versions(1, 3))
+	srv1 := CreateServer(t, versions(2, 4))
+	defer srv0.Close()
+	defer srv1.Close()
+
+	MultiJoin(t, srv0, srv1)
+
+	// wait for both handshakes to complete
+	time.Sleep(500 * time.Millisecond)
+
+	for _, srv := range []*Server{srv0, srv1} {
+		peers := srv.Peers()
+		assert.Len(t, peers, 1)
+		assert.Equal(t, uint64(3), peers[0].Version)
+	}
+
+	// non overlapping ranges refuse the connection
+	srv2 := CreateServer(t, versions(1, 1))
+	srv3 := CreateServer(t, versions(2, 2))
+	defer srv2.Close()
+	defer srv3.Close()
+
+	srv2.Join(srv3.AddrInfo(), 2*time.Second)
+	time.Sleep(500 * time.Millisecond)
+
+	assert.Len(t, srv2.Peers(), 0)
+	assert.Len(t, srv3.Peers(), 0)
+}
+
+func TestHandshakeVersion_Legacy(t *testing.T) {
+	local := &proto.Status{MinVersion: 1, MaxVersion: 2}
+
+	// peers without versions are assumed to run the first one
+	version, err := negotiateVersion(local, &proto.Status{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), version)
+
+	_, err = negotiateVersion(&proto.Status{MinVersion: 2, MaxVersion: 2}, &proto.Status{})
+	assert.Error(t, err)
+}
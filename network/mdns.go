@@ -0,0 +1,61 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	peerstore "github.com/libp2p/go-libp2p-peerstore"
+	mdnsDiscovery "github.com/libp2p/go-libp2p/p2p/discovery"
+)
+
+// mdnsInterval is the interval between the queries for local peers
+const mdnsInterval = 10 * time.Second
+
+// mdnsServiceTag is the mDNS service of the nodes of a chain so that
+// nodes from different chains do not find each other
+func mdnsServiceTag(chainID int) string {
+	return fmt.Sprintf("_polygon-%d._udp", chainID)
+}
+
+// mdns finds the peers in the local network with mDNS. It coexists
+// with the discovery protocol, the peers found are included in the
+// routing table (if any) or in the dial queue
+type mdns struct {
+	srv     *Server
+	service mdnsDiscovery.Service
+}
+
+func (m *mdns) setup() error {
+	service, err := mdnsDiscovery.NewMdnsService(context.Background(), m.srv.host, mdnsInterval, mdnsServiceTag(m.srv.config.Chain.Params.ChainID))
+	if err != nil {
+		return err
+	}
+	service.RegisterNotifee(m)
+	m.service = service
+
+	return nil
+}
+
+func (m *mdns) close() error {
+	return m.service.Close()
+}
+
+// HandlePeerFound implements the mdns Notifee interface
+func (m *mdns) HandlePeerFound(info peer.AddrInfo) {
+	if info.ID == m.srv.host.ID() || m.srv.isConnected(info.ID) || m.srv.IsBanned(info.ID) {
+		return
+	}
+	m.srv.logger.Debug("found local peer", "id", info.ID, "addrs", info.Addrs)
+
+	m.srv.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.AddressTTL)
+
+	if m.srv.discovery != nil {
+		// the routing table queues the peer for dialing once included
+		if added, err := m.srv.discovery.routingTable.TryAddPeer(info.ID, false, false); err != nil || added {
+			return
+		}
+	}
+	m.srv.dialQueue.add(&info, 10)
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMDNS_HandlePeerFound(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	defer srv0.Close()
+	defer srv1.Close()
+
+	// a peer found in the local network is dialed
+	m := &mdns{srv: srv0}
+	m.HandlePeerFound(*srv1.AddrInfo())
+
+	assert.Eventually(t, func() bool {
+		return srv0.PeerCount() == 1 && srv1.PeerCount() == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// the node itself is skipped
+	m.HandlePeerFound(*srv0.AddrInfo())
+	assert.Equal(t, int64(1), srv0.PeerCount())
+}
+
+func TestMDNS_Discover(t *testing.T) {
+	conf := func(c *Config) {
+		c.EnableMDNS = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	defer srv0.Close()
+	defer srv1.Close()
+
+	// the nodes find each other without a join
+	assert.Eventually(t, func() bool {
+		return srv0.PeerCount() == 1 && srv1.PeerCount() == 1
+	}, 15*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,144 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var PeerStoreName = "peers.json"
+
+const (
+	// maxStoredPeers is the maximum number of peers saved in the peer store
+	maxStoredPeers = 128
+
+	// maxPeerFailures is the number of consecutive failed connections
+	// after which a peer is removed from the peer store
+	maxPeerFailures = 3
+)
+
+// storedPeer is a peer saved in the peer store
+type storedPeer struct {
+	// Addrs are the dialable addresses of the peer
+	Addrs []string `json:"addrs"`
+
+	// Failures is the number of consecutive failed connections
+	Failures uint64 `json:"failures"`
+}
+
+// peerStore saves the addresses of the connected peers in the data directory
+// so that they can be dialed again after a restart
+type peerStore struct {
+	path string
+
+	lock  sync.Mutex
+	peers map[string]*storedPeer
+}
+
+// newPeerStore loads the peer store from the data directory. If the
+// file does not exist, the peer store starts empty
+func newPeerStore(dataDir string) (*peerStore, error) {
+	p := &peerStore{
+		path:  filepath.Join(dataDir, PeerStoreName),
+		peers: map[string]*storedPeer{},
+	}
+
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p.peers); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", p.path, err)
+	}
+	return p, nil
+}
+
+// list returns the address info of the stored peers
+func (p *peerStore) list() []*peer.AddrInfo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	infos := []*peer.AddrInfo{}
+	for _, stored := range p.peers {
+		info, err := StringsToAddrInfo(stored.Addrs)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// connected saves the peer and resets its failures. A new peer
+// is not saved if the peer store is full
+func (p *peerStore) connected(info *peer.AddrInfo) error {
+	addrs := AddrInfoToStrings(info)
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	id := info.ID.String()
+	if _, ok := p.peers[id]; !ok && len(p.peers) >= maxStoredPeers {
+		return nil
+	}
+	p.peers[id] = &storedPeer{
+		Addrs: addrs,
+	}
+	return p.save()
+}
+
+// failed counts a failed connection to the peer. The peer is removed
+// after maxPeerFailures consecutive failures
+func (p *peerStore) failed(id peer.ID) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	stored, ok := p.peers[id.String()]
+	if !ok {
+		return nil
+	}
+	stored.Failures++
+	if stored.Failures >= maxPeerFailures {
+		delete(p.peers, id.String())
+	}
+	return p.save()
+}
+
+// del removes the peer from the peer store
+func (p *peerStore) del(id peer.ID) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.peers[id.String()]; !ok {
+		return nil
+	}
+	delete(p.peers, id.String())
+	return p.save()
+}
+
+// save writes the peer store to disk, the caller must hold the lock
+func (p *peerStore) save() error {
+	data, err := json.Marshal(p.peers)
+	if err != nil {
+		return err
+	}
+
+	// write on a temporary file first so that the store
+	// is not corrupted if the node stops while writing
+	tmpPath := p.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, p.path)
+}
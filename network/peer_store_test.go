@@ -0,0 +1,130 @@
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStoredPeer(t *testing.T, port int) *peer.AddrInfo {
+	_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	assert.NoError(t, err)
+
+	id, err := peer.IDFromPublicKey(pub)
+	assert.NoError(t, err)
+
+	addr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", port))
+	assert.NoError(t, err)
+
+	return &peer.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{addr}}
+}
+
+func TestPeerStore_Reload(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("/tmp", "libp2p-peerstore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := newPeerStore(tmpDir)
+	assert.NoError(t, err)
+	assert.Empty(t, store.list())
+
+	info := newStoredPeer(t, 1500)
+	assert.NoError(t, store.connected(info))
+
+	// a fresh server with the same data directory queues the saved
+	// peer for dialing. Without slots, the peer is never dialed
+	srv := CreateServer(t, func(c *Config) {
+		c.DataDir = tmpDir
+		c.MaxPeers = 0
+	})
+	defer srv.Close()
+
+	srv.dialQueue.lock.Lock()
+	task, ok := srv.dialQueue.items[info.ID]
+	srv.dialQueue.lock.Unlock()
+
+	assert.True(t, ok)
+	assert.Equal(t, info, task.addr)
+	assert.Equal(t, info.Addrs, srv.host.Peerstore().Addrs(info.ID))
+}
+
+func TestPeerStore_SaveConnectedPeers(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("/tmp", "libp2p-peerstore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	srv0 := CreateServer(t, func(c *Config) {
+		c.DataDir = tmpDir
+	})
+	srv1 := CreateServer(t, nil)
+	defer srv1.Close()
+
+	MultiJoin(t, srv0, srv1)
+
+	// the peer is saved once it is connected
+	var infos []*peer.AddrInfo
+	for i := 0; i < 20; i++ {
+		store, err := newPeerStore(tmpDir)
+		assert.NoError(t, err)
+
+		if infos = store.list(); len(infos) != 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Len(t, infos, 1)
+	assert.Equal(t, srv1.AddrInfo().ID, infos[0].ID)
+	assert.NoError(t, srv0.Close())
+}
+
+func TestPeerStore_PruneFailedPeers(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("/tmp", "libp2p-peerstore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := newPeerStore(tmpDir)
+	assert.NoError(t, err)
+
+	info := newStoredPeer(t, 1500)
+	assert.NoError(t, store.connected(info))
+
+	// a connection resets the failures
+	for i := 0; i < maxPeerFailures-1; i++ {
+		assert.NoError(t, store.failed(info.ID))
+	}
+	assert.NoError(t, store.connected(info))
+
+	for i := 0; i < maxPeerFailures-1; i++ {
+		assert.NoError(t, store.failed(info.ID))
+	}
+	assert.Len(t, store.list(), 1)
+
+	// the peer is removed after too many failures
+	assert.NoError(t, store.failed(info.ID))
+	assert.Empty(t, store.list())
+
+	store, err = newPeerStore(tmpDir)
+	assert.NoError(t, err)
+	assert.Empty(t, store.list())
+}
+
+func TestPeerStore_MaxPeers(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("/tmp", "libp2p-peerstore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store, err := newPeerStore(tmpDir)
+	assert.NoError(t, err)
+
+	for i := 0; i < maxStoredPeers+1; i++ {
+		assert.NoError(t, store.connected(newStoredPeer(t, 1500+i)))
+	}
+	assert.Len(t, store.list(), maxStoredPeers)
+}
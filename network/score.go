@@ -0,0 +1,132 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Penalties lowered from the score of a peer
+const (
+	// DialFailurePenalty is the penalty of a peer that cannot be dialed
+	DialFailurePenalty int64 = 1
+
+	// HandshakeFailurePenalty is the penalty of a peer that fails the handshake
+	HandshakeFailurePenalty int64 = 2
+
+	// SyncFailurePenalty is the penalty of a peer that serves invalid blocks
+	SyncFailurePenalty int64 = 5
+
+	// InvalidMessagePenalty is the penalty of a peer that gossips an invalid message
+	InvalidMessagePenalty int64 = 2
+)
+
+const (
+	// minDialScore is the score below which a peer is not dialed
+	minDialScore int64 = -10
+
+	// scoreRecoveryInterval is the time it takes to recover one point of score
+	scoreRecoveryInterval = 1 * time.Minute
+)
+
+// lowScoreDialBackoff returns the time it takes to a peer with
+// the given score to recover up to the minimum dial score
+func lowScoreDialBackoff(score int64) time.Duration {
+	if score >= minDialScore {
+		return 0
+	}
+	return time.Duration(minDialScore-score) * scoreRecoveryInterval
+}
+
+// peerScore is the score of a peer. The score starts at zero and it
+// recovers with time up to zero again
+type peerScore struct {
+	score int64
+	last  time.Time
+}
+
+// peerScores tracks the score of the peers
+type peerScores struct {
+	lock   sync.Mutex
+	scores map[peer.ID]*peerScore
+
+	// now returns the current time, it can be replaced for testing
+	now func() time.Time
+}
+
+func newPeerScores() *peerScores {
+	return &peerScores{
+		scores: map[peer.ID]*peerScore{},
+		now:    time.Now,
+	}
+}
+
+// current returns the score after the recovery, the caller must hold the lock
+func (p *peerScores) current(id peer.ID) int64 {
+	s, ok := p.scores[id]
+	if !ok {
+		return 0
+	}
+	now := p.now()
+	if recovered := int64(now.Sub(s.last) / scoreRecoveryInterval); recovered > 0 {
+		s.score += recovered
+		s.last = s.last.Add(time.Duration(recovered) * scoreRecoveryInterval)
+	}
+	if s.score >= 0 {
+		delete(p.scores, id)
+		return 0
+	}
+	return s.score
+}
+
+func (p *peerScores) get(id peer.ID) int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.current(id)
+}
+
+func (p *peerScores) penalize(id peer.ID, penalty int64) int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	score := p.current(id) - penalty
+	if s, ok := p.scores[id]; ok {
+		s.score = score
+	} else {
+		p.scores[id] = &peerScore{score: score, last: p.now()}
+	}
+	return score
+}
+
+func (p *peerScores) list() map[peer.ID]int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	scores := map[peer.ID]int64{}
+	for id := range p.scores {
+		if score := p.current(id); score != 0 {
+			scores[id] = score
+		}
+	}
+	return scores
+}
+
+// PeerScore returns the score of the peer. A peer starts with a score of zero, the
+// score is lowered with every penalty and it recovers one point every minute
+func (s *Server) PeerScore(id peer.ID) int64 {
+	return s.scores.get(id)
+}
+
+// PeerScores returns the scores of the peers with a penalty
+func (s *Server) PeerScores() map[peer.ID]int64 {
+	return s.scores.list()
+}
+
+// PenalizePeer lowers the score of the peer. A peer with a score
+// below the minimum is not dialed until its score recovers
+func (s *Server) PenalizePeer(id peer.ID, penalty int64) {
+	score := s.scores.penalize(id, penalty)
+	s.logger.Debug("penalize peer", "id", id, "penalty", penalty, "score", score)
+}
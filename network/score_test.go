@@ -0,0 +1,150 @@
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerScores(t *testing.T) {
+	scores := newPeerScores()
+
+	now := time.Now()
+	scores.now = func() time.Time {
+		return now
+	}
+
+	id := peer.ID("a")
+	assert.Equal(t, int64(0), scores.get(id))
+
+	assert.Equal(t, int64(-2), scores.penalize(id, 2))
+	assert.Equal(t, int64(-7), scores.penalize(id, 5))
+	assert.Equal(t, map[peer.ID]int64{id: -7}, scores.list())
+
+	// the score recovers one point per interval
+	now = now.Add(3*scoreRecoveryInterval + scoreRecoveryInterval/2)
+	assert.Equal(t, int64(-4), scores.get(id))
+
+	now = now.Add(scoreRecoveryInterval / 2)
+	assert.Equal(t, int64(-3), scores.get(id))
+
+	// up to zero
+	now = now.Add(10 * scoreRecoveryInterval)
+	assert.Equal(t, int64(0), scores.get(id))
+	assert.Empty(t, scores.list())
+}
+
+func TestDialQueue_Score(t *testing.T) {
+	scores := newPeerScores()
+
+	q := newDialQueue()
+	q.score = scores.get
+
+	scores.penalize(peer.ID("a"), 5)
+	scores.penalize(peer.ID("b"), 1)
+
+	for _, id := range []string{"a", "b", "c"} {
+		q.add(&peer.AddrInfo{ID: peer.ID(id)}, 10)
+	}
+	// a lower priority goes first regardless of the score
+	q.add(&peer.AddrInfo{ID: peer.ID("d")}, 1)
+	scores.penalize(peer.ID("d"), 10)
+
+	for _, id := range []string{"d", "c", "b", "a"} {
+		assert.Equal(t, peer.ID(id), q.popImpl().addr.ID)
+	}
+	assert.Nil(t, q.popImpl())
+}
+
+func TestPeerScores_SkipLowScorePeers(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	defer srv0.Close()
+	defer srv1.Close()
+
+	// a peer below the minimum score is not dialed, the join
+	// fails without waiting for the timeout
+	srv0.PenalizePeer(srv1.AddrInfo().ID, -minDialScore+1)
+
+	start := time.Now()
+	assert.Error(t, srv0.Join(srv1.AddrInfo(), 10*time.Second))
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.False(t, srv0.isConnected(srv1.AddrInfo().ID))
+
+	// a peer that fails to dial is penalized
+	srv2 := CreateServer(t, conf)
+	info := srv2.AddrInfo()
+	assert.NoError(t, srv2.Close())
+
+	assert.Error(t, srv0.Join(info, 2*time.Second))
+	assert.Less(t, srv0.PeerScore(info.ID), int64(0))
+}
+
+func TestPeerScores_SkipKeepsStoredPeer(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("/tmp", "libp2p-peerstore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	srv0 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+		c.DataDir = tmpDir
+	})
+	srv1 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+	})
+	defer srv0.Close()
+	defer srv1.Close()
+
+	assert.NoError(t, srv0.peerStore.connected(srv1.AddrInfo()))
+	srv0.PenalizePeer(srv1.AddrInfo().ID, -minDialScore+1)
+
+	// the skipped dials fail the joins but they are not failed connections
+	for i := 0; i < maxPeerFailures+1; i++ {
+		err := srv0.Join(srv1.AddrInfo(), 5*time.Second)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "skipped")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Len(t, srv0.peerStore.list(), 1)
+}
+
+func TestLowScoreDialBackoff(t *testing.T) {
+	assert.Equal(t, time.Duration(0), lowScoreDialBackoff(minDialScore))
+	assert.Equal(t, scoreRecoveryInterval, lowScoreDialBackoff(minDialScore-1))
+	assert.Equal(t, 5*scoreRecoveryInterval, lowScoreDialBackoff(minDialScore-5))
+}
+
+func TestRequeueDial(t *testing.T) {
+	srv := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+	})
+	defer srv.Close()
+
+	failedCh := make(chan struct{}, 1)
+	assert.NoError(t, srv.SubscribeFn(func(evnt *PeerEvent) {
+		if evnt.Type == PeerEventConnectedFailed && evnt.PeerID == peer.ID("a") {
+			select {
+			case failedCh <- struct{}{}:
+			default:
+			}
+		}
+	}))
+
+	// the task is dialed again after the wait
+	task := &dialTask{addr: &peer.AddrInfo{ID: peer.ID("a")}, priority: 10}
+	srv.requeueDial(task, 50*time.Millisecond)
+
+	select {
+	case <-failedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the dial was not queued again")
+	}
+}
@@ -2,9 +2,11 @@ package network
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xPolygon/minimal/chain"
@@ -19,24 +21,60 @@ import (
 	peerstore "github.com/libp2p/go-libp2p-peerstore"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr-net"
 )
 
 const DefaultLibp2pPort int = 1478
 
+const DefaultDialConcurrency uint64 = 5
+
 type Config struct {
 	NoDiscover bool
+	NoGossip   bool
 	Addr       *net.TCPAddr
 	NatAddr    net.IP
 	DataDir    string
 	MaxPeers   uint64
 	Chain      *chain.Chain
+
+	// DialConcurrency is the maximum number of dials in progress at the same time
+	DialConcurrency uint64
+
+	// MinHandshakeVersion and MaxHandshakeVersion are the range of handshake
+	// versions supported. The highest version supported by both peers is used
+	MinHandshakeVersion uint64
+	MaxHandshakeVersion uint64
+
+	// JoinRetries is the number of times a failed join is attempted again
+	JoinRetries uint64
+
+	// JoinBackoff is the wait before the first retry of a join,
+	// it doubles with every retry
+	JoinBackoff time.Duration
+
+	// StaticPeers are the multiaddrs of the peers that are always
+	// kept connected and do not count against MaxPeers
+	StaticPeers []string
+
+	// EnableMDNS finds the peers in the local network with mDNS
+	EnableMDNS bool
+
+	// FindPeersTimeout is the time given to a peer to answer a find
+	// peers call of the discovery. The default is used if zero
+	FindPeersTimeout time.Duration
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		NoDiscover: false,
-		Addr:       &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultLibp2pPort},
-		MaxPeers:   10,
+		NoDiscover:      false,
+		Addr:            &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: DefaultLibp2pPort},
+		MaxPeers:        10,
+		DialConcurrency: DefaultDialConcurrency,
+
+		MinHandshakeVersion: HandshakeVersion,
+		MaxHandshakeVersion: HandshakeVersion,
+
+		JoinBackoff: DefaultJoinBackoff,
 	}
 }
 
@@ -54,14 +92,30 @@ type Server struct {
 
 	dialQueue *dialQueue
 
+	// gater rejects the connections of the banned peers
+	gater *connGater
+
+	scores *peerScores
+
+	staticPeers     map[peer.ID]*staticPeer
+	staticPeersLock sync.Mutex
+	staticPeersCh   chan struct{}
+
+	// peerStore saves the connected peers, it is only
+	// set if the server has a data directory
+	peerStore *peerStore
+
 	identity  *identity
 	discovery *discovery
+	mdns      *mdns
 
 	protocols     map[string]Protocol
 	protocolsLock sync.Mutex
 
 	// pubsub
-	ps *pubsub.PubSub
+	ps         *pubsub.PubSub
+	topics     map[string]*Topic
+	topicsLock sync.Mutex
 
 	joinWatchers     map[peer.ID]chan error
 	joinWatchersLock sync.Mutex
@@ -73,6 +127,9 @@ type Peer struct {
 	srv *Server
 
 	Info peer.AddrInfo
+
+	// Version is the handshake version negotiated with the peer
+	Version uint64
 }
 
 func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
@@ -100,6 +157,8 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		return addrs
 	}
 
+	gater := newConnGater()
+
 	host, err := libp2p.New(
 		context.Background(),
 		// Use noise as the encryption protocol
@@ -107,6 +166,7 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		libp2p.ListenAddrs(listenAddr),
 		libp2p.AddrsFactory(addrsFactory),
 		libp2p.Identity(key),
+		libp2p.ConnectionGater(gater),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create libp2p stack: %v", err)
@@ -124,63 +184,126 @@ func NewServer(logger hclog.Logger, config *Config) (*Server, error) {
 		addrs:            host.Addrs(),
 		peers:            map[peer.ID]*Peer{},
 		dialQueue:        newDialQueue(),
+		gater:            gater,
+		scores:           newPeerScores(),
+		staticPeers:      map[peer.ID]*staticPeer{},
+		staticPeersCh:    make(chan struct{}, 1),
 		closeCh:          make(chan struct{}),
 		emitterPeerEvent: emitter,
 		protocols:        map[string]Protocol{},
+		topics:           map[string]*Topic{},
 	}
 
+	srv.dialQueue.score = srv.PeerScore
+
 	// start identity
 	srv.identity = &identity{srv: srv}
 	srv.identity.setup()
 
+	srv.setupDisconnect()
+
+	// keep the peers in sync with the connections of the host
+	host.Network().Notify(srv)
+
+	if config.DataDir != "" {
+		if err := srv.setupPeerStore(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, raw := range config.StaticPeers {
+		if err := srv.AddStaticPeer(raw); err != nil {
+			return nil, fmt.Errorf("failed to parse static peer %s: %v", raw, err)
+		}
+	}
+
 	go srv.runDial()
 
-	logger.Info("LibP2P server running", "addr", AddrInfoToString(srv.AddrInfo()))
+	logger.Info("LibP2P server running", "addrs", AddrInfoToStrings(srv.AddrInfo()))
 
 	if !config.NoDiscover {
 		// start discovery
 		srv.discovery = &discovery{srv: srv}
 		srv.discovery.setup()
 
-		// try to decode the bootnodes
+		// try to decode the bootnodes, the invalid ones are skipped
 		bootnodes := []*peer.AddrInfo{}
 		for _, raw := range config.Chain.Bootnodes {
 			node, err := StringToAddrInfo(raw)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse bootnode %s: %v", raw, err)
+				logger.Error("failed to parse bootnode", "addr", raw, "err", err)
+				continue
 			}
-			// add the bootnode to the peerstore
-			srv.host.Peerstore().AddAddr(node.ID, node.Addrs[0], peerstore.AddressTTL)
+			// add the bootnode to the peerstore and dial it right away
+			srv.host.Peerstore().AddAddrs(node.ID, node.Addrs, peerstore.AddressTTL)
+			srv.dialQueue.add(node, 10)
+
 			bootnodes = append(bootnodes, node)
 		}
 
 		srv.discovery.setBootnodes(bootnodes)
 	}
 
-	// start gossip protocol
-	ps, err := pubsub.NewGossipSub(context.Background(), host)
-	if err != nil {
-		return nil, err
+	if config.EnableMDNS {
+		srv.mdns = &mdns{srv: srv}
+		if err := srv.mdns.setup(); err != nil {
+			return nil, fmt.Errorf("failed to start mdns: %v", err)
+		}
+	}
+
+	if !config.NoGossip {
+		// start gossip protocol
+		ps, err := pubsub.NewGossipSub(context.Background(), host)
+		if err != nil {
+			return nil, err
+		}
+		srv.ps = ps
 	}
-	srv.ps = ps
 
 	go srv.runJoinWatcher()
 
-	// watch for disconnected peers
-	host.Network().Notify(&network.NotifyBundle{
-		DisconnectedF: func(net network.Network, conn network.Conn) {
-			go func() {
-				srv.delPeer(conn.RemotePeer())
-			}()
-		},
-	})
+	go srv.runStaticPeers()
 
 	return srv, nil
 }
 
+// setupPeerStore queues the saved peers for dialing and keeps
+// the peer store updated with the result of the connections
+func (s *Server) setupPeerStore() error {
+	store, err := newPeerStore(s.config.DataDir)
+	if err != nil {
+		return err
+	}
+	s.peerStore = store
+
+	for _, info := range store.list() {
+		s.logger.Debug("dial saved peer", "id", info.ID)
+
+		s.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.AddressTTL)
+		s.dialQueue.add(info, 10)
+	}
+
+	return s.SubscribeFn(func(evnt *PeerEvent) {
+		var err error
+		switch evnt.Type {
+		case PeerEventConnected:
+			info := s.host.Peerstore().PeerInfo(evnt.PeerID)
+			err = store.connected(&info)
+		case PeerEventConnectedFailed:
+			err = store.failed(evnt.PeerID)
+		default:
+			return
+		}
+		if err != nil {
+			s.logger.Error("failed to update the peer store", "err", err)
+		}
+	})
+}
+
 func (s *Server) runDial() {
-	// watch for events of peers included or removed
-	notifyCh := make(chan struct{})
+	// watch for events of peers included or removed. The channel is buffered
+	// so that a wake up is not lost while the loop is busy dialing
+	notifyCh := make(chan struct{}, 1)
 	err := s.SubscribeFn(func(evnt *PeerEvent) {
 		switch evnt.Type {
 		case PeerEventConnected, PeerEventConnectedFailed, PeerEventDisconnected, PeerEventDialCompleted:
@@ -197,18 +320,47 @@ func (s *Server) runDial() {
 		s.logger.Error("dial manager failed to subscribe", "err", err)
 	}
 
+	concurrency := s.config.DialConcurrency
+	if concurrency == 0 {
+		concurrency = DefaultDialConcurrency
+	}
+
+	// dialSlots bounds the number of dials in progress
+	dialSlots := make(chan struct{}, concurrency)
+
+	// number of dials in progress that do not count yet as open slots
+	var inflight int64
+
 	for {
-		slots := s.numOpenSlots()
+		slots := s.numOpenSlots() - atomic.LoadInt64(&inflight)
+		s.logger.Trace("dial slots", "max_peers", s.config.MaxPeers, "num_peers", s.PeerCount(), "pending", s.identity.numPending(), "slots", slots)
 
-		// TODO: Right now the dial task are done sequentially because Connect
-		// is a blocking request. In the future we should try to make up to
-		// maxDials requests concurrently.
 		for i := int64(0); i < slots; i++ {
+			// wait for a free dial slot
+			select {
+			case dialSlots <- struct{}{}:
+			case <-s.closeCh:
+				return
+			}
+
 			tt := s.dialQueue.pop()
 			if tt == nil {
 				// dial closed
 				return
 			}
+			if score := s.PeerScore(tt.addr.ID); score < minDialScore {
+				// skip the peer until its score recovers, the joins waiting
+				// for it fail and the dial is queued again after the backoff
+				backoff := lowScoreDialBackoff(score)
+				s.logger.Debug("skip dial of low score peer", "addr", tt.addr.String(), "score", score, "backoff", backoff)
+				s.emitEvent(&PeerEvent{
+					PeerID: tt.addr.ID,
+					Type:   PeerEventDialSkipped,
+				})
+				s.requeueDial(tt, backoff)
+				<-dialSlots
+				continue
+			}
 			s.logger.Debug("dial", "local", s.host.ID(), "addr", tt.addr.String())
 
 			if s.isConnected(tt.addr.ID) {
@@ -218,13 +370,46 @@ func (s *Server) runDial() {
 					PeerID: tt.addr.ID,
 					Type:   PeerEventDialConnectedNode,
 				})
-			} else {
+				<-dialSlots
+				continue
+			}
+
+			atomic.AddInt64(&inflight, 1)
+			go func(addr *peer.AddrInfo) {
+				defer func() {
+					atomic.AddInt64(&inflight, -1)
+					<-dialSlots
+
+					// wake up the loop since the dial slot is free again
+					select {
+					case notifyCh <- struct{}{}:
+					default:
+					}
+				}()
+
+				// a failed resolution is not the fault of the peer, the
+				// addresses are resolved again on the next dial
+				resolved, err := resolveAddrs(addr)
+				if err != nil {
+					s.logger.Debug("failed to resolve", "addr", addr.String(), "err", err)
+					s.emitEvent(&PeerEvent{
+						PeerID: addr.ID,
+						Type:   PeerEventConnectedFailed,
+					})
+					return
+				}
+
 				// the connection process is async because it involves connection (here) +
 				// the handshake done in the identity service.
-				if err := s.host.Connect(context.Background(), *tt.addr); err != nil {
-					s.logger.Trace("failed to dial", "addr", tt.addr.String(), "err", err)
+				if err := s.host.Connect(context.Background(), *resolved); err != nil {
+					s.logger.Trace("failed to dial", "addr", addr.String(), "err", err)
+					s.PenalizePeer(addr.ID, DialFailurePenalty)
+					s.emitEvent(&PeerEvent{
+						PeerID: addr.ID,
+						Type:   PeerEventConnectedFailed,
+					})
 				}
-			}
+			}(tt.addr)
 		}
 
 		// wait until there is a change in the state of a peer that
@@ -237,11 +422,35 @@ func (s *Server) runDial() {
 	}
 }
 
+// requeueDial adds the dial task back to the queue after the wait
+func (s *Server) requeueDial(tt *dialTask, wait time.Duration) {
+	go func() {
+		select {
+		case <-time.After(wait):
+			s.dialQueue.add(tt.addr, tt.priority)
+		case <-s.closeCh:
+		}
+	}()
+}
+
 // PeerEventDialConnectedNode
 func (s *Server) numPeers() int64 {
 	return int64(len(s.peers))
 }
 
+// PeerCount returns the number of connected peers
+func (s *Server) PeerCount() int64 {
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+
+	return s.numPeers()
+}
+
+// IsListening returns true if the host is accepting connections
+func (s *Server) IsListening() bool {
+	return len(s.host.Network().ListenAddresses()) != 0
+}
+
 func (s *Server) Peers() []*Peer {
 	s.peersLock.Lock()
 	defer s.peersLock.Unlock()
@@ -253,8 +462,10 @@ func (s *Server) Peers() []*Peer {
 	return peers
 }
 
+// numOpenSlots returns the number of peers that can still be connected.
+// The static peers do not take any slot
 func (s *Server) numOpenSlots() int64 {
-	n := int64(s.config.MaxPeers) - (s.numPeers() + s.identity.numPending())
+	n := int64(s.config.MaxPeers) - (s.numPeers() - s.numStaticPeers() + s.identity.numPending())
 	if n < 0 {
 		n = 0
 	}
@@ -273,15 +484,58 @@ func (s *Server) GetPeerInfo(peerID peer.ID) peer.AddrInfo {
 	return s.host.Peerstore().PeerInfo(peerID)
 }
 
-func (s *Server) addPeer(id peer.ID) {
-	s.logger.Info("Peer connected", "id", id.String())
+var _ network.Notifiee = &Server{}
+
+// Connected implements the network.Notifiee interface. The peer
+// is added once the identity handshake completes
+func (s *Server) Connected(net network.Network, conn network.Conn) {
+	s.identity.connected(conn)
+}
 
+// Disconnected implements the network.Notifiee interface
+func (s *Server) Disconnected(net network.Network, conn network.Conn) {
+	go func() {
+		// the peer might still have other open connections
+		if s.isConnected(conn.RemotePeer()) {
+			return
+		}
+		s.delPeer(conn.RemotePeer())
+	}()
+}
+
+// Listen implements the network.Notifiee interface
+func (s *Server) Listen(network.Network, multiaddr.Multiaddr) {}
+
+// ListenClose implements the network.Notifiee interface
+func (s *Server) ListenClose(network.Network, multiaddr.Multiaddr) {}
+
+// OpenedStream implements the network.Notifiee interface
+func (s *Server) OpenedStream(network.Network, network.Stream) {}
+
+// ClosedStream implements the network.Notifiee interface
+func (s *Server) ClosedStream(network.Network, network.Stream) {}
+
+// errPeerDisconnected is returned when the connection with a
+// peer is closed before the peer is added
+var errPeerDisconnected = errors.New("peer disconnected")
+
+// addPeer adds a peer after a successful handshake and emits the connected event
+func (s *Server) addPeer(id peer.ID, version uint64) error {
 	s.peersLock.Lock()
 	defer s.peersLock.Unlock()
 
+	// the connection might have been closed during the handshake, in which
+	// case the disconnection has already been handled
+	if !s.isConnected(id) {
+		return errPeerDisconnected
+	}
+
+	s.logger.Info("Peer connected", "id", id.String())
+
 	p := &Peer{
-		srv:  s,
-		Info: s.host.Peerstore().PeerInfo(id),
+		srv:     s,
+		Info:    s.host.Peerstore().PeerInfo(id),
+		Version: version,
 	}
 	s.peers[id] = p
 
@@ -289,8 +543,10 @@ func (s *Server) addPeer(id peer.ID) {
 		PeerID: id,
 		Type:   PeerEventConnected,
 	})
+	return nil
 }
 
+// delPeer removes the peer and emits the disconnected event
 func (s *Server) delPeer(id peer.ID) {
 	s.logger.Info("Peer disconnected", "id", id.String())
 
@@ -305,11 +561,58 @@ func (s *Server) delPeer(id peer.ID) {
 	})
 }
 
+// Disconnect closes the connection with the peer. The reason is sent to the
+// peer first, the call does not wait for it to be delivered
 func (s *Server) Disconnect(peer peer.ID, reason string) {
-	if s.host.Network().Connectedness(peer) == network.Connected {
-		// send some close message
+	if s.host.Network().Connectedness(peer) != network.Connected {
+		return
+	}
+
+	s.logger.Debug("Disconnect peer", "id", peer, "reason", reason)
+
+	go func() {
+		if err := s.sendDisconnect(peer, reason); err != nil {
+			s.logger.Debug("failed to send the disconnect reason", "id", peer, "err", err)
+		}
 		s.host.Network().ClosePeer(peer)
+	}()
+}
+
+// BanPeer disconnects the peer and rejects any further connection
+// from or to it. The peer is removed from the routing table, the
+// dial queue and the peer store. A static peer is not redialed while banned
+func (s *Server) BanPeer(id peer.ID) {
+	s.logger.Info("Ban peer", "id", id.String())
+
+	s.gater.ban(id)
+
+	s.dialQueue.del(id)
+	if s.discovery != nil {
+		s.discovery.delPeer(id)
 	}
+	if s.peerStore != nil {
+		if err := s.peerStore.del(id); err != nil {
+			s.logger.Error("failed to update the peer store", "err", err)
+		}
+	}
+
+	s.Disconnect(id, "banned")
+}
+
+// UnbanPeer allows again the connections from and to the peer
+func (s *Server) UnbanPeer(id peer.ID) {
+	s.logger.Info("Unban peer", "id", id.String())
+
+	s.gater.unban(id)
+
+	if s.IsStaticPeer(id) {
+		s.notifyStaticPeers()
+	}
+}
+
+// IsBanned returns true if the peer is banned
+func (s *Server) IsBanned(id peer.ID) bool {
+	return s.gater.isBanned(id)
 }
 
 func (s *Server) waitForEvent(timeout time.Duration, handler func(evnt *PeerEvent) bool) bool {
@@ -349,31 +652,58 @@ func (s *Server) waitForEvent(timeout time.Duration, handler func(evnt *PeerEven
 
 var DefaultJoinTimeout = 10 * time.Second
 
-func (s *Server) JoinAddr(addr string, timeout time.Duration) error {
-	addr0, err := multiaddr.NewMultiaddr(addr)
-	if err != nil {
-		return err
-	}
-	addr1, err := peer.AddrInfoFromP2pAddr(addr0)
+var DefaultJoinBackoff = 1 * time.Second
+
+// JoinAddr joins the peer with the given multiaddr. The context
+// cancels the wait for the connection
+func (s *Server) JoinAddr(ctx context.Context, addr string, timeout time.Duration) error {
+	addr1, err := StringToAddrInfo(addr)
 	if err != nil {
 		return err
 	}
-	return s.Join(addr1, timeout)
+	return s.join(ctx, addr1, timeout)
 }
 
 func (s *Server) Join(addr *peer.AddrInfo, timeout time.Duration) error {
+	return s.join(context.Background(), addr, timeout)
+}
+
+// join dials the peer and waits for the handshake. A failed attempt is retried
+// up to JoinRetries times. Without a timeout, the join does not wait for the
+// result and it is not retried
+func (s *Server) join(ctx context.Context, addr *peer.AddrInfo, timeout time.Duration) error {
 	s.logger.Info("Join request", "addr", addr.String())
-	s.dialQueue.add(addr, 1)
 
 	if timeout == 0 {
+		s.dialQueue.add(addr, 1)
 		return nil
 	}
-	err := s.watch(addr.ID, timeout)
-	return err
+
+	backoff := s.config.JoinBackoff
+	for attempt := uint64(0); ; attempt++ {
+		s.dialQueue.add(addr, 1)
+
+		err := s.watch(ctx, addr.ID, timeout)
+		if err == nil || ctx.Err() != nil || attempt == s.config.JoinRetries {
+			return err
+		}
+		s.logger.Debug("join failed, retrying", "addr", addr.String(), "attempt", attempt+1, "backoff", backoff, "err", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.closeCh:
+			return err
+		}
+		backoff *= 2
+	}
 }
 
-func (s *Server) watch(peerID peer.ID, dur time.Duration) error {
-	ch := make(chan error)
+func (s *Server) watch(ctx context.Context, peerID peer.ID, dur time.Duration) error {
+	// the channel is buffered so that the watcher does not block
+	// if the wait is over
+	ch := make(chan error, 1)
 
 	s.joinWatchersLock.Lock()
 	if s.joinWatchers == nil {
@@ -382,13 +712,21 @@ func (s *Server) watch(peerID peer.ID, dur time.Duration) error {
 	s.joinWatchers[peerID] = ch
 	s.joinWatchersLock.Unlock()
 
-	select {
-	case <-time.After(dur):
+	removeWatcher := func() {
 		s.joinWatchersLock.Lock()
 		delete(s.joinWatchers, peerID)
 		s.joinWatchersLock.Unlock()
+	}
+
+	select {
+	case <-time.After(dur):
+		removeWatcher()
 
 		return fmt.Errorf("timeout %s %s", s.host.ID(), peerID)
+	case <-ctx.Done():
+		removeWatcher()
+
+		return ctx.Err()
 	case err := <-ch:
 		return err
 	}
@@ -396,8 +734,15 @@ func (s *Server) watch(peerID peer.ID, dur time.Duration) error {
 
 func (s *Server) runJoinWatcher() error {
 	return s.SubscribeFn(func(evnt *PeerEvent) {
-		// only concerned about 'PeerEventConnected' and 'PeerEventConnectedFailed'
-		if evnt.Type != PeerEventConnected && evnt.Type != PeerEventConnectedFailed && evnt.Type != PeerEventDialConnectedNode {
+		// only concerned about the events that end a dial
+		var err error
+		switch evnt.Type {
+		case PeerEventConnected, PeerEventDialConnectedNode:
+		case PeerEventConnectedFailed:
+			err = fmt.Errorf("failed to connect %s %s", s.host.ID(), evnt.PeerID)
+		case PeerEventDialSkipped:
+			err = fmt.Errorf("dial to %s skipped, the peer score is too low", evnt.PeerID)
+		default:
 			return
 		}
 
@@ -405,7 +750,7 @@ func (s *Server) runJoinWatcher() error {
 		s.joinWatchersLock.Lock()
 		errCh, ok := s.joinWatchers[evnt.PeerID]
 		if ok {
-			errCh <- nil
+			errCh <- err
 			delete(s.joinWatchers, evnt.PeerID)
 		}
 		s.joinWatchersLock.Unlock()
@@ -413,6 +758,12 @@ func (s *Server) runJoinWatcher() error {
 }
 
 func (s *Server) Close() error {
+	if s.mdns != nil {
+		if err := s.mdns.close(); err != nil {
+			s.logger.Error("failed to close mdns", "err", err)
+		}
+	}
+
 	err := s.host.Close()
 	s.dialQueue.Close()
 	close(s.closeCh)
@@ -436,7 +787,14 @@ func (s *Server) NewProtoStream(proto string, id peer.ID) (interface{}, error) {
 }
 
 func (s *Server) NewStream(proto string, id peer.ID) (network.Stream, error) {
-	return s.host.NewStream(context.Background(), id, protocol.ID(proto))
+	return s.host.NewStream(context.Background(), id, protocol.ID(s.protocolID(proto)))
+}
+
+// protocolID prefixes the protocol with the chain id so that
+// nodes from different chains do not have any protocol in common
+// (i.e. /polygon/<chainID>/disc/0.1)
+func (s *Server) protocolID(proto string) string {
+	return fmt.Sprintf("/polygon/%d%s", s.config.Chain.Params.ChainID, proto)
 }
 
 type Protocol interface {
@@ -452,7 +810,7 @@ func (s *Server) Register(id string, p Protocol) {
 }
 
 func (s *Server) wrapStream(id string, handle func(network.Stream)) {
-	s.host.SetStreamHandler(protocol.ID(id), func(stream network.Stream) {
+	s.host.SetStreamHandler(protocol.ID(s.protocolID(id)), func(stream network.Stream) {
 		peerID := stream.Conn().RemotePeer()
 		s.logger.Trace("open stream", "protocol", id, "peer", peerID)
 
@@ -584,12 +942,54 @@ func StringToAddrInfo(addr string) (*peer.AddrInfo, error) {
 	return addr1, nil
 }
 
-// AddrInfoToString converts an AddrInfo into a string representation that can be dialed from another node
-func AddrInfoToString(addr *peer.AddrInfo) string {
-	if len(addr.Addrs) != 1 {
-		panic("Not supported")
+// StringsToAddrInfo merges the dialable addresses of a single node into one AddrInfo.
+// It is the inverse of AddrInfoToStrings
+func StringsToAddrInfo(addrs []string) (*peer.AddrInfo, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses")
+	}
+	maddrs := []multiaddr.Multiaddr{}
+	for _, addr := range addrs {
+		maddr, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		maddrs = append(maddrs, maddr)
+	}
+	infos, err := peer.AddrInfosFromP2pAddrs(maddrs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) != 1 {
+		return nil, fmt.Errorf("expected the addresses of one peer but found %d peers", len(infos))
+	}
+	return &infos[0], nil
+}
+
+// AddrInfoToString converts an AddrInfo into a string representation that can be dialed from another node.
+// If the node has more than one address, the first public one is preferred
+func AddrInfoToString(addr *peer.AddrInfo) (string, error) {
+	if len(addr.Addrs) == 0 {
+		return "", fmt.Errorf("no addresses for peer %s", addr.ID)
+	}
+	preferred := addr.Addrs[0]
+	for _, maddr := range addr.Addrs {
+		if manet.IsPublicAddr(maddr) {
+			preferred = maddr
+			break
+		}
+	}
+	return preferred.String() + "/p2p/" + addr.ID.String(), nil
+}
+
+// AddrInfoToStrings converts each address of an AddrInfo into a string representation
+// that can be dialed from another node
+func AddrInfoToStrings(addr *peer.AddrInfo) []string {
+	addrs := []string{}
+	for _, maddr := range addr.Addrs {
+		addrs = append(addrs, maddr.String()+"/p2p/"+addr.ID.String())
 	}
-	return addr.Addrs[0].String() + "/p2p/" + addr.ID.String()
+	return addrs
 }
 
 type PeerConnectedEvent struct {
@@ -607,6 +1007,14 @@ const (
 	PeerEventDisconnected      = "PeerDisconnected"
 	PeerEventDialConnectedNode = "PeerDialConnectedNode"
 	PeerEventDialCompleted     = "PeerDialCompleted"
+
+	// PeerEventDialSkipped is emitted when a queued dial is not attempted
+	// because the score of the peer is too low. It is not a failed connection
+	PeerEventDialSkipped = "PeerDialSkipped"
+
+	// PeerEventDisconnectReason is emitted when a peer closes the
+	// connection, the Desc of the event is the reason sent by the peer
+	PeerEventDisconnectReason = "PeerDisconnectReason"
 )
 
 type PeerEvent struct {
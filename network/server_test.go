@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"testing"
@@ -85,6 +86,50 @@ func TestPeersLifecycle(t *testing.T) {
 	assert.True(t, <-disconnectedCh1)
 }
 
+func TestPeerCount(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+	srv2 := CreateServer(t, conf)
+
+	assert.True(t, srv0.IsListening())
+	assert.Equal(t, int64(0), srv0.PeerCount())
+
+	MultiJoin(t, srv0, srv1, srv0, srv2)
+
+	assert.Equal(t, int64(2), srv0.PeerCount())
+}
+
+func TestPeers_ConsistentWithConnections(t *testing.T) {
+	conf := func(c *Config) {
+		c.NoDiscover = true
+	}
+	srv0 := CreateServer(t, conf)
+	srv1 := CreateServer(t, conf)
+
+	MultiJoin(t, srv0, srv1)
+
+	assert.Equal(t, int64(1), srv0.PeerCount())
+	assert.Equal(t, int64(1), srv1.PeerCount())
+
+	// close the connection in libp2p without going through Disconnect
+	disconnectedCh := asyncWaitForEvent(srv0, 5*time.Second, disconnectedPeerHandler(srv1.AddrInfo().ID))
+	assert.NoError(t, srv0.host.Network().ClosePeer(srv1.AddrInfo().ID))
+	assert.True(t, <-disconnectedCh)
+
+	assert.Equal(t, int64(0), srv0.PeerCount())
+	assert.Eventually(t, func() bool {
+		return srv1.PeerCount() == 0
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// a handshake that completes after the connection
+	// is closed does not add the peer
+	assert.Equal(t, errPeerDisconnected, srv0.addPeer(srv1.AddrInfo().ID, HandshakeVersion))
+	assert.Equal(t, int64(0), srv0.PeerCount())
+}
+
 func asyncWaitForEvent(s *Server, timeout time.Duration, handler func(*PeerEvent) bool) <-chan bool {
 	resCh := make(chan bool, 1)
 	go func(ch chan<- bool) {
@@ -146,10 +191,79 @@ func TestEncodingPeerAddr(t *testing.T) {
 		Addrs: []multiaddr.Multiaddr{addr},
 	}
 
-	str := AddrInfoToString(info)
+	str, err := AddrInfoToString(info)
+	assert.NoError(t, err)
+
 	info2, err := StringToAddrInfo(str)
 	assert.NoError(t, err)
 	assert.Equal(t, info, info2)
+
+	// an AddrInfo without addresses cannot be encoded
+	_, err = AddrInfoToString(&peer.AddrInfo{ID: id})
+	assert.Error(t, err)
+	assert.Empty(t, AddrInfoToStrings(&peer.AddrInfo{ID: id}))
+
+	_, err = StringsToAddrInfo([]string{})
+	assert.Error(t, err)
+}
+
+func TestEncodingPeerAddr_MultipleAddrs(t *testing.T) {
+	_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	assert.NoError(t, err)
+
+	id, err := peer.IDFromPublicKey(pub)
+	assert.NoError(t, err)
+
+	info := &peer.AddrInfo{ID: id}
+	for _, raw := range []string{"/ip4/127.0.0.1/tcp/90", "/ip4/192.168.1.10/tcp/90", "/ip4/8.8.8.8/tcp/90"} {
+		addr, err := multiaddr.NewMultiaddr(raw)
+		assert.NoError(t, err)
+		info.Addrs = append(info.Addrs, addr)
+	}
+
+	// one dialable string per address
+	strs := AddrInfoToStrings(info)
+	assert.Len(t, strs, 3)
+
+	for indx, str := range strs {
+		info2, err := StringToAddrInfo(str)
+		assert.NoError(t, err)
+		assert.Equal(t, id, info2.ID)
+		assert.Equal(t, []multiaddr.Multiaddr{info.Addrs[indx]}, info2.Addrs)
+	}
+
+	info2, err := StringsToAddrInfo(strs)
+	assert.NoError(t, err)
+	assert.Equal(t, info, info2)
+
+	// the public address is preferred
+	str, err := AddrInfoToString(info)
+	assert.NoError(t, err)
+	assert.Equal(t, "/ip4/8.8.8.8/tcp/90/p2p/"+id.String(), str)
+
+	// the addresses of different peers are not merged
+	_, pub2, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+	assert.NoError(t, err)
+
+	id2, err := peer.IDFromPublicKey(pub2)
+	assert.NoError(t, err)
+
+	_, err = StringsToAddrInfo([]string{strs[0], "/ip4/127.0.0.1/tcp/91/p2p/" + id2.String()})
+	assert.Error(t, err)
+}
+
+func TestJoinAddr(t *testing.T) {
+	srv0 := CreateServer(t, nil)
+	srv1 := CreateServer(t, nil)
+	defer srv0.Close()
+	defer srv1.Close()
+
+	// the server can join the address it emits
+	addr, err := AddrInfoToString(srv1.AddrInfo())
+	assert.NoError(t, err)
+
+	assert.NoError(t, srv0.JoinAddr(context.Background(), addr, DefaultJoinTimeout))
+	assert.Equal(t, int64(1), srv0.PeerCount())
 }
 
 func TestJoinWhenAlreadyConnected(t *testing.T) {
@@ -162,6 +276,37 @@ func TestJoinWhenAlreadyConnected(t *testing.T) {
 	assert.NoError(t, srv1.Join(srv0.AddrInfo(), DefaultJoinTimeout))
 }
 
+func TestJoin_Retry(t *testing.T) {
+	conf := func(c *Config) {
+		c.MaxPeers = 1
+		c.NoDiscover = true
+	}
+
+	srv0 := CreateServer(t, func(c *Config) {
+		conf(c)
+		c.JoinRetries = 2
+		c.JoinBackoff = 2 * time.Second
+	})
+	srv1 := CreateServer(t, conf)
+	srv2 := CreateServer(t, conf)
+
+	// srv1 has no slots left so the first attempt of srv0 fails
+	assert.NoError(t, srv2.Join(srv1.AddrInfo(), 5*time.Second))
+
+	failedCh := asyncWaitForEvent(srv0, 10*time.Second, func(evnt *PeerEvent) bool {
+		return evnt.Type == PeerEventConnectedFailed && evnt.PeerID == srv1.host.ID()
+	})
+	go func() {
+		// free the slot of srv1 before the next attempt
+		if <-failedCh {
+			srv2.Disconnect(srv1.host.ID(), "bye")
+		}
+	}()
+
+	assert.NoError(t, srv0.Join(srv1.AddrInfo(), 5*time.Second))
+	assert.Len(t, srv0.Peers(), 1)
+}
+
 func TestNat(t *testing.T) {
 	testIP := "192.0.2.1"
 	testPort := 1500 // important to be less than 2000 because of other tests and more than 1024 because of OS security
@@ -209,3 +354,73 @@ func TestNat(t *testing.T) {
 		assert.True(t, found)
 	})
 }
+
+func TestDialConcurrency(t *testing.T) {
+	// the remote peers accept the tcp connection but never complete
+	// the handshake, so every dial stays in progress
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer lis.Close()
+
+	acceptedCh := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			acceptedCh <- conn
+		}
+	}()
+
+	srv := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+		c.DialConcurrency = 3
+	})
+	defer srv.Close()
+
+	addr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", lis.Addr().(*net.TCPAddr).Port))
+	assert.NoError(t, err)
+
+	for i := 0; i < 6; i++ {
+		_, pub, err := crypto.GenerateKeyPair(crypto.Secp256k1, 256)
+		assert.NoError(t, err)
+
+		id, err := peer.IDFromPublicKey(pub)
+		assert.NoError(t, err)
+
+		srv.dialQueue.add(&peer.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{addr}}, 1)
+	}
+
+	// up to DialConcurrency dials are in progress at the same time
+	conns := []net.Conn{}
+	for i := 0; i < 3; i++ {
+		select {
+		case conn := <-acceptedCh:
+			conns = append(conns, conn)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %d concurrent dials but found %d", 3, i)
+		}
+	}
+
+	// no more dials start until one of the slots is free
+	select {
+	case <-acceptedCh:
+		t.Fatal("more dials than the concurrency limit")
+	case <-time.After(1 * time.Second):
+	}
+
+	// release one of the dials
+	conns[0].Close()
+
+	select {
+	case conn := <-acceptedCh:
+		conns = append(conns, conn)
+	case <-time.After(5 * time.Second):
+		t.Fatal("a new dial should start after a slot is released")
+	}
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,180 @@
+package network
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	peerstore "github.com/libp2p/go-libp2p-peerstore"
+)
+
+// maxStaticPeerBackoff is the maximum wait between two dials to a static peer
+const maxStaticPeerBackoff = 1 * time.Minute
+
+// staticPeer is a peer that is always kept connected
+type staticPeer struct {
+	info *peer.AddrInfo
+
+	// dialing is true while there is a dial in progress
+	dialing bool
+
+	// backoff is the wait before the next dial, it doubles
+	// with every dial and it is reset once connected
+	backoff time.Duration
+	next    time.Time
+}
+
+func (s *Server) initialStaticPeerBackoff() time.Duration {
+	if s.config.JoinBackoff != 0 {
+		return s.config.JoinBackoff
+	}
+	return DefaultJoinBackoff
+}
+
+// AddStaticPeer includes the peer with the given multiaddr as a static peer. A static peer
+// is redialed whenever it is disconnected and it does not count against MaxPeers
+func (s *Server) AddStaticPeer(addr string) error {
+	info, err := StringToAddrInfo(addr)
+	if err != nil {
+		return err
+	}
+	s.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+
+	s.staticPeersLock.Lock()
+	s.staticPeers[info.ID] = &staticPeer{
+		info:    info,
+		backoff: s.initialStaticPeerBackoff(),
+	}
+	s.staticPeersLock.Unlock()
+
+	s.notifyStaticPeers()
+	return nil
+}
+
+// RemoveStaticPeer stops redialing the peer. The peer is not disconnected
+func (s *Server) RemoveStaticPeer(id peer.ID) {
+	s.staticPeersLock.Lock()
+	defer s.staticPeersLock.Unlock()
+
+	delete(s.staticPeers, id)
+}
+
+// IsStaticPeer returns true if the peer is a static peer
+func (s *Server) IsStaticPeer(id peer.ID) bool {
+	s.staticPeersLock.Lock()
+	defer s.staticPeersLock.Unlock()
+
+	_, ok := s.staticPeers[id]
+	return ok
+}
+
+// numStaticPeers returns the number of connected static peers
+func (s *Server) numStaticPeers() int64 {
+	s.staticPeersLock.Lock()
+	ids := make([]peer.ID, 0, len(s.staticPeers))
+	for id := range s.staticPeers {
+		ids = append(ids, id)
+	}
+	s.staticPeersLock.Unlock()
+
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+
+	num := int64(0)
+	for _, id := range ids {
+		if _, ok := s.peers[id]; ok {
+			num++
+		}
+	}
+	return num
+}
+
+func (s *Server) notifyStaticPeers() {
+	select {
+	case s.staticPeersCh <- struct{}{}:
+	default:
+	}
+}
+
+// runStaticPeers keeps the static peers connected
+func (s *Server) runStaticPeers() {
+	err := s.SubscribeFn(func(evnt *PeerEvent) {
+		switch evnt.Type {
+		case PeerEventConnected:
+			// reset the backoff of the static peer
+			s.staticPeersLock.Lock()
+			if p, ok := s.staticPeers[evnt.PeerID]; ok {
+				p.backoff = s.initialStaticPeerBackoff()
+			}
+			s.staticPeersLock.Unlock()
+
+		case PeerEventDisconnected, PeerEventConnectedFailed:
+			if s.IsStaticPeer(evnt.PeerID) {
+				s.notifyStaticPeers()
+			}
+		}
+	})
+	if err != nil {
+		s.logger.Error("static peers failed to subscribe", "err", err)
+	}
+
+	for {
+		wait := s.dialStaticPeers()
+
+		select {
+		case <-s.staticPeersCh:
+		case <-time.After(wait):
+		case <-s.closeCh:
+			return
+		}
+	}
+}
+
+// dialStaticPeers dials the static peers that are not connected and
+// returns the time until the next dial is due
+func (s *Server) dialStaticPeers() time.Duration {
+	s.staticPeersLock.Lock()
+	defer s.staticPeersLock.Unlock()
+
+	now := time.Now()
+	wait := maxStaticPeerBackoff
+
+	for id, p := range s.staticPeers {
+		if p.dialing || s.isConnected(id) {
+			continue
+		}
+		// the banned peers are kept but not dialed until unbanned
+		if s.IsBanned(id) {
+			continue
+		}
+		if now.Before(p.next) {
+			if d := p.next.Sub(now); d < wait {
+				wait = d
+			}
+			continue
+		}
+
+		p.dialing = true
+		p.next = now.Add(p.backoff)
+		if p.backoff *= 2; p.backoff > maxStaticPeerBackoff {
+			p.backoff = maxStaticPeerBackoff
+		}
+
+		go s.dialStaticPeer(p)
+	}
+	return wait
+}
+
+func (s *Server) dialStaticPeer(p *staticPeer) {
+	s.logger.Debug("dial static peer", "addr", p.info.String())
+
+	if err := s.host.Connect(context.Background(), *p.info); err != nil {
+		s.logger.Trace("failed to dial static peer", "addr", p.info.String(), "err", err)
+	}
+
+	s.staticPeersLock.Lock()
+	p.dialing = false
+	s.staticPeersLock.Unlock()
+
+	s.notifyStaticPeers()
+}
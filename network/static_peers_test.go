@@ -0,0 +1,102 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticPeers_Redial(t *testing.T) {
+	srv1 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+	})
+	defer srv1.Close()
+
+	addr, err := AddrInfoToString(srv1.AddrInfo())
+	assert.NoError(t, err)
+
+	// the static peer is dialed even without slots available
+	srv0 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+		c.MaxPeers = 0
+		c.JoinBackoff = 100 * time.Millisecond
+		c.StaticPeers = []string{addr}
+	})
+	defer srv0.Close()
+
+	connected := func() bool {
+		return srv0.PeerCount() == 1 && srv1.PeerCount() == 1
+	}
+	assert.Eventually(t, connected, 10*time.Second, 100*time.Millisecond)
+	assert.True(t, srv0.IsStaticPeer(srv1.AddrInfo().ID))
+	assert.Equal(t, int64(0), srv0.numOpenSlots())
+
+	// the static peer is redialed once it drops
+	disconnectedCh := asyncWaitForEvent(srv0, 10*time.Second, disconnectedPeerHandler(srv1.AddrInfo().ID))
+	srv1.Disconnect(srv0.AddrInfo().ID, "bye")
+	assert.True(t, <-disconnectedCh)
+	assert.Eventually(t, connected, 10*time.Second, 100*time.Millisecond)
+
+	// a removed static peer is not redialed
+	srv0.RemoveStaticPeer(srv1.AddrInfo().ID)
+	assert.False(t, srv0.IsStaticPeer(srv1.AddrInfo().ID))
+
+	disconnectedCh = asyncWaitForEvent(srv0, 10*time.Second, disconnectedPeerHandler(srv1.AddrInfo().ID))
+	connectedCh := asyncWaitForEvent(srv1, 2*time.Second, connectedPeerHandler(srv0.AddrInfo().ID))
+
+	srv1.Disconnect(srv0.AddrInfo().ID, "bye")
+	assert.True(t, <-disconnectedCh)
+	assert.False(t, <-connectedCh)
+}
+
+func TestStaticPeers_Banned(t *testing.T) {
+	srv1 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+	})
+	defer srv1.Close()
+
+	addr, err := AddrInfoToString(srv1.AddrInfo())
+	assert.NoError(t, err)
+
+	srv0 := CreateServer(t, func(c *Config) {
+		c.NoDiscover = true
+		c.JoinBackoff = 100 * time.Millisecond
+		c.StaticPeers = []string{addr}
+	})
+	defer srv0.Close()
+
+	id := srv1.AddrInfo().ID
+	connected := func() bool {
+		return srv0.PeerCount() == 1 && srv1.PeerCount() == 1
+	}
+	assert.Eventually(t, connected, 10*time.Second, 100*time.Millisecond)
+
+	backoff := func() time.Duration {
+		srv0.staticPeersLock.Lock()
+		defer srv0.staticPeersLock.Unlock()
+
+		return srv0.staticPeers[id].backoff
+	}
+
+	// a banned static peer is not redialed
+	disconnectedCh := asyncWaitForEvent(srv0, 10*time.Second, disconnectedPeerHandler(id))
+	srv0.BanPeer(id)
+	assert.True(t, <-disconnectedCh)
+
+	time.Sleep(1 * time.Second)
+	assert.True(t, srv0.IsStaticPeer(id))
+	assert.Equal(t, int64(0), srv0.PeerCount())
+	assert.Equal(t, 100*time.Millisecond, backoff())
+
+	// it is dialed again once unbanned
+	srv0.UnbanPeer(id)
+	assert.Eventually(t, connected, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestStaticPeers_InvalidAddr(t *testing.T) {
+	srv := CreateServer(t, nil)
+	defer srv.Close()
+
+	assert.Error(t, srv.AddStaticPeer("invalid"))
+}
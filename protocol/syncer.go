@@ -144,7 +144,8 @@ type Syncer struct {
 	logger     hclog.Logger
 	blockchain blockchainShim
 
-	peers map[peer.ID]*syncPeer // TODO: Remove
+	peers     map[peer.ID]*syncPeer // TODO: Remove
+	peersLock sync.RWMutex
 
 	serviceV1 *serviceV1
 	stopCh    chan struct{}
@@ -220,7 +221,10 @@ const syncerV1 = "/syncer/0.1"
 func (s *Syncer) enqueueBlock(peerID peer.ID, b *types.Block) {
 	s.logger.Debug("enqueue block", "peer", peerID, "number", b.Number(), "hash", b.Hash())
 
+	s.peersLock.RLock()
 	p, ok := s.peers[peerID]
+	s.peersLock.RUnlock()
+
 	if ok {
 		p.appendBlock(b)
 	}
@@ -242,7 +246,14 @@ func (s *Syncer) Broadcast(b *types.Block) {
 			Value: b.MarshalRLP(),
 		},
 	}
+	s.peersLock.RLock()
+	peers := make([]*syncPeer, 0, len(s.peers))
 	for _, p := range s.peers {
+		peers = append(peers, p)
+	}
+	s.peersLock.RUnlock()
+
+	for _, p := range peers {
 		if _, err := p.client.Notify(context.Background(), req); err != nil {
 			s.logger.Error("failed to notify", "err", err)
 		}
@@ -297,12 +308,15 @@ func (s *Syncer) BestPeer() *syncPeer {
 	var bestPeer *syncPeer
 	var bestTd *big.Int
 
+	s.peersLock.RLock()
 	for _, p := range s.peers {
 		status := p.status
 		if bestPeer == nil || status.Difficulty.Cmp(bestTd) > 0 {
 			bestPeer, bestTd = p, status.Difficulty
 		}
 	}
+	s.peersLock.RUnlock()
+
 	if bestPeer == nil {
 		return nil
 	}
@@ -313,6 +327,25 @@ func (s *Syncer) BestPeer() *syncPeer {
 	return bestPeer
 }
 
+// BestPeerNumber returns the block height of the best peer,
+// false if there is no peer ahead of the node
+func (s *Syncer) BestPeerNumber() (uint64, bool) {
+	p := s.BestPeer()
+	if p == nil {
+		return 0, false
+	}
+
+	// the status is the one of the handshake, the
+	// peer might have broadcasted new blocks since
+	p.enqueueLock.Lock()
+	defer p.enqueueLock.Unlock()
+
+	if p.number > p.status.Number {
+		return p.number, true
+	}
+	return p.status.Number, true
+}
+
 // HandleUser is a helper method that is used to handle new user connections within the Syncer
 func (s *Syncer) HandleUser(peerID peer.ID, conn *grpc.ClientConn) error {
 	// watch for changes of the other node first
@@ -326,6 +359,9 @@ func (s *Syncer) HandleUser(peerID peer.ID, conn *grpc.ClientConn) error {
 	if err != nil {
 		return err
 	}
+	s.peersLock.Lock()
+	defer s.peersLock.Unlock()
+
 	s.peers[peerID] = &syncPeer{
 		peer:      peerID,
 		client:    clt,
@@ -414,6 +450,7 @@ func (s *Syncer) WatchSyncWithPeer(p *syncPeer, handler func(b *types.Block) boo
 
 		if err := s.blockchain.WriteBlocks([]*types.Block{b}); err != nil {
 			s.logger.Error("failed to write block", "err", err)
+			s.server.PenalizePeer(p.peer, network.SyncFailurePenalty)
 			break
 		}
 		if !handler(b) {
@@ -455,6 +492,7 @@ func (s *Syncer) BulkSyncWithPeer(p *syncPeer) error {
 			}
 
 			if err := sk.build(p.client, startBlock.Hash); err != nil {
+				s.server.PenalizePeer(p.peer, network.SyncFailurePenalty)
 				return fmt.Errorf("failed to build skeleton: %v", err)
 			}
 
@@ -466,6 +504,7 @@ func (s *Syncer) BulkSyncWithPeer(p *syncPeer) error {
 			// sync the data
 			for _, slot := range sk.slots {
 				if err := s.blockchain.WriteBlocks(slot.blocks); err != nil {
+					s.server.PenalizePeer(p.peer, network.SyncFailurePenalty)
 					return fmt.Errorf("failed to write bulk sync blocks: %v", err)
 				}
 			}
@@ -13,10 +13,18 @@ import (
 
 const (
 	spuriousDragonMaxCodeSize = 24576
+
+	// accessListAddressGas and accessListStorageKeyGas are the intrinsic
+	// gas costs of the entries of the access list (EIP-2930)
+	accessListAddressGas    = 2400
+	accessListStorageKeyGas = 1900
 )
 
 var (
 	errorVMOutOfGas = fmt.Errorf("out of gas")
+
+	// ErrTxTypeNotSupported is returned if the type of a transaction is not enabled by the forks
+	ErrTxTypeNotSupported = fmt.Errorf("transaction type not supported")
 )
 
 var emptyCodeHashTwo = types.BytesToHash(crypto.Keccak256(nil))
@@ -34,6 +42,14 @@ type Executor struct {
 	GetHash  GetHashByNumberHelper
 
 	PostHook func(txn *Transition)
+
+	// FinalizeHook is called once all the transactions of a block
+	// are applied, before the state of the block is committed
+	FinalizeHook func(txn *Transition)
+
+	// Parallel enables the experimental parallel execution of the
+	// transactions of a block (see processBlockParallel)
+	Parallel bool
 }
 
 // NewExecutor creates a new executor
@@ -45,11 +61,27 @@ func NewExecutor(config *chain.Params, s State) *Executor {
 	}
 }
 
-func (e *Executor) WriteGenesis(alloc map[types.Address]*chain.GenesisAccount) types.Hash {
+// WriteGenesis writes the genesis alloc to the state and returns the state root.
+// If the genesis sets a total supply, the alloc balances must add up to it and,
+// if it sets an expected state root, the computed root must match it
+func (e *Executor) WriteGenesis(genesis *chain.Genesis) (types.Hash, error) {
+	if genesis.TotalSupply != nil {
+		// the premined balances must match the total supply of the network
+		supply := big.NewInt(0)
+		for _, account := range genesis.Alloc {
+			if account.Balance != nil {
+				supply.Add(supply, account.Balance)
+			}
+		}
+		if supply.Cmp(genesis.TotalSupply) != 0 {
+			return types.Hash{}, fmt.Errorf("genesis alloc balances %s do not match the total supply %s", supply, genesis.TotalSupply)
+		}
+	}
+
 	snap := e.state.NewSnapshot()
 	txn := NewTxn(e.state, snap)
 
-	for addr, account := range alloc {
+	for addr, account := range genesis.Alloc {
 		if account.Balance != nil {
 			txn.AddBalance(addr, account.Balance)
 		}
@@ -64,8 +96,13 @@ func (e *Executor) WriteGenesis(alloc map[types.Address]*chain.GenesisAccount) t
 		}
 	}
 
-	_, root := txn.Commit(false)
-	return types.BytesToHash(root)
+	_, rootBytes := txn.Commit(false)
+	root := types.BytesToHash(rootBytes)
+
+	if genesis.ExpectedStateRoot != nil && *genesis.ExpectedStateRoot != root {
+		return types.Hash{}, fmt.Errorf("genesis state root %s does not match the expected state root %s", root, *genesis.ExpectedStateRoot)
+	}
+	return root, nil
 }
 
 // SetRuntime adds a runtime to the runtime set
@@ -87,11 +124,21 @@ func (e *Executor) ProcessBlock(parentRoot types.Hash, block *types.Block, block
 	}
 
 	txn.block = block
-	for _, t := range block.Transactions {
-		if err := txn.Write(t); err != nil {
+	if e.Parallel && e.PostHook == nil && txn.config.Byzantium {
+		// without intermediate roots the transactions
+		// can be executed in parallel
+		if err := txn.writeParallel(block.Transactions); err != nil {
 			return nil, err
 		}
+	} else {
+		for _, t := range block.Transactions {
+			if err := txn.Write(t); err != nil {
+				return nil, err
+			}
+		}
 	}
+	txn.Finalize()
+
 	_, root := txn.Commit()
 
 	res := &BlockResult{
@@ -112,6 +159,13 @@ func (e *Executor) StateAt(root types.Hash) (Snapshot, error) {
 	return e.state.NewSnapshotAt(root)
 }
 
+// WithState returns a copy of the executor that runs on top of the given state
+func (e *Executor) WithState(s State) *Executor {
+	ex := *e
+	ex.state = s
+	return &ex
+}
+
 func (e *Executor) BeginTxn(parentRoot types.Hash, header *types.Header, coinbaseReceiver types.Address) (*Transition, error) {
 	config := e.config.Forks.At(header.Number)
 
@@ -164,14 +218,56 @@ type Transition struct {
 	receipts []*types.Receipt
 	totalGas uint64
 
+	// deferFee defers the payment to the coinbase to the merge
+	// of a speculative execution (see writeParallel)
+	deferFee    bool
+	coinbaseFee *big.Int
+
 	// The return value for the contract execution
 	returnValue []byte
+
+	// The error of the contract execution
+	returnErr error
+
+	// tracer receives the steps of the execution (optional)
+	tracer runtime.Tracer
+}
+
+// WithStateOverride applies the overridden accounts to the state of the transition.
+// The overrides are only discarded if the transition is not committed
+func (t *Transition) WithStateOverride(override types.StateOverride) error {
+	for addr, account := range override {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr)
+		}
+		if account.Nonce != nil {
+			t.state.SetNonce(addr, *account.Nonce)
+		}
+		if account.Balance != nil {
+			t.state.SetBalance(addr, account.Balance)
+		}
+		if account.Code != nil {
+			t.state.SetCode(addr, account.Code)
+		}
+		if account.State != nil {
+			t.state.SetFullState(addr, account.State)
+		}
+		for key, value := range account.StateDiff {
+			t.state.SetState(addr, key, value)
+		}
+	}
+	return nil
 }
 
 func (t *Transition) ReturnValue() []byte {
 	return t.returnValue
 }
 
+// ReturnErr returns the error of the last contract execution
+func (t *Transition) ReturnErr() error {
+	return t.returnErr
+}
+
 func (t *Transition) TotalGas() uint64 {
 	return t.totalGas
 }
@@ -184,15 +280,12 @@ var emptyFrom = types.Address{}
 
 // Write writes another transaction to the executor
 func (t *Transition) Write(txn *types.Transaction) error {
-	signer := crypto.NewSigner(t.config, uint64(t.r.config.ChainID))
-
-	var err error
-	if txn.From == emptyFrom {
-		// Decrypt the from address
-		txn.From, err = signer.Sender(txn)
-		if err != nil {
-			return err
-		}
+	// a block with a transaction type that is not enabled is invalid
+	if err := t.checkTxType(txn); err != nil {
+		return err
+	}
+	if err := t.recoverSender(txn); err != nil {
+		return err
 	}
 
 	// Make a local copy and apply the transaction
@@ -202,6 +295,50 @@ func (t *Transition) Write(txn *types.Transaction) error {
 	if err != nil {
 		fmt.Printf("Apply err: %v", err)
 	}
+	t.writeReceipt(txn, msg, gasUsed, failed, t.revertData())
+	return nil
+}
+
+// checkTxType returns an error if the type or the access list of the transaction is not enabled
+func (t *Transition) checkTxType(txn *types.Transaction) error {
+	if !t.config.AcceptsTx(txn) {
+		return ErrTxTypeNotSupported
+	}
+	return nil
+}
+
+// recoverSender sets the sender of the transaction if it is not known yet
+func (t *Transition) recoverSender(txn *types.Transaction) error {
+	if txn.From != emptyFrom {
+		return nil
+	}
+
+	// Decrypt the from address. The typed transactions
+	// are always signed with the chain id
+	signer := crypto.NewSigner(t.config, uint64(t.r.config.ChainID))
+	if txn.Type != types.LegacyTx {
+		signer = crypto.NewEIP155Signer(uint64(t.r.config.ChainID))
+	}
+
+	from, err := signer.Sender(txn)
+	if err != nil {
+		return err
+	}
+	txn.From = from
+	return nil
+}
+
+// revertData returns the data of the last contract execution if it was reverted
+func (t *Transition) revertData() []byte {
+	if t.returnErr != runtime.ErrExecutionReverted || len(t.returnValue) == 0 {
+		return nil
+	}
+	return append([]byte{}, t.returnValue...)
+}
+
+// writeReceipt closes the state of an applied transaction and creates its receipt.
+// The revert data is only set in the receipt if the transaction failed
+func (t *Transition) writeReceipt(txn, msg *types.Transaction, gasUsed uint64, failed bool, revert []byte) {
 	t.totalGas += gasUsed
 
 	logs := t.state.Logs()
@@ -212,6 +349,7 @@ func (t *Transition) Write(txn *types.Transaction) error {
 		CumulativeGasUsed: t.totalGas,
 		TxHash:            txn.Hash,
 		GasUsed:           gasUsed,
+		EffectiveGasPrice: new(big.Int).Set(msg.GasPrice),
 	}
 
 	if t.config.Byzantium {
@@ -220,6 +358,7 @@ func (t *Transition) Write(txn *types.Transaction) error {
 
 		if failed {
 			receipt.SetStatus(types.ReceiptFailed)
+			receipt.RevertReason = revert
 		} else {
 			receipt.SetStatus(types.ReceiptSuccess)
 		}
@@ -240,8 +379,14 @@ func (t *Transition) Write(txn *types.Transaction) error {
 	receipt.Logs = logs
 	receipt.LogsBloom = types.CreateBloom([]*types.Receipt{receipt})
 	t.receipts = append(t.receipts, receipt)
+}
 
-	return nil
+// Finalize runs the finalize hook of the executor. The block producers
+// must call it after the transactions of the block are applied
+func (t *Transition) Finalize() {
+	if t.r.FinalizeHook != nil {
+		t.r.FinalizeHook(t)
+	}
 }
 
 // Commit commits the final result
@@ -278,7 +423,7 @@ func (t *Transition) GetTxnHash() types.Hash {
 // Apply applies a new transaction
 func (t *Transition) Apply(msg *types.Transaction) (uint64, bool, error) {
 	s := t.state.Snapshot()
-	returnValue, gasUsed, failed, err := t.apply(msg)
+	returnValue, gasUsed, returnErr, err := t.apply(msg)
 	if err != nil {
 		t.state.RevertToSnapshot(s)
 	}
@@ -288,7 +433,8 @@ func (t *Transition) Apply(msg *types.Transaction) (uint64, bool, error) {
 	}
 
 	t.returnValue = returnValue
-	return gasUsed, failed, err
+	t.returnErr = returnErr
+	return gasUsed, returnErr != nil, err
 }
 
 // ContextPtr returns reference of context
@@ -325,9 +471,46 @@ func (t *Transition) transactionGasCost(msg *types.Transaction) uint64 {
 		cost += uint64(nonZeros) * nonZeroCost
 	}
 
+	if t.config.Berlin {
+		cost += uint64(len(msg.AccessList)) * accessListAddressGas
+		cost += uint64(msg.AccessList.StorageKeys()) * accessListStorageKeyGas
+	}
+
 	return uint64(cost)
 }
 
+// precompiledRuntime is a runtime that runs the code of a set of addresses.
+// Only the enabled precompiled contracts are warm in the access list
+type precompiledRuntime interface {
+	Addresses(config *chain.ForksInTime) []types.Address
+}
+
+// prepareAccessList resets the access list and adds the entries that are warm from
+// the start of the transaction: the sender, the receiver, the precompiled contracts
+// and the access list of the transaction (EIP-2929, EIP-2930)
+func (t *Transition) prepareAccessList(msg *types.Transaction) {
+	t.state.ClearAccessList()
+
+	t.state.AccessAddress(msg.From)
+	if msg.To != nil {
+		t.state.AccessAddress(*msg.To)
+	}
+	for _, r := range t.r.runtimes {
+		if p, ok := r.(precompiledRuntime); ok {
+			for _, addr := range p.Addresses(&t.config) {
+				t.state.AccessAddress(addr)
+			}
+		}
+	}
+
+	for _, tuple := range msg.AccessList {
+		t.state.AccessAddress(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			t.state.AccessSlot(tuple.Address, key)
+		}
+	}
+}
+
 func (t *Transition) preCheck(msg *types.Transaction) (uint64, error) {
 	// validate nonce
 	nonce := t.state.GetNonce(msg.From)
@@ -356,23 +539,29 @@ func (t *Transition) preCheck(msg *types.Transaction) (uint64, error) {
 	return gasAvailable, nil
 }
 
+// apply applies the transaction, it returns the return value, the gas used
+// and the error of the execution (if the execution failed)
 func (t *Transition) apply(msg *types.Transaction) (
-	[]byte, uint64, bool, error,
+	[]byte, uint64, error, error,
 ) {
+	if err := t.checkTxType(msg); err != nil {
+		return nil, 0, nil, err
+	}
+
 	// check if there is enough gas in the pool
 	if err := t.subGasPool(msg.Gas); err != nil {
-		return nil, 0, false, err
+		return nil, 0, nil, err
 	}
 
 	txn := t.state
 
 	leftoverGas, err := t.preCheck(msg)
 	if err != nil {
-		return nil, 0, false, err
+		return nil, 0, nil, err
 	}
 	// TODO: Check if this is even possible
 	if leftoverGas > msg.Gas {
-		return nil, 0, false, errorVMOutOfGas
+		return nil, 0, nil, errorVMOutOfGas
 	}
 
 	gasPrice := new(big.Int).Set(msg.GasPrice)
@@ -382,6 +571,10 @@ func (t *Transition) apply(msg *types.Transaction) (
 	t.ctx.GasPrice = types.BytesToHash(gasPrice.Bytes())
 	t.ctx.Origin = msg.From
 
+	if t.config.Berlin {
+		t.prepareAccessList(msg)
+	}
+
 	var subErr error
 	var gasLeft uint64
 	var returnValue []byte
@@ -396,7 +589,7 @@ func (t *Transition) apply(msg *types.Transaction) (
 		// fmt.Printf("suberr: %s\n", subErr.Error())
 
 		if subErr == runtime.ErrNotEnoughFunds {
-			return nil, 0, false, subErr
+			return nil, 0, nil, subErr
 		}
 	}
 
@@ -416,12 +609,16 @@ func (t *Transition) apply(msg *types.Transaction) (
 
 	// pay the coinbase
 	coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice)
-	txn.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	if t.deferFee {
+		t.coinbaseFee = coinbaseFee
+	} else {
+		txn.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	}
 
 	// return gas to the pool
 	t.addGasPool(gasLeft)
 
-	return returnValue, gasUsed, subErr != nil, nil
+	return returnValue, gasUsed, subErr, nil
 }
 
 func (t *Transition) Create2(caller types.Address, code []byte, value *big.Int, gas uint64) ([]byte, uint64, error) {
@@ -459,7 +656,14 @@ func (t *Transition) transfer(from, to types.Address, amount *big.Int) error {
 	return nil
 }
 
-func (t *Transition) applyCall(c *runtime.Contract, callType runtime.CallType, host runtime.Host) ([]byte, uint64, error) {
+func (t *Transition) applyCall(c *runtime.Contract, callType runtime.CallType, host runtime.Host) (ret []byte, gasLeft uint64, err error) {
+	if t.tracer != nil {
+		t.tracer.CaptureEnter(callType, c)
+		defer func() {
+			t.tracer.CaptureExit(ret, c.Gas-gasLeft, err)
+		}()
+	}
+
 	if c.Depth > int(1024)+1 {
 		return nil, c.Gas, runtime.ErrDepth
 	}
@@ -474,11 +678,11 @@ func (t *Transition) applyCall(c *runtime.Contract, callType runtime.CallType, h
 		}
 	}
 
-	ret, gas, err := t.run(c, host)
+	ret, gasLeft, err = t.run(c, host)
 	if err != nil {
 		t.state.RevertToSnapshot(snapshot)
 	}
-	return ret, gas, err
+	return ret, gasLeft, err
 }
 
 var emptyHash types.Hash
@@ -495,7 +699,19 @@ func (t *Transition) hasCodeOrNonce(addr types.Address) bool {
 	return false
 }
 
-func (t *Transition) applyCreate(msg *runtime.Contract, host runtime.Host) ([]byte, uint64, error) {
+func (t *Transition) applyCreate(msg *runtime.Contract, host runtime.Host) (ret []byte, gasLeft uint64, err error) {
+	if t.tracer != nil {
+		// the contract creation of a transaction is always a CREATE
+		callType := runtime.Create
+		if msg.Type == runtime.Create2 {
+			callType = runtime.Create2
+		}
+		t.tracer.CaptureEnter(callType, msg)
+		defer func() {
+			t.tracer.CaptureExit(ret, msg.Gas-gasLeft, err)
+		}()
+	}
+
 	if msg.Depth > int(1024)+1 {
 		return nil, msg.Gas, runtime.ErrDepth
 	}
@@ -505,6 +721,11 @@ func (t *Transition) applyCreate(msg *runtime.Contract, host runtime.Host) ([]by
 	// Increment the nonce of the caller
 	t.state.IncrNonce(msg.Caller)
 
+	// the address of the contract is warm even if the creation fails
+	if t.config.Berlin {
+		t.state.AccessAddress(msg.Address)
+	}
+
 	// Check if there if there is a collision and the address already exists
 	if t.hasCodeOrNonce(msg.Address) {
 		return nil, 0, runtime.ErrContractAddressCollision
@@ -598,10 +819,29 @@ func (t *Transition) Empty(addr types.Address) bool {
 	return t.state.Empty(addr)
 }
 
+// SetTracer sets the tracer that receives the steps of the execution
+// of the next transactions
+func (t *Transition) SetTracer(tracer runtime.Tracer) {
+	t.tracer = tracer
+}
+
+// GetTracer implements the runtime.Host interface
+func (t *Transition) GetTracer() runtime.Tracer {
+	return t.tracer
+}
+
 func (t *Transition) GetNonce(addr types.Address) uint64 {
 	return t.state.GetNonce(addr)
 }
 
+func (t *Transition) AccessAddress(addr types.Address) bool {
+	return t.state.AccessAddress(addr)
+}
+
+func (t *Transition) AccessSlot(addr types.Address, key types.Hash) bool {
+	return t.state.AccessSlot(addr, key)
+}
+
 func (t *Transition) Selfdestruct(addr types.Address, beneficiary types.Address) {
 	if !t.state.HasSuicided(addr) {
 		t.state.AddRefund(24000)
@@ -611,7 +851,7 @@ func (t *Transition) Selfdestruct(addr types.Address, beneficiary types.Address)
 }
 
 func (t *Transition) Callx(c *runtime.Contract, h runtime.Host) ([]byte, uint64, error) {
-	if c.Type == runtime.Create {
+	if c.Type == runtime.Create || c.Type == runtime.Create2 {
 		return t.applyCreate(c, h)
 	}
 	return t.applyCall(c, c.Type, h)
@@ -0,0 +1,712 @@
+package state_test
+
+import (
+	"expvar"
+	"math/big"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
+	"github.com/0xPolygon/minimal/state"
+	itrie "github.com/0xPolygon/minimal/state/immutable-trie"
+	"github.com/0xPolygon/minimal/state/runtime"
+	"github.com/0xPolygon/minimal/state/runtime/evm"
+	"github.com/0xPolygon/minimal/state/runtime/precompiled"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutor_WriteGenesis_TotalSupply(t *testing.T) {
+	newGenesis := func(totalSupply int64, balances ...int64) *chain.Genesis {
+		genesis := &chain.Genesis{
+			Alloc:       map[types.Address]*chain.GenesisAccount{},
+			TotalSupply: big.NewInt(totalSupply),
+		}
+		for i, balance := range balances {
+			genesis.Alloc[types.Address{byte(i + 1)}] = &chain.GenesisAccount{
+				Balance: big.NewInt(balance),
+			}
+		}
+		return genesis
+	}
+
+	cases := []struct {
+		name    string
+		genesis *chain.Genesis
+		err     bool
+	}{
+		{
+			"alloc sums to the total supply",
+			newGenesis(100, 60, 40),
+			false,
+		},
+		{
+			"alloc exceeds the total supply",
+			newGenesis(100, 60, 41),
+			true,
+		},
+		{
+			"alloc below the total supply",
+			newGenesis(100, 60),
+			true,
+		},
+		{
+			"no total supply",
+			&chain.Genesis{
+				Alloc: map[types.Address]*chain.GenesisAccount{
+					{0x1}: {Balance: big.NewInt(100)},
+				},
+			},
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			executor := state.NewExecutor(&chain.Params{}, itrie.NewState(itrie.NewMemoryStorage()))
+
+			root, err := executor.WriteGenesis(c.genesis)
+			if c.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEqual(t, types.ZeroHash, root)
+			}
+		})
+	}
+}
+
+func TestExecutor_WriteGenesis_ExpectedStateRoot(t *testing.T) {
+	writeGenesis := func(expected *types.Hash) (types.Hash, error) {
+		executor := state.NewExecutor(&chain.Params{}, itrie.NewState(itrie.NewMemoryStorage()))
+
+		return executor.WriteGenesis(&chain.Genesis{
+			Alloc: map[types.Address]*chain.GenesisAccount{
+				{0x1}: {Balance: big.NewInt(100)},
+			},
+			ExpectedStateRoot: expected,
+		})
+	}
+
+	root, err := writeGenesis(nil)
+	assert.NoError(t, err)
+
+	// the computed root matches the expected one
+	res, err := writeGenesis(&root)
+	assert.NoError(t, err)
+	assert.Equal(t, root, res)
+
+	// the computed root does not match the expected one
+	wrong := types.StringToHash("1")
+	_, err = writeGenesis(&wrong)
+	assert.Error(t, err)
+}
+
+func TestExecutor_DisabledPrecompile(t *testing.T) {
+	identity := types.StringToAddress("4")
+	sender := types.Address{0x1}
+	input := []byte{0x1, 0x2, 0x3}
+
+	call := func(disabled bool) []byte {
+		executor := state.NewExecutor(&chain.Params{
+			Forks: &chain.Forks{},
+		}, itrie.NewState(itrie.NewMemoryStorage()))
+		executor.GetHash = func(*types.Header) state.GetHashByNumber {
+			return func(uint64) types.Hash {
+				return types.ZeroHash
+			}
+		}
+
+		precompiles := precompiled.NewPrecompiled()
+		if disabled {
+			precompiles.Disable(identity)
+		}
+		executor.SetRuntime(precompiles)
+		executor.SetRuntime(evm.NewEVM())
+
+		root, err := executor.WriteGenesis(&chain.Genesis{
+			Alloc: map[types.Address]*chain.GenesisAccount{
+				sender: {Balance: big.NewInt(1000000)},
+			},
+		})
+		assert.NoError(t, err)
+
+		transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+		assert.NoError(t, err)
+
+		_, failed, err := transition.Apply(&types.Transaction{
+			From:     sender,
+			To:       &identity,
+			Value:    big.NewInt(0),
+			GasPrice: big.NewInt(0),
+			Gas:      100000,
+			Input:    input,
+		})
+		assert.NoError(t, err)
+		assert.False(t, failed)
+
+		return transition.ReturnValue()
+	}
+
+	// the identity precompile returns the input
+	assert.Equal(t, input, call(false))
+
+	// once disabled, the address is a plain account without code
+	assert.Empty(t, call(true))
+}
+
+func TestExecutor_StateOverride(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	// returns the value of the storage slot 0
+	sload := []byte{0x60, 0x00, 0x54, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+	// returns 0x2a
+	ret := []byte{0x60, 0x2a, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+
+	executor := state.NewExecutor(&chain.Params{
+		Forks: &chain.Forks{},
+	}, itrie.NewState(itrie.NewMemoryStorage()))
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash {
+			return types.ZeroHash
+		}
+	}
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+
+	root, err := executor.WriteGenesis(&chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender: {Balance: big.NewInt(1000000)},
+			contract: {
+				Code: sload,
+				Storage: map[types.Hash]types.Hash{
+					types.ZeroHash: types.BytesToHash([]byte{0x1}),
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	call := func(override types.StateOverride) []byte {
+		transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+		assert.NoError(t, err)
+		assert.NoError(t, transition.WithStateOverride(override))
+
+		_, failed, err := transition.Apply(&types.Transaction{
+			From:     sender,
+			To:       &contract,
+			Value:    big.NewInt(0),
+			GasPrice: big.NewInt(0),
+			Gas:      100000,
+		})
+		assert.NoError(t, err)
+		assert.False(t, failed)
+
+		return transition.ReturnValue()
+	}
+
+	slot := func(b byte) []byte {
+		return types.BytesToHash([]byte{b}).Bytes()
+	}
+
+	assert.Equal(t, slot(0x1), call(nil))
+
+	// the overridden code is executed
+	assert.Equal(t, slot(0x2a), call(types.StateOverride{
+		contract: {Code: ret},
+	}))
+
+	// the state diff only replaces the given slots
+	assert.Equal(t, slot(0x1), call(types.StateOverride{
+		contract: {StateDiff: map[types.Hash]types.Hash{
+			types.BytesToHash([]byte{0x1}): types.BytesToHash([]byte{0x5}),
+		}},
+	}))
+
+	// the state replaces the whole storage
+	assert.Equal(t, slot(0x0), call(types.StateOverride{
+		contract: {State: map[types.Hash]types.Hash{
+			types.BytesToHash([]byte{0x1}): types.BytesToHash([]byte{0x5}),
+		}},
+	}))
+	assert.Equal(t, slot(0x5), call(types.StateOverride{
+		contract: {State: map[types.Hash]types.Hash{
+			types.ZeroHash: types.BytesToHash([]byte{0x5}),
+		}},
+	}))
+
+	// the overrides are discarded with the transition
+	assert.Equal(t, slot(0x1), call(nil))
+
+	// the state and the state diff cannot be set at the same time
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+	assert.NoError(t, err)
+	assert.Error(t, transition.WithStateOverride(types.StateOverride{
+		contract: {
+			State:     map[types.Hash]types.Hash{},
+			StateDiff: map[types.Hash]types.Hash{},
+		},
+	}))
+}
+
+func TestExecutor_EffectiveGasPrice(t *testing.T) {
+	sender := types.Address{0x1}
+	receiver := types.Address{0x2}
+
+	executor := state.NewExecutor(&chain.Params{
+		Forks: &chain.Forks{
+			Byzantium: chain.NewFork(0),
+		},
+	}, itrie.NewState(itrie.NewMemoryStorage()))
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash {
+			return types.ZeroHash
+		}
+	}
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+
+	root, err := executor.WriteGenesis(&chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender: {Balance: big.NewInt(1000000)},
+		},
+	})
+	assert.NoError(t, err)
+
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+	assert.NoError(t, err)
+
+	// a legacy transaction pays its gas price
+	assert.NoError(t, transition.Write(&types.Transaction{
+		From:     sender,
+		To:       &receiver,
+		Value:    big.NewInt(0),
+		GasPrice: big.NewInt(5),
+		Gas:      21000,
+	}))
+
+	receipts := transition.Receipts()
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, big.NewInt(5), receipts[0].EffectiveGasPrice)
+}
+
+func TestExecutor_TypedTx_Forks(t *testing.T) {
+	sender := types.Address{0x1}
+	receiver := types.Address{0x2}
+
+	transition := func(forks *chain.Forks) *state.Transition {
+		executor := state.NewExecutor(&chain.Params{
+			Forks: forks,
+		}, itrie.NewState(itrie.NewMemoryStorage()))
+		executor.GetHash = func(*types.Header) state.GetHashByNumber {
+			return func(uint64) types.Hash {
+				return types.ZeroHash
+			}
+		}
+		executor.SetRuntime(evm.NewEVM())
+
+		root, err := executor.WriteGenesis(&chain.Genesis{
+			Alloc: map[types.Address]*chain.GenesisAccount{
+				sender: {Balance: big.NewInt(1000000)},
+			},
+		})
+		assert.NoError(t, err)
+
+		transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+		assert.NoError(t, err)
+		return transition
+	}
+
+	txn := &types.Transaction{
+		Type:      types.DynamicFeeTx,
+		From:      sender,
+		To:        &receiver,
+		Value:     big.NewInt(0),
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+		Gas:       21000,
+	}
+
+	// a typed transaction is rejected before the london fork
+	before := transition(&chain.Forks{Byzantium: chain.NewFork(0)})
+
+	_, _, err := before.Apply(txn.Copy())
+	assert.Equal(t, state.ErrTxTypeNotSupported, err)
+	assert.Equal(t, state.ErrTxTypeNotSupported, before.Write(txn.Copy()))
+
+	after := transition(chain.AllForksEnabled)
+
+	_, _, err = after.Apply(txn.Copy())
+	assert.NoError(t, err)
+
+	// the access lists are rejected before the berlin fork, in any type of transaction
+	london := &chain.Forks{London: chain.NewFork(0)}
+
+	accessList := types.AccessList{
+		{Address: receiver, StorageKeys: []types.Hash{{}}},
+	}
+	for _, txType := range []types.TxType{types.LegacyTx, types.AccessListTx, types.DynamicFeeTx} {
+		txn := txn.Copy()
+		txn.Type = txType
+		txn.Gas = 30000
+		txn.AccessList = accessList
+
+		_, _, err := transition(london).Apply(txn)
+		assert.Equal(t, state.ErrTxTypeNotSupported, err)
+	}
+
+	txn = txn.Copy()
+	txn.Type = types.AccessListTx
+	txn.Nonce = 1
+	txn.Gas = 30000
+	txn.AccessList = accessList
+
+	_, _, err = after.Apply(txn)
+	assert.NoError(t, err)
+}
+
+func TestExecutor_Revert(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	// Error("boom")
+	reason := []byte{0x08, 0xc3, 0x79, 0xa0}
+	reason = append(reason, types.BytesToHash([]byte{0x20}).Bytes()...)
+	reason = append(reason, types.BytesToHash([]byte{0x4}).Bytes()...)
+	reason = append(reason, types.BytesToHash([]byte("boom")).Bytes()...)
+
+	// copies the data after the code to memory and reverts with it
+	size := byte(len(reason))
+	code := []byte{0x60, size, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, size, 0x60, 0x00, 0xfd}
+	code = append(code, reason...)
+
+	executor := state.NewExecutor(&chain.Params{
+		Forks: &chain.Forks{
+			Byzantium: chain.NewFork(0),
+		},
+	}, itrie.NewState(itrie.NewMemoryStorage()))
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(uint64) types.Hash {
+			return types.ZeroHash
+		}
+	}
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+
+	root, err := executor.WriteGenesis(&chain.Genesis{
+		Alloc: map[types.Address]*chain.GenesisAccount{
+			sender:   {Balance: big.NewInt(1000000)},
+			contract: {Code: code},
+		},
+	})
+	assert.NoError(t, err)
+
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+	assert.NoError(t, err)
+
+	_, failed, err := transition.Apply(&types.Transaction{
+		From:     sender,
+		To:       &contract,
+		Value:    big.NewInt(0),
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+	})
+	assert.NoError(t, err)
+	assert.True(t, failed)
+
+	// the revert data is the return value
+	assert.Equal(t, runtime.ErrExecutionReverted, transition.ReturnErr())
+	assert.Equal(t, reason, transition.ReturnValue())
+
+	// and the revert reason of the failed receipt
+	assert.NoError(t, transition.Write(&types.Transaction{
+		From:     sender,
+		To:       &contract,
+		Nonce:    1,
+		Value:    big.NewInt(0),
+		GasPrice: big.NewInt(0),
+		Gas:      100000,
+	}))
+
+	receipts := transition.Receipts()
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, types.ReceiptFailed, *receipts[0].Status)
+	assert.Equal(t, reason, receipts[0].RevertReason)
+}
+
+func TestExecutor_Berlin_AccessList(t *testing.T) {
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	// loads the slot 0 twice
+	code := []byte{0x60, 0x00, 0x54, 0x50, 0x60, 0x00, 0x54, 0x50, 0x00}
+
+	gasUsed := func(forks *chain.Forks, txType types.TxType, accessList types.AccessList) uint64 {
+		executor := state.NewExecutor(&chain.Params{
+			Forks: forks,
+		}, itrie.NewState(itrie.NewMemoryStorage()))
+		executor.GetHash = func(*types.Header) state.GetHashByNumber {
+			return func(uint64) types.Hash {
+				return types.ZeroHash
+			}
+		}
+		executor.SetRuntime(precompiled.NewPrecompiled())
+		executor.SetRuntime(evm.NewEVM())
+
+		root, err := executor.WriteGenesis(&chain.Genesis{
+			Alloc: map[types.Address]*chain.GenesisAccount{
+				sender:   {Balance: big.NewInt(1000000)},
+				contract: {Code: code},
+			},
+		})
+		assert.NoError(t, err)
+
+		transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+		assert.NoError(t, err)
+
+		gasUsed, failed, err := transition.Apply(&types.Transaction{
+			Type:       txType,
+			From:       sender,
+			To:         &contract,
+			Value:      big.NewInt(0),
+			GasPrice:   big.NewInt(0),
+			Gas:        100000,
+			AccessList: accessList,
+		})
+		assert.NoError(t, err)
+		assert.False(t, failed)
+		return gasUsed
+	}
+
+	istanbul := &chain.Forks{
+		Homestead:      chain.NewFork(0),
+		EIP150:         chain.NewFork(0),
+		EIP155:         chain.NewFork(0),
+		EIP158:         chain.NewFork(0),
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(0),
+		Petersburg:     chain.NewFork(0),
+		Istanbul:       chain.NewFork(0),
+	}
+	berlin := *istanbul
+	berlin.Berlin = chain.NewFork(0)
+
+	// each push and pop costs 5 gas
+	const opsGas = 2 * (3 + 2)
+
+	// without the fork both loads cost 800 gas
+	assert.Equal(t, uint64(21000+opsGas+800+800), gasUsed(istanbul, types.LegacyTx, nil))
+
+	// with the fork the first load is cold and the second one warm
+	assert.Equal(t, uint64(21000+opsGas+2100+100), gasUsed(&berlin, types.LegacyTx, nil))
+
+	// the slots in the access list are warm from the start
+	accessList := types.AccessList{
+		{Address: contract, StorageKeys: []types.Hash{{}}},
+	}
+	assert.Equal(t, uint64(21000+2400+1900+opsGas+100+100), gasUsed(&berlin, types.AccessListTx, accessList))
+}
+
+func TestExecutor_Berlin_DisabledPrecompileIsCold(t *testing.T) {
+	identity := types.StringToAddress("4")
+	sender := types.Address{0x1}
+	contract := types.Address{0x2}
+
+	// reads the balance of the identity precompile
+	code := []byte{0x60, 0x04, 0x31, 0x50, 0x00}
+
+	gasUsed := func(disabled bool) uint64 {
+		executor := state.NewExecutor(&chain.Params{
+			Forks: chain.AllForksEnabled,
+		}, itrie.NewState(itrie.NewMemoryStorage()))
+		executor.GetHash = func(*types.Header) state.GetHashByNumber {
+			return func(uint64) types.Hash {
+				return types.ZeroHash
+			}
+		}
+
+		precompiles := precompiled.NewPrecompiled()
+		if disabled {
+			precompiles.Disable(identity)
+		}
+		executor.SetRuntime(precompiles)
+		executor.SetRuntime(evm.NewEVM())
+
+		root, err := executor.WriteGenesis(&chain.Genesis{
+			Alloc: map[types.Address]*chain.GenesisAccount{
+				sender:   {Balance: big.NewInt(1000000)},
+				contract: {Code: code},
+			},
+		})
+		assert.NoError(t, err)
+
+		transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000}, types.ZeroAddress)
+		assert.NoError(t, err)
+
+		gasUsed, failed, err := transition.Apply(&types.Transaction{
+			From:     sender,
+			To:       &contract,
+			Value:    big.NewInt(0),
+			GasPrice: big.NewInt(0),
+			Gas:      100000,
+		})
+		assert.NoError(t, err)
+		assert.False(t, failed)
+		return gasUsed
+	}
+
+	// the enabled precompile is warm from the start
+	assert.Equal(t, uint64(21000+3+100+2), gasUsed(false))
+
+	// the disabled one is a cold account
+	assert.Equal(t, uint64(21000+3+2600+2), gasUsed(true))
+}
+
+func TestExecutor_ParallelBlock(t *testing.T) {
+	var (
+		addr1    = types.Address{0x1}
+		addr2    = types.Address{0x2}
+		addr3    = types.Address{0x3}
+		addr4    = types.Address{0x4}
+		coinbase = types.Address{0x5}
+	)
+
+	transfer := func(from, to types.Address, value int64) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			To:       &to,
+			Value:    big.NewInt(value),
+			GasPrice: big.NewInt(1),
+			Gas:      21000,
+		}
+	}
+
+	// each one of the precompiles is called by its own sender
+	precompiles := []types.Address{
+		types.StringToAddress("1"),
+		types.StringToAddress("3"),
+		types.StringToAddress("5"),
+		types.StringToAddress("6"),
+		types.StringToAddress("7"),
+		types.StringToAddress("8"),
+	}
+	sender := func(i int) types.Address {
+		return types.Address{0x10, byte(i)}
+	}
+	call := func(from, to types.Address) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			To:       &to,
+			Value:    big.NewInt(0),
+			Input:    make([]byte, 192),
+			GasPrice: big.NewInt(1),
+			Gas:      300000,
+		}
+	}
+
+	process := func(parallel bool, txns ...*types.Transaction) *state.BlockResult {
+		executor := state.NewExecutor(&chain.Params{
+			Forks: &chain.Forks{
+				Byzantium: chain.NewFork(0),
+			},
+		}, itrie.NewState(itrie.NewMemoryStorage()))
+		executor.GetHash = func(*types.Header) state.GetHashByNumber {
+			return func(uint64) types.Hash {
+				return types.ZeroHash
+			}
+		}
+		executor.SetRuntime(precompiled.NewPrecompiled())
+		executor.SetRuntime(evm.NewEVM())
+		executor.Parallel = parallel
+
+		alloc := map[types.Address]*chain.GenesisAccount{
+			addr1: {Balance: big.NewInt(1000000)},
+			addr2: {Balance: big.NewInt(1000000)},
+		}
+		for i := range precompiles {
+			alloc[sender(i)] = &chain.GenesisAccount{Balance: big.NewInt(1000000)}
+		}
+		root, err := executor.WriteGenesis(&chain.Genesis{
+			Alloc: alloc,
+		})
+		assert.NoError(t, err)
+
+		block := &types.Block{
+			Header: &types.Header{
+				Number:   1,
+				GasLimit: 10000000,
+			},
+			Transactions: txns,
+		}
+		res, err := executor.ProcessBlock(root, block, coinbase)
+		assert.NoError(t, err)
+		return res
+	}
+
+	conflicts := func() int64 {
+		return expvar.Get("state_parallel_conflicts").(*expvar.Int).Value()
+	}
+
+	cases := []struct {
+		name      string
+		txns      func() []*types.Transaction
+		conflicts int64
+	}{
+		{
+			"independent transfers",
+			func() []*types.Transaction {
+				return []*types.Transaction{
+					transfer(addr1, addr3, 10),
+					transfer(addr2, addr4, 20),
+				}
+			},
+			0,
+		},
+		{
+			"the second transfer reads the receiver of the first one",
+			func() []*types.Transaction {
+				return []*types.Transaction{
+					transfer(addr1, addr2, 10),
+					transfer(addr2, addr3, 20),
+				}
+			},
+			1,
+		},
+		{
+			// the precompiles are shared by all the workers
+			"precompile calls",
+			func() []*types.Transaction {
+				txns := []*types.Transaction{}
+				for i, addr := range precompiles {
+					txns = append(txns, call(sender(i), addr))
+				}
+				return txns
+			},
+			0,
+		},
+	}
+
+	// run several workers even on a single cpu so that -race can catch
+	// any state shared between them
+	defer goruntime.GOMAXPROCS(goruntime.GOMAXPROCS(4))
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			serial := process(false, c.txns()...)
+
+			before := conflicts()
+			parallel := process(true, c.txns()...)
+			assert.Equal(t, c.conflicts, conflicts()-before)
+
+			assert.Equal(t, serial.Root, parallel.Root)
+			assert.Equal(t, serial.TotalGas, parallel.TotalGas)
+			assert.Equal(t, serial.Receipts, parallel.Receipts)
+
+			for _, receipt := range parallel.Receipts {
+				assert.Equal(t, types.ReceiptSuccess, *receipt.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Iterate walks the trie in key order and calls fn with each key and value,
+// starting at the first key equal or greater than start. The walk stops
+// when fn returns false
+func (t *Trie) Iterate(start []byte, fn func(key, value []byte) bool) error {
+	startHex := keybytesToHex(start)
+	startHex = startHex[:len(startHex)-1]
+
+	_, err := t.iterate(t.root, []byte{}, startHex, fn)
+	return err
+}
+
+func (t *Trie) iterate(node Node, prefix, start []byte, fn func(key, value []byte) bool) (bool, error) {
+	// skip the subtree if all its keys are lower than the start key
+	if n := len(prefix); n <= len(start) {
+		if bytes.Compare(prefix, start[:n]) < 0 {
+			return true, nil
+		}
+	} else if bytes.Compare(prefix[:len(start)], start) < 0 {
+		return true, nil
+	}
+
+	switch n := node.(type) {
+	case nil:
+		return true, nil
+
+	case *ValueNode:
+		if n.hash {
+			nc, ok, err := GetNode(n.buf, t.storage)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				return false, fmt.Errorf("node %x not found", n.buf)
+			}
+			return t.iterate(nc, prefix, start, fn)
+		}
+		if len(prefix) < len(start) && bytes.Equal(prefix, start[:len(prefix)]) {
+			// the key is a prefix of the start key, thus it is lower
+			return true, nil
+		}
+		return fn(hexToKeybytes(prefix), n.buf), nil
+
+	case *ShortNode:
+		key := n.key
+		if hasTerm(key) {
+			key = key[:len(key)-1]
+		}
+		return t.iterate(n.child, concat(prefix, key), start, fn)
+
+	case *FullNode:
+		// the value of the node goes before any of its children
+		if ok, err := t.iterate(n.value, prefix, start, fn); !ok || err != nil {
+			return ok, err
+		}
+		for i, child := range n.children {
+			if ok, err := t.iterate(child, concat(prefix, []byte{byte(i)}), start, fn); !ok || err != nil {
+				return ok, err
+			}
+		}
+		return true, nil
+
+	default:
+		panic(fmt.Sprintf("unknown node type %v", n))
+	}
+}
+
+// hexToKeybytes packs the nibbles of a key (without the terminator) into bytes
+func hexToKeybytes(hex []byte) []byte {
+	key := make([]byte, len(hex)/2)
+	for i := range key {
+		key[i] = hex[2*i]<<4 | hex[2*i+1]
+	}
+	return key
+}
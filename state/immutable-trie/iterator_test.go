@@ -0,0 +1,63 @@
+package itrie
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sort"
+	"testing"
+
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrie_Iterate(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	keys := [][]byte{}
+	for i := 0; i < 100; i++ {
+		key := make([]byte, 32)
+		rand.Read(key)
+		keys = append(keys, key)
+	}
+
+	batch := storage.Batch()
+
+	txn := NewState(storage).NewSnapshot().(*Trie).Txn()
+	txn.batch = batch
+	for _, key := range keys {
+		txn.Insert(key, key)
+	}
+	root, err := txn.Hash()
+	assert.NoError(t, err)
+	batch.Write()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	// load the trie from the storage so that the nodes are resolved on the walk
+	snap, err := NewState(storage).NewSnapshotAt(types.BytesToHash(root))
+	assert.NoError(t, err)
+	trie := snap.(*Trie)
+
+	collect := func(start []byte, limit int) [][]byte {
+		res := [][]byte{}
+		err := trie.Iterate(start, func(key, value []byte) bool {
+			assert.Equal(t, key, value)
+			res = append(res, key)
+			return len(res) < limit
+		})
+		assert.NoError(t, err)
+		return res
+	}
+
+	// all the keys in order
+	assert.Equal(t, keys, collect(nil, len(keys)+1))
+
+	// start at an existing key
+	assert.Equal(t, keys[10:20], collect(keys[10], 10))
+
+	// start between two keys
+	start := append(append([]byte{}, keys[50]...), 0x1)
+	assert.Equal(t, keys[51:], collect(start, len(keys)))
+}
@@ -0,0 +1,232 @@
+package itrie
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// commitRoots commits num states on top of each other and returns their roots
+func commitRoots(t *testing.T, s *State, num int) []types.Hash {
+	roots := []types.Hash{}
+
+	var snap state.Snapshot = s.NewSnapshot()
+	root := types.EmptyRootHash
+	for i := 1; i <= num; i++ {
+		// the account with storage is updated on every state
+		storageRoot := types.EmptyRootHash
+		if i > 1 {
+			acct, ok := snap.(*Trie).Get(hashit(types.Address{0x2}.Bytes()))
+			assert.True(t, ok)
+
+			var account state.Account
+			assert.NoError(t, account.UnmarshalRlp(acct))
+			storageRoot = account.Root
+		}
+
+		var data []byte
+		snap, data = snap.Commit([]*state.Object{
+			{
+				Address: types.Address{0x1},
+				Balance: big.NewInt(int64(i)),
+				Root:    types.EmptyRootHash,
+			},
+			{
+				Address: types.Address{0x2},
+				Balance: big.NewInt(1),
+				Root:    storageRoot,
+				Storage: []*state.StorageObject{
+					{Key: []byte{byte(i)}, Val: []byte{byte(i)}},
+				},
+			},
+		})
+		root = types.BytesToHash(data)
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+func testPrune(t *testing.T, storage Storage) {
+	s := NewState(storage)
+	roots := commitRoots(t, s, 5)
+
+	s.EnablePruning()
+
+	pruned, err := s.Prune(roots[3:])
+	assert.NoError(t, err)
+	assert.NotZero(t, pruned)
+
+	// the old states are removed
+	for _, root := range roots[:3] {
+		_, err := s.NewSnapshotAt(root)
+		assert.Error(t, err)
+	}
+
+	// the retained states are complete, including the storage
+	for i, root := range roots[3:] {
+		snap, err := s.NewSnapshotAt(root)
+		assert.NoError(t, err)
+
+		data, ok := snap.(*Trie).Get(hashit(types.Address{0x1}.Bytes()))
+		assert.True(t, ok)
+
+		var account state.Account
+		assert.NoError(t, account.UnmarshalRlp(data))
+		assert.Equal(t, int64(i+4), account.Balance.Int64())
+
+		data, ok = snap.(*Trie).Get(hashit(types.Address{0x2}.Bytes()))
+		assert.True(t, ok)
+		assert.NoError(t, account.UnmarshalRlp(data))
+
+		storage, err := s.NewSnapshotAt(account.Root)
+		assert.NoError(t, err)
+		for j := 1; j <= i+4; j++ {
+			_, ok := storage.(*Trie).Get(hashit([]byte{byte(j)}))
+			assert.True(t, ok)
+		}
+	}
+
+	// pruning again with the same roots does not remove anything
+	pruned, err = s.Prune(roots[3:])
+	assert.NoError(t, err)
+	assert.Zero(t, pruned)
+}
+
+func TestPrune_Memory(t *testing.T) {
+	testPrune(t, NewMemoryStorage())
+}
+
+func TestPrune_LevelDB(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "trie-prune")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	storage, err := NewLevelDBStorage(dir, nil, hclog.NewNullLogger())
+	assert.NoError(t, err)
+
+	testPrune(t, storage)
+}
+
+func TestPrune_NotEnabled(t *testing.T) {
+	s := NewState(NewMemoryStorage())
+	roots := commitRoots(t, s, 2)
+
+	_, err := s.Prune(roots)
+	assert.Error(t, err)
+}
+
+func TestPrune_RetainCommitted(t *testing.T) {
+	// the roots committed since the last prune are retained even
+	// if they are not part of the roots yet (i.e. the block is being written)
+	s := NewState(NewMemoryStorage())
+	s.EnablePruning()
+
+	roots := commitRoots(t, s, 3)
+
+	_, err := s.Prune(roots[2:])
+	assert.NoError(t, err)
+
+	for _, root := range roots {
+		_, err := s.NewSnapshotAt(root)
+		assert.NoError(t, err)
+	}
+
+	// they were opened since the last prune so the
+	// next prune retains them and the one after removes them
+	for i := 0; i < 2; i++ {
+		_, err = s.Prune(roots[2:])
+		assert.NoError(t, err)
+	}
+
+	_, err = s.NewSnapshotAt(roots[0])
+	assert.Error(t, err)
+}
+
+func TestPrune_RetainOpened(t *testing.T) {
+	s := NewState(NewMemoryStorage())
+	roots := commitRoots(t, s, 3)
+
+	s.EnablePruning()
+
+	// a snapshot of an old state is in use while the state is pruned
+	snap, err := s.NewSnapshotAt(roots[0])
+	assert.NoError(t, err)
+
+	_, err = s.Prune(roots[2:])
+	assert.NoError(t, err)
+
+	data, ok := snap.(*Trie).Get(hashit(types.Address{0x2}.Bytes()))
+	assert.True(t, ok)
+
+	var account state.Account
+	assert.NoError(t, account.UnmarshalRlp(data))
+
+	// the storage of the old state is retained too
+	storage, err := s.NewSnapshotAt(account.Root)
+	assert.NoError(t, err)
+
+	_, ok = storage.(*Trie).Get(hashit([]byte{1}))
+	assert.True(t, ok)
+}
+
+func TestPrune_ConcurrentCommit(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "trie-prune")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	storage, err := NewLevelDBStorage(dir, nil, hclog.NewNullLogger())
+	assert.NoError(t, err)
+
+	s := NewState(storage)
+	s.EnablePruning()
+
+	roots := commitRoots(t, s, 5)
+
+	// the states are committed while the old ones are pruned
+	doneCh := make(chan []types.Hash)
+	go func() {
+		doneCh <- commitRoots(t, s, 5)
+	}()
+
+	_, err = s.Prune(roots[4:])
+	assert.NoError(t, err)
+
+	// the new states are complete
+	for _, root := range <-doneCh {
+		snap, err := s.NewSnapshotAt(root)
+		assert.NoError(t, err)
+
+		_, ok := snap.(*Trie).Get(hashit(types.Address{0x2}.Bytes()))
+		assert.True(t, ok)
+	}
+}
+
+func TestPrune_SnapshotInUse(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	s := NewState(storage)
+	s.EnablePruning()
+
+	roots := commitRoots(t, s, 5)
+
+	// a snapshot that is read from the storage and kept across the prunes
+	snap, err := NewState(storage).NewSnapshotAt(roots[0])
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = s.Prune(roots[3:])
+		assert.NoError(t, err)
+	}
+
+	// the pruned nodes are not found instead of a panic
+	assert.NotPanics(t, func() {
+		_, ok := snap.(*Trie).Get(hashit(types.Address{0x1}.Bytes()))
+		assert.False(t, ok)
+	})
+}
@@ -2,6 +2,7 @@ package itrie
 
 import (
 	"fmt"
+	"sync"
 
 	lru "github.com/hashicorp/golang-lru"
 
@@ -12,6 +13,17 @@ import (
 type State struct {
 	storage Storage
 	cache   *lru.Cache
+
+	// pruneLock blocks the commits while the pruned nodes are removed
+	pruneLock sync.RWMutex
+
+	// recent are the roots committed or opened since the last prune,
+	// nil if the pruning is not enabled
+	recent     map[types.Hash]struct{}
+	recentLock sync.Mutex
+
+	// base is the state below an overlay (see NewOverlay)
+	base *State
 }
 
 func NewState(storage Storage) *State {
@@ -49,6 +61,7 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 	if ok {
 		t := tt.(*Trie)
 		t.state = s
+		s.addRecent(root)
 		return tt.(*Trie), nil
 	}
 	n, ok, err := GetNode(root.Bytes(), s.storage)
@@ -63,9 +76,189 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 		state:   s,
 		storage: s.storage,
 	}
+
+	// the state of an open snapshot is retained by the next prune
+	s.addRecent(root)
 	return t, nil
 }
 
 func (s *State) AddState(root types.Hash, t *Trie) {
 	s.cache.Add(root, t)
 }
+
+// NewOverlay returns a state on top of s that keeps its commits in memory.
+// The nodes are read from the storage of s but nothing is written to it
+func (s *State) NewOverlay() *State {
+	o := NewState(newOverlayStorage(s.storage))
+	o.base = s
+	return o
+}
+
+// EnablePruning starts tracking the committed and opened roots so that the state can be pruned
+func (s *State) EnablePruning() {
+	s.recentLock.Lock()
+	defer s.recentLock.Unlock()
+
+	if s.recent == nil {
+		s.recent = map[types.Hash]struct{}{}
+	}
+}
+
+// addRecent tracks a committed or opened root if the pruning is enabled
+func (s *State) addRecent(root types.Hash) {
+	if s.base != nil {
+		// the roots of an overlay read from the base are retained by its prune
+		if _, ok := s.base.storage.Get(root.Bytes()); ok {
+			s.base.addRecent(root)
+		}
+		return
+	}
+
+	s.recentLock.Lock()
+	defer s.recentLock.Unlock()
+
+	if s.recent != nil {
+		s.recent[root] = struct{}{}
+	}
+}
+
+// Prune removes the trie nodes that are not referenced by the given roots.
+// The roots committed or opened since the last prune are also retained since
+// their blocks might not be written yet or they might still be in use.
+// The nodes are marked and collected while the state is in use, the commits
+// are only blocked while the unreferenced nodes are removed.
+// It returns the number of removed nodes
+func (s *State) Prune(roots []types.Hash) (int, error) {
+	s.recentLock.Lock()
+	if s.recent == nil {
+		s.recentLock.Unlock()
+		return 0, fmt.Errorf("pruning is not enabled")
+	}
+	retained := append([]types.Hash{}, roots...)
+	for root := range s.recent {
+		retained = append(retained, root)
+	}
+	s.recentLock.Unlock()
+
+	// mark the nodes reachable from the retained roots. The commits
+	// in the meantime only write new nodes on top of the stored ones
+	marked := map[types.Hash]struct{}{}
+	for _, root := range retained {
+		if err := s.markRoot(root, marked); err != nil {
+			return 0, err
+		}
+	}
+
+	// collect the rest of the nodes, the code is stored
+	// under a prefix and it is never removed
+	unmarked := []types.Hash{}
+	err := s.storage.Keys(func(k []byte) {
+		if len(k) != types.HashLength {
+			return
+		}
+		if _, ok := marked[types.BytesToHash(k)]; ok {
+			return
+		}
+		unmarked = append(unmarked, types.BytesToHash(k))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.pruneLock.Lock()
+	defer s.pruneLock.Unlock()
+
+	s.recentLock.Lock()
+	defer s.recentLock.Unlock()
+
+	// mark the roots committed or opened while the nodes were collected.
+	// Most of their nodes are already marked so this is only a small walk
+	for root := range s.recent {
+		if err := s.markRoot(root, marked); err != nil {
+			return 0, err
+		}
+	}
+
+	pruned := 0
+	for _, k := range unmarked {
+		if _, ok := marked[k]; ok {
+			continue
+		}
+		s.storage.Delete(k.Bytes())
+		pruned++
+	}
+
+	// the cache might reference the pruned roots
+	s.cache.Purge()
+	s.recent = map[types.Hash]struct{}{}
+
+	return pruned, nil
+}
+
+// markRoot marks the nodes of the state with the given root. The root is
+// either an account trie or a storage trie since the opened roots are tracked too
+func (s *State) markRoot(root types.Hash, marked map[types.Hash]struct{}) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+	return s.markNodes(root.Bytes(), true, marked)
+}
+
+// markNodes marks the stored node with the given hash and all its children.
+// The leafs of the account trie also reference the storage tries
+func (s *State) markNodes(hash []byte, account bool, marked map[types.Hash]struct{}) error {
+	if _, ok := marked[types.BytesToHash(hash)]; ok {
+		return nil
+	}
+	n, ok, err := GetNode(hash, s.storage)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("node %x not found", hash)
+	}
+	marked[types.BytesToHash(hash)] = struct{}{}
+
+	return s.markNode(n, account, marked)
+}
+
+func (s *State) markNode(node Node, account bool, marked map[types.Hash]struct{}) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+
+	case *ValueNode:
+		if n.hash {
+			return s.markNodes(n.buf, account, marked)
+		}
+		// the accounts are encoded as lists and the storage values as bytes
+		if !account || len(n.buf) == 0 || n.buf[0] < 0xc0 {
+			return nil
+		}
+		var acct state.Account
+		if err := acct.UnmarshalRlp(n.buf); err != nil {
+			return err
+		}
+		if acct.Root == types.EmptyRootHash || acct.Root == types.ZeroHash {
+			return nil
+		}
+		return s.markNodes(acct.Root.Bytes(), false, marked)
+
+	case *ShortNode:
+		return s.markNode(n.child, account, marked)
+
+	case *FullNode:
+		if err := s.markNode(n.value, account, marked); err != nil {
+			return err
+		}
+		for _, child := range n.children {
+			if err := s.markNode(child, account, marked); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown node type %T", n)
+	}
+}
@@ -1,9 +1,12 @@
 package itrie
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/0xPolygon/minimal/state"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestState(t *testing.T) {
@@ -17,3 +20,51 @@ func buildPreState(pre state.PreStates) (state.State, state.Snapshot) {
 
 	return st, snap
 }
+
+func TestState_Overlay(t *testing.T) {
+	storage := NewMemoryStorage()
+	s := NewState(storage)
+	roots := commitRoots(t, s, 2)
+	s.EnablePruning()
+
+	countKeys := func() int {
+		count := 0
+		assert.NoError(t, storage.Keys(func([]byte) {
+			count++
+		}))
+		return count
+	}
+	keys := countKeys()
+
+	o := s.NewOverlay()
+	snap, err := o.NewSnapshotAt(roots[0])
+	assert.NoError(t, err)
+
+	_, data := snap.Commit([]*state.Object{
+		{
+			Address: types.Address{0x3},
+			Balance: big.NewInt(1),
+			Root:    types.EmptyRootHash,
+		},
+	})
+	root := types.BytesToHash(data)
+
+	// the commit is only readable from the overlay
+	assert.Equal(t, keys, countKeys())
+
+	_, err = o.NewSnapshotAt(root)
+	assert.NoError(t, err)
+
+	_, err = NewState(storage).NewSnapshotAt(root)
+	assert.Error(t, err)
+
+	// the base root opened by the overlay is retained by the prune of the base
+	_, err = s.Prune(nil)
+	assert.NoError(t, err)
+
+	_, err = NewState(storage).NewSnapshotAt(roots[0])
+	assert.NoError(t, err)
+
+	_, err = NewState(storage).NewSnapshotAt(roots[1])
+	assert.Error(t, err)
+}
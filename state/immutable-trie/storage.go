@@ -3,6 +3,7 @@ package itrie
 import (
 	"fmt"
 
+	ldbstorage "github.com/0xPolygon/minimal/blockchain/storage/leveldb"
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/0xPolygon/minimal/types"
 	"github.com/hashicorp/go-hclog"
@@ -26,6 +27,8 @@ type Batch interface {
 type Storage interface {
 	Put(k, v []byte)
 	Get(k []byte) ([]byte, bool)
+	Delete(k []byte)
+	Keys(fn func(k []byte)) error
 	Batch() Batch
 	SetCode(hash types.Hash, code []byte)
 	GetCode(hash types.Hash) ([]byte, bool)
@@ -66,6 +69,22 @@ func (kv *KVStorage) Put(k, v []byte) {
 	kv.db.Put(k, v, nil)
 }
 
+func (kv *KVStorage) Delete(k []byte) {
+	kv.db.Delete(k, nil)
+}
+
+// Keys calls fn with every key in the storage. The key
+// is only valid until fn returns
+func (kv *KVStorage) Keys(fn func(k []byte)) error {
+	iter := kv.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		fn(iter.Key())
+	}
+	return iter.Error()
+}
+
 func (kv *KVStorage) Get(k []byte) ([]byte, bool) {
 	data, err := kv.db.Get(k, nil)
 	if err != nil {
@@ -78,8 +97,10 @@ func (kv *KVStorage) Get(k []byte) ([]byte, bool) {
 	return data, true
 }
 
-func NewLevelDBStorage(path string, logger hclog.Logger) (Storage, error) {
-	db, err := leveldb.OpenFile(path, nil)
+// NewLevelDBStorage creates the trie storage with leveldb.
+// A nil config uses the default configuration
+func NewLevelDBStorage(path string, config *ldbstorage.Config, logger hclog.Logger) (Storage, error) {
+	db, err := leveldb.OpenFile(path, config.Options())
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +135,21 @@ func (m *memStorage) Get(p []byte) ([]byte, bool) {
 	return v, true
 }
 
+func (m *memStorage) Delete(p []byte) {
+	delete(m.db, hex.EncodeToHex(p))
+}
+
+func (m *memStorage) Keys(fn func(k []byte)) error {
+	for k := range m.db {
+		buf, err := hex.DecodeHex(k)
+		if err != nil {
+			return err
+		}
+		fn(buf)
+	}
+	return nil
+}
+
 func (m *memStorage) SetCode(hash types.Hash, code []byte) {
 	m.code[hash.String()] = code
 }
@@ -136,6 +172,34 @@ func (m *memBatch) Put(p, v []byte) {
 func (m *memBatch) Write() {
 }
 
+// overlayStorage keeps the writes in memory and reads the
+// keys that are not written from the base storage
+type overlayStorage struct {
+	*memStorage
+	base Storage
+}
+
+func newOverlayStorage(base Storage) Storage {
+	return &overlayStorage{
+		memStorage: NewMemoryStorage().(*memStorage),
+		base:       base,
+	}
+}
+
+func (o *overlayStorage) Get(p []byte) ([]byte, bool) {
+	if v, ok := o.memStorage.Get(p); ok {
+		return v, true
+	}
+	return o.base.Get(p)
+}
+
+func (o *overlayStorage) GetCode(hash types.Hash) ([]byte, bool) {
+	if code, ok := o.memStorage.GetCode(hash); ok {
+		return code, true
+	}
+	return o.base.GetCode(hash)
+}
+
 // GetNode retrieves a node from storage
 func GetNode(root []byte, storage Storage) (Node, bool, error) {
 	data, ok := storage.Get(root)
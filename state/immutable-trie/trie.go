@@ -124,6 +124,10 @@ var accountArenaPool fastrlp.ArenaPool
 var stateArenaPool fastrlp.ArenaPool // TODO, Remove once we do update in fastrlp
 
 func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
+	// the state cannot be pruned while the nodes are written
+	t.state.pruneLock.RLock()
+	defer t.state.pruneLock.RUnlock()
+
 	// Create an insertion batch for all the entries
 	batch := t.storage.Batch()
 
@@ -198,6 +202,7 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 	batch.Write()
 
 	t.state.AddState(types.BytesToHash(root), nTrie)
+	t.state.addRecent(types.BytesToHash(root))
 	return nTrie, root
 }
 
@@ -271,6 +276,9 @@ func (t *Txn) lookup(node interface{}, key []byte) (Node, []byte) {
 				panic(err)
 			}
 			if !ok {
+				// the node is referenced but it is not stored, the state was
+				// pruned while it was in use. The value is not found so that
+				// the readers fail instead of the node
 				return nil, nil
 			}
 			_, res := t.lookup(nc, key)
@@ -0,0 +1,229 @@
+package state
+
+import (
+	"expvar"
+	"runtime"
+	"sync"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// The parallel execution runs all the transactions of the block speculatively
+// and in parallel on top of the parent state while it tracks the accounts
+// each one of them reads and writes. Then, the results are merged in order.
+// A result is only valid if none of the accounts it read was written by
+// a previous transaction of the block, otherwise the transaction is executed
+// again on top of the merged state. Thus, the outcome is always the same
+// as the one of the serial execution.
+
+var metricParallelConflicts = expvar.NewInt("state_parallel_conflicts")
+
+// accessSet is the set of accounts read and written by a transaction
+type accessSet struct {
+	reads  map[types.Address]struct{}
+	writes map[types.Address]struct{}
+}
+
+func newAccessSet() *accessSet {
+	return &accessSet{
+		reads:  map[types.Address]struct{}{},
+		writes: map[types.Address]struct{}{},
+	}
+}
+
+func (a *accessSet) read(addr types.Address) {
+	a.reads[addr] = struct{}{}
+}
+
+func (a *accessSet) write(addr types.Address) {
+	a.writes[addr] = struct{}{}
+}
+
+// conflicts returns true if any of the accounts read is in the dirty set
+func (a *accessSet) conflicts(dirty map[types.Address]struct{}) bool {
+	for addr := range a.reads {
+		if _, ok := dirty[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedState serializes the access to the state. The tries load the
+// nodes lazily on lookup so they are not safe for concurrent reads
+type lockedState struct {
+	State
+	lock *sync.Mutex
+}
+
+func (s *lockedState) NewSnapshotAt(root types.Hash) (Snapshot, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	snap, err := s.State.NewSnapshotAt(root)
+	if err != nil {
+		return nil, err
+	}
+	return &lockedSnapshot{Snapshot: snap, lock: s.lock}, nil
+}
+
+func (s *lockedState) NewSnapshot() Snapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return &lockedSnapshot{Snapshot: s.State.NewSnapshot(), lock: s.lock}
+}
+
+func (s *lockedState) GetCode(hash types.Hash) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.State.GetCode(hash)
+}
+
+type lockedSnapshot struct {
+	Snapshot
+	lock *sync.Mutex
+}
+
+func (s *lockedSnapshot) Get(k []byte) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.Snapshot.Get(k)
+}
+
+func (s *lockedSnapshot) Commit(objs []*Object) (Snapshot, []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.Snapshot.Commit(objs)
+}
+
+// speculativeResult is the result of the speculative execution of a transaction
+type speculativeResult struct {
+	transition *Transition
+	msg        *types.Transaction
+	gasUsed    uint64
+	failed     bool
+
+	// senderErr is set if the sender of the transaction could not be recovered
+	senderErr error
+	// applyErr is set if the transaction could not be applied
+	applyErr error
+}
+
+// writeParallel writes the transactions executing them in parallel. It is only
+// valid for a transition without intermediate state roots (byzantium)
+func (t *Transition) writeParallel(txns []*types.Transaction) error {
+	results := make([]*speculativeResult, len(txns))
+
+	lock := &sync.Mutex{}
+	state := &lockedState{State: t.state.state, lock: lock}
+	snapshot := &lockedSnapshot{Snapshot: t.state.snapshot, lock: lock}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(txns) {
+		workers = len(txns)
+	}
+
+	indexCh := make(chan int, len(txns))
+	for i := range txns {
+		indexCh <- i
+	}
+	close(indexCh)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexCh {
+				results[i] = t.speculate(txns[i], NewTxn(state, snapshot))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// track the accounts written by the merged transactions
+	t.state.access = newAccessSet()
+	defer func() {
+		t.state.access = nil
+	}()
+
+	for i, txn := range txns {
+		res := results[i]
+		if res.senderErr != nil {
+			return res.senderErr
+		}
+
+		if res.applyErr != nil || res.transition.state.access.conflicts(t.state.access.writes) || t.gasPool < res.msg.Gas {
+			// execute it again on top of the merged state
+			metricParallelConflicts.Add(1)
+			if err := t.Write(txn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		t.merge(res)
+		t.writeReceipt(txn, res.msg, res.gasUsed, res.failed, res.transition.revertData())
+	}
+	return nil
+}
+
+// speculate applies the transaction on its own state
+func (t *Transition) speculate(txn *types.Transaction, state *Txn) *speculativeResult {
+	if err := t.recoverSender(txn); err != nil {
+		return &speculativeResult{senderErr: err}
+	}
+
+	state.access = newAccessSet()
+
+	transition := &Transition{
+		r:        t.r,
+		ctx:      t.ctx,
+		state:    state,
+		getHash:  t.getHash,
+		auxState: t.auxState,
+		config:   t.config,
+		gasPool:  t.gasPool,
+		block:    t.block,
+		deferFee: true,
+	}
+
+	// Make a local copy and apply the transaction
+	msg := txn.Copy()
+
+	gasUsed, failed, err := transition.Apply(msg)
+	return &speculativeResult{
+		transition: transition,
+		msg:        msg,
+		gasUsed:    gasUsed,
+		failed:     failed,
+		applyErr:   err,
+	}
+}
+
+// merge moves the changes of a valid speculative execution to the transition
+func (t *Transition) merge(res *speculativeResult) {
+	state := res.transition.state
+
+	for addr := range state.access.writes {
+		if obj, ok := state.txn.Get(addr.Bytes()); ok {
+			t.state.txn.Insert(addr.Bytes(), obj)
+		}
+		t.state.access.write(addr)
+	}
+	if logs := state.Logs(); logs != nil {
+		t.state.txn.Insert(logIndex, logs)
+	}
+
+	// pay the coinbase
+	t.state.AddBalance(t.ctx.Coinbase, res.transition.coinbaseFee)
+
+	// the gas left is returned to the pool
+	t.gasPool -= res.gasUsed
+	t.returnValue = res.transition.returnValue
+	t.returnErr = res.transition.returnErr
+}
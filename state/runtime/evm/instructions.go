@@ -442,13 +442,37 @@ func opMStore8(c *state) {
 	c.memory[offset.Uint64()] = byte(val.Uint64() & 0xff)
 }
 
+// --- access lists ---
+
+// gas costs of the accounts and the storage slots after the berlin fork (eip-2929)
+const (
+	coldAccountAccessCost uint64 = 2600
+	coldSloadCost         uint64 = 2100
+	warmStorageReadCost   uint64 = 100
+)
+
+// accountAccessGas adds the account to the access list and returns the cost of accessing it
+func (c *state) accountAccessGas(addr types.Address) uint64 {
+	if c.host.AccessAddress(addr) {
+		return warmStorageReadCost
+	}
+	return coldAccountAccessCost
+}
+
 // --- storage ---
 
 func opSload(c *state) {
 	loc := c.top()
 
 	var gas uint64
-	if c.config.Istanbul {
+	if c.config.Berlin {
+		// eip-2929
+		if c.host.AccessSlot(c.msg.Address, bigToHash(loc)) {
+			gas = warmStorageReadCost
+		} else {
+			gas = coldSloadCost
+		}
+	} else if c.config.Istanbul {
 		// eip-1884
 		gas = 800
 	} else if c.config.EIP150 {
@@ -480,12 +504,20 @@ func opSStore(c *state) {
 
 	legacyGasMetering := !c.config.Istanbul && (c.config.Petersburg || !c.config.Constantinople)
 
-	status := c.host.SetStorage(c.msg.Address, key, val, c.config)
 	cost := uint64(0)
+	if c.config.Berlin && !c.host.AccessSlot(c.msg.Address, key) {
+		// eip-2929, the slot is cold
+		cost = coldSloadCost
+	}
+
+	status := c.host.SetStorage(c.msg.Address, key, val, c.config)
 
 	switch status {
 	case runtime.StorageUnchanged:
-		if c.config.Istanbul {
+		if c.config.Berlin {
+			// eip-2929
+			cost += warmStorageReadCost
+		} else if c.config.Istanbul {
 			// eip-2200
 			cost = 800
 		} else if legacyGasMetering {
@@ -495,10 +527,18 @@ func opSStore(c *state) {
 		}
 
 	case runtime.StorageModified:
-		cost = 5000
+		if c.config.Berlin {
+			// eip-2929, the cold access is charged apart
+			cost += 5000 - coldSloadCost
+		} else {
+			cost = 5000
+		}
 
 	case runtime.StorageModifiedAgain:
-		if c.config.Istanbul {
+		if c.config.Berlin {
+			// eip-2929
+			cost += warmStorageReadCost
+		} else if c.config.Istanbul {
 			// eip-2200
 			cost = 800
 		} else if legacyGasMetering {
@@ -508,10 +548,15 @@ func opSStore(c *state) {
 		}
 
 	case runtime.StorageAdded:
-		cost = 20000
+		cost += 20000
 
 	case runtime.StorageDeleted:
-		cost = 5000
+		if c.config.Berlin {
+			// eip-2929, the cold access is charged apart
+			cost += 5000 - coldSloadCost
+		} else {
+			cost = 5000
+		}
 	}
 	if !c.consumeGas(cost) {
 		return
@@ -554,7 +599,10 @@ func opBalance(c *state) {
 	addr, _ := c.popAddr()
 
 	var gas uint64
-	if c.config.Istanbul {
+	if c.config.Berlin {
+		// eip-2929
+		gas = c.accountAccessGas(addr)
+	} else if c.config.Istanbul {
 		// eip-1884
 		gas = 700
 	} else if c.config.EIP150 {
@@ -634,7 +682,10 @@ func opExtCodeSize(c *state) {
 	addr, _ := c.popAddr()
 
 	var gas uint64
-	if c.config.EIP150 {
+	if c.config.Berlin {
+		// eip-2929
+		gas = c.accountAccessGas(addr)
+	} else if c.config.EIP150 {
 		gas = 700
 	} else {
 		gas = 20
@@ -667,7 +718,10 @@ func opExtCodeHash(c *state) {
 	address, _ := c.popAddr()
 
 	var gas uint64
-	if c.config.Istanbul {
+	if c.config.Berlin {
+		// eip-2929
+		gas = c.accountAccessGas(address)
+	} else if c.config.Istanbul {
 		gas = 700
 	} else {
 		gas = 400
@@ -738,7 +792,10 @@ func opExtCodeCopy(c *state) {
 	}
 
 	var gas uint64
-	if c.config.EIP150 {
+	if c.config.Berlin {
+		// eip-2929
+		gas = c.accountAccessGas(address)
+	} else if c.config.EIP150 {
 		gas = 700
 	} else {
 		gas = 20
@@ -878,6 +935,10 @@ func opSelfDestruct(c *state) {
 	// EIP150 reprice fork
 	if c.config.EIP150 {
 		gas = 5000
+		if c.config.Berlin && !c.host.AccessAddress(address) {
+			// eip-2929, the beneficiary is cold
+			gas += coldAccountAccessCost
+		}
 		if c.config.EIP158 {
 			// if empty and transfers value
 			if c.host.Empty(address) && c.host.GetBalance(c.msg.Address).Sign() != 0 {
@@ -1031,6 +1092,9 @@ func opCreate(op OpCode) instruction {
 		}
 
 		contract.Type = runtime.Create
+		if op == CREATE2 {
+			contract.Type = runtime.Create2
+		}
 
 		// Correct call
 		ret, gas, err := c.host.Callx(contract, c.host)
@@ -1152,7 +1216,10 @@ func (c *state) buildCallContract(op OpCode) (*runtime.Contract, uint64, uint64,
 	}
 
 	var gasCost uint64
-	if c.config.EIP150 {
+	if c.config.Berlin {
+		// eip-2929
+		gasCost = c.accountAccessGas(addr)
+	} else if c.config.EIP150 {
 		gasCost = 700
 	} else {
 		gasCost = 40
@@ -197,6 +197,18 @@ func (c *state) Run() ([]byte, error) {
 	var vmerr error
 
 	codeSize := len(c.code)
+	var (
+		tracer  runtime.Tracer
+		options runtime.TracerOptions
+	)
+	if c.host != nil {
+		if tracer = c.host.GetTracer(); tracer != nil {
+			options = tracer.Options()
+			if options.DisableSteps {
+				tracer = nil
+			}
+		}
+	}
 	for !c.stop {
 		if c.ip >= codeSize {
 			c.halt()
@@ -210,6 +222,12 @@ func (c *state) Run() ([]byte, error) {
 			c.exit(errOpCodeNotFound)
 			break
 		}
+		var step *runtime.ExecutionStep
+		if tracer != nil {
+			step = c.captureState(tracer, options, op)
+		}
+		gas := c.gas
+
 		// check if the depth of the stack is enough for the instruction
 		if c.sp < inst.stack {
 			c.exit(errStackUnderflow)
@@ -224,6 +242,10 @@ func (c *state) Run() ([]byte, error) {
 		// execute the instruction
 		inst.inst(c)
 
+		if step != nil {
+			step.GasCost = gas - c.gas
+		}
+
 		// check if stack size exceeds the max size
 		if c.sp > stackSize {
 			c.exit(errStackOverflow)
@@ -238,6 +260,29 @@ func (c *state) Run() ([]byte, error) {
 	return c.ret, vmerr
 }
 
+// captureState sends the state of the vm before the opcode is executed to the tracer.
+// The stack and the memory are only copied if the tracer consumes them
+func (c *state) captureState(tracer runtime.Tracer, options runtime.TracerOptions, op OpCode) *runtime.ExecutionStep {
+	step := &runtime.ExecutionStep{
+		PC:      uint64(c.ip),
+		Op:      op.String(),
+		Gas:     c.gas,
+		Depth:   c.msg.Depth,
+		Address: c.msg.Address,
+	}
+	if !options.DisableStack {
+		step.Stack = make([]*big.Int, c.sp)
+		for i, v := range c.stack[:c.sp] {
+			step.Stack[i] = new(big.Int).Set(v)
+		}
+	}
+	if !options.DisableMemory {
+		step.Memory = append([]byte{}, c.memory...)
+	}
+	tracer.CaptureState(c.host, step)
+	return step
+}
+
 func (c *state) inStaticCall() bool {
 	return c.msg.Static
 }
@@ -3,6 +3,7 @@ package precompiled
 import (
 	"testing"
 
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/helper/hex"
 	"github.com/stretchr/testify/assert"
 )
@@ -11,6 +12,7 @@ type precompiledTest struct {
 	Name     string
 	Input    string
 	Expected string
+	Gas      uint64
 }
 
 func testPrecompiled(t *testing.T, p contract, cases []precompiledTest) {
@@ -25,6 +27,16 @@ func testPrecompiled(t *testing.T, p contract, cases []precompiledTest) {
 	}
 }
 
+// testPrecompiledGas checks the gas cost of the test cases with the given forks
+func testPrecompiledGas(t *testing.T, p contract, cases []precompiledTest, config *chain.ForksInTime) {
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			h, _ := hex.DecodeString(c.Input)
+			assert.Equal(t, c.Gas, p.gas(h, config))
+		})
+	}
+}
+
 func TestECRecover(t *testing.T) {
 	var tests = []precompiledTest{
 		{
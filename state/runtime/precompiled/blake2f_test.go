@@ -3,6 +3,8 @@ package precompiled
 import (
 	"bytes"
 	"testing"
+
+	"github.com/0xPolygon/minimal/chain"
 )
 
 func TestBlake2f(t *testing.T) {
@@ -17,5 +19,8 @@ func TestBlake2f(t *testing.T) {
 		if !bytes.Equal(c.Expected, out) {
 			t.Fatal("bad")
 		}
+		if gas := b.gas(c.Input, &chain.ForksInTime{Istanbul: true}); gas != c.Gas {
+			t.Fatalf("bad gas, expected %d but found %d", c.Gas, gas)
+		}
 	})
 }
@@ -14,12 +14,15 @@ type modExp struct {
 
 var (
 	big1      = big.NewInt(1)
+	big3      = big.NewInt(3)
 	big4      = big.NewInt(4)
+	big7      = big.NewInt(7)
 	big8      = big.NewInt(8)
 	big16     = big.NewInt(16)
 	big32     = big.NewInt(32)
 	big64     = big.NewInt(64)
 	big96     = big.NewInt(96)
+	big200    = big.NewInt(200)
 	big480    = big.NewInt(480)
 	big1024   = big.NewInt(1024)
 	big3072   = big.NewInt(3072)
@@ -107,25 +110,42 @@ func (m *modExp) gas(input []byte, config *chain.ForksInTime) uint64 {
 		expHead.SetBytes(val)
 	}
 
-	// a := mult_complexity(max(length_of_MODULUS, length_of_BASE)
-	gasCost := new(big.Int)
+	// max(length_of_MODULUS, length_of_BASE)
+	maxLen := new(big.Int)
 	if modLen.Cmp(baseLen) >= 0 {
-		gasCost.Set(modLen)
+		maxLen.Set(modLen)
 	} else {
-		gasCost.Set(baseLen)
+		maxLen.Set(baseLen)
 	}
-	gasCost = multComplexity(gasCost)
 
-	// a = a * max(ADJUSTED_EXPONENT_LENGTH, 1)
+	// max(ADJUSTED_EXPONENT_LENGTH, 1)
 	adjExpLen := adjustedExponentLength(expLen, expHead)
-	if adjExpLen.Cmp(big1) >= 0 {
+	if adjExpLen.Cmp(big1) < 0 {
+		adjExpLen.Set(big1)
+	}
+
+	var gasCost *big.Int
+	if config.Berlin {
+		// eip-2565
+		// a := ceil(max_length / 8) ** 2
+		gasCost = new(big.Int).Add(maxLen, big7)
+		gasCost.Div(gasCost, big8)
+		gasCost.Mul(gasCost, gasCost)
+
+		// a = max(a * max(ADJUSTED_EXPONENT_LENGTH, 1) / 3, 200)
 		gasCost.Mul(gasCost, adjExpLen)
+		gasCost.Div(gasCost, big3)
+		if gasCost.Cmp(big200) < 0 {
+			gasCost.Set(big200)
+		}
 	} else {
-		gasCost.Mul(gasCost, big1)
-	}
+		// a := mult_complexity(max_length)
+		gasCost = multComplexity(maxLen)
 
-	// a = a / div
-	gasCost.Div(gasCost, divisor)
+		// a = a * max(ADJUSTED_EXPONENT_LENGTH, 1) / div
+		gasCost.Mul(gasCost, adjExpLen)
+		gasCost.Div(gasCost, divisor)
+	}
 
 	// cap to the max uint64
 	if !gasCost.IsUint64() {
@@ -15,9 +15,9 @@ type contract interface {
 	run(input []byte) ([]byte, error)
 }
 
-// Precompiled is the runtime for the precompiled contracts
+// Precompiled is the runtime for the precompiled contracts. It does not hold
+// any state between calls so it is safe to share it between transitions
 type Precompiled struct {
-	buf       []byte
 	contracts map[types.Address]contract
 }
 
@@ -51,6 +51,11 @@ func (p *Precompiled) register(addrStr string, b contract) {
 	p.contracts[types.StringToAddress(addrStr)] = b
 }
 
+// Disable removes a precompiled contract from the runtime
+func (p *Precompiled) Disable(addr types.Address) {
+	delete(p.contracts, addr)
+}
+
 var (
 	five  = types.StringToAddress("5")
 	six   = types.StringToAddress("6")
@@ -68,9 +73,24 @@ func (p *Precompiled) CanRun(c *runtime.Contract, host runtime.Host, config *cha
 	if _, ok := p.contracts[c.CodeAddress]; !ok {
 		return false
 	}
+	return isActive(c.CodeAddress, config)
+}
+
+// Addresses returns the addresses of the enabled precompiled contracts
+// that are active in the given fork
+func (p *Precompiled) Addresses(config *chain.ForksInTime) []types.Address {
+	addrs := []types.Address{}
+	for addr := range p.contracts {
+		if isActive(addr, config) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
 
+func isActive(addr types.Address, config *chain.ForksInTime) bool {
 	// byzantium precompiles
-	switch c.CodeAddress {
+	switch addr {
 	case five:
 		fallthrough
 	case six:
@@ -82,7 +102,7 @@ func (p *Precompiled) CanRun(c *runtime.Contract, host runtime.Host, config *cha
 	}
 
 	// istanbul precompiles
-	switch c.CodeAddress {
+	switch addr {
 	case nine:
 		return config.Istanbul
 	}
@@ -112,8 +132,6 @@ func (p *Precompiled) Run(c *runtime.Contract, host runtime.Host, config *chain.
 	return ret, c.Gas, err
 }
 
-var zeroPadding = make([]byte, 64)
-
 func (p *Precompiled) leftPad(buf []byte, n int) []byte {
 	// TODO, avoid buffer allocation
 	l := len(buf)
@@ -126,38 +144,23 @@ func (p *Precompiled) leftPad(buf []byte, n int) []byte {
 	return tmp
 }
 
+// get returns the next size bytes of the input right padded with zeros. The
+// buffer is allocated on every call since the runtime can run concurrently
 func (p *Precompiled) get(input []byte, size int) ([]byte, []byte) {
-	p.buf = extendByteSlice(p.buf, size)
 	n := size
 	if len(input) < n {
 		n = len(input)
 	}
 
-	// copy the part from the input
-	copy(p.buf[0:], input[:n])
+	// the rest of the buffer is already zero
+	buf := make([]byte, size)
+	copy(buf[0:], input[:n])
 
-	// copy empty values
-	if n < size {
-		rest := size - n
-		if rest < 64 {
-			copy(p.buf[n:], zeroPadding[0:size-n])
-		} else {
-			copy(p.buf[n:], make([]byte, rest))
-		}
-	}
-	return p.buf, input[n:]
+	return buf, input[n:]
 }
 
 func (p *Precompiled) getUint64(input []byte) (uint64, []byte) {
-	p.buf, input = p.get(input, 32)
-	num := binary.BigEndian.Uint64(p.buf[24:32])
+	buf, input := p.get(input, 32)
+	num := binary.BigEndian.Uint64(buf[24:32])
 	return num, input
 }
-
-func extendByteSlice(b []byte, needLen int) []byte {
-	b = b[:cap(b)]
-	if n := needLen - cap(b); n > 0 {
-		b = append(b, make([]byte, n)...)
-	}
-	return b[:needLen]
-}
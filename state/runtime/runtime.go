@@ -70,6 +70,60 @@ type Host interface {
 	Callx(*Contract, Host) ([]byte, uint64, error)
 	Empty(addr types.Address) bool
 	GetNonce(addr types.Address) uint64
+
+	// AccessAddress adds the address to the access list of the transaction (EIP-2929),
+	// it returns true if the address was already in the list (warm)
+	AccessAddress(addr types.Address) bool
+
+	// AccessSlot adds the storage slot to the access list of the transaction (EIP-2929),
+	// it returns true if the slot was already in the list (warm)
+	AccessSlot(addr types.Address, key types.Hash) bool
+
+	// GetTracer returns the tracer of the execution, nil if it is not traced
+	GetTracer() Tracer
+}
+
+// Tracer receives the steps of the execution of the contracts
+type Tracer interface {
+	// CaptureState is called before each opcode is executed. The GasCost
+	// of the step is only set once the opcode is executed
+	CaptureState(host Host, step *ExecutionStep)
+
+	// CaptureEnter is called when a call or a contract creation starts,
+	// including the ones of the transaction itself
+	CaptureEnter(callType CallType, c *Contract)
+
+	// CaptureExit is called when the last started call or contract creation ends
+	CaptureExit(output []byte, gasUsed uint64, err error)
+
+	// Options returns the state of the vm that the tracer consumes on each step
+	Options() TracerOptions
+}
+
+// TracerOptions selects the state of the vm copied to the steps of a tracer
+type TracerOptions struct {
+	// DisableSteps skips CaptureState for the tracers that only follow the calls
+	DisableSteps bool
+
+	DisableStack  bool
+	DisableMemory bool
+}
+
+// ExecutionStep is the state of the vm before an opcode is executed
+type ExecutionStep struct {
+	PC      uint64
+	Op      string
+	Gas     uint64
+	GasCost uint64
+	Depth   int
+
+	// Address is the account whose code is executed
+	Address types.Address
+
+	// Stack and Memory are copies of the stack and the memory of the vm,
+	// nil if they are disabled in the options of the tracer
+	Stack  []*big.Int
+	Memory []byte
 }
 
 var (
@@ -88,6 +142,25 @@ var (
 	ErrCodeStoreOutOfGas        = fmt.Errorf("code storage out of gas")
 )
 
+// ExecutionResult is the outcome of the execution of a transaction
+type ExecutionResult struct {
+	ReturnValue []byte
+	GasUsed     uint64
+
+	// Err is the error of the execution (if any)
+	Err error
+}
+
+// Failed returns true if the execution did not succeed
+func (r *ExecutionResult) Failed() bool {
+	return r.Err != nil
+}
+
+// Reverted returns true if the execution was reverted, the return value is the revert data
+func (r *ExecutionResult) Reverted() bool {
+	return r.Err == ErrExecutionReverted
+}
+
 type CallType int
 
 const (
@@ -99,6 +172,25 @@ const (
 	Create2
 )
 
+func (c CallType) String() string {
+	switch c {
+	case Call:
+		return "CALL"
+	case CallCode:
+		return "CALLCODE"
+	case DelegateCall:
+		return "DELEGATECALL"
+	case StaticCall:
+		return "STATICCALL"
+	case Create:
+		return "CREATE"
+	case Create2:
+		return "CREATE2"
+	default:
+		panic("BUG: call type not found")
+	}
+}
+
 // Runtime can process contracts
 type Runtime interface {
 	Run(c *Contract, host Host, config *chain.ForksInTime) ([]byte, uint64, error)
@@ -20,6 +20,9 @@ var (
 
 	// refundIndex is the index of the refund
 	refundIndex = types.BytesToHash([]byte{3}).Bytes()
+
+	// accessListIndex is the prefix of the entries of the access list
+	accessListIndex = types.BytesToHash([]byte{4}).Bytes()
 )
 
 // Txn is a reference of the state
@@ -30,6 +33,9 @@ type Txn struct {
 	txn       *iradix.Txn
 	codeCache *lru.Cache
 	hash      *keccak.Keccak
+
+	// access tracks the accounts read and written by the txn (if set)
+	access *accessSet
 }
 
 func NewTxn(state State, snapshot Snapshot) *Txn {
@@ -93,6 +99,10 @@ func (txn *Txn) GetAccount(addr types.Address) (*Account, bool) {
 }
 
 func (txn *Txn) getStateObject(addr types.Address) (*StateObject, bool) {
+	if txn.access != nil {
+		txn.access.read(addr)
+	}
+
 	// Check what this first fetch tries to do? Why is it here?
 	val, exists := txn.txn.Get(addr.Bytes())
 	if exists {
@@ -143,6 +153,10 @@ func (txn *Txn) upsertAccount(addr types.Address, create bool, f func(object *St
 		}
 	}
 
+	if txn.access != nil {
+		txn.access.write(addr)
+	}
+
 	// run the callback to modify the account
 	f(object)
 
@@ -286,13 +300,19 @@ func (txn *Txn) SetStorage(addr types.Address, key types.Hash, value types.Hash,
 	if original == value {
 		if original == zeroHash { // reset to original nonexistent slot (2.2.2.1)
 			// Storage was used as memory (allocation and deallocation occurred within the same contract)
-			if config.Istanbul {
+			if config.Berlin {
+				// eip-2929, the warm read costs 100
+				txn.AddRefund(19900)
+			} else if config.Istanbul {
 				txn.AddRefund(19200)
 			} else {
 				txn.AddRefund(19800)
 			}
 		} else { // reset to original existing slot (2.2.2.2)
-			if config.Istanbul {
+			if config.Berlin {
+				// eip-2929, the reset costs 2900 and the warm read 100
+				txn.AddRefund(2800)
+			} else if config.Istanbul {
 				txn.AddRefund(4200)
 			} else {
 				txn.AddRefund(4800)
@@ -317,6 +337,18 @@ func (txn *Txn) SetState(addr types.Address, key, value types.Hash) {
 	})
 }
 
+// SetFullState replaces the whole storage of an address
+func (txn *Txn) SetFullState(addr types.Address, storage map[types.Hash]types.Hash) {
+	txn.upsertAccount(addr, true, func(object *StateObject) {
+		object.Account.Trie = txn.state.NewSnapshot()
+		object.Account.Root = emptyStateHash
+		object.Txn = iradix.New().Txn()
+	})
+	for key, value := range storage {
+		txn.SetState(addr, key, value)
+	}
+}
+
 // GetState returns the state of the address at a given key
 func (txn *Txn) GetState(addr types.Address, key types.Hash) types.Hash {
 	object, exists := txn.getStateObject(addr)
@@ -449,6 +481,34 @@ func (txn *Txn) Logs() []*types.Log {
 	return data.([]*types.Log)
 }
 
+// AccessAddress adds the address to the access list, it returns
+// true if the address was already in the list
+func (txn *Txn) AccessAddress(addr types.Address) bool {
+	return txn.addAccess(append(append([]byte{}, accessListIndex...), addr.Bytes()...))
+}
+
+// AccessSlot adds the storage slot of the address to the access list,
+// it returns true if the slot was already in the list
+func (txn *Txn) AccessSlot(addr types.Address, key types.Hash) bool {
+	index := append(append([]byte{}, accessListIndex...), addr.Bytes()...)
+	return txn.addAccess(append(index, key.Bytes()...))
+}
+
+// addAccess inserts the entry of the access list. The entries are part of the
+// txn so that they are removed if the txn is reverted to a previous snapshot
+func (txn *Txn) addAccess(index []byte) bool {
+	if _, ok := txn.txn.Get(index); ok {
+		return true
+	}
+	txn.txn.Insert(index, true)
+	return false
+}
+
+// ClearAccessList removes all the entries of the access list
+func (txn *Txn) ClearAccessList() {
+	txn.txn.DeletePrefix(accessListIndex)
+}
+
 func (txn *Txn) GetRefund() uint64 {
 	data, exists := txn.txn.Get(refundIndex)
 	if !exists {
@@ -512,6 +572,9 @@ func (txn *Txn) CreateAccount(addr types.Address) {
 	if ok {
 		obj.Account.Balance.SetBytes(prev.Account.Balance.Bytes())
 	}
+	if txn.access != nil {
+		txn.access.write(addr)
+	}
 
 	txn.txn.Insert(addr.Bytes(), obj)
 }
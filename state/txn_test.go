@@ -129,6 +129,26 @@ func TestSnapshotUpdateData(t *testing.T) {
 	assert.Equal(t, hash1, txn.GetState(addr1, hash1))
 }
 
+func TestSnapshotAccessList(t *testing.T) {
+	txn := newTestTxn(defaultPreState)
+
+	assert.False(t, txn.AccessAddress(addr1))
+	assert.True(t, txn.AccessAddress(addr1))
+
+	// the slots are tracked apart from the address
+	ss := txn.Snapshot()
+	assert.False(t, txn.AccessSlot(addr1, hash1))
+	assert.True(t, txn.AccessSlot(addr1, hash1))
+
+	// the entries added after the snapshot are reverted
+	txn.RevertToSnapshot(ss)
+	assert.True(t, txn.AccessAddress(addr1))
+	assert.False(t, txn.AccessSlot(addr1, hash1))
+
+	txn.ClearAccessList()
+	assert.False(t, txn.AccessAddress(addr1))
+}
+
 func hashit(k []byte) []byte {
 	h := sha3.NewLegacyKeccak256()
 	h.Write(k)
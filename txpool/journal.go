@@ -0,0 +1,188 @@
+package txpool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/0xPolygon/minimal/types"
+)
+
+// defaultJournalCompactSize is the number of bytes appended to the journal
+// after which it is compacted to the transactions that are still pending
+const defaultJournalCompactSize = 4 * 1024 * 1024
+
+// journal is an append only file with the local transactions
+// of the pool so that they survive a restart of the node.
+// Each entry is the RLP encoding of the transaction prefixed
+// with its size as a 4 bytes big endian integer
+type journal struct {
+	path string
+
+	lock   sync.Mutex
+	writer *os.File
+
+	// size is the number of bytes appended since the last rotation
+	size uint64
+
+	// compactSize is the size that triggers a compaction
+	compactSize uint64
+}
+
+func newJournal(path string) *journal {
+	return &journal{
+		path:        path,
+		compactSize: defaultJournalCompactSize,
+	}
+}
+
+// load reads all the transactions in the journal and passes them
+// to the add function. A truncated entry at the end of the file
+// (i.e. a crash during a write) is ignored
+func (j *journal) load(add func(txn *types.Transaction) error) error {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		buf := make([]byte, binary.BigEndian.Uint32(header))
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+
+		txn := new(types.Transaction)
+		if err := txn.UnmarshalRLP(buf); err != nil {
+			return fmt.Errorf("failed to decode journal txn: %w", err)
+		}
+		if err := add(txn); err != nil {
+			return err
+		}
+	}
+}
+
+// insert appends a transaction to the journal. Once the appended entries
+// reach the compaction size, the journal is rewritten with only the
+// transactions for which keep returns true
+func (j *journal) insert(txn *types.Transaction, keep func(txn *types.Transaction) bool) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.writer == nil {
+		return fmt.Errorf("journal not open")
+	}
+
+	n, err := writeJournalTxn(j.writer, txn)
+	if err != nil {
+		return err
+	}
+
+	j.size += uint64(n)
+	if j.size < j.compactSize {
+		return nil
+	}
+
+	kept := []*types.Transaction{}
+	if err := j.load(func(txn *types.Transaction) error {
+		if keep(txn) {
+			kept = append(kept, txn)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return j.rotateImpl(kept)
+}
+
+// rotate replaces the content of the journal with the given
+// transactions and opens the journal for writing
+func (j *journal) rotate(txns []*types.Transaction) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return j.rotateImpl(txns)
+}
+
+func (j *journal) rotateImpl(txns []*types.Transaction) error {
+	if j.writer != nil {
+		if err := j.writer.Close(); err != nil {
+			return err
+		}
+		j.writer = nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+
+	// write the transactions in a temporal file first so that
+	// a crash during the rotation does not lose the journal
+	tmp, err := os.OpenFile(j.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	for _, txn := range txns {
+		if _, err := writeJournalTxn(tmp, txn); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(j.path+".new", j.path); err != nil {
+		return err
+	}
+
+	writer, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	j.writer = writer
+	j.size = 0
+	return nil
+}
+
+// close closes the journal file
+func (j *journal) close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.writer == nil {
+		return nil
+	}
+	err := j.writer.Close()
+	j.writer = nil
+	return err
+}
+
+func writeJournalTxn(w io.Writer, txn *types.Transaction) (int, error) {
+	data := txn.MarshalRLP()
+
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+
+	return w.Write(buf)
+}
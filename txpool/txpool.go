@@ -2,6 +2,7 @@ package txpool
 
 import (
 	"container/heap"
+	"errors"
 	"fmt"
 	"math/big"
 	"sort"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/0xPolygon/minimal/blockchain"
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/network"
 	"github.com/0xPolygon/minimal/txpool/proto"
 	"github.com/0xPolygon/minimal/types"
@@ -18,9 +20,52 @@ import (
 )
 
 const (
-	defaultIdlePeriod = 1 * time.Minute
+	defaultIdlePeriod    = 1 * time.Minute
+	defaultMaxTxDataSize = 128 * 1024 // 128Kb
 )
 
+var (
+	// ErrOversizedData is returned if the input data of a transaction exceeds the pool limit
+	ErrOversizedData = errors.New("oversized data")
+
+	// ErrAlreadyKnown is returned if the transaction is already in the pool
+	ErrAlreadyKnown = errors.New("already known")
+
+	// ErrTxnNotFound is returned if the transaction is not in the pool
+	ErrTxnNotFound = errors.New("transaction not found in the pool")
+
+	// ErrNoBroadcast is returned if the pool cannot broadcast transactions
+	ErrNoBroadcast = errors.New("transaction broadcast is disabled")
+
+	// ErrBlockLimitExceeded is returned if the gas of a transaction exceeds the block gas limit
+	ErrBlockLimitExceeded = errors.New("exceeds block gas limit")
+
+	// ErrTxTypeNotSupported is returned if the type of a transaction is not enabled in the next block
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+)
+
+// Config is the configuration for the transaction pool
+type Config struct {
+	// FIFO sorts the promoted transactions by arrival order instead of by gas price.
+	// Useful to get deterministic block contents in tests
+	FIFO bool
+
+	// MaxTxDataSize is the maximum size (in bytes) of the transaction input data
+	MaxTxDataSize uint64
+
+	// Journal is the path of the file where the local transactions are persisted
+	// to survive a restart. The journal is disabled if empty
+	Journal string
+}
+
+// DefaultConfig returns the default configuration of the pool (priced ordering)
+func DefaultConfig() *Config {
+	return &Config{
+		FIFO:          false,
+		MaxTxDataSize: defaultMaxTxDataSize,
+	}
+}
+
 type store interface {
 	Header() *types.Header
 	GetNonce(root types.Hash, addr types.Address) uint64
@@ -39,12 +84,25 @@ type TxPool struct {
 	store      store
 	idlePeriod time.Duration
 
+	// maximum size of the transaction input data
+	maxTxDataSize uint64
+
+	// blockGasLimit returns the gas limit of the next sealed block
+	blockGasLimit func() uint64
+
+	// forks of the chain, they enable the typed transactions
+	forks *chain.Forks
+
 	// unsorted list of transactions per account
-	queue map[types.Address]*txQueue
+	queue     map[types.Address]*txQueue
+	queueLock sync.Mutex
 
 	// sorted list of current valid transactions
 	sorted *txPriceHeap
 
+	// journal of the local transactions
+	journal *journal
+
 	// network stack
 	network *network.Server
 	topic   *network.Topic
@@ -53,22 +111,39 @@ type TxPool struct {
 	dev      bool
 	NotifyCh chan struct{}
 
+	// subscriptions for the hashes of the new transactions
+	pendingSubsLock sync.Mutex
+	pendingSubs     map[chan types.Hash]struct{}
+
 	proto.UnimplementedTxnPoolOperatorServer
 }
 
 // NewTxPool creates a new pool of transactios
-func NewTxPool(logger hclog.Logger, sealing bool, store store, grpcServer *grpc.Server, network *network.Server) (*TxPool, error) {
+func NewTxPool(logger hclog.Logger, sealing bool, config *Config, store store, grpcServer *grpc.Server, network *network.Server) (*TxPool, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	maxTxDataSize := config.MaxTxDataSize
+	if maxTxDataSize == 0 {
+		maxTxDataSize = defaultMaxTxDataSize
+	}
+
 	txPool := &TxPool{
 		logger:     logger.Named("txpool"),
 		store:      store,
 		idlePeriod: defaultIdlePeriod,
 		queue:      make(map[types.Address]*txQueue),
 		network:    network,
-		sorted:     newTxPriceHeap(),
+		sorted:     newTxPriceHeap(config.FIFO),
 		sealing:    sealing,
+
+		maxTxDataSize: maxTxDataSize,
+	}
+	if config.Journal != "" {
+		txPool.journal = newJournal(config.Journal)
 	}
 
-	if network != nil {
+	if network != nil && network.IsGossipEnabled() {
 		// subscribe to the gossip protocol
 		topic, err := network.NewTopic(topicNameV1, &proto.Txn{})
 		if err != nil {
@@ -85,6 +160,9 @@ func NewTxPool(logger hclog.Logger, sealing bool, store store, grpcServer *grpc.
 }
 
 func (t *TxPool) GetNonce(addr types.Address) (uint64, bool) {
+	t.queueLock.Lock()
+	defer t.queueLock.Unlock()
+
 	q, ok := t.queue[addr]
 	if !ok {
 		return 0, false
@@ -92,6 +170,50 @@ func (t *TxPool) GetNonce(addr types.Address) (uint64, bool) {
 	return q.nextNonce, true
 }
 
+// GetPendingTx returns a transaction from the pool (either promoted or queued)
+func (t *TxPool) GetPendingTx(txHash types.Hash) (*types.Transaction, bool) {
+	if txn, ok := t.sorted.Get(txHash); ok {
+		return txn, true
+	}
+
+	t.queueLock.Lock()
+	defer t.queueLock.Unlock()
+
+	for _, q := range t.queue {
+		for _, txn := range q.txs {
+			if txn.Hash == txHash {
+				return txn, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// IsPromotedTx returns true if the transaction is promoted, that is,
+// it is ready to be sealed and it is not waiting for a nonce gap
+func (t *TxPool) IsPromotedTx(txHash types.Hash) bool {
+	_, ok := t.sorted.Get(txHash)
+	return ok
+}
+
+// GetTxs returns the transactions in the pool grouped by sender, both the promoted
+// ones (pending) and the ones waiting for a nonce gap (queued)
+func (t *TxPool) GetTxs() (pending, queued map[types.Address][]*types.Transaction) {
+	pending = t.sorted.List()
+
+	t.queueLock.Lock()
+	defer t.queueLock.Unlock()
+
+	queued = map[types.Address][]*types.Transaction{}
+	for addr, q := range t.queue {
+		if len(q.txs) == 0 {
+			continue
+		}
+		queued[addr] = append([]*types.Transaction{}, q.txs...)
+	}
+	return
+}
+
 func (t *TxPool) AddSigner(s signer) {
 	// TODO: We can add more types of signers here
 	t.signer = s
@@ -119,25 +241,38 @@ func (t *TxPool) EnableDev() {
 	t.dev = true
 }
 
+// SetForks sets the forks of the chain. The typed transactions are
+// rejected until they are enabled in the next block
+func (t *TxPool) SetForks(forks *chain.Forks) {
+	t.forks = forks
+}
+
+// SetBlockGasLimit sets the function that returns the gas limit of the next
+// sealed block. The transactions that do not fit in a block are rejected
+func (t *TxPool) SetBlockGasLimit(fn func() uint64) {
+	t.blockGasLimit = fn
+}
+
 // AddTx adds a new transaction to the pool
 func (t *TxPool) AddTx(tx *types.Transaction) error {
+	// the same transaction might be submitted more than once (i.e. retries)
+	tx.ComputeHash()
+	if _, ok := t.GetPendingTx(tx.Hash); ok {
+		return ErrAlreadyKnown
+	}
+
 	if err := t.addImpl("addTxn", tx); err != nil {
 		return err
 	}
 
-	// broadcast the transaction only if network is enabled
-	// and we are not in dev mode
-	if t.topic != nil && !t.dev {
-		txn := &proto.Txn{
-			Raw: &any.Any{
-				Value: tx.MarshalRLP(),
-			},
-		}
-		if err := t.topic.Publish(txn); err != nil {
-			t.logger.Error("failed to topic txn", "err", err)
+	if t.journal != nil {
+		if err := t.journal.insert(tx, t.isPending); err != nil {
+			t.logger.Error("failed to journal txn", "err", err)
 		}
 	}
 
+	t.broadcast(tx)
+
 	if t.NotifyCh != nil {
 		select {
 		case t.NotifyCh <- struct{}{}:
@@ -147,6 +282,82 @@ func (t *TxPool) AddTx(tx *types.Transaction) error {
 	return nil
 }
 
+// isPending returns true if the transaction is still in the pool
+func (t *TxPool) isPending(txn *types.Transaction) bool {
+	_, ok := t.GetPendingTx(txn.Hash)
+	return ok
+}
+
+// LoadJournal replays the local transactions from the journal into the pool
+// and rotates the journal to keep only the ones that are still pending
+func (t *TxPool) LoadJournal() error {
+	if t.journal == nil {
+		return nil
+	}
+
+	var loaded []*types.Transaction
+	err := t.journal.load(func(txn *types.Transaction) error {
+		if err := t.addImpl("journal", txn); err != nil {
+			t.logger.Debug("failed to add journal txn", "hash", txn.Hash, "err", err)
+			return nil
+		}
+		loaded = append(loaded, txn)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pending := []*types.Transaction{}
+	for _, txn := range loaded {
+		if _, ok := t.GetPendingTx(txn.Hash); ok {
+			pending = append(pending, txn)
+			t.broadcast(txn)
+		}
+	}
+	t.logger.Info("loaded journal txns", "path", t.journal.path, "num", len(pending))
+
+	return t.journal.rotate(pending)
+}
+
+// Close closes the transaction pool
+func (t *TxPool) Close() error {
+	if t.journal != nil {
+		return t.journal.close()
+	}
+	return nil
+}
+
+// Rebroadcast broadcasts again to the peers a transaction
+// that is already in the pool
+func (t *TxPool) Rebroadcast(txHash types.Hash) error {
+	txn, ok := t.GetPendingTx(txHash)
+	if !ok {
+		return ErrTxnNotFound
+	}
+	if t.topic == nil || t.dev {
+		return ErrNoBroadcast
+	}
+	t.broadcast(txn)
+	return nil
+}
+
+// broadcast publishes the transaction to the peers only if
+// the network is enabled and we are not in dev mode
+func (t *TxPool) broadcast(tx *types.Transaction) {
+	if t.topic == nil || t.dev {
+		return
+	}
+	txn := &proto.Txn{
+		Raw: &any.Any{
+			Value: tx.MarshalRLP(),
+		},
+	}
+	if err := t.topic.Publish(txn); err != nil {
+		t.logger.Error("failed to topic txn", "err", err)
+	}
+}
+
 func (t *TxPool) addImpl(ctx string, txns ...*types.Transaction) error {
 	if len(txns) == 0 {
 		return nil
@@ -180,6 +391,9 @@ func (t *TxPool) addImpl(ctx string, txns ...*types.Transaction) error {
 		t.logger.Debug("add txn", "ctx", ctx, "hash", txn.Hash, "from", from)
 	}
 
+	t.queueLock.Lock()
+	defer t.queueLock.Unlock()
+
 	txnsQueue, ok := t.queue[from]
 	if !ok {
 		stateRoot := t.store.Header().StateRoot
@@ -196,9 +410,49 @@ func (t *TxPool) addImpl(ctx string, txns ...*types.Transaction) error {
 	for _, promoted := range txnsQueue.Promote() {
 		t.sorted.Push(promoted)
 	}
+
+	for _, txn := range txns {
+		t.notifyPendingTx(txn.Hash)
+	}
 	return nil
 }
 
+// pendingTxsBufferSize is the size of the buffer of each pending txs subscription
+const pendingTxsBufferSize = 256
+
+// SubscribePendingTxs returns a channel that receives the hash of each new
+// transaction added to the pool and a function to cancel the subscription.
+// The hashes are dropped if the subscriber does not keep up
+func (t *TxPool) SubscribePendingTxs() (<-chan types.Hash, func()) {
+	ch := make(chan types.Hash, pendingTxsBufferSize)
+
+	t.pendingSubsLock.Lock()
+	if t.pendingSubs == nil {
+		t.pendingSubs = map[chan types.Hash]struct{}{}
+	}
+	t.pendingSubs[ch] = struct{}{}
+	t.pendingSubsLock.Unlock()
+
+	cancel := func() {
+		t.pendingSubsLock.Lock()
+		delete(t.pendingSubs, ch)
+		t.pendingSubsLock.Unlock()
+	}
+	return ch, cancel
+}
+
+func (t *TxPool) notifyPendingTx(hash types.Hash) {
+	t.pendingSubsLock.Lock()
+	defer t.pendingSubsLock.Unlock()
+
+	for ch := range t.pendingSubs {
+		select {
+		case ch <- hash:
+		default:
+		}
+	}
+}
+
 func (t *TxPool) Length() uint64 {
 	return t.sorted.Length()
 }
@@ -264,10 +518,16 @@ func (t *TxPool) ProcessEvent(evnt *blockchain.Event) {
 }
 
 func (t *TxPool) validateTx(tx *types.Transaction) error {
+	if uint64(len(tx.Input)) > t.maxTxDataSize {
+		return ErrOversizedData
+	}
+	if t.blockGasLimit != nil && tx.Gas > t.blockGasLimit() {
+		return ErrBlockLimitExceeded
+	}
+	if t.forks != nil && !t.forks.At(t.store.Header().Number+1).AcceptsTx(tx) {
+		return ErrTxTypeNotSupported
+	}
 	/*
-		if tx.Size() > 32*1024 {
-			return fmt.Errorf("oversize data")
-		}
 		if tx.Value.Sign() < 0 {
 			return fmt.Errorf("negative value")
 		}
@@ -407,19 +667,32 @@ type pricedTx struct {
 	tx    *types.Transaction
 	from  types.Address
 	price *big.Int
+	seq   uint64
 	index int
 }
 
+// txPriceHeap sorts the promoted transactions for the block building. Only
+// the transaction with the lowest nonce of each account is in the heap, the
+// next one of the account is included once it is popped. This way the
+// transactions are sorted by gas price but always in nonce order per account
 type txPriceHeap struct {
 	lock  sync.Mutex
 	index map[types.Hash]*pricedTx
 	heap  txPriceHeapImpl
+	seq   uint64
+
+	// transactions of each account sorted by nonce, the first one is in the heap
+	accounts map[types.Address][]*pricedTx
 }
 
-func newTxPriceHeap() *txPriceHeap {
+func newTxPriceHeap(fifo bool) *txPriceHeap {
 	return &txPriceHeap{
 		index: make(map[types.Hash]*pricedTx),
-		heap:  make(txPriceHeapImpl, 0),
+		heap: txPriceHeapImpl{
+			fifo: fifo,
+			txs:  make([]*pricedTx, 0),
+		},
+		accounts: make(map[types.Address][]*pricedTx),
 	}
 }
 
@@ -430,13 +703,52 @@ func (t *txPriceHeap) Length() uint64 {
 	return uint64(len(t.index))
 }
 
+func (t *txPriceHeap) Get(hash types.Hash) (*types.Transaction, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	item, ok := t.index[hash]
+	if !ok {
+		return nil, false
+	}
+	return item.tx, true
+}
+
+// List returns the transactions in the heap grouped by sender
+func (t *txPriceHeap) List() map[types.Address][]*types.Transaction {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	res := map[types.Address][]*types.Transaction{}
+	for _, item := range t.index {
+		res[item.from] = append(res[item.from], item.tx)
+	}
+	return res
+}
+
 func (t *txPriceHeap) Delete(tx *types.Transaction) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	if item, ok := t.index[tx.Hash]; ok {
+	item, ok := t.index[tx.Hash]
+	if !ok {
+		return
+	}
+	delete(t.index, tx.Hash)
+
+	txs := t.accounts[item.from]
+	for i, accountTx := range txs {
+		if accountTx == item {
+			txs = append(txs[:i], txs[i+1:]...)
+			break
+		}
+	}
+	t.setAccount(item.from, txs)
+
+	if item.index >= 0 {
+		// the transaction was the first one of the account
 		heap.Remove(&t.heap, item.index)
-		delete(t.index, tx.Hash)
+		t.pushFirst(item.from)
 	}
 }
 
@@ -454,9 +766,31 @@ func (t *txPriceHeap) Push(tx *types.Transaction) error {
 		tx:    tx,
 		from:  tx.From,
 		price: price,
+		seq:   t.seq,
+		index: -1,
 	}
+	t.seq++
+
 	t.index[tx.Hash] = pTx
-	heap.Push(&t.heap, pTx)
+
+	// insert the transaction in nonce order after any
+	// other transaction of the account with the same nonce
+	txs := t.accounts[tx.From]
+	i := sort.Search(len(txs), func(i int) bool {
+		return txs[i].tx.Nonce > tx.Nonce
+	})
+	txs = append(txs, nil)
+	copy(txs[i+1:], txs[i:])
+	txs[i] = pTx
+	t.accounts[tx.From] = txs
+
+	if i == 0 {
+		// the transaction replaces the first one of the account in the heap
+		if len(txs) > 1 {
+			heap.Remove(&t.heap, txs[1].index)
+		}
+		heap.Push(&t.heap, pTx)
+	}
 	return nil
 }
 
@@ -469,43 +803,71 @@ func (t *txPriceHeap) Pop() *pricedTx {
 	}
 	tx := heap.Pop(&t.heap).(*pricedTx)
 	delete(t.index, tx.tx.Hash)
+
+	// the next transaction of the account can be popped now
+	t.setAccount(tx.from, t.accounts[tx.from][1:])
+	t.pushFirst(tx.from)
+
 	return tx
 }
 
+// pushFirst includes the first transaction of the account in the heap
+func (t *txPriceHeap) pushFirst(from types.Address) {
+	if txs := t.accounts[from]; len(txs) != 0 {
+		heap.Push(&t.heap, txs[0])
+	}
+}
+
+func (t *txPriceHeap) setAccount(from types.Address, txs []*pricedTx) {
+	if len(txs) == 0 {
+		delete(t.accounts, from)
+		return
+	}
+	t.accounts[from] = txs
+}
+
 func (t *txPriceHeap) Contains(tx *types.Transaction) bool {
 	_, ok := t.index[tx.Hash]
 	return ok
 }
 
-type txPriceHeapImpl []*pricedTx
+// txPriceHeapImpl sorts the transactions either by gas price (highest first)
+// or by arrival order if fifo is set. It holds at most one transaction per account
+type txPriceHeapImpl struct {
+	fifo bool
+	txs  []*pricedTx
+}
 
-func (t txPriceHeapImpl) Len() int { return len(t) }
+func (t txPriceHeapImpl) Len() int { return len(t.txs) }
 
 func (t txPriceHeapImpl) Less(i, j int) bool {
-	if t[i].from == t[j].from {
-		return t[i].tx.Nonce < t[j].tx.Nonce
+	a, b := t.txs[i], t.txs[j]
+	if !t.fifo {
+		if c := a.price.Cmp(b.price); c != 0 {
+			return c > 0
+		}
 	}
-	return t[i].price.Cmp((t[j].price)) < 0
+	return a.seq < b.seq
 }
 
 func (t txPriceHeapImpl) Swap(i, j int) {
-	t[i], t[j] = t[j], t[i]
-	t[i].index = i
-	t[j].index = j
+	t.txs[i], t.txs[j] = t.txs[j], t.txs[i]
+	t.txs[i].index = i
+	t.txs[j].index = j
 }
 
 func (t *txPriceHeapImpl) Push(x interface{}) {
-	n := len(*t)
+	n := len(t.txs)
 	job := x.(*pricedTx)
 	job.index = n
-	*t = append(*t, job)
+	t.txs = append(t.txs, job)
 }
 
 func (t *txPriceHeapImpl) Pop() interface{} {
-	old := *t
+	old := t.txs
 	n := len(old)
 	job := old[n-1]
 	job.index = -1
-	*t = old[0 : n-1]
+	t.txs = old[0 : n-1]
 	return job
 }
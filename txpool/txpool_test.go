@@ -2,9 +2,15 @@ package txpool
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/0xPolygon/minimal/chain"
 	"github.com/0xPolygon/minimal/crypto"
 	"github.com/0xPolygon/minimal/network"
 	"github.com/0xPolygon/minimal/types"
@@ -14,7 +20,7 @@ import (
 
 func TestMultipleTransactions(t *testing.T) {
 	// if we add the same transaction it should only be included once
-	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
 	assert.NoError(t, err)
 	pool.EnableDev()
 
@@ -55,7 +61,7 @@ func TestBroadcast(t *testing.T) {
 	signer := &crypto.FrontierSigner{}
 
 	createPool := func() *TxPool {
-		pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, network.CreateServer(t, nil))
+		pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, network.CreateServer(t, nil))
 		assert.NoError(t, err)
 		pool.AddSigner(signer)
 		return pool
@@ -95,7 +101,7 @@ func (m *mockStore) Header() *types.Header {
 }
 
 func TestTxnQueue_Promotion(t *testing.T) {
-	pool, err := NewTxPool(hclog.NewNullLogger(), false, &mockStore{}, nil, nil)
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
 	assert.NoError(t, err)
 	pool.EnableDev()
 
@@ -121,3 +127,536 @@ func TestTxnQueue_Promotion(t *testing.T) {
 	assert.Equal(t, nonce, uint64(2))
 	assert.Equal(t, pool.Length(), uint64(2))
 }
+
+func TestTxPool_Ordering(t *testing.T) {
+	// txns from different accounts submitted with increasing gas price
+	txns := []*types.Transaction{
+		{From: types.Address{0x1}, GasPrice: big.NewInt(1), Value: big.NewInt(0)},
+		{From: types.Address{0x2}, GasPrice: big.NewInt(3), Value: big.NewInt(0)},
+		{From: types.Address{0x3}, GasPrice: big.NewInt(2), Value: big.NewInt(0)},
+	}
+
+	popAll := func(pool *TxPool) []types.Address {
+		res := []types.Address{}
+		for {
+			txn, _ := pool.Pop()
+			if txn == nil {
+				break
+			}
+			res = append(res, txn.From)
+		}
+		return res
+	}
+
+	cases := []struct {
+		name   string
+		config *Config
+		order  []types.Address
+	}{
+		{
+			name:   "fifo preserves the submission order",
+			config: &Config{FIFO: true},
+			order:  []types.Address{{0x1}, {0x2}, {0x3}},
+		},
+		{
+			name:   "priced sorts by gas price",
+			config: DefaultConfig(),
+			order:  []types.Address{{0x2}, {0x3}, {0x1}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pool, err := NewTxPool(hclog.NewNullLogger(), false, c.config, &mockStore{}, nil, nil)
+			assert.NoError(t, err)
+			pool.EnableDev()
+
+			for _, txn := range txns {
+				assert.NoError(t, pool.addImpl("", txn.Copy()))
+			}
+			assert.Equal(t, c.order, popAll(pool))
+		})
+	}
+}
+
+func TestTxPriceHeap_NonceOrder(t *testing.T) {
+	newTxn := func(from byte, nonce uint64, price int64) *types.Transaction {
+		txn := &types.Transaction{
+			From:     types.Address{from},
+			Nonce:    nonce,
+			GasPrice: big.NewInt(price),
+			Value:    big.NewInt(0),
+		}
+		txn.ComputeHash()
+		return txn
+	}
+
+	popAll := func(h *txPriceHeap) []*types.Transaction {
+		res := []*types.Transaction{}
+		for {
+			txn := h.Pop()
+			if txn == nil {
+				break
+			}
+			res = append(res, txn.tx)
+		}
+		return res
+	}
+
+	a0, a1, a2 := newTxn(0x1, 0, 1), newTxn(0x1, 1, 10), newTxn(0x1, 2, 4)
+	b0 := newTxn(0x2, 0, 5)
+
+	h := newTxPriceHeap(false)
+	for _, txn := range []*types.Transaction{a1, b0, a2, a0} {
+		assert.NoError(t, h.Push(txn))
+	}
+
+	// the cheap first transaction of 0x1 holds back the expensive ones
+	assert.Equal(t, []*types.Transaction{b0, a0, a1, a2}, popAll(h))
+
+	// deleting the first transaction of the account frees the next one
+	for _, txn := range []*types.Transaction{a0, a1, a2, b0} {
+		assert.NoError(t, h.Push(txn))
+	}
+	h.Delete(a0)
+	assert.Equal(t, uint64(3), h.Length())
+	assert.Equal(t, []*types.Transaction{a1, b0, a2}, popAll(h))
+
+	// a popped transaction pushed back goes first again
+	for _, txn := range []*types.Transaction{a0, a1} {
+		assert.NoError(t, h.Push(txn))
+	}
+	first := h.Pop()
+	assert.Equal(t, a0, first.tx)
+	assert.NoError(t, h.Push(first.tx))
+	assert.Equal(t, []*types.Transaction{a0, a1}, popAll(h))
+}
+
+func TestTxPool_MaxTxDataSize(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, &Config{MaxTxDataSize: 1024}, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	newTxn := func(nonce uint64, size int) *types.Transaction {
+		return &types.Transaction{
+			From:     types.Address{0x1},
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+			Input:    make([]byte, size),
+		}
+	}
+
+	// oversized input is rejected
+	assert.Equal(t, ErrOversizedData, pool.addImpl("", newTxn(0, 1025)))
+	assert.Equal(t, pool.Length(), uint64(0))
+
+	// input just under the limit is accepted
+	assert.NoError(t, pool.addImpl("", newTxn(0, 1024)))
+	assert.Equal(t, pool.Length(), uint64(1))
+}
+
+func TestTxPool_DefaultMaxTxDataSize(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	txn := &types.Transaction{
+		From:     types.Address{0x1},
+		GasPrice: big.NewInt(1),
+		Input:    make([]byte, 128*1024+1),
+	}
+	assert.Equal(t, ErrOversizedData, pool.addImpl("", txn))
+}
+
+func TestTxPool_BlockGasLimit(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+	pool.SetBlockGasLimit(func() uint64 {
+		return 100000
+	})
+
+	newTxn := func(nonce, gas uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     types.Address{0x1},
+			Nonce:    nonce,
+			Gas:      gas,
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(0),
+		}
+	}
+
+	// a transaction that does not fit in a block is rejected
+	assert.Equal(t, ErrBlockLimitExceeded, pool.addImpl("", newTxn(0, 100001)))
+	assert.Equal(t, pool.Length(), uint64(0))
+
+	assert.NoError(t, pool.addImpl("", newTxn(0, 100000)))
+	assert.Equal(t, pool.Length(), uint64(1))
+}
+
+func TestTxPool_TypedTx_Forks(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	newTxn := func(nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			Type:     types.DynamicFeeTx,
+			From:     types.Address{0x1},
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(0),
+		}
+	}
+
+	// the next block is before the london fork
+	pool.SetForks(&chain.Forks{London: chain.NewFork(2)})
+	assert.Equal(t, ErrTxTypeNotSupported, pool.addImpl("", newTxn(0)))
+
+	pool.SetForks(&chain.Forks{London: chain.NewFork(1)})
+	assert.NoError(t, pool.addImpl("", newTxn(0)))
+
+	// the access lists need the berlin fork
+	txn := newTxn(1)
+	txn.Type = types.AccessListTx
+	txn.AccessList = types.AccessList{{Address: types.Address{0x2}}}
+	assert.Equal(t, ErrTxTypeNotSupported, pool.addImpl("", txn))
+
+	pool.SetForks(&chain.Forks{Berlin: chain.NewFork(1), London: chain.NewFork(1)})
+	assert.NoError(t, pool.addImpl("", txn))
+}
+
+func TestTxPool_GetPendingTx(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	newTxn := func(nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     types.Address{0x1},
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(0),
+		}
+	}
+
+	// promoted transaction
+	promoted := newTxn(0)
+	assert.NoError(t, pool.addImpl("", promoted))
+
+	// queued transaction (nonce gap)
+	queued := newTxn(2)
+	assert.NoError(t, pool.addImpl("", queued))
+
+	for _, txn := range []*types.Transaction{promoted, queued} {
+		found, ok := pool.GetPendingTx(txn.Hash)
+		assert.True(t, ok)
+		assert.Equal(t, txn, found)
+	}
+
+	_, ok := pool.GetPendingTx(types.Hash{0x1})
+	assert.False(t, ok)
+
+	// only the promoted transaction is ready to be sealed
+	assert.True(t, pool.IsPromotedTx(promoted.Hash))
+	assert.False(t, pool.IsPromotedTx(queued.Hash))
+}
+
+func TestTxPool_GetPendingTx_Concurrent(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	// the queued transactions are added while they are looked up
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for i := 0; i < 100; i++ {
+			txn := &types.Transaction{
+				From:     types.Address{byte(i + 1)},
+				Nonce:    1,
+				GasPrice: big.NewInt(int64(i + 1)),
+				Value:    big.NewInt(0),
+			}
+			assert.NoError(t, pool.addImpl("", txn))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		pool.GetPendingTx(types.Hash{0x1})
+	}
+	<-doneCh
+}
+
+func TestTxPool_GetTxs(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	newTxn := func(from types.Address, nonce uint64) *types.Transaction {
+		return &types.Transaction{
+			From:     from,
+			Nonce:    nonce,
+			GasPrice: big.NewInt(1),
+			Value:    big.NewInt(0),
+		}
+	}
+
+	addr1, addr2 := types.Address{0x1}, types.Address{0x2}
+
+	txns := []*types.Transaction{
+		newTxn(addr1, 0),
+		newTxn(addr1, 1),
+		newTxn(addr1, 3), // nonce gap
+		newTxn(addr2, 0),
+	}
+	// the sender is not part of the hash of an unsigned transaction
+	txns[3].GasPrice = big.NewInt(2)
+
+	for _, txn := range txns {
+		assert.NoError(t, pool.addImpl("", txn))
+	}
+
+	pending, queued := pool.GetTxs()
+	assert.Len(t, pending, 2)
+	assert.ElementsMatch(t, txns[:2], pending[addr1])
+	assert.Equal(t, txns[3:], pending[addr2])
+
+	assert.Len(t, queued, 1)
+	assert.Equal(t, txns[2:3], queued[addr1])
+}
+
+func TestTxPool_GetTxs_Concurrent(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	// the queued transactions are added while they are listed
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for i := 0; i < 100; i++ {
+			txn := &types.Transaction{
+				From:     types.Address{byte(i + 1)},
+				Nonce:    1,
+				GasPrice: big.NewInt(int64(i + 1)),
+				Value:    big.NewInt(0),
+			}
+			assert.NoError(t, pool.addImpl("", txn))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		pool.GetTxs()
+	}
+	<-doneCh
+
+	_, queued := pool.GetTxs()
+	assert.Len(t, queued, 100)
+}
+
+func TestTxPool_Rebroadcast(t *testing.T) {
+	key0, _ := crypto.GenerateKey()
+	signer := &crypto.FrontierSigner{}
+
+	createPool := func(sealing bool) *TxPool {
+		srv := network.CreateServer(t, func(c *network.Config) {
+			c.NoDiscover = true
+		})
+		pool, err := NewTxPool(hclog.NewNullLogger(), sealing, nil, &mockStore{}, nil, srv)
+		assert.NoError(t, err)
+		pool.AddSigner(signer)
+		return pool
+	}
+
+	pool0 := createPool(false)
+	pool1 := createPool(true)
+
+	network.MultiJoin(t, pool0.network, pool1.network)
+
+	txn := &types.Transaction{
+		Value:    big.NewInt(10),
+		GasPrice: big.NewInt(1),
+	}
+	txn, err := signer.SignTx(txn, key0)
+	assert.NoError(t, err)
+
+	// include the txn in pool0 without broadcasting it
+	assert.NoError(t, pool0.addImpl("", txn))
+
+	// unknown transactions cannot be rebroadcasted
+	assert.Equal(t, ErrTxnNotFound, pool0.Rebroadcast(types.Hash{0x1}))
+
+	// the gossip mesh might take some time to be ready,
+	// rebroadcast until the peer receives the txn
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, pool0.Rebroadcast(txn.Hash))
+		time.Sleep(500 * time.Millisecond)
+
+		if _, ok := pool1.GetPendingTx(txn.Hash); ok {
+			return
+		}
+	}
+	t.Fatal("txn not received by the peer")
+}
+
+func TestTxPool_Journal(t *testing.T) {
+	key0, _ := crypto.GenerateKey()
+	signer := &crypto.FrontierSigner{}
+
+	dir, err := ioutil.TempDir("/tmp", "txpool-journal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := DefaultConfig()
+	config.Journal = filepath.Join(dir, "transactions.rlp")
+
+	createPool := func() *TxPool {
+		pool, err := NewTxPool(hclog.NewNullLogger(), false, config, &mockStore{}, nil, nil)
+		assert.NoError(t, err)
+		pool.AddSigner(signer)
+		assert.NoError(t, pool.LoadJournal())
+		return pool
+	}
+
+	pool := createPool()
+
+	hashes := []types.Hash{}
+	for i := 0; i < 3; i++ {
+		txn, err := signer.SignTx(&types.Transaction{
+			Nonce:    uint64(i),
+			Value:    big.NewInt(10),
+			GasPrice: big.NewInt(1),
+		}, key0)
+		assert.NoError(t, err)
+		assert.NoError(t, pool.AddTx(txn))
+
+		hashes = append(hashes, txn.Hash)
+	}
+	assert.NoError(t, pool.Close())
+
+	// restart the pool with the same journal
+	pool = createPool()
+	defer pool.Close()
+
+	assert.Equal(t, uint64(3), pool.Length())
+	for _, hash := range hashes {
+		_, ok := pool.GetPendingTx(hash)
+		assert.True(t, ok)
+	}
+}
+
+func TestTxPool_Journal_Compact(t *testing.T) {
+	signer := &crypto.FrontierSigner{}
+
+	dir, err := ioutil.TempDir("/tmp", "txpool-journal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := DefaultConfig()
+	config.Journal = filepath.Join(dir, "transactions.rlp")
+
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, config, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.AddSigner(signer)
+	assert.NoError(t, pool.LoadJournal())
+	defer pool.Close()
+
+	addTxs := func(num int) []types.Hash {
+		var (
+			wg     sync.WaitGroup
+			lock   sync.Mutex
+			hashes []types.Hash
+		)
+		for i := 0; i < num; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				key, _ := crypto.GenerateKey()
+				txn, err := signer.SignTx(&types.Transaction{
+					Value:    big.NewInt(10),
+					GasPrice: big.NewInt(1),
+				}, key)
+				assert.NoError(t, err)
+				assert.NoError(t, pool.AddTx(txn))
+
+				lock.Lock()
+				hashes = append(hashes, txn.Hash)
+				lock.Unlock()
+			}()
+		}
+		wg.Wait()
+		return hashes
+	}
+	journalTxs := func() []types.Hash {
+		hashes := []types.Hash{}
+		assert.NoError(t, newJournal(config.Journal).load(func(txn *types.Transaction) error {
+			hashes = append(hashes, txn.Hash)
+			return nil
+		}))
+		return hashes
+	}
+
+	// the transactions are appended concurrently
+	hashes := addTxs(5)
+	assert.ElementsMatch(t, hashes, journalTxs())
+
+	// the next insert compacts the journal, the popped transaction is dropped
+	popped, _ := pool.Pop()
+	pool.journal.compactSize = 1
+
+	hashes = append(hashes, addTxs(1)...)
+
+	expected := []types.Hash{}
+	for _, hash := range hashes {
+		if hash != popped.Hash {
+			expected = append(expected, hash)
+		}
+	}
+	assert.ElementsMatch(t, expected, journalTxs())
+}
+
+func TestTxPool_AddTx_AlreadyKnown(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	txn := &types.Transaction{
+		From:     types.Address{0x1},
+		GasPrice: big.NewInt(1),
+	}
+	assert.NoError(t, pool.AddTx(txn))
+	assert.Equal(t, ErrAlreadyKnown, pool.AddTx(txn))
+	assert.Equal(t, uint64(1), pool.Length())
+}
+
+func TestTxPool_SubscribePendingTxs(t *testing.T) {
+	pool, err := NewTxPool(hclog.NewNullLogger(), false, nil, &mockStore{}, nil, nil)
+	assert.NoError(t, err)
+	pool.EnableDev()
+
+	ch, cancel := pool.SubscribePendingTxs()
+
+	txn := &types.Transaction{
+		From:     types.Address{0x1},
+		GasPrice: big.NewInt(1),
+	}
+	assert.NoError(t, pool.AddTx(txn))
+
+	select {
+	case hash := <-ch:
+		assert.Equal(t, txn.Hash, hash)
+	default:
+		t.Fatal("pending txn not notified")
+	}
+
+	// no more notifications after the subscription is canceled
+	cancel()
+	assert.NoError(t, pool.AddTx(&types.Transaction{
+		From:     types.Address{0x1},
+		Nonce:    1,
+		GasPrice: big.NewInt(1),
+	}))
+	assert.Len(t, ch, 0)
+}
@@ -2,11 +2,14 @@ package buildroot
 
 import (
 	"bytes"
+	"math/big"
 	"math/rand"
 	"testing"
 	"time"
 
 	"github.com/0xPolygon/minimal/helper/keccak"
+	"github.com/0xPolygon/minimal/types"
+	"github.com/stretchr/testify/assert"
 )
 
 func BenchmarkFast(b *testing.B) {
@@ -84,3 +87,31 @@ func buildRandomInput(num int) func(i int) []byte {
 		return res[i]
 	}
 }
+
+func TestCalculateTransactionsRoot_Typed(t *testing.T) {
+	to := types.Address{0x1}
+	txns := []*types.Transaction{
+		{
+			To:       &to,
+			Value:    big.NewInt(1),
+			GasPrice: big.NewInt(1),
+		},
+		{
+			Type:      types.DynamicFeeTx,
+			ChainID:   big.NewInt(1),
+			To:        &to,
+			Value:     big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			GasTipCap: big.NewInt(1),
+		},
+	}
+
+	// the typed transactions are stored with their raw envelope (type || payload)
+	expected := deriveSlow(len(txns), func(i int) []byte {
+		return txns[i].MarshalRLPTo(nil)
+	})
+	assert.Equal(t, types.BytesToHash(expected), CalculateTransactionsRoot(txns))
+
+	raw := txns[1].MarshalRLPTo(nil)
+	assert.Equal(t, byte(types.DynamicFeeTx), raw[0])
+}
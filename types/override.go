@@ -0,0 +1,20 @@
+package types
+
+import "math/big"
+
+// StateOverride is the set of accounts overridden on top of the state
+// for the execution of a call
+type StateOverride map[Address]OverrideAccount
+
+// OverrideAccount are the fields of an overridden account. The nil fields are not modified
+type OverrideAccount struct {
+	Nonce   *uint64
+	Balance *big.Int
+	Code    []byte
+
+	// State replaces the whole storage of the account
+	State map[Hash]Hash
+
+	// StateDiff replaces only the given storage slots of the account
+	StateDiff map[Hash]Hash
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"database/sql/driver"
+	"math/big"
 
 	goHex "encoding/hex"
 
@@ -30,6 +31,12 @@ type Receipt struct {
 	GasUsed         uint64
 	ContractAddress Address
 	TxHash          Hash
+
+	// EffectiveGasPrice is the price per unit of gas charged to the sender
+	EffectiveGasPrice *big.Int
+
+	// RevertReason is the data returned by a failed transaction that reverted
+	RevertReason []byte
 }
 
 func (r *Receipt) SetStatus(s ReceiptStatus) {
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -42,3 +43,136 @@ func TestRLPUnmarshal_Header_ComputeHash(t *testing.T) {
 	assert.NoError(t, h2.UnmarshalRLP(data))
 	assert.Equal(t, h.Hash, h2.Hash)
 }
+
+func TestRLPStorage_Receipt_EffectiveGasPrice(t *testing.T) {
+	r := &Receipt{
+		CumulativeGasUsed: 100,
+		GasUsed:           50,
+		EffectiveGasPrice: big.NewInt(10),
+	}
+	r.SetStatus(ReceiptSuccess)
+
+	r2 := new(Receipt)
+	assert.NoError(t, r2.UnmarshalStoreRLP(r.MarshalStoreRLPTo(nil)))
+	assert.Equal(t, big.NewInt(10), r2.EffectiveGasPrice)
+	assert.Equal(t, r.GasUsed, r2.GasUsed)
+
+	// the receipts stored without the effective gas price can still be decoded
+	r.EffectiveGasPrice = nil
+
+	r3 := new(Receipt)
+	assert.NoError(t, r3.UnmarshalStoreRLP(r.MarshalStoreRLPTo(nil)))
+	assert.Nil(t, r3.EffectiveGasPrice)
+	assert.Equal(t, r.GasUsed, r3.GasUsed)
+}
+
+func TestRLPStorage_Receipt_RevertReason(t *testing.T) {
+	r := &Receipt{
+		CumulativeGasUsed: 100,
+		GasUsed:           50,
+		RevertReason:      []byte{0x1, 0x2},
+	}
+	r.SetStatus(ReceiptFailed)
+
+	r2 := new(Receipt)
+	assert.NoError(t, r2.UnmarshalStoreRLP(r.MarshalStoreRLPTo(nil)))
+	assert.Equal(t, r.RevertReason, r2.RevertReason)
+	assert.Equal(t, r.GasUsed, r2.GasUsed)
+}
+
+func TestRLPEncoding_AccessListTx(t *testing.T) {
+	to := StringToAddress("11")
+	txn := &Transaction{
+		Type:     AccessListTx,
+		ChainID:  big.NewInt(100),
+		Nonce:    1,
+		GasPrice: big.NewInt(2),
+		Gas:      21000,
+		To:       &to,
+		Value:    big.NewInt(3),
+		Input:    []byte{0x1},
+		AccessList: AccessList{
+			{Address: to, StorageKeys: []Hash{StringToHash("1"), StringToHash("2")}},
+			{Address: StringToAddress("12")},
+		},
+		V: 1,
+		R: []byte{0x2},
+		S: []byte{0x3},
+	}
+	txn.ComputeHash()
+
+	buf := txn.MarshalRLP()
+	assert.Equal(t, byte(AccessListTx), buf[0])
+
+	txn2 := new(Transaction)
+	assert.NoError(t, txn2.UnmarshalRLP(buf))
+	assert.Equal(t, AccessListTx, txn2.Type)
+	assert.Equal(t, txn.Hash, txn2.Hash)
+	assert.Equal(t, txn.GasPrice, txn2.GasPrice)
+	assert.Nil(t, txn2.GasFeeCap)
+	assert.Equal(t, 2, txn2.AccessList.StorageKeys())
+	assert.Equal(t, txn.AccessList[0], txn2.AccessList[0])
+	assert.Equal(t, to, *txn2.To)
+	assert.Equal(t, buf, txn2.MarshalRLP())
+}
+
+func TestRLPEncoding_DynamicFeeTx(t *testing.T) {
+	to := StringToAddress("11")
+	txn := &Transaction{
+		Type:      DynamicFeeTx,
+		ChainID:   big.NewInt(100),
+		Nonce:     1,
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(10),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(3),
+		Input:     []byte{0x1},
+		V:         1,
+		R:         []byte{0x2},
+		S:         []byte{0x3},
+	}
+	txn.ComputeHash()
+
+	// the raw encoding is the type followed by the rlp payload
+	buf := txn.MarshalRLP()
+	assert.Equal(t, byte(DynamicFeeTx), buf[0])
+
+	txn2 := new(Transaction)
+	assert.NoError(t, txn2.UnmarshalRLP(buf))
+	assert.Equal(t, DynamicFeeTx, txn2.Type)
+	assert.Equal(t, txn.Hash, txn2.Hash)
+	assert.Equal(t, txn.GasFeeCap, txn2.GasFeeCap)
+	assert.Equal(t, txn.GasTipCap, txn2.GasTipCap)
+	assert.Equal(t, txn.GasTipCap, txn2.GasPrice)
+	assert.Equal(t, buf, txn2.MarshalRLP())
+
+	// typed and legacy transactions in the same block body
+	legacy := &Transaction{
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+		To:       &to,
+	}
+	legacy.ComputeHash()
+	txn.From = StringToAddress("22")
+
+	body := &Body{
+		Transactions: []*Transaction{legacy, txn},
+	}
+	body2 := new(Body)
+	assert.NoError(t, body2.UnmarshalRLP(body.MarshalRLPTo(nil)))
+	assert.Len(t, body2.Transactions, 2)
+	assert.Equal(t, LegacyTx, body2.Transactions[0].Type)
+	assert.Equal(t, legacy.Hash, body2.Transactions[0].Hash)
+	assert.Equal(t, DynamicFeeTx, body2.Transactions[1].Type)
+	assert.Equal(t, txn.Hash, body2.Transactions[1].Hash)
+	assert.Equal(t, txn.From, body2.Transactions[1].From)
+
+	// the priority fee cannot be higher than the fee cap
+	txn.GasTipCap = big.NewInt(11)
+	assert.Error(t, new(Transaction).UnmarshalRLP(txn.MarshalRLP()))
+
+	// other transaction types are not supported
+	buf[0] = 0x3
+	assert.Error(t, new(Transaction).UnmarshalRLP(buf))
+}
@@ -149,12 +149,23 @@ func (t *Transaction) MarshalRLP() []byte {
 	return t.MarshalRLPTo(nil)
 }
 
+// MarshalRLPTo marshals the transaction to RLP. A typed transaction is
+// encoded as its type followed by the RLP of its payload (EIP-2718)
 func (t *Transaction) MarshalRLPTo(dst []byte) []byte {
+	if t.Type != LegacyTx {
+		dst = append(dst, byte(t.Type))
+		return MarshalRLPTo(t.marshalTypedRLPWith, dst)
+	}
 	return MarshalRLPTo(t.MarshalRLPWith, dst)
 }
 
-// MarshalRLPWith marshals the transaction to RLP with a specific fastrlp.Arena
+// MarshalRLPWith marshals the transaction to RLP with a specific fastrlp.Arena.
+// A typed transaction is an RLP string with its EIP-2718 encoding
 func (t *Transaction) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	if t.Type != LegacyTx {
+		return arena.NewBytes(t.MarshalRLPTo(nil))
+	}
+
 	vv := arena.NewArray()
 
 	vv.Set(arena.NewUint(t.Nonce))
@@ -178,3 +189,58 @@ func (t *Transaction) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
 
 	return vv
 }
+
+// MarshalRLPWith marshals the access list with a specific fastrlp.Arena
+func (a AccessList) MarshalRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	if len(a) == 0 {
+		return arena.NewNullArray()
+	}
+
+	vv := arena.NewArray()
+	for _, tuple := range a {
+		v := arena.NewArray()
+		v.Set(arena.NewBytes(tuple.Address.Bytes()))
+
+		keys := arena.NewArray()
+		for _, key := range tuple.StorageKeys {
+			keys.Set(arena.NewBytes(key.Bytes()))
+		}
+		v.Set(keys)
+		vv.Set(v)
+	}
+	return vv
+}
+
+// marshalTypedRLPWith marshals the payload of an EIP-2930 or an EIP-1559 transaction
+func (t *Transaction) marshalTypedRLPWith(arena *fastrlp.Arena) *fastrlp.Value {
+	vv := arena.NewArray()
+
+	vv.Set(arena.NewBigInt(t.ChainID))
+	vv.Set(arena.NewUint(t.Nonce))
+	if t.Type == DynamicFeeTx {
+		vv.Set(arena.NewBigInt(t.GasTipCap))
+		vv.Set(arena.NewBigInt(t.GasFeeCap))
+	} else {
+		vv.Set(arena.NewBigInt(t.GasPrice))
+	}
+	vv.Set(arena.NewUint(t.Gas))
+
+	// Address may be empty
+	if t.To != nil {
+		vv.Set(arena.NewBytes((*t.To).Bytes()))
+	} else {
+		vv.Set(arena.NewNull())
+	}
+
+	vv.Set(arena.NewBigInt(t.Value))
+	vv.Set(arena.NewCopyBytes(t.Input))
+
+	vv.Set(t.AccessList.MarshalRLPWith(arena))
+
+	// signature values
+	vv.Set(arena.NewUint(uint64(t.V)))
+	vv.Set(arena.NewCopyBytes(t.R))
+	vv.Set(arena.NewCopyBytes(t.S))
+
+	return vv
+}
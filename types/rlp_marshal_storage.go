@@ -77,5 +77,17 @@ func (r *Receipt) MarshalStoreRLPWith(a *fastrlp.Arena) *fastrlp.Value {
 
 	// gas used
 	vv.Set(a.NewUint(r.GasUsed))
+
+	// effective gas price (not set in the receipts stored before it was added)
+	if r.EffectiveGasPrice != nil {
+		vv.Set(a.NewBigInt(r.EffectiveGasPrice))
+	} else if len(r.RevertReason) != 0 {
+		vv.Set(a.NewUint(0))
+	}
+
+	// revert reason (only set for the reverted transactions)
+	if len(r.RevertReason) != 0 {
+		vv.Set(a.NewBytes(r.RevertReason))
+	}
 	return vv
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/0xPolygon/minimal/helper/keccak"
 	"github.com/umbracle/fastrlp"
 )
 
@@ -263,12 +264,27 @@ func (l *Log) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
 	return nil
 }
 
+// UnmarshalRLP unmarshals a transaction, either a legacy one or
+// a typed one with its EIP-2718 encoding
 func (t *Transaction) UnmarshalRLP(input []byte) error {
+	if len(input) != 0 && input[0] <= 0x7f {
+		return t.unmarshalTypedRLP(input)
+	}
 	return UnmarshalRlp(t.UnmarshalRLPFrom, input)
 }
 
 // UnmarshalRLP unmarshals a Transaction in RLP format
 func (t *Transaction) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	if v.Type() == fastrlp.TypeBytes {
+		// a typed transaction is an RLP string with its EIP-2718 encoding
+		buf, err := v.Bytes()
+		if err != nil {
+			return err
+		}
+		return t.unmarshalTypedRLP(buf)
+	}
+	t.Type = LegacyTx
+
 	elems, err := v.GetElems()
 	if err != nil {
 		return err
@@ -331,3 +347,158 @@ func (t *Transaction) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) erro
 	}
 	return nil
 }
+
+// unmarshalTypedRLP unmarshals the EIP-2718 encoding of a typed transaction
+func (t *Transaction) unmarshalTypedRLP(input []byte) error {
+	if len(input) == 0 {
+		return fmt.Errorf("empty typed transaction")
+	}
+	typ := TxType(input[0])
+	if typ != AccessListTx && typ != DynamicFeeTx {
+		return fmt.Errorf("transaction type %d not supported", typ)
+	}
+	t.Type = typ
+
+	if err := UnmarshalRlp(t.unmarshalTypedRLPFrom, input[1:]); err != nil {
+		return err
+	}
+	t.Hash = BytesToHash(keccak.Keccak256(nil, input))
+	return nil
+}
+
+// unmarshalTypedRLPFrom unmarshals the payload of an EIP-2930 or an EIP-1559 transaction.
+// The payload of an EIP-2930 transaction has the gas price instead of the fee caps
+func (t *Transaction) unmarshalTypedRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+
+	expected := 12
+	if t.Type == AccessListTx {
+		expected = 11
+	}
+	if num := len(elems); num != expected {
+		return fmt.Errorf("not enough elements to decode typed transaction, expected %d but found %d", expected, num)
+	}
+
+	// chainID
+	t.ChainID = new(big.Int)
+	if err := elems[0].GetBigInt(t.ChainID); err != nil {
+		return err
+	}
+	// nonce
+	if t.Nonce, err = elems[1].GetUint64(); err != nil {
+		return err
+	}
+	if t.Type == DynamicFeeTx {
+		// maxPriorityFeePerGas
+		t.GasTipCap = new(big.Int)
+		if err := elems[2].GetBigInt(t.GasTipCap); err != nil {
+			return err
+		}
+		// maxFeePerGas
+		t.GasFeeCap = new(big.Int)
+		if err := elems[3].GetBigInt(t.GasFeeCap); err != nil {
+			return err
+		}
+		if t.GasTipCap.Cmp(t.GasFeeCap) > 0 {
+			return fmt.Errorf("max priority fee per gas higher than max fee per gas")
+		}
+		// without a base fee, the price paid is the priority fee
+		t.GasPrice = new(big.Int).Set(t.GasTipCap)
+
+		// the rest of the fields are at the same position for both types
+		elems = elems[1:]
+	} else {
+		t.GasTipCap, t.GasFeeCap = nil, nil
+
+		// gasPrice
+		t.GasPrice = new(big.Int)
+		if err := elems[2].GetBigInt(t.GasPrice); err != nil {
+			return err
+		}
+	}
+	// gas
+	if t.Gas, err = elems[3].GetUint64(); err != nil {
+		return err
+	}
+	// to
+	vv, _ := elems[4].Bytes()
+	if len(vv) == 20 {
+		// address
+		addr := BytesToAddress(vv)
+		t.To = &addr
+	} else {
+		// reset To
+		t.To = nil
+	}
+	// value
+	t.Value = new(big.Int)
+	if err := elems[5].GetBigInt(t.Value); err != nil {
+		return err
+	}
+	// input
+	if t.Input, err = elems[6].GetBytes(t.Input[:0]); err != nil {
+		return err
+	}
+	// accessList
+	t.AccessList = nil
+	if err := t.AccessList.UnmarshalRLPFrom(p, elems[7]); err != nil {
+		return err
+	}
+	// v (the y parity of the signature)
+	parity, err := elems[8].GetUint64()
+	if err != nil {
+		return err
+	}
+	if parity > 1 {
+		return fmt.Errorf("invalid signature y parity %d", parity)
+	}
+	t.V = byte(parity)
+	// R
+	if t.R, err = elems[9].GetBytes(t.R[:0]); err != nil {
+		return err
+	}
+	// S
+	if t.S, err = elems[10].GetBytes(t.S[:0]); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalRLPFrom unmarshals an access list in RLP format
+func (a *AccessList) UnmarshalRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		tuple, err := elem.GetElems()
+		if err != nil {
+			return err
+		}
+		if num := len(tuple); num != 2 {
+			return fmt.Errorf("not enough elements to decode access tuple, expected 2 but found %d", num)
+		}
+
+		var entry AccessTuple
+		// address
+		if err := tuple[0].GetAddr(entry.Address[:]); err != nil {
+			return err
+		}
+		// storage keys
+		keys, err := tuple[1].GetElems()
+		if err != nil {
+			return err
+		}
+		entry.StorageKeys = make([]Hash, len(keys))
+		for i, key := range keys {
+			if err := key.GetHash(entry.StorageKeys[i][:]); err != nil {
+				return err
+			}
+		}
+		*a = append(*a, entry)
+	}
+	return nil
+}
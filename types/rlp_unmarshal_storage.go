@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/umbracle/fastrlp"
 )
@@ -104,8 +105,8 @@ func (r *Receipt) UnmarshalStoreRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) err
 	if err != nil {
 		return err
 	}
-	if len(elems) != 3 {
-		return fmt.Errorf("expected 3 elements")
+	if len(elems) < 3 || len(elems) > 5 {
+		return fmt.Errorf("expected 3 to 5 elements")
 	}
 
 	if err := r.UnmarshalRLPFrom(p, elems[0]); err != nil {
@@ -128,5 +129,20 @@ func (r *Receipt) UnmarshalStoreRLPFrom(p *fastrlp.Parser, v *fastrlp.Value) err
 	if r.GasUsed, err = elems[2].GetUint64(); err != nil {
 		return err
 	}
+
+	// effective gas price
+	if len(elems) >= 4 {
+		r.EffectiveGasPrice = new(big.Int)
+		if err := elems[3].GetBigInt(r.EffectiveGasPrice); err != nil {
+			return err
+		}
+	}
+
+	// revert reason
+	if len(elems) == 5 {
+		if r.RevertReason, err = elems[4].GetBytes(nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
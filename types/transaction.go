@@ -6,6 +6,54 @@ import (
 	"github.com/0xPolygon/minimal/helper/keccak"
 )
 
+// TxType is the EIP-2718 type of a transaction
+type TxType byte
+
+const (
+	// LegacyTx is a transaction without a type envelope
+	LegacyTx TxType = 0x0
+
+	// AccessListTx is an EIP-2930 transaction
+	AccessListTx TxType = 0x1
+
+	// DynamicFeeTx is an EIP-1559 transaction
+	DynamicFeeTx TxType = 0x2
+)
+
+// AccessTuple is an entry of the access list of a transaction
+type AccessTuple struct {
+	Address     Address
+	StorageKeys []Hash
+}
+
+// AccessList are the addresses and storage slots that a transaction
+// accesses, they are warm from the start of its execution (EIP-2930)
+type AccessList []AccessTuple
+
+// StorageKeys returns the number of storage slots in the access list
+func (a AccessList) StorageKeys() int {
+	num := 0
+	for _, tuple := range a {
+		num += len(tuple.StorageKeys)
+	}
+	return num
+}
+
+// Copy returns a deep copy of the access list
+func (a AccessList) Copy() AccessList {
+	if a == nil {
+		return nil
+	}
+	res := make(AccessList, len(a))
+	for i, tuple := range a {
+		res[i] = AccessTuple{
+			Address:     tuple.Address,
+			StorageKeys: append([]Hash{}, tuple.StorageKeys...),
+		}
+	}
+	return res
+}
+
 type Transaction struct {
 	Nonce    uint64
 	GasPrice *big.Int
@@ -18,6 +66,16 @@ type Transaction struct {
 	S        []byte
 	Hash     Hash
 	From     Address
+
+	Type TxType
+
+	// ChainID and AccessList are only set for the typed transactions. GasTipCap
+	// and GasFeeCap are only set for the dynamic fee transactions. Since the chain
+	// has no base fee, the GasPrice of a dynamic fee transaction is its GasTipCap
+	ChainID    *big.Int
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	AccessList AccessList
 }
 
 func (t *Transaction) IsContractCreation() bool {
@@ -26,6 +84,12 @@ func (t *Transaction) IsContractCreation() bool {
 
 // ComputeHash computes the hash of the transaction
 func (t *Transaction) ComputeHash() *Transaction {
+	if t.Type != LegacyTx {
+		// the hash of a typed transaction includes its type
+		t.Hash = BytesToHash(keccak.Keccak256(nil, t.MarshalRLP()))
+		return t
+	}
+
 	ar := marshalArenaPool.Get()
 	hash := keccak.DefaultKeccakPool.Get()
 
@@ -54,5 +118,16 @@ func (t *Transaction) Copy() *Transaction {
 
 	tt.Input = make([]byte, len(t.Input))
 	copy(tt.Input[:], t.Input[:])
+
+	if t.ChainID != nil {
+		tt.ChainID = new(big.Int).Set(t.ChainID)
+	}
+	if t.GasTipCap != nil {
+		tt.GasTipCap = new(big.Int).Set(t.GasTipCap)
+	}
+	if t.GasFeeCap != nil {
+		tt.GasFeeCap = new(big.Int).Set(t.GasFeeCap)
+	}
+	tt.AccessList = t.AccessList.Copy()
 	return tt
 }